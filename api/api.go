@@ -0,0 +1,137 @@
+// Package api is the control API of a running cmux: the types it speaks
+// and a client for it. cmux serves the API as HTTP over a Unix socket, so
+// scripts and editor plugins can list and drive Claude sessions.
+//
+//	GET    /v1/sessions                       list sessions
+//	GET    /v1/session?name=NAME              one session
+//	GET    /v1/messages?session=NAME          a session's conversation
+//	POST   /v1/sessions                       create a session (CreateRequest)
+//	DELETE /v1/session?name=NAME&worktree=1   delete a session
+//	POST   /v1/prompt                         send a prompt (PromptRequest)
+//	POST   /v1/permission                     answer a permission prompt (PermissionRequest)
+//	GET    /v1/events                         stream of Events, one JSON object per line
+//	GET    /v1/info                           what is serving the API (Info)
+//
+// Errors are returned as an ErrorResponse with a 4xx or 5xx status.
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Session statuses, as reported by Claude's hooks.
+const (
+	StatusIdle       = "idle"        // waiting for a prompt
+	StatusThinking   = "thinking"    // working on a prompt
+	StatusTool       = "tool"        // running a tool
+	StatusActive     = "active"      // working, between tools
+	StatusNeedsInput = "needs_input" // waiting on a permission prompt
+)
+
+// Session is a tmux session running Claude.
+type Session struct {
+	Name       string      `json:"name"` // tmux session
+	Repo       string      `json:"repo,omitempty"`
+	Branch     string      `json:"branch,omitempty"`
+	Dir        string      `json:"dir,omitempty"` // worktree or working directory
+	Attached   bool        `json:"attached"`
+	Status     string      `json:"status"`
+	Tool       string      `json:"tool,omitempty"`       // tool running, while Status is "tool"
+	Permission *Permission `json:"permission,omitempty"` // prompt waiting, while Status is "needs_input"
+	ClaudeID   string      `json:"claude_id,omitempty"`  // Claude's own session ID
+	Updated    time.Time   `json:"updated,omitempty"`    // last hook event
+}
+
+// Permission is a pending permission prompt.
+type Permission struct {
+	Tool    string          `json:"tool,omitempty"`
+	Input   json.RawMessage `json:"input,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// Message is a turn of a session's conversation.
+type Message struct {
+	Role     string     `json:"role"` // "user" or "assistant"
+	Text     string     `json:"text,omitempty"`
+	Tools    []ToolCall `json:"tools,omitempty"`
+	Time     time.Time  `json:"time"`
+	Complete bool       `json:"complete,omitempty"` // the assistant finished its turn
+}
+
+// ToolCall is a tool an assistant message used.
+type ToolCall struct {
+	Name    string `json:"name"`
+	Summary string `json:"summary,omitempty"` // e.g. "Edit: main.go"
+	Status  string `json:"status,omitempty"`  // pending, running, complete or failed
+	Error   string `json:"error,omitempty"`
+}
+
+// Event types.
+const (
+	EventStatus  = "status"  // a hook event updated a session
+	EventCreated = "created" // a tmux session appeared
+	EventDeleted = "deleted" // a tmux session went away
+)
+
+// Event is an entry of the event stream.
+type Event struct {
+	Type    string    `json:"type"`
+	Session string    `json:"session"`
+	Hook    string    `json:"hook,omitempty"`   // hook event name, e.g. "Stop"
+	Status  string    `json:"status,omitempty"` // the session's status after the event
+	Tool    string    `json:"tool,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// CreateRequest asks for a session on a branch of a configured repository.
+// The branch is created if it doesn't exist.
+type CreateRequest struct {
+	Repo   string `json:"repo"` // repository name or path
+	Branch string `json:"branch"`
+}
+
+// Created is the reply to a CreateRequest.
+type Created struct {
+	Name    string `json:"name"`              // the new session
+	Warning string `json:"warning,omitempty"` // why its worktree couldn't be set up, if it couldn't
+}
+
+// PromptRequest submits text to a session's Claude.
+type PromptRequest struct {
+	Session string `json:"session"`
+	Text    string `json:"text"`
+}
+
+// PermissionRequest answers a session's permission prompt.
+type PermissionRequest struct {
+	Session string `json:"session"`
+	Allow   bool   `json:"allow"`
+}
+
+// Info describes the cmux serving the API.
+type Info struct {
+	Version string `json:"version,omitempty"`
+	Daemon  bool   `json:"daemon"` // "cmux daemon" rather than a TUI
+	PID     int    `json:"pid"`
+}
+
+// ErrorResponse is the body of a failed request.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// SocketPath returns where cmux serves the API: $CMUX_SOCKET, or
+// cmux.sock next to the hook events in the temp directory.
+func SocketPath() string {
+	if path := os.Getenv("CMUX_SOCKET"); path != "" {
+		return path
+	}
+	tmpdir := os.Getenv("TMPDIR")
+	if tmpdir == "" {
+		tmpdir = "/tmp"
+	}
+	return filepath.Join(tmpdir, "cmux", "cmux.sock")
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+)
+
+var (
+	// ErrNotRunning is returned when nothing serves the API on the socket.
+	ErrNotRunning = errors.New("cmux is not running")
+	// ErrNotFound is matched by errors for unknown sessions or repositories.
+	ErrNotFound = errors.New("not found")
+	// ErrConflict is matched by errors for requests the session's state
+	// doesn't allow, like answering a permission prompt that isn't there.
+	ErrConflict = errors.New("conflict")
+)
+
+// Error is a request the server refused or failed.
+type Error struct {
+	Status  int // HTTP status
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches ErrNotFound and ErrConflict by status.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrConflict:
+		return e.Status == http.StatusConflict
+	}
+	return false
+}
+
+// Client talks to the API of a running cmux.
+type Client struct {
+	socket string
+	http   *http.Client
+}
+
+// NewClient returns a client for the API served on socket, or on
+// SocketPath() if socket is empty.
+func NewClient(socket string) *Client {
+	if socket == "" {
+		socket = SocketPath()
+	}
+	return &Client{
+		socket: socket,
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}},
+	}
+}
+
+// Info describes the cmux serving the API.
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	var info Info
+	err := c.do(ctx, http.MethodGet, "/v1/info", nil, &info)
+	return info, err
+}
+
+// Sessions lists the sessions running Claude.
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	sessions := []Session{}
+	err := c.do(ctx, http.MethodGet, "/v1/sessions", nil, &sessions)
+	return sessions, err
+}
+
+// Session returns one session.
+func (c *Client) Session(ctx context.Context, name string) (Session, error) {
+	var sess Session
+	err := c.do(ctx, http.MethodGet, "/v1/session?"+url.Values{"name": {name}}.Encode(), nil, &sess)
+	return sess, err
+}
+
+// Messages returns a session's conversation, oldest first.
+func (c *Client) Messages(ctx context.Context, name string) ([]Message, error) {
+	messages := []Message{}
+	err := c.do(ctx, http.MethodGet, "/v1/messages?"+url.Values{"session": {name}}.Encode(), nil, &messages)
+	return messages, err
+}
+
+// Create starts a session on a branch of a configured repository, creating
+// the branch if it doesn't exist.
+func (c *Client) Create(ctx context.Context, repo, branch string) (Created, error) {
+	var created Created
+	err := c.do(ctx, http.MethodPost, "/v1/sessions", CreateRequest{Repo: repo, Branch: branch}, &created)
+	return created, err
+}
+
+// Delete kills a session, and removes its worktree if removeWorktree is set.
+func (c *Client) Delete(ctx context.Context, name string, removeWorktree bool) error {
+	query := url.Values{"name": {name}}
+	if removeWorktree {
+		query.Set("worktree", "1")
+	}
+	return c.do(ctx, http.MethodDelete, "/v1/session?"+query.Encode(), nil, nil)
+}
+
+// Prompt submits text to a session's Claude.
+func (c *Client) Prompt(ctx context.Context, name, text string) error {
+	return c.do(ctx, http.MethodPost, "/v1/prompt", PromptRequest{Session: name, Text: text}, nil)
+}
+
+// Answer allows or denies a session's pending permission prompt.
+func (c *Client) Answer(ctx context.Context, name string, allow bool) error {
+	return c.do(ctx, http.MethodPost, "/v1/permission", PermissionRequest{Session: name, Allow: allow}, nil)
+}
+
+// Events subscribes to the event stream, of one session or of all of them
+// if session is empty. The stream ends when ctx is cancelled or it is
+// closed.
+func (c *Client) Events(ctx context.Context, session string) (*EventStream, error) {
+	path := "/v1/events"
+	if session != "" {
+		path += "?" + url.Values{"session": {session}}.Encode()
+	}
+	resp, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{body: resp.Body, dec: json.NewDecoder(bufio.NewReader(resp.Body))}, nil
+}
+
+// EventStream reads events as cmux sends them.
+type EventStream struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+}
+
+// Next waits for the next event. It returns io.EOF once cmux has stopped.
+func (s *EventStream) Next() (Event, error) {
+	var ev Event
+	err := s.dec.Decode(&ev)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return ev, err
+}
+
+// Close ends the subscription.
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// do sends a request with body as JSON and decodes the reply into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// request sends a request, turning error replies into an *Error.
+func (c *Client) request(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://cmux"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("%w: nothing is serving %s", ErrNotRunning, c.socket)
+		}
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var reply ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil || reply.Error == "" {
+			reply.Error = resp.Status
+		}
+		return nil, &Error{Status: resp.StatusCode, Message: reply.Error}
+	}
+	return resp, nil
+}
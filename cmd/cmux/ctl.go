@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/api"
+)
+
+const ctlUsage = `Usage: cmux ctl [--socket path] <command> [args]
+
+Commands:
+  info                      show what is serving the API
+  ls                        list Claude sessions and their status
+  show <session>            show one session
+  messages <session>        print a session's conversation
+  send <session> <text>     send a prompt
+  approve <session>         allow the pending permission prompt
+  deny <session>            deny the pending permission prompt
+  new <repo> <branch>       create a session, and the branch if needed
+  rm [--worktree] <session> kill a session, optionally removing its worktree
+  events [session]          stream events, one JSON object per line
+
+Output is JSON. A cmux TUI or "cmux daemon" must be running.`
+
+// runCtl drives a running cmux through its control API.
+func runCtl(args []string) int {
+	flags := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	socket := flags.String("socket", api.SocketPath(), "Unix socket cmux serves the control API on")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, ctlUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	client := api.NewClient(*socket)
+	command, args := flags.Arg(0), flags.Args()[1:]
+
+	result, err := ctl(ctx, client, command, args)
+	if errors.Is(err, errUsage) {
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	if result != nil {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(result)
+	}
+	return 0
+}
+
+// errUsage is returned by ctl for commands it can't make sense of.
+var errUsage = errors.New("usage")
+
+// ctl runs a command, returning what to print, if anything.
+func ctl(ctx context.Context, client *api.Client, command string, args []string) (any, error) {
+	switch {
+	case command == "info" && len(args) == 0:
+		return client.Info(ctx)
+	case command == "ls" && len(args) == 0:
+		return client.Sessions(ctx)
+	case command == "show" && len(args) == 1:
+		return client.Session(ctx, args[0])
+	case command == "messages" && len(args) == 1:
+		return client.Messages(ctx, args[0])
+	case command == "send" && len(args) >= 2:
+		return nil, client.Prompt(ctx, args[0], strings.Join(args[1:], " "))
+	case command == "approve" && len(args) == 1:
+		return nil, client.Answer(ctx, args[0], true)
+	case command == "deny" && len(args) == 1:
+		return nil, client.Answer(ctx, args[0], false)
+	case command == "new" && len(args) == 2:
+		return client.Create(ctx, args[0], args[1])
+	case command == "rm" && len(args) == 2 && args[0] == "--worktree":
+		return nil, client.Delete(ctx, args[1], true)
+	case command == "rm" && len(args) == 1:
+		return nil, client.Delete(ctx, args[0], false)
+	case command == "events" && len(args) <= 1:
+		session := ""
+		if len(args) == 1 {
+			session = args[0]
+		}
+		return nil, streamEvents(ctx, client, session)
+	}
+	return nil, errUsage
+}
+
+// streamEvents prints events as they arrive until interrupted or cmux stops.
+func streamEvents(ctx context.Context, client *api.Client, session string) error {
+	stream, err := client.Events(ctx, session)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	enc := json.NewEncoder(os.Stdout)
+	for {
+		ev, err := stream.Next()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if errors.Is(err, io.EOF) {
+				return errors.New("cmux stopped")
+			}
+			return fmt.Errorf("reading events: %w", err)
+		}
+		enc.Encode(ev)
+	}
+}
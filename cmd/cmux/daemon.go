@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/control"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/notify"
+	"github.com/abdullathedruid/cmux/internal/rules"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// runDaemon runs cmux headless until interrupted: it watches hooks,
+// discovers sessions, notifies and runs rules, and serves the control API
+// that scripts and TUIs attach to.
+func runDaemon(args []string) int {
+	flags := flag.NewFlagSet("daemon", flag.ContinueOnError)
+	socket := flags.String("socket", api.SocketPath(), "Unix socket to serve the control API on")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cmux daemon [--socket path]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+
+	tmuxClient := tmux.NewClient(cfg.ClaudeCommand)
+	tmuxClient.SetClaudePatterns(cfg.ClaudePatterns)
+	tmuxClient.SetSessionOptions(func(dir string) tmux.SessionOptions {
+		return session.SessionOptionsForDir(git.Default, cfg, dir)
+	})
+	discoverySvc := discovery.NewService(tmuxClient, git.Default, cfg)
+	defer discoverySvc.Stop()
+
+	watcher, err := claude.NewEventWatcher(claude.EventsDir())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating event watcher: %v\n", err)
+		return 1
+	}
+	defer watcher.Stop()
+
+	notifier, err := notify.New(cfg.Notify, tmuxClient)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring notifications: %v\n", err)
+		return 1
+	}
+	ruleEngine, err := rules.New(cfg.Rules)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error configuring rules: %v\n", err)
+		return 1
+	}
+	defer ruleEngine.Stop()
+
+	server := control.NewServer(cfg, tmuxClient, discoverySvc, session.NewManager(tmuxClient, git.Default, cfg))
+	server.Daemon(ruleEngine)
+	// Sessions on the screens of attached TUIs count as focused
+	notifier.ShowOn(server.ShownIn)
+	watcher.OnEvent(server.OnHookEvent)
+	watcher.OnEvent(notifier.OnEvent)
+	watcher.OnEvent(ruleEngine.OnHookEvent)
+	if err := server.Start(*socket); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	defer server.Close()
+	if err := watcher.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error starting event watcher: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "cmux daemon serving %s\n", *socket)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+	return 0
+}
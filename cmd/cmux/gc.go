@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/cleanup"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// runGC cleans up the worktrees of the configured repositories that no
+// tmux session uses, applying the same rules as the cleanup modal: missing
+// worktrees are pruned, merged ones removed along with their branches, and
+// unmerged work is kept.
+func runGC(args []string) int {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "show what would be cleaned up without changing anything")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cmux gc [--dry-run]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	repos := cfg.ExpandedRepositories()
+	if len(repos) == 0 {
+		fmt.Fprintln(os.Stderr, "No repositories configured.")
+		return 1
+	}
+
+	// Without the active set every worktree would look unused, so give up
+	// rather than clean up the worktrees of running sessions
+	active, err := sessionDirs(tmux.NewClient(cfg.ClaudeCommand))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing tmux sessions: %v\n", err)
+		return 1
+	}
+	reports := cleanup.Scan(git.Default, cfg, repos, func(path string) bool {
+		for _, dir := range active {
+			if dir == path || strings.HasPrefix(dir, path+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	})
+
+	status := 0
+	for _, r := range reports {
+		plan := r.SafePlan()
+		fmt.Printf("%s/%s (%s): %s\n", r.RepoName, r.Name(), strings.Join(r.Labels(), ", "), plan)
+		if *dryRun || plan.Empty() {
+			continue
+		}
+		if err := cleanup.Apply(git.Default, r, plan); err != nil {
+			fmt.Fprintf(os.Stderr, "Error cleaning up %s: %v\n", r.Worktree.Path, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+// sessionDirs returns the directories tmux sessions were started in or
+// are working in.
+func sessionDirs(client tmux.Client) ([]string, error) {
+	sessions, err := client.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, s := range sessions {
+		dirs = append(dirs, s.Path)
+		dir, err := client.GetSessionWorkingDir(s.Name)
+		if err != nil {
+			return nil, fmt.Errorf("working directory of %s: %w", s.Name, err)
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
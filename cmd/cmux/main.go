@@ -2,16 +2,50 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/abdullathedruid/cmux/internal/app"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/workspace"
 )
 
 func main() {
 	sessions := os.Args[1:]
 
-	application, err := app.NewStructuredApp()
+	// cmux open <workspace>: restore a saved workspace
+	if len(sessions) > 0 && sessions[0] == "open" {
+		os.Exit(runOpen(sessions[1:]))
+	}
+
+	// cmux gc [--dry-run]: clean up unused worktrees
+	if len(sessions) > 0 && sessions[0] == "gc" {
+		os.Exit(runGC(sessions[1:]))
+	}
+
+	// cmux daemon: watch and serve sessions headless, for TUIs to attach to
+	if len(sessions) > 0 && sessions[0] == "daemon" {
+		os.Exit(runDaemon(sessions[1:]))
+	}
+
+	// cmux ctl <command>: drive a running cmux through its control API
+	if len(sessions) > 0 && sessions[0] == "ctl" {
+		os.Exit(runCtl(sessions[1:]))
+	}
+
+	// cmux status-line: summarize sessions for tmux's status-right
+	if len(sessions) > 0 && sessions[0] == "status-line" {
+		os.Exit(runStatusLine(sessions[1:]))
+	}
+
+	// cmux ls, new, rm, send, approve, deny, wait, attach: script sessions
+	if len(sessions) > 0 && sessionCommands[sessions[0]] != nil {
+		os.Exit(runSessionCommand(sessions[0], sessions[1:]))
+	}
+
+	application, err := app.NewStructuredApp(git.Default)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing application: %v\n", err)
 		os.Exit(1)
@@ -36,3 +70,53 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runOpen restores a workspace and runs the app with its sessions pinned.
+// Without a name it lists the saved workspaces.
+func runOpen(args []string) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+
+	if len(args) == 0 {
+		names, err := workspace.NewStore(cfg.WorkspacesDir()).List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing workspaces: %v\n", err)
+			return 1
+		}
+		if len(names) == 0 {
+			fmt.Fprintln(os.Stderr, "No saved workspaces. Press 'w' in cmux to save the tiled sessions.")
+			return 1
+		}
+		fmt.Println("Usage: cmux open <workspace>\n\nWorkspaces:")
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
+		}
+		return 2
+	}
+
+	application, err := app.NewStructuredAppWithConfig(cfg, git.Default)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing application: %v\n", err)
+		return 1
+	}
+
+	if err := application.OpenWorkspace(args[0]); err != nil {
+		// Partial restores still open; report what failed afterwards
+		var restoreErr *workspace.RestoreError
+		if !errors.As(err, &restoreErr) {
+			application.Close()
+			fmt.Fprintf(os.Stderr, "Error opening workspace: %v\n", err)
+			return 1
+		}
+		defer fmt.Fprintf(os.Stderr, "Some sessions could not be restored:\n%v\n", err)
+	}
+
+	if err := application.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
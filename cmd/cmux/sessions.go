@@ -0,0 +1,432 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/control"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+	"github.com/abdullathedruid/cmux/internal/ui"
+)
+
+const sessionsUsage = `Usage:
+  cmux ls [--json]                                   list Claude sessions
+  cmux new <repo> <branch> [--prompt text]           create a session, and the branch if needed
+  cmux rm <session> [--worktree]                     kill a Claude session, optionally removing its worktree
+  cmux send <session> <text>                         send Claude a prompt
+  cmux approve <session>                             allow the pending permission prompt
+  cmux deny <session>                                deny the pending permission prompt
+  cmux wait <session> --until <status> [--timeout d] wait for Claude's status
+  cmux attach <session>                              attach to or switch to a session
+
+Statuses are idle, thinking, tool, active and needs_input.
+
+Exit codes:
+  0  success
+  1  error
+  2  usage error
+  3  no such session or repository, or the session ended while waiting
+  4  wait timed out
+  5  no permission prompt is pending`
+
+// Exit codes of the session commands, which scripts may rely on.
+const (
+	exitOK        = 0
+	exitError     = 1
+	exitUsage     = 2
+	exitNotFound  = 3
+	exitTimeout   = 4
+	exitNoPending = 5
+)
+
+// statusPollInterval is how often wait and send check a session's hook
+// events.
+const statusPollInterval = 250 * time.Millisecond
+
+// promptAckTimeout is how long send waits for Claude's hook to report the
+// prompt, so that a wait right after it sees the turn start.
+const promptAckTimeout = 5 * time.Second
+
+// sessionCommands are the commands taking the place of session names.
+var sessionCommands = map[string]func(*cli, []string) int{
+	"ls":      (*cli).ls,
+	"new":     (*cli).create,
+	"rm":      (*cli).remove,
+	"send":    (*cli).send,
+	"approve": func(c *cli, args []string) int { return c.answer("approve", args, true) },
+	"deny":    func(c *cli, args []string) int { return c.answer("deny", args, false) },
+	"wait":    (*cli).wait,
+	"attach":  (*cli).attach,
+}
+
+// cli runs the session commands against tmux and Claude's hook events
+// directly, so they work whether or not cmux is running.
+type cli struct {
+	cfg       *config.Config
+	tmux      tmux.Client
+	discovery *discovery.Service
+}
+
+// manager returns a session manager for the commands that change sessions.
+func (c *cli) manager() *session.Manager {
+	return session.NewManager(c.tmux, git.Default, c.cfg)
+}
+
+// runSessionCommand runs one of sessionCommands.
+func runSessionCommand(command string, args []string) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return exitError
+	}
+	tmuxClient := tmux.NewClient(cfg.ClaudeCommand)
+	tmuxClient.SetClaudePatterns(cfg.ClaudePatterns)
+	tmuxClient.SetSessionOptions(func(dir string) tmux.SessionOptions {
+		return session.SessionOptionsForDir(git.Default, cfg, dir)
+	})
+	c := &cli{cfg: cfg, tmux: tmuxClient, discovery: discovery.NewService(tmuxClient, git.Default, cfg)}
+	return sessionCommands[command](c, args)
+}
+
+// commandFlags returns a flag set for a command, printing sessionsUsage
+// on errors.
+func commandFlags(command string) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, sessionsUsage)
+	}
+	return flags
+}
+
+// parse parses flags that may come before, between or after the
+// positional arguments, and returns the positional ones. Arguments after
+// "--", or after the first stopAfter positional ones if stopAfter is not
+// zero, are positional even when they look like flags.
+func parse(flags *flag.FlagSet, args []string, stopAfter int) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := flags.Args()
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+		if len(positional) == stopAfter {
+			return append(positional, args...), nil
+		}
+	}
+}
+
+// fail reports an error and returns the exit code for it.
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if errors.Is(err, control.ErrNotFound) {
+		return exitNotFound
+	}
+	return exitError
+}
+
+// find returns the Claude session with a name, from a discovery pass.
+func (c *cli) find(name string) (*state.Session, error) {
+	snap := c.discovery.Discover()
+	if snap.Err != nil {
+		return nil, fmt.Errorf("discovering sessions: %w", snap.Err)
+	}
+	return control.FindSession(snap.All, name)
+}
+
+// hookState follows a session's status through the hook events recorded
+// for it, as the TUI builds it.
+type hookState struct {
+	view   *claude.View
+	reader *claude.EventReader
+	last   time.Time // when the latest event was written
+}
+
+func newHookState(name string) *hookState {
+	return &hookState{
+		view:   claude.NewView(name, 80, 24),
+		reader: claude.NewEventReader(filepath.Join(claude.EventsDir(), name+".jsonl")),
+	}
+}
+
+// poll applies the events written since the last poll, and returns them.
+func (h *hookState) poll() ([]claude.HookEvent, error) {
+	events, err := h.reader.Poll()
+	for _, event := range events {
+		h.view.UpdateFromHookEvent(event)
+		if event.Timestamp.After(h.last) {
+			h.last = event.Timestamp
+		}
+	}
+	return events, err
+}
+
+// status returns the session's Claude status.
+func (h *hookState) status() claude.SessionStatus {
+	return h.view.Session().Status
+}
+
+// describe returns a session as the control API shows it, active when its
+// latest hook event was written.
+func describe(sess *state.Session) (api.Session, error) {
+	h := newHookState(sess.Name)
+	if _, err := h.poll(); err != nil {
+		return api.Session{}, fmt.Errorf("reading hook events of %s: %w", sess.Name, err)
+	}
+	out := control.Describe(sess, h.view)
+	out.Updated = h.last
+	return out, nil
+}
+
+func (c *cli) ls(args []string) int {
+	flags := commandFlags("ls")
+	asJSON := flags.Bool("json", false, "print JSON, as cmux ctl ls does")
+	if rest, err := parse(flags, args, 0); err != nil || len(rest) != 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	snap := c.discovery.Discover()
+	if snap.Err != nil {
+		return fail(fmt.Errorf("discovering sessions: %w", snap.Err))
+	}
+	sessions := make([]api.Session, 0, len(snap.All))
+	for _, sess := range snap.All {
+		out, err := describe(sess)
+		if err != nil {
+			return fail(err)
+		}
+		sessions = append(sessions, out)
+	}
+	slices.SortFunc(sessions, func(a, b api.Session) int { return strings.Compare(a.Name, b.Name) })
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(sessions)
+		return exitOK
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SESSION\tSTATUS\tREPO\tBRANCH\tTOOL\tACTIVE")
+	for _, s := range sessions {
+		active := "-"
+		if !s.Updated.IsZero() {
+			active = ui.FormatDuration(int64(time.Since(s.Updated).Seconds()))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Name, s.Status, dash(s.Repo), dash(s.Branch), dash(s.Tool), active)
+	}
+	w.Flush()
+	return exitOK
+}
+
+// dash stands in for empty table cells.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (c *cli) create(args []string) int {
+	flags := commandFlags("new")
+	prompt := flags.String("prompt", "", "prompt to send Claude once it starts")
+	rest, err := parse(flags, args, 0)
+	if err != nil || len(rest) != 2 {
+		flags.Usage()
+		return exitUsage
+	}
+	repoName, branch := rest[0], rest[1]
+
+	repoPath, err := control.FindRepository(c.discovery.GetConfiguredRepositories(), repoName)
+	if err != nil {
+		return fail(err)
+	}
+	created, err := control.CreateSession(c.manager(), repoPath, branch)
+	if err != nil {
+		return fail(err)
+	}
+	if created.Warning != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", created.Warning)
+	}
+
+	if *prompt != "" {
+		if err := session.SubmitPrompt(c.tmux, created.Name, *prompt); err != nil {
+			return fail(fmt.Errorf("sending prompt: %w", err))
+		}
+	}
+	fmt.Println(created.Name)
+	return exitOK
+}
+
+func (c *cli) remove(args []string) int {
+	flags := commandFlags("rm")
+	worktree := flags.Bool("worktree", false, "also remove the session's worktree")
+	rest, err := parse(flags, args, 0)
+	if err != nil || len(rest) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	sess, err := c.find(rest[0])
+	if err != nil {
+		return fail(err)
+	}
+	if err := control.DeleteSession(c.manager(), sess.Name, *worktree); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func (c *cli) send(args []string) int {
+	flags := commandFlags("send")
+	// The text is sent as written, dashes and all
+	rest, err := parse(flags, args, 1)
+	if err != nil || len(rest) < 2 {
+		flags.Usage()
+		return exitUsage
+	}
+	text := strings.Join(rest[1:], " ")
+
+	sess, err := c.find(rest[0])
+	if err != nil {
+		return fail(err)
+	}
+	h := newHookState(sess.Name)
+	if _, err := h.poll(); err != nil {
+		return fail(fmt.Errorf("reading hook events of %s: %w", sess.Name, err))
+	}
+	if err := control.Prompt(control.Target(sess, h.view), text, c.tmux.SubmitText); err != nil {
+		return fail(err)
+	}
+
+	// Return once Claude has taken the prompt, so that "cmux wait --until
+	// idle" right after waits for the turn rather than the one before
+	deadline := time.Now().Add(promptAckTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(statusPollInterval)
+		events, err := h.poll()
+		if err != nil {
+			return fail(fmt.Errorf("reading hook events of %s: %w", sess.Name, err))
+		}
+		for _, event := range events {
+			if event.EventName == "UserPromptSubmit" {
+				return exitOK
+			}
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: no hook event reported the prompt within %s; is the cmux hook installed?\n", promptAckTimeout)
+	return exitOK
+}
+
+func (c *cli) answer(command string, args []string, allow bool) int {
+	flags := commandFlags(command)
+	rest, err := parse(flags, args, 0)
+	if err != nil || len(rest) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	sess, err := c.find(rest[0])
+	if err != nil {
+		return fail(err)
+	}
+	h := newHookState(sess.Name)
+	if _, err := h.poll(); err != nil {
+		return fail(fmt.Errorf("reading hook events of %s: %w", sess.Name, err))
+	}
+	err = control.Answer(control.Describe(sess, h.view), control.Target(sess, h.view), allow, claude.SendPermissionResponse)
+	if errors.Is(err, control.ErrNoPrompt) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitNoPending
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+func (c *cli) wait(args []string) int {
+	flags := commandFlags("wait")
+	until := flags.String("until", "", "status to wait for: idle, thinking, tool, active or needs_input")
+	timeout := flags.Duration("timeout", 0, "give up after this long, e.g. 10m (default: wait forever)")
+	rest, err := parse(flags, args, 0)
+	statuses := []string{api.StatusIdle, api.StatusThinking, api.StatusTool, api.StatusActive, api.StatusNeedsInput}
+	if err != nil || len(rest) != 1 || !slices.Contains(statuses, *until) {
+		flags.Usage()
+		return exitUsage
+	}
+
+	sess, err := c.find(rest[0])
+	if err != nil {
+		return fail(err)
+	}
+	h := newHookState(sess.Name)
+	var deadline <-chan time.Time
+	if *timeout > 0 {
+		deadline = time.After(*timeout)
+	}
+	ticker := time.NewTicker(statusPollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := h.poll(); err != nil {
+			return fail(fmt.Errorf("reading hook events of %s: %w", sess.Name, err))
+		}
+		if string(h.status()) == *until {
+			return exitOK
+		}
+		if !c.tmux.HasSession(sess.Name) {
+			return fail(fmt.Errorf("session %s ended: %w", sess.Name, control.ErrNotFound))
+		}
+
+		select {
+		case <-deadline:
+			fmt.Fprintf(os.Stderr, "Error: %s is still %s after %s\n", sess.Name, h.status(), *timeout)
+			return exitTimeout
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *cli) attach(args []string) int {
+	flags := commandFlags("attach")
+	rest, err := parse(flags, args, 0)
+	if err != nil || len(rest) != 1 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	if !c.tmux.HasSession(rest[0]) {
+		return fail(fmt.Errorf("no session %q: %w", rest[0], control.ErrNotFound))
+	}
+	if c.tmux.IsInsideTmux() {
+		err = c.tmux.SwitchSession(rest[0])
+	} else {
+		err = c.tmux.AttachSession(rest[0])
+	}
+	if err != nil {
+		return fail(fmt.Errorf("attaching to %s: %w", rest[0], err))
+	}
+	return exitOK
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/control"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/ui"
+)
+
+// runStatusLine prints a summary of the Claude sessions for tmux's status
+// line, from the status file of the running cmux. tmux runs it every few
+// seconds, so it never discovers sessions itself; without a running cmux it
+// prints nothing.
+func runStatusLine(args []string) int {
+	flags := flag.NewFlagSet("status-line", flag.ContinueOnError)
+	socket := flags.String("socket", api.SocketPath(), "Unix socket the running cmux serves the control API on")
+	sessionName := flags.String("session", "", "print only this session's status")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cmux status-line [--socket path] [--session name]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	ui.SetTheme(&cfg.Theme)
+
+	status, err := control.ReadStatus(control.StatusPath(*socket))
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, control.ErrStale) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if *sessionName != "" {
+		for _, sess := range status.Sessions {
+			if sess.Name == *sessionName {
+				fmt.Println(ui.TmuxStatus(sessionStatus(sess)))
+			}
+		}
+		return 0
+	}
+
+	statuses := make([]state.SessionStatus, len(status.Sessions))
+	for i, sess := range status.Sessions {
+		statuses[i] = sessionStatus(sess)
+	}
+	if line := ui.TmuxStatusLine(statuses); line != "" {
+		fmt.Println(line)
+	}
+	return 0
+}
+
+// sessionStatus returns the status the theme shows for a session. An idle
+// Claude that has run a turn is done; one that hasn't is just idle.
+func sessionStatus(sess api.Session) state.SessionStatus {
+	switch sess.Status {
+	case api.StatusActive:
+		return state.StatusActive
+	case api.StatusThinking:
+		return state.StatusThinking
+	case api.StatusTool:
+		return state.StatusTool
+	case api.StatusNeedsInput:
+		return state.StatusNeedsInput
+	}
+	if sess.ClaudeID != "" {
+		return state.StatusStopped
+	}
+	return state.StatusIdle
+}
@@ -6,6 +6,7 @@ require (
 	github.com/alecthomas/chroma/v2 v2.14.0
 	github.com/creack/pty v1.1.24
 	github.com/fsnotify/fsnotify v1.9.0
+	github.com/gdamore/tcell/v2 v2.13.5
 	github.com/go-errors/errors v1.0.2
 	github.com/hexops/gotextdiff v1.0.3
 	github.com/jesseduffield/gocui v0.3.1-0.20260111170441-330357056207
@@ -23,7 +24,6 @@ require (
 	github.com/danielgatis/go-vte v1.0.8 // indirect
 	github.com/dlclark/regexp2 v1.11.0 // indirect
 	github.com/gdamore/encoding v1.0.1 // indirect
-	github.com/gdamore/tcell/v2 v2.13.5 // indirect
 	github.com/lucasb-eyer/go-colorful v1.3.0 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/muesli/termenv v0.16.0 // indirect
@@ -11,6 +11,7 @@
 #   "hooks": {
 #     "PreToolUse": [{"matcher": "*", "hooks": [{"type": "command", "command": "/path/to/cmux-hook.sh"}]}],
 #     "PostToolUse": [{"matcher": "*", "hooks": [{"type": "command", "command": "/path/to/cmux-hook.sh"}]}],
+#     "PostToolUseFailure": [{"matcher": "*", "hooks": [{"type": "command", "command": "/path/to/cmux-hook.sh"}]}],
 #     "UserPromptSubmit": [{"hooks": [{"type": "command", "command": "/path/to/cmux-hook.sh"}]}],
 #     "Stop": [{"hooks": [{"type": "command", "command": "/path/to/cmux-hook.sh"}]}],
 #     "SubagentStop": [{"hooks": [{"type": "command", "command": "/path/to/cmux-hook.sh"}]}],
@@ -22,7 +23,8 @@
 DIR="${TMPDIR:-/tmp}/cmux/events"
 mkdir -p "$DIR"
 
-# Get tmux session name (skip if not in tmux)
+# Get tmux session name (skip if not in tmux). $TMUX_PANE identifies the pane
+# Claude runs in, so cmux can target it when a session has several panes.
 SESSION=$(tmux display-message -p '#{session_name}' 2>/dev/null) || exit 0
 [ -z "$SESSION" ] && exit 0
 
@@ -30,5 +32,6 @@ SESSION=$(tmux display-message -p '#{session_name}' 2>/dev/null) || exit 0
 # Create parent directory in case session name contains slashes
 OUTFILE="$DIR/$SESSION.jsonl"
 mkdir -p "$(dirname "$OUTFILE")"
-jq -c --arg ts "$(date -Iseconds)" --arg tmux "$SESSION" '. + {ts: $ts, tmux_session: $tmux}' \
+jq -c --arg ts "$(date -Iseconds)" --arg tmux "$SESSION" --arg pane "${TMUX_PANE:-}" \
+    '. + {ts: $ts, tmux_session: $tmux, tmux_pane: $pane}' \
     >> "$OUTFILE"
@@ -48,7 +48,8 @@ echo "" >> "$LOGFILE"
 TIMESTAMP=$(date -u +"%Y-%m-%dT%H:%M:%SZ")
 EVENTFILE="$EVENTSDIR/$SESSION.jsonl"
 mkdir -p "$(dirname "$EVENTFILE")"
-echo "$INPUT" | jq -c --arg ts "$TIMESTAMP" --arg tmux "$SESSION" '. + {ts: $ts, tmux_session: $tmux}' >> "$EVENTFILE"
+echo "$INPUT" | jq -c --arg ts "$TIMESTAMP" --arg tmux "$SESSION" --arg pane "${TMUX_PANE:-}" \
+    '. + {ts: $ts, tmux_session: $tmux, tmux_pane: $pane}' >> "$EVENTFILE"
 
 # Parse fields
 EVENT=$(echo "$INPUT" | jq -r '.hook_event_name // empty')
@@ -0,0 +1,162 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/jesseduffield/gocui"
+)
+
+// sessionMessages returns the conversation of a session, from its loaded
+// view if it has one, else read from its transcript.
+func (a *StructuredApp) sessionMessages(session string) []claude.Message {
+	if view, ok := a.views[session]; ok {
+		if msgs := view.Messages(); len(msgs) > 0 {
+			return msgs
+		}
+	}
+
+	transcriptPath := claude.GetLatestTranscriptPath(session)
+	if entry, ok := a.registry.Get(session); ok && transcriptPath == "" {
+		transcriptPath = entry.TranscriptPath
+	}
+	if transcriptPath == "" {
+		return nil
+	}
+	reader := claude.NewTranscriptReader(transcriptPath)
+	if _, _, err := reader.Poll(); err != nil {
+		return nil
+	}
+	return reader.Messages()
+}
+
+// loadHistory attributes the diff session's edits to its prompts.
+func (a *StructuredApp) loadHistory() bool {
+	d := a.diff
+	if d.session == "" {
+		d.message = "no Claude session works in this worktree"
+		return false
+	}
+	d.history = claude.BuildHistory(a.sessionMessages(d.session))
+	return true
+}
+
+// openFileBlame lists the turns that changed the selected file.
+func (a *StructuredApp) openFileBlame() {
+	d := a.diff
+	entry, ok := d.currentEntry()
+	if !ok || d.blame != "" || !a.loadHistory() {
+		return
+	}
+
+	d.blameFile = filepath.Join(d.root, entry.diff.Path)
+	d.blameTurns = d.history.FileTurns(d.blameFile)
+	if len(d.blameTurns) == 0 {
+		d.message = fmt.Sprintf("%s was not edited by %s", entry.diff.Path, d.session)
+		return
+	}
+	d.message = ""
+	d.blame = "file"
+	a.selectBlameTurn(len(d.blameTurns) - 1) // Most recent turn
+}
+
+// openTurnBlame shows every file changed by the selected turn.
+func (a *StructuredApp) openTurnBlame() {
+	d := a.diff
+	if d.blame != "file" || d.blameIdx >= len(d.blameTurns) {
+		return
+	}
+	d.turnIdx = d.blameTurns[d.blameIdx]
+	d.blame = "turn"
+	d.scroll = 0
+}
+
+// selectBlameTurn selects entry i of the file's turns.
+func (a *StructuredApp) selectBlameTurn(i int) {
+	d := a.diff
+	if d.blame != "file" || len(d.blameTurns) == 0 {
+		return
+	}
+	d.blameIdx = max(min(i, len(d.blameTurns)-1), 0)
+	d.follow = true
+}
+
+// relPath returns path relative to the diff's worktree when it is inside it.
+func (d *diffPanel) relPath(path string) string {
+	if rel, err := filepath.Rel(d.root, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// turnHeader returns the heading line of a turn.
+func (d *diffPanel) turnHeader(idx int, selected bool, width int) string {
+	turn := d.history.Turns[idx]
+	marker := "  "
+	if selected {
+		marker = "\033[33m▶\033[0m "
+	}
+	ts := ""
+	if !turn.Prompt.Timestamp.IsZero() {
+		ts = turn.Prompt.Timestamp.Format("Jan 2 15:04") + " "
+	}
+	return fmt.Sprintf("%s\033[1;34m#%d\033[0m \033[90m%s\033[0m%s",
+		marker, idx+1, ts, turn.PromptSummary(max(width-24, 20)))
+}
+
+// renderBlame draws file or turn blame into the diff view.
+func (a *StructuredApp) renderBlame(v *gocui.View) {
+	d := a.diff
+	width, _ := v.Size()
+	var lines []string
+
+	switch d.blame {
+	case "file":
+		v.Title = fmt.Sprintf(" Blame: %s ", d.relPath(d.blameFile))
+		v.Footer = " n/N turn  Enter files of turn  Esc back "
+
+		offsets := make([]int, len(d.blameTurns))
+		for i, idx := range d.blameTurns {
+			offsets[i] = len(lines)
+			lines = append(lines, d.turnHeader(idx, i == d.blameIdx, width))
+			for _, edit := range d.history.Turns[idx].Edits {
+				if edit.Path == d.blameFile {
+					lines = append(lines, d.renderer.RenderFileEdit(edit.Tool)...)
+				}
+			}
+			lines = append(lines, "")
+		}
+		if d.follow && d.blameIdx < len(offsets) {
+			d.scroll = offsets[d.blameIdx]
+		}
+
+	case "turn":
+		turn := d.history.Turns[d.turnIdx]
+		v.Title = fmt.Sprintf(" Turn #%d ", d.turnIdx+1)
+		v.Footer = " Ctrl+U/D scroll  Esc back "
+
+		lines = append(lines, d.turnHeader(d.turnIdx, false, width))
+		for _, path := range turn.Files() {
+			lines = append(lines, "", fmt.Sprintf("  \033[1m%s\033[0m", d.relPath(path)))
+			for _, edit := range turn.Edits {
+				if edit.Path == path {
+					lines = append(lines, d.renderer.RenderFileEdit(edit.Tool)...)
+				}
+			}
+		}
+		if d.follow {
+			d.scroll = 0
+		}
+	}
+	d.follow = false
+
+	if d.message != "" {
+		v.Footer = " " + d.message + " "
+	}
+	fmt.Fprint(v, strings.Join(lines, "\n"))
+
+	d.scroll = max(min(d.scroll, len(lines)-1), 0)
+	v.SetOrigin(0, d.scroll)
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+)
+
+// hookSource delivers Claude's hook events: a claude.EventWatcher, or a
+// cmux daemon's watcher when the app is attached to one.
+type hookSource interface {
+	OnEvent(cb func(tmuxSession string, event claude.HookEvent))
+	Start() error
+	Stop()
+}
+
+// snapshotSource discovers sessions: a discovery.Service, or a cmux
+// daemon's when the app is attached to one.
+type snapshotSource interface {
+	Discover() discovery.Snapshot
+	Subscribe() <-chan discovery.Snapshot
+	Start(interval time.Duration)
+	Refresh()
+	Stop()
+}
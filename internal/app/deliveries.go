@@ -0,0 +1,112 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abdullathedruid/cmux/internal/rules"
+	"github.com/abdullathedruid/cmux/internal/ui"
+	"github.com/jesseduffield/gocui"
+)
+
+// onDelivery is called from a rule's delivery goroutine with each attempt,
+// and refreshes the delivery log if it is open.
+func (a *StructuredApp) onDelivery(rules.Delivery) {
+	a.gui.Update(func(g *gocui.Gui) error {
+		if a.deliveriesOpen {
+			a.deliveries = a.deliveryLog()
+		}
+		return nil
+	})
+}
+
+// deliveryLog returns the latest deliveries, newest first: of this cmux's
+// rules, or of the daemon's it is attached to.
+func (a *StructuredApp) deliveryLog() []rules.Delivery {
+	if a.remote != nil {
+		log, _ := a.remote.Deliveries()
+		return log
+	}
+	return a.rules.Log()
+}
+
+// deliveryLine formats a delivery log entry on one line.
+func deliveryLine(d rules.Delivery) string {
+	icon := ui.ColorGreen + "✓" + ui.ColorReset
+	switch d.Status {
+	case rules.Retrying:
+		icon = ui.ColorYellow + "↻" + ui.ColorReset
+	case rules.Failed, rules.Dropped:
+		icon = ui.ColorRed + "✗" + ui.ColorReset
+	}
+	attempt := ""
+	if d.Attempt > 1 {
+		attempt = fmt.Sprintf(" #%d", d.Attempt)
+	}
+	return fmt.Sprintf("%s %s %s%s %s %s %s%s%s", d.Time.Format("15:04:05"), icon, d.Rule, attempt,
+		d.Event.Type, d.Event.Session, ui.ColorDim, d.Detail, ui.ColorReset)
+}
+
+// layoutDeliveries draws the delivery log over the main area and gives it
+// focus. It reports whether the log is shown.
+func (a *StructuredApp) layoutDeliveries(g *gocui.Gui, maxX, maxY int) (bool, error) {
+	if !a.deliveriesOpen {
+		g.DeleteView("deliveries")
+		return false, nil
+	}
+
+	log := a.deliveries
+	width := min(100, maxX-4)
+	height := min(max(len(log), 1)+2, maxY-4)
+	x0, y0, x1, y1 := ui.ModalDimensions(maxX, maxY, width, height)
+	v, err := g.SetView("deliveries", x0, y0, x1, y1, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+			return false, err
+		}
+	}
+	v.Title = " Rule Deliveries "
+	v.Footer = " Esc close "
+	v.Frame = true
+	v.FrameRunes = []rune{'━', '┃', '┏', '┓', '┗', '┛'}
+	v.FrameColor = gocui.ColorYellow
+	v.Clear()
+
+	if len(log) == 0 {
+		fmt.Fprintln(v, " No deliveries yet")
+	}
+	for _, d := range log {
+		fmt.Fprintf(v, " %s\n", deliveryLine(d))
+	}
+
+	if _, err := g.SetCurrentView("deliveries"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// setupDeliveriesKeybindings configures 'H', which opens the delivery log,
+// and the keys closing it.
+func (a *StructuredApp) setupDeliveriesKeybindings() error {
+	if err := a.gui.SetKeybinding("", 'H', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			a.deliveriesOpen = true
+			a.deliveries = a.deliveryLog()
+		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+			a.terminalCtrl.SendLiteralKeys("H")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, key := range []any{gocui.KeyEsc, 'q', 'H'} {
+		if err := a.gui.SetKeybinding("deliveries", key, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			a.deliveriesOpen = false
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
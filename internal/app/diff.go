@@ -0,0 +1,551 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/pane"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/jesseduffield/gocui"
+)
+
+// diffEntry is one file in the diff panel.
+type diffEntry struct {
+	diff      git.FileDiff
+	staged    bool // the diff is between HEAD and the index
+	untracked bool
+}
+
+// label returns the file list line for the entry.
+func (e diffEntry) label() string {
+	switch {
+	case e.untracked:
+		return "? " + e.diff.Path
+	case e.staged:
+		return "S " + e.diff.Path
+	default:
+		return "M " + e.diff.Path
+	}
+}
+
+// diffPanel is the state of the diff panel shown in place of the tiles.
+type diffPanel struct {
+	root     string // worktree root
+	session  string // session whose worktree is shown, for blame
+	base     bool   // compare against the merge base instead of the index
+	baseRef  string // merge base commit when base is set
+	entries  []diffEntry
+	fileIdx  int
+	hunkIdx  int
+	scroll   int    // first visible line of the hunk view
+	discard  bool   // X pressed once, waiting for confirmation
+	message  string // result of the last action
+	renderer *claude.Renderer
+
+	// Blame by prompt
+	blame      string          // "", "file" (turns that touched a file) or "turn" (files a turn changed)
+	history    *claude.History // the session's edits grouped by turn
+	blameFile  string          // absolute path shown in file blame
+	blameTurns []int           // turns that touched blameFile
+	blameIdx   int             // selected entry of blameTurns
+	turnIdx    int             // turn shown in turn blame
+	follow     bool            // scroll the selection into view on the next layout
+
+	// Background reads of the worktree's changes
+	mu    sync.Mutex // serializes the git runs of loads and hunk changes
+	loads int        // loads started; only the latest is shown
+}
+
+// diffCandidate is a directory the diff panel may show, and the session
+// working in it.
+type diffCandidate struct {
+	dir     string
+	session string
+	cwd     bool // look up the session's working directory instead of dir
+}
+
+// diffCandidates lists where the diff panel looks for a worktree: the
+// active session's worktree, else the selected session's, else the
+// selected repository, else the current directory.
+func (a *StructuredApp) diffCandidates() []diffCandidate {
+	var candidates []diffCandidate
+	if active := a.ActiveSession(); active != "" {
+		for _, sess := range a.sessionsForRepo {
+			if sess.Name == active && sess.Worktree != "" {
+				candidates = append(candidates, diffCandidate{dir: sess.Worktree, session: active})
+			}
+		}
+		candidates = append(candidates, diffCandidate{session: active, cwd: true})
+	}
+	if a.sessionSelectedIdx < len(a.sessionsForRepo) {
+		sess := a.sessionsForRepo[a.sessionSelectedIdx]
+		candidates = append(candidates, diffCandidate{dir: sess.Worktree, session: sess.Name})
+	}
+	if a.repoSelectedIdx < len(a.repositories) {
+		candidates = append(candidates, diffCandidate{dir: a.repositories[a.repoSelectedIdx].Path})
+	}
+	if wd, err := os.Getwd(); err == nil {
+		candidates = append(candidates, diffCandidate{dir: wd})
+	}
+	return candidates
+}
+
+// diffRoot returns the root of the first candidate in a git worktree and
+// the session working in it.
+func (a *StructuredApp) diffRoot(candidates []diffCandidate) (root, session string, err error) {
+	for _, c := range candidates {
+		dir := c.dir
+		if c.cwd {
+			dir = getTmuxSessionCwd(c.session)
+		}
+		if dir == "" {
+			continue
+		}
+		if root, err := a.git.TopLevel(dir); err == nil {
+			return root, c.session, nil
+		}
+	}
+	return "", "", errors.New("no git worktree found")
+}
+
+// openDiff shows the diff panel for the current worktree, which is found
+// and read in the background.
+func (a *StructuredApp) openDiff() {
+	d := &diffPanel{renderer: claude.NewRenderer(80, 24), message: "loading changes"}
+	a.diff = d
+	a.focusedPane = "diff"
+
+	candidates := a.diffCandidates()
+	d.loads++
+	seq := d.loads
+	go func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		root, session, err := a.diffRoot(candidates)
+		var load diffLoad
+		if err != nil {
+			load.message = err.Error()
+		} else {
+			load = a.readDiff(root, false)
+		}
+		a.gui.Update(func(g *gocui.Gui) error {
+			if a.diff == d && d.loads == seq {
+				d.root, d.session, d.message = root, session, ""
+				a.applyDiff(load, "")
+			}
+			return nil
+		})
+	}()
+}
+
+// closeDiff hides the diff panel and returns focus to the tiles.
+func (a *StructuredApp) closeDiff() {
+	a.diff = nil
+	a.gui.DeleteView("diff-files")
+	a.gui.DeleteView("diff-view")
+	if len(a.sessions) > 0 {
+		a.focusedPane = "main"
+	} else {
+		a.focusedPane = "sessions"
+	}
+}
+
+// diffLoad is a reading of a worktree's changes.
+type diffLoad struct {
+	entries []diffEntry
+	base    bool   // against the merge base, unless it couldn't be found
+	baseRef string // merge base commit when base is set
+	message string // why reading failed
+}
+
+// reloadDiff reads the worktree's changes again in the background,
+// keeping the selection where possible. change, if set, runs first, as
+// staging a hunk does, and returns the message to show.
+func (a *StructuredApp) reloadDiff(change func() string) {
+	d := a.diff
+	if d.root == "" {
+		return // Not found yet, or not a worktree
+	}
+	d.loads++
+	seq, root, base := d.loads, d.root, d.base
+	go func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+
+		message := ""
+		if change != nil {
+			message = change()
+		}
+		load := a.readDiff(root, base)
+		a.gui.Update(func(g *gocui.Gui) error {
+			if a.diff == d && d.loads == seq {
+				a.applyDiff(load, message)
+			}
+			return nil
+		})
+	}()
+}
+
+// readDiff reads the changes of the worktree at root, against the merge
+// base with its base branch if base is set or else against the index and
+// HEAD.
+func (a *StructuredApp) readDiff(root string, base bool) diffLoad {
+	load := diffLoad{base: base}
+
+	if base {
+		ref, err := a.git.MergeBase(root, session.BaseBranch(a.git, a.config, session.MainCheckout(a.git, root)))
+		if err != nil {
+			load.message = err.Error()
+			load.base = false
+		} else {
+			load.baseRef = ref
+			diffs, err := a.git.DiffAgainst(root, ref)
+			if err != nil {
+				load.message = err.Error()
+			}
+			for _, fd := range diffs {
+				load.entries = append(load.entries, diffEntry{diff: fd})
+			}
+		}
+	}
+	if !load.base {
+		staged, err := a.git.DiffWorktree(root, true)
+		if err != nil {
+			load.message = err.Error()
+		}
+		for _, fd := range staged {
+			load.entries = append(load.entries, diffEntry{diff: fd, staged: true})
+		}
+		unstaged, _ := a.git.DiffWorktree(root, false)
+		for _, fd := range unstaged {
+			load.entries = append(load.entries, diffEntry{diff: fd})
+		}
+	}
+
+	// git diff leaves out untracked files in both modes
+	changes, _ := a.git.Status(root)
+	for _, c := range changes {
+		if !c.Untracked() {
+			continue
+		}
+		fd, err := git.UntrackedDiff(root, c.Path)
+		if err != nil {
+			continue
+		}
+		load.entries = append(load.entries, diffEntry{diff: fd, untracked: true})
+	}
+	return load
+}
+
+// applyDiff shows a reading of the worktree's changes, and the message of
+// the change made before it unless reading failed.
+func (a *StructuredApp) applyDiff(load diffLoad, message string) {
+	d := a.diff
+	d.entries, d.base, d.baseRef = load.entries, load.base, load.baseRef
+	switch {
+	case load.message != "":
+		d.message = load.message
+	case message != "":
+		d.message = message
+	}
+	if d.fileIdx >= len(d.entries) {
+		d.fileIdx = max(len(d.entries)-1, 0)
+	}
+	a.selectHunk(d.hunkIdx)
+}
+
+// currentEntry returns the selected file, if any.
+func (d *diffPanel) currentEntry() (diffEntry, bool) {
+	if d.fileIdx >= len(d.entries) {
+		return diffEntry{}, false
+	}
+	return d.entries[d.fileIdx], true
+}
+
+// selectFile moves the file selection by delta.
+func (a *StructuredApp) selectFile(delta int) {
+	d := a.diff
+	if d.blame != "" {
+		a.selectBlameTurn(d.blameIdx + delta)
+		return
+	}
+	if len(d.entries) == 0 {
+		return
+	}
+	d.fileIdx = (d.fileIdx + delta + len(d.entries)) % len(d.entries)
+	d.discard = false
+	a.selectHunk(0)
+}
+
+// selectHunk selects hunk i of the current file and scrolls it into view.
+func (a *StructuredApp) selectHunk(i int) {
+	d := a.diff
+	d.discard = false
+	entry, ok := d.currentEntry()
+	if !ok || len(entry.diff.Hunks) == 0 {
+		d.hunkIdx, d.scroll = 0, 0
+		return
+	}
+	d.hunkIdx = max(min(i, len(entry.diff.Hunks)-1), 0)
+
+	// One title line, then each hunk's header and body; the first hunk
+	// keeps the title in view
+	d.scroll = 0
+	if d.hunkIdx > 0 {
+		d.scroll = 1
+		for j := 0; j < d.hunkIdx; j++ {
+			d.scroll += 1 + len(entry.diff.Hunks[j].Lines)
+		}
+	}
+}
+
+// applyHunk stages, unstages or discards the selected hunk.
+func (a *StructuredApp) applyHunk(action rune) {
+	d := a.diff
+	entry, ok := d.currentEntry()
+	if !ok || len(entry.diff.Hunks) == 0 || d.blame != "" {
+		return
+	}
+	if d.base {
+		d.message = "read-only against the merge base, press b to switch"
+		return
+	}
+	patch := entry.diff.HunkPatch(d.hunkIdx)
+
+	var cached, reverse bool
+	var done string
+	switch action {
+	case 's':
+		if entry.staged {
+			d.message = "already staged"
+			return
+		}
+		cached, done = true, "staged hunk"
+	case 'u':
+		if !entry.staged {
+			d.message = "not staged"
+			return
+		}
+		cached, reverse, done = true, true, "unstaged hunk"
+	case 'X':
+		if entry.staged {
+			d.message = "unstage the hunk before discarding it"
+			return
+		}
+		if !d.discard {
+			d.discard = true
+			d.message = "press X again to discard this hunk"
+			return
+		}
+		reverse, done = true, "discarded hunk"
+	}
+
+	root := d.root
+	a.reloadDiff(func() string {
+		if err := a.git.ApplyPatch(root, patch, cached, reverse); err != nil {
+			return err.Error()
+		}
+		return done
+	})
+}
+
+// layoutDiff renders the diff panel inside area: the file list on the
+// left and the selected file's hunks on the right.
+func (a *StructuredApp) layoutDiff(g *gocui.Gui, area pane.Layout) error {
+	d := a.diff
+	a.deleteTiles(g, 0)
+	g.DeleteView("main-view")
+
+	listWidth := max(min(area.Width()*30/100, 40), 20)
+	split := area.X0 + listWidth
+
+	files, err := g.SetView("diff-files", area.X0, area.Y0, split, area.Y1, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+			return err
+		}
+	}
+	files.Title = " Changes "
+	if d.base {
+		files.Title = fmt.Sprintf(" Changes since %.8s ", d.baseRef)
+	}
+	files.Frame = true
+	files.Clear()
+	if len(d.entries) == 0 {
+		fmt.Fprint(files, "\n  No changes")
+	}
+	for i, entry := range d.entries {
+		prefix := "  "
+		if i == d.fileIdx {
+			prefix = "> "
+		}
+		fmt.Fprintf(files, "%s%s\n", prefix, entry.label())
+	}
+
+	view, err := g.SetView("diff-view", split+1, area.Y0, area.X1, area.Y1, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+			return err
+		}
+	}
+	view.Title = " Diff "
+	view.Frame = true
+	view.FrameColor = gocui.ColorCyan
+	view.TitleColor = gocui.ColorCyan
+	view.Wrap = false
+	view.Footer = " s stage  u unstage  X discard  n/N hunk  j/k file  b base  B blame  r reload  Esc close "
+	if d.message != "" {
+		view.Footer = " " + d.message + " "
+	}
+	view.Clear()
+
+	width, _ := view.Size()
+	d.renderer.Resize(width, area.Height())
+
+	if d.blame != "" {
+		a.renderBlame(view)
+		return nil
+	}
+
+	entry, ok := d.currentEntry()
+	if !ok {
+		return nil
+	}
+	view.Title = fmt.Sprintf(" %s ", entry.diff.Path)
+	if entry.diff.OldPath != "" && entry.diff.OldPath != entry.diff.Path {
+		view.Title = fmt.Sprintf(" %s -> %s ", entry.diff.OldPath, entry.diff.Path)
+	}
+
+	var lines []string
+	switch {
+	case entry.diff.Binary:
+		lines = append(lines, "  Binary file or too large to show")
+	case entry.untracked:
+		lines = append(lines, "  \033[2mUntracked file\033[0m")
+	case entry.staged:
+		lines = append(lines, "  \033[2mStaged\033[0m")
+	default:
+		lines = append(lines, "  \033[2mNot staged\033[0m")
+	}
+	for i, hunk := range entry.diff.Hunks {
+		marker := "  "
+		if i == d.hunkIdx {
+			marker = "\033[33m▶\033[0m "
+		}
+		lines = append(lines, fmt.Sprintf("%s\033[36m%s\033[0m", marker, hunk.Header))
+		lines = append(lines, d.renderer.RenderUnifiedLines(entry.diff.Path, hunk.Lines)...)
+	}
+	fmt.Fprint(view, strings.Join(lines, "\n"))
+
+	d.scroll = max(min(d.scroll, len(lines)-1), 0)
+	view.SetOrigin(0, d.scroll)
+	return nil
+}
+
+// stepDiff moves to the next or previous hunk, or turn when blaming.
+func (a *StructuredApp) stepDiff(delta int) {
+	if a.diff.blame != "" {
+		a.selectBlameTurn(a.diff.blameIdx + delta)
+		return
+	}
+	a.selectHunk(a.diff.hunkIdx + delta)
+}
+
+// diffBack leaves turn blame for file blame, file blame for the diff, or
+// closes the panel.
+func (a *StructuredApp) diffBack() {
+	d := a.diff
+	switch {
+	case d.blame == "turn" && d.blameFile != "":
+		d.blame = "file"
+		d.follow = true
+	case d.blame != "":
+		d.blame = ""
+		a.selectHunk(d.hunkIdx)
+	default:
+		a.closeDiff()
+	}
+}
+
+// scrollDiff moves the hunk view by half a page.
+func (a *StructuredApp) scrollDiff(up bool) {
+	v, err := a.gui.View("diff-view")
+	if err != nil {
+		return
+	}
+	step := max(v.InnerHeight()/2, 1)
+	if up {
+		step = -step
+	}
+	a.diff.scroll = max(a.diff.scroll+step, 0)
+}
+
+// setupDiffKeybindings configures the diff panel. Its bindings are on the
+// diff view so they take precedence over the global ones while it has focus.
+func (a *StructuredApp) setupDiffKeybindings() error {
+	// 'D' - Toggle the diff panel
+	if err := a.gui.SetKeybinding("", 'D', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			if a.diff == nil {
+				a.openDiff()
+			} else {
+				a.closeDiff()
+			}
+		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+			a.terminalCtrl.SendLiteralKeys("D")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	keys := map[any]func(){
+		'j':                func() { a.selectFile(1) },
+		gocui.KeyArrowDown: func() { a.selectFile(1) },
+		'k':                func() { a.selectFile(-1) },
+		gocui.KeyArrowUp:   func() { a.selectFile(-1) },
+		'n':                func() { a.stepDiff(1) },
+		'N':                func() { a.stepDiff(-1) },
+		'B':                a.openFileBlame,
+		gocui.KeyEnter:     a.openTurnBlame,
+		's':                func() { a.applyHunk('s') },
+		'u':                func() { a.applyHunk('u') },
+		'X':                func() { a.applyHunk('X') },
+		'r': func() {
+			a.diff.message = ""
+			a.reloadDiff(nil)
+		},
+		'b': func() {
+			if a.diff.blame != "" {
+				return
+			}
+			a.diff.base = !a.diff.base
+			a.diff.message = ""
+			a.diff.fileIdx = 0
+			a.reloadDiff(nil)
+		},
+		gocui.KeyCtrlU: func() { a.scrollDiff(true) },
+		gocui.KeyCtrlD: func() { a.scrollDiff(false) },
+		gocui.KeyEsc:   a.diffBack,
+		'q':            a.diffBack,
+		'D':            a.closeDiff,
+	}
+	for key, action := range keys {
+		fn := action
+		if err := a.gui.SetKeybinding("diff-view", key, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			if a.diff != nil && a.input.Mode().IsNormal() {
+				fn()
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
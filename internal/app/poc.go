@@ -13,6 +13,7 @@ import (
 	"github.com/abdullathedruid/cmux/internal/git"
 	"github.com/abdullathedruid/cmux/internal/input"
 	"github.com/abdullathedruid/cmux/internal/pane"
+	"github.com/abdullathedruid/cmux/internal/session"
 	"github.com/abdullathedruid/cmux/internal/terminal"
 	"github.com/abdullathedruid/cmux/internal/tmux"
 	"github.com/abdullathedruid/cmux/internal/ui"
@@ -26,6 +27,7 @@ type PocApp struct {
 	input    *input.Handler
 	config   *config.Config
 	tmux     tmux.Client
+	git      *git.Client
 	repoRoot string
 
 	// Layout state for resize detection
@@ -35,13 +37,13 @@ type PocApp struct {
 	firstCall          bool
 }
 
-// NewPocApp creates a new application instance.
-func NewPocApp() (*PocApp, error) {
-	return NewPocAppWithConfig(nil)
+// NewPocApp creates a new application instance running git with gitClient.
+func NewPocApp(gitClient *git.Client) (*PocApp, error) {
+	return NewPocAppWithConfig(nil, gitClient)
 }
 
 // NewPocAppWithConfig creates a new application instance with the given config.
-func NewPocAppWithConfig(cfg *config.Config) (*PocApp, error) {
+func NewPocAppWithConfig(cfg *config.Config, gitClient *git.Client) (*PocApp, error) {
 	if cfg == nil {
 		var err error
 		cfg, err = config.Load()
@@ -58,7 +60,7 @@ func NewPocAppWithConfig(cfg *config.Config) (*PocApp, error) {
 	}
 
 	// Find repository root from current directory
-	repoRoot, err := git.FindRepoRoot(".")
+	repoRoot, err := gitClient.FindRepoRoot(".")
 	if err != nil {
 		// Fall back to current directory if not in a git repo
 		repoRoot = "."
@@ -70,6 +72,7 @@ func NewPocAppWithConfig(cfg *config.Config) (*PocApp, error) {
 		input:     input.NewHandler(),
 		config:    cfg,
 		tmux:      tmux.NewClient(cfg.ClaudeCommand),
+		git:       gitClient,
 		repoRoot:  repoRoot,
 		firstCall: true,
 	}, nil
@@ -103,11 +106,20 @@ func (a *PocApp) InitSessions(sessions []string) error {
 }
 
 // processOutput reads from a pane's output channel and writes to its terminal.
+// The pane is removed once its control connection ends.
 func (a *PocApp) processOutput(p *pane.Pane) {
 	for data := range p.OutputChan() {
 		p.WriteToTerminal(data)
 		a.gui.Update(func(g *gocui.Gui) error { return nil })
 	}
+
+	a.gui.Update(func(g *gocui.Gui) error {
+		if idx := a.panes.IndexOf(p); idx >= 0 {
+			a.panes.Remove(idx)
+			g.DeleteView(p.ViewName)
+		}
+		return nil
+	})
 }
 
 // Run starts the main event loop.
@@ -302,9 +314,10 @@ func (a *PocApp) addNewPane(sessionName string) error {
 
 // createWorktreeAndSession creates a git worktree and a tmux session in it.
 func (a *PocApp) createWorktreeAndSession(name string) error {
-	// Use the git package to create the worktree with a new branch
-	worktreePath, err := git.CreateWorktree(a.repoRoot, name, true)
-	if err != nil {
+	// Create the worktree with a new branch where the config places them;
+	// a failed setup still leaves it usable
+	worktreePath, err := session.CreateWorktree(a.git, a.config, a.repoRoot, name, true)
+	if worktreePath == "" {
 		return fmt.Errorf("creating worktree: %w", err)
 	}
 
@@ -349,4 +362,3 @@ func (a *PocApp) makeInputEditor() func(v *gocui.View, key gocui.Key, ch rune, m
 		return false
 	}
 }
-
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"slices"
+
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/jesseduffield/gocui"
+)
+
+// isRemoteBranch reports whether name is a remote-tracking branch of the
+// repository, such as "origin/feature".
+func (a *StructuredApp) isRemoteBranch(repoPath, name string) bool {
+	remotes, _ := a.git.ListRemoteBranches(repoPath)
+	return slices.Contains(remotes, name)
+}
+
+// createSessionForPR checks out a pull request's head, creates a session on
+// it and submits the review prompt once Claude is ready, as a job.
+func (a *StructuredApp) createSessionForPR(repoPath, ref, number string) {
+	a.jobs.Start("review "+ref, func(progress func(string)) (string, error) {
+		progress("fetching")
+		name, prompt, err := a.sessionManager.CreateSessionForPR(repoPath, ref, number)
+		if name == "" {
+			return "", err
+		}
+		a.gui.Update(func(g *gocui.Gui) error {
+			a.selectNewSession(name)
+			return nil
+		})
+		if prompt == "" {
+			return name, nil
+		}
+
+		progress("waiting for Claude")
+		if err := session.SubmitPrompt(a.tmuxClient, name, prompt); err != nil {
+			return "", err
+		}
+		return "review started in " + name, nil
+	})
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/registry"
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+// recordHookEvent remembers the Claude session behind a tmux session so it
+// can be resumed after the tmux session dies. Called from the event watcher.
+func (a *StructuredApp) recordHookEvent(tmuxSession string, event claude.HookEvent) {
+	changed := a.registry.Record(registry.Entry{
+		Name:            tmuxSession,
+		Worktree:        event.Cwd,
+		ClaudeSessionID: event.SessionID,
+		TranscriptPath:  event.TranscriptPath,
+	})
+	if changed {
+		a.registry.Save()
+	}
+}
+
+// recordSessions remembers the repo and branch of discovered sessions.
+func (a *StructuredApp) recordSessions(sessions []*state.Session) {
+	changed := false
+	for _, sess := range sessions {
+		if a.registry.Record(registry.Entry{
+			Name:     sess.Name,
+			RepoPath: sess.RepoPath,
+			Branch:   sess.Branch,
+			Worktree: sess.Worktree,
+		}) {
+			changed = true
+		}
+	}
+	if changed {
+		a.registry.Save()
+	}
+}
+
+// dormantSessionsForRepo returns the registry's resumable sessions for a
+// repository that have no running tmux session.
+func (a *StructuredApp) dormantSessionsForRepo(repoPath string) []*state.Session {
+	// Without a tmux listing every session would look dormant
+	if a.snapshot.Err != nil {
+		return nil
+	}
+
+	var dormant []*state.Session
+	for _, e := range a.registry.Dormant(a.snapshot.Live) {
+		if e.RepoPath != repoPath {
+			continue
+		}
+		dormant = append(dormant, &state.Session{
+			Name:       e.Name,
+			RepoPath:   e.RepoPath,
+			RepoName:   filepath.Base(e.RepoPath),
+			Worktree:   e.Worktree,
+			Branch:     e.Branch,
+			SessionID:  e.ClaudeSessionID,
+			LastActive: e.LastSeen,
+			Dormant:    true,
+		})
+	}
+	return dormant
+}
+
+// isDormant reports whether a session is only known from the registry.
+func (a *StructuredApp) isDormant(name string) bool {
+	if _, ok := a.registry.Get(name); !ok {
+		return false
+	}
+	if slices.Contains(a.snapshot.Live, name) {
+		return false
+	}
+	return !a.tmuxClient.HasSession(name)
+}
+
+// resumeSession recreates a dormant session's tmux session and resumes its
+// Claude conversation.
+func (a *StructuredApp) resumeSession(name string) error {
+	entry, ok := a.registry.Get(name)
+	if !ok {
+		return fmt.Errorf("no record of session %s", name)
+	}
+
+	dir := entry.Worktree
+	if dir == "" {
+		dir = entry.RepoPath
+	}
+	if err := a.sessionManager.ResumeSession(name, dir, entry.ClaudeSessionID); err != nil {
+		return err
+	}
+
+	a.discoveryService.Refresh()
+	return nil
+}
+
+// forgetSession removes a session from the registry so it is no longer
+// offered for resuming.
+func (a *StructuredApp) forgetSession(name string) {
+	a.registry.Remove(name)
+	a.registry.Save()
+}
@@ -7,17 +7,25 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
-	"sort"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/abdullathedruid/cmux/api"
 	"github.com/abdullathedruid/cmux/internal/claude"
 	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/control"
 	"github.com/abdullathedruid/cmux/internal/discovery"
 	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/gitstatus"
 	"github.com/abdullathedruid/cmux/internal/input"
+	"github.com/abdullathedruid/cmux/internal/jobs"
+	"github.com/abdullathedruid/cmux/internal/notify"
 	"github.com/abdullathedruid/cmux/internal/pane"
+	"github.com/abdullathedruid/cmux/internal/registry"
+	"github.com/abdullathedruid/cmux/internal/rules"
 	"github.com/abdullathedruid/cmux/internal/session"
 	"github.com/abdullathedruid/cmux/internal/state"
 	"github.com/abdullathedruid/cmux/internal/terminal"
@@ -33,14 +41,35 @@ type StructuredApp struct {
 	config *config.Config
 	input  *input.Handler
 
-	// Session views keyed by tmux session name
+	// Session views keyed by tmux session name. Like the rest of the app's
+	// state it belongs to the gocui goroutine; other goroutines reach it
+	// through gui.Update.
 	views map[string]*claude.View
 
+	// Copies of the views handed to the transcript poller, latest only
+	polledViews chan []*claude.View
+
 	// Session order for layout
 	sessions []string
 
 	// Event watcher for hook events
-	eventWatcher *claude.EventWatcher
+	eventWatcher hookSource
+
+	// Notifications of sessions needing attention, and the session on
+	// screen as of the last layout, which needs none
+	notifier *notify.Notifier
+	onScreen atomic.Value // string
+
+	// Control API for scripts and editor plugins, unless attached to a
+	// cmux daemon which serves it and notifies and runs rules instead
+	control *control.Server
+	remote  *control.Remote
+
+	// Webhooks and commands run on session events, and their delivery
+	// log while it is open
+	rules          *rules.Engine
+	deliveriesOpen bool
+	deliveries     []rules.Delivery
 
 	// Active session index
 	activeIdx int
@@ -58,28 +87,60 @@ type StructuredApp struct {
 	sidebarEnabled     bool
 	availableSessions  []string // All discovered Claude sessions
 	sidebarSelectedIdx int      // Cursor in sidebar
-	tmuxClient         *tmux.RealClient
+	tmuxClient         tmux.Client
 	inputPurpose       string // "new_session" when creating a new session
 
 	// Advanced session management
-	discoveryService *discovery.Service
+	discoveryService snapshotSource
+	snapshot         discovery.Snapshot // latest discovery pass
+	state            *state.State       // sessions of the latest discovery pass
 	sessionManager   *session.Manager
+	registry         *registry.Registry // Sessions seen before, for resuming
+	git              *git.Client
 
 	// Unified sidebar state
-	focusedPane      string                     // "repos", "sessions", or "main"
-	repositories     []discovery.RepositoryInfo // All configured repositories
-	repoSelectedIdx  int                        // Currently selected repo index
-	sessionsForRepo  []*state.Session           // Sessions filtered for selected repo
-	sessionSelectedIdx int                      // Selected session index in the list
+	focusedPane        string                     // "repos", "sessions", or "main"
+	pendingRepo        string                     // added repository to select once discovered
+	pendingSession     string                     // created session to select once discovered
+	repositories       []discovery.RepositoryInfo // All configured repositories
+	repoSelectedIdx    int                        // Currently selected repo index
+	sessionsForRepo    []*state.Session           // Sessions filtered for selected repo
+	sessionSelectedIdx int                        // Selected session index in the list
+
+	// Tiled main area (sidebar mode)
+	pinned      map[string]bool   // Sessions kept in the tile grid
+	preset      pane.LayoutPreset // Current tile arrangement
+	zoomed      bool              // Active tile fills the main area
+	tileCount   int               // Tile views created by the last layout
+	mainArea    pane.Layout       // Area the tiles were laid out in
+	dragging    bool              // Split drag in progress
+	layoutDirty bool              // Split ratio changed since the config was saved
+	// Split ratios of an open workspace by preset, kept out of the config
+	workspaceRatios map[string]int
+
+	// Diff panel shown in place of the tiles, nil when closed
+	diff *diffPanel
+
+	// Background commit, push and PR jobs
+	jobs          *jobs.Manager
+	pendingCommit *pendingCommit // commit waiting for its message in the input modal
+
+	// Git state of the sessions' worktrees, checked in the background
+	gitStatus *gitstatus.Refresher
+
+	// Session templates offered by 'n', and the one waiting for input
+	templatePicker  *templatePicker
+	pendingTemplate *pendingTemplate
 }
 
-// NewStructuredApp creates a new structured view application.
-func NewStructuredApp() (*StructuredApp, error) {
-	return NewStructuredAppWithConfig(nil)
+// NewStructuredApp creates a new structured view application running git
+// with gitClient.
+func NewStructuredApp(gitClient *git.Client) (*StructuredApp, error) {
+	return NewStructuredAppWithConfig(nil, gitClient)
 }
 
 // NewStructuredAppWithConfig creates a new structured view application with the given config.
-func NewStructuredAppWithConfig(cfg *config.Config) (*StructuredApp, error) {
+func NewStructuredAppWithConfig(cfg *config.Config, gitClient *git.Client) (*StructuredApp, error) {
 	if cfg == nil {
 		var err error
 		cfg, err = config.Load()
@@ -95,30 +156,108 @@ func NewStructuredAppWithConfig(cfg *config.Config) (*StructuredApp, error) {
 		return nil, fmt.Errorf("initializing GUI: %w", err)
 	}
 
-	// Create event watcher for hooks
-	eventsDir := claude.EventsDir()
-	watcher, err := claude.NewEventWatcher(eventsDir)
+	// Attach to a running cmux daemon, or watch hooks ourselves
+	var hooks hookSource
+	remote := control.Attach(api.SocketPath())
+	if remote != nil {
+		hooks = remote.Hooks()
+	} else {
+		watcher, err := claude.NewEventWatcher(claude.EventsDir())
+		if err != nil {
+			g.Close()
+			return nil, fmt.Errorf("creating event watcher: %w", err)
+		}
+		hooks = watcher
+	}
+
+	tmuxClient := tmux.NewClient(cfg.ClaudeCommand)
+	tmuxClient.SetClaudePatterns(cfg.ClaudePatterns)
+	tmuxClient.SetSessionOptions(func(dir string) tmux.SessionOptions {
+		return session.SessionOptionsForDir(gitClient, cfg, dir)
+	})
+
+	app, err := newStructuredApp(g, cfg, gitClient, tmuxClient, hooks, remote)
 	if err != nil {
+		hooks.Stop()
 		g.Close()
-		return nil, fmt.Errorf("creating event watcher: %w", err)
+		return nil, err
 	}
+	return app, nil
+}
 
-	tmuxClient := tmux.NewClient(cfg.ClaudeCommand)
-	discoverySvc := discovery.NewService(tmuxClient, cfg)
-	sessionMgr := session.NewManager(tmuxClient, cfg)
+// newStructuredApp creates the app around a GUI, git, tmux and a source of
+// hook events. With a remote, the app is a client of that cmux daemon: it
+// follows the daemon's discovery, and leaves notifications, rules and the
+// control API to it.
+func newStructuredApp(g *gocui.Gui, cfg *config.Config, gitClient *git.Client, tmuxClient tmux.Client, hooks hookSource, remote *control.Remote) (*StructuredApp, error) {
+	sessionMgr := session.NewManager(tmuxClient, gitClient, cfg)
+
+	var discoverySvc snapshotSource
+	var notifier *notify.Notifier
+	var ruleEngine *rules.Engine
+	var controlServer *control.Server
+	if remote != nil {
+		discoverySvc = remote.Discovery()
+	} else {
+		var err error
+		notifier, err = notify.New(cfg.Notify, tmuxClient)
+		if err != nil {
+			return nil, fmt.Errorf("configuring notifications: %w", err)
+		}
+		ruleEngine, err = rules.New(cfg.Rules)
+		if err != nil {
+			return nil, fmt.Errorf("configuring rules: %w", err)
+		}
+		local := discovery.NewService(tmuxClient, gitClient, cfg)
+		controlServer = control.NewServer(cfg, tmuxClient, local, sessionMgr)
+		discoverySvc = local
+	}
+
+	// A missing or unreadable registry just means nothing to resume yet
+	reg := registry.New(cfg.RegistryFile())
+	reg.Load()
 
 	app := &StructuredApp{
 		gui:              g,
 		config:           cfg,
+		git:              gitClient,
 		input:            input.NewHandler(),
 		views:            make(map[string]*claude.View),
+		polledViews:      make(chan []*claude.View, 1),
 		sessions:         make([]string, 0),
-		eventWatcher:     watcher,
+		eventWatcher:     hooks,
+		notifier:         notifier,
+		control:          controlServer,
+		remote:           remote,
+		rules:            ruleEngine,
 		tmuxClient:       tmuxClient,
 		discoveryService: discoverySvc,
+		state:            state.New(),
 		sessionManager:   sessionMgr,
+		registry:         reg,
 		focusedPane:      "sessions", // Default focus on sessions pane
-	}
+		pinned:           make(map[string]bool),
+		preset:           pane.ParsePreset(cfg.Layout.Preset),
+		jobs:             jobs.NewManager(),
+	}
+	app.jobs.OnUpdate(app.onJobUpdate)
+	app.gitStatus = app.newGitStatusRefresher()
+	app.eventWatcher.OnEvent(app.onHookEvent)
+	if remote != nil {
+		// The daemon notifies, so it is told what is on screen instead
+		remote.ShowIn(tmuxClient.GetCurrentSession())
+		return app, nil
+	}
+	app.eventWatcher.OnEvent(notifier.OnEvent)
+	app.eventWatcher.OnEvent(ruleEngine.OnHookEvent)
+	app.eventWatcher.OnEvent(controlServer.OnHookEvent)
+	ruleEngine.OnDelivery(app.onDelivery)
+
+	// The session on screen in cmux is in front of the user while cmux is
+	app.onScreen.Store("")
+	notifier.ShowIn(tmuxClient.GetCurrentSession(), func(session string) bool {
+		return app.onScreen.Load() == session
+	})
 
 	return app, nil
 }
@@ -145,15 +284,7 @@ func (a *StructuredApp) InitSessions(sessions []string) error {
 		a.views[session] = view
 		a.sessions = append(a.sessions, session)
 	}
-
-	// Wire up event callbacks
-	a.eventWatcher.OnEvent(func(tmuxSession string, event claude.HookEvent) {
-		if view, ok := a.views[tmuxSession]; ok {
-			view.UpdateFromHookEvent(event)
-			// Trigger redraw
-			a.gui.Update(func(g *gocui.Gui) error { return nil })
-		}
-	})
+	a.pollViews()
 
 	return nil
 }
@@ -163,13 +294,17 @@ func (a *StructuredApp) InitWithDiscovery() error {
 	a.sidebarEnabled = true
 	a.focusedPane = "sessions"
 
-	// Load repositories and sessions
-	a.refreshRepositories()
+	// Mouse clicks focus tiles and drag the split between them
+	a.gui.Mouse = true
 
-	// Also refresh available sessions for backward compatibility
-	if err := a.refreshAvailableSessions(); err != nil {
-		return err
+	// Load repositories and sessions before the first frame, then keep
+	// discovering in the background
+	snap := a.discoveryService.Discover()
+	a.applySnapshot(snap)
+	if snap.Err != nil {
+		return snap.Err
 	}
+	a.watchDiscovery()
 
 	// Load first session from selected repo if any exist
 	if len(a.sessionsForRepo) > 0 {
@@ -179,31 +314,24 @@ func (a *StructuredApp) InitWithDiscovery() error {
 		a.loadSession(a.availableSessions[0])
 	}
 
-	// Wire up event callbacks for dynamic session discovery
-	a.eventWatcher.OnEvent(func(tmuxSession string, event claude.HookEvent) {
+	return nil
+}
+
+// onHookEvent is called from the event watcher's goroutine with each hook
+// event, and hands it to the session's view on the gocui goroutine.
+func (a *StructuredApp) onHookEvent(tmuxSession string, event claude.HookEvent) {
+	// The registry is safe to use from any goroutine
+	a.recordHookEvent(tmuxSession, event)
+	a.gui.Update(func(g *gocui.Gui) error {
 		if view, ok := a.views[tmuxSession]; ok {
 			view.UpdateFromHookEvent(event)
-			a.gui.Update(func(g *gocui.Gui) error { return nil })
 		}
+		return nil
 	})
-
-	return nil
 }
 
-// refreshAvailableSessions rescans for Claude sessions.
-func (a *StructuredApp) refreshAvailableSessions() error {
-	sessions, err := a.tmuxClient.DiscoverClaudeSessions()
-	if err != nil {
-		return err
-	}
-
-	// Extract session names and sort them
-	names := make([]string, 0, len(sessions))
-	for _, s := range sessions {
-		names = append(names, s.Name)
-	}
-	sort.Strings(names)
-
+// setAvailableSessions records the discovered Claude sessions, sorted.
+func (a *StructuredApp) setAvailableSessions(names []string) {
 	a.availableSessions = names
 
 	// Adjust selection if needed
@@ -214,22 +342,40 @@ func (a *StructuredApp) refreshAvailableSessions() error {
 			a.sidebarSelectedIdx = 0
 		}
 	}
-
-	return nil
 }
 
-// loadSession loads a session into the main view area.
+// loadSession loads a session into the main view area. Pinned sessions stay
+// tiled alongside it; any other previously loaded session is replaced.
 func (a *StructuredApp) loadSession(name string) {
-	// Check if already loaded
-	if _, ok := a.views[name]; ok {
-		// Already loaded, just make it active
-		for i, s := range a.sessions {
-			if s == name {
-				a.activeIdx = i
-				return
-			}
+	if _, ok := a.views[name]; !ok {
+		a.views[name] = a.newSessionView(name)
+		a.pollViews()
+	}
+
+	var sessions []string
+	found := false
+	for _, s := range a.sessions {
+		if s == name {
+			found = true
+		} else if !a.pinned[s] {
+			continue
 		}
+		sessions = append(sessions, s)
+	}
+	if !found {
+		sessions = append(sessions, name)
 	}
+	a.sessions = sessions
+
+	for i, s := range a.sessions {
+		if s == name {
+			a.activeIdx = i
+		}
+	}
+}
+
+// newSessionView creates a view for a session sized for the main area.
+func (a *StructuredApp) newSessionView(name string) *claude.View {
 
 	// Calculate view dimensions
 	maxX, maxY := a.gui.Size()
@@ -248,23 +394,62 @@ func (a *StructuredApp) loadSession(name string) {
 	// Create the view
 	view := claude.NewView(name, width, height)
 
-	// Initialize transcript from event file to load chat history
-	if transcriptPath := claude.GetLatestTranscriptPath(name); transcriptPath != "" {
+	// Initialize transcript from event file to load chat history, falling
+	// back to the registry for sessions whose event file is gone
+	transcriptPath := claude.GetLatestTranscriptPath(name)
+	if entry, ok := a.registry.Get(name); ok && transcriptPath == "" {
+		transcriptPath = entry.TranscriptPath
+	}
+	if transcriptPath != "" {
 		view.InitTranscript(transcriptPath)
 		view.PollTranscript() // Load existing messages
 	}
 
-	a.views[name] = view
+	return view
+}
+
+// watchDiscovery starts discovering in the background, applying each
+// snapshot on the UI goroutine.
+func (a *StructuredApp) watchDiscovery() {
+	snapshots := a.discoveryService.Subscribe()
+	a.discoveryService.Start(time.Duration(a.config.RefreshInterval) * time.Second)
+	go func() {
+		for snap := range snapshots {
+			a.gui.Update(func(g *gocui.Gui) error {
+				a.applySnapshot(snap)
+				return nil
+			})
+		}
+	}()
+}
+
+// applySnapshot replaces the discovered repositories and sessions with those
+// of a discovery pass. A pass that couldn't query tmux only updates the
+// repositories, keeping the sessions of the last good one.
+func (a *StructuredApp) applySnapshot(snap discovery.Snapshot) {
+	a.repositories = snap.Repositories
+	if snap.Err != nil {
+		a.refreshRepositories()
+		return
+	}
+	a.snapshot = snap
+	a.state.UpdateSessions(snap.Sessions)
+	a.recordSessions(snap.Sessions)
+	a.setAvailableSessions(snap.Claude)
+	if a.rules != nil {
+		a.rules.Discovered(snap)
+	}
 
-	// Clear existing sessions and add just this one (single session mode for sidebar)
-	a.sessions = []string{name}
-	a.activeIdx = 0
+	a.selectPendingRepo()
+	a.refreshRepositories()
+	if a.pendingSession != "" {
+		a.selectPendingSession()
+	}
 }
 
-// refreshRepositories reloads the configured repositories.
+// refreshRepositories fits the repository selection to the discovered
+// repositories and reloads the selected one's sessions.
 func (a *StructuredApp) refreshRepositories() {
-	a.repositories = a.discoveryService.GetConfiguredRepositories()
-
 	// Adjust selection if needed
 	if a.repoSelectedIdx >= len(a.repositories) {
 		if len(a.repositories) > 0 {
@@ -278,19 +463,15 @@ func (a *StructuredApp) refreshRepositories() {
 	a.refreshSessionsForSelectedRepo()
 }
 
-// refreshSessionsForSelectedRepo loads sessions for the currently selected repository.
+// refreshSessionsForSelectedRepo loads sessions for the currently selected
+// repository from the latest discovery pass, without running tmux or git.
 func (a *StructuredApp) refreshSessionsForSelectedRepo() {
 	if len(a.repositories) == 0 || a.repoSelectedIdx >= len(a.repositories) {
 		a.sessionsForRepo = nil
 		return
 	}
 
-	allSessions, err := a.discoveryService.DiscoverSessions()
-	if err != nil {
-		a.sessionsForRepo = nil
-		return
-	}
-
+	allSessions := a.state.GetSessions()
 	repoPath := a.repositories[a.repoSelectedIdx].Path
 
 	var filtered []*state.Session
@@ -299,7 +480,14 @@ func (a *StructuredApp) refreshSessionsForSelectedRepo() {
 			filtered = append(filtered, sess)
 		}
 	}
+
+	// Sessions that died are listed after the live ones, ready to resume
+	dormant := a.dormantSessionsForRepo(repoPath)
+	filtered = append(filtered, dormant...)
+	a.applyRegistryInfo(filtered)
+	a.applyGitStatus(filtered)
 	a.sessionsForRepo = filtered
+	a.prunePinned(append(allSessions, dormant...))
 
 	// Adjust session selection
 	if a.sessionSelectedIdx >= len(a.sessionsForRepo) {
@@ -325,6 +513,16 @@ func (a *StructuredApp) Run() error {
 		return fmt.Errorf("starting event watcher: %w", err)
 	}
 
+	// Serve the control API unless another cmux already does, and say so
+	// in the status bar if it can't
+	if a.control != nil {
+		if err := a.control.Start(api.SocketPath()); err != nil {
+			a.jobs.Start("control API", func(func(string)) (string, error) {
+				return "", err
+			})
+		}
+	}
+
 	a.gui.SetManagerFunc(a.layout)
 
 	if err := a.setupKeybindings(); err != nil {
@@ -332,7 +530,7 @@ func (a *StructuredApp) Run() error {
 	}
 
 	// Start transcript polling goroutine
-	go a.pollTranscripts()
+	go a.pollTranscripts(a.polledViews)
 
 	// Handle SIGINT/SIGTERM for clean exit
 	sigCh := make(chan os.Signal, 1)
@@ -353,17 +551,48 @@ func (a *StructuredApp) Run() error {
 
 // Close cleans up all resources.
 func (a *StructuredApp) Close() {
+	a.saveLayout()
 	a.eventWatcher.Stop()
+	a.discoveryService.Stop()
+	if a.rules != nil {
+		a.rules.Stop()
+	}
+	if a.control != nil {
+		a.control.Close()
+	}
+	a.gitStatus.Close()
 	a.gui.Close()
 }
 
-// pollTranscripts periodically polls transcript files for updates.
-func (a *StructuredApp) pollTranscripts() {
+// pollViews hands the current views to the transcript poller, replacing
+// any it hasn't picked up yet. Call it whenever a.views changes.
+func (a *StructuredApp) pollViews() {
+	views := make([]*claude.View, 0, len(a.views))
+	for _, view := range a.views {
+		views = append(views, view)
+	}
+	select {
+	case <-a.polledViews:
+	default:
+	}
+	a.polledViews <- views
+}
+
+// pollTranscripts periodically polls the transcript files of the views last
+// received from pollViews. Views lock themselves, so polling them here is
+// safe; only the set of views belongs to the gocui goroutine.
+func (a *StructuredApp) pollTranscripts(updates <-chan []*claude.View) {
 	ticker := time.NewTicker(200 * time.Millisecond)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		for _, view := range a.views {
+	var views []*claude.View
+	for {
+		select {
+		case views = <-updates:
+			continue
+		case <-ticker.C:
+		}
+		for _, view := range views {
 			if err := view.PollTranscript(); err != nil {
 				continue // Ignore errors
 			}
@@ -378,6 +607,11 @@ func (a *StructuredApp) pollTranscripts() {
 func (a *StructuredApp) layout(g *gocui.Gui) error {
 	maxX, maxY := g.Size()
 	currentMode := a.input.Mode()
+	onScreen := a.ActiveSession()
+	a.onScreen.Store(onScreen)
+	if a.remote != nil {
+		a.remote.Show(onScreen)
+	}
 
 	// Reserve 1 visible row for status bar
 	paneMaxY := maxY - pane.StatusBarHeight
@@ -471,6 +705,10 @@ func (a *StructuredApp) layout(g *gocui.Gui) error {
 		modalHeight := height - 2
 		a.terminalTerm.Resize(modalHeight, modalWidth)
 		a.terminalCtrl.Resize(modalWidth, modalHeight)
+		v.Subtitle = ""
+		if err := a.terminalCtrl.ResizeErr(); err != nil {
+			v.Subtitle = " " + err.Error() + " "
+		}
 
 		// Render terminal content
 		v.Clear()
@@ -536,6 +774,13 @@ func (a *StructuredApp) layoutWithSidebar(g *gocui.Gui, maxX, paneMaxY int, curr
 	// No status bar in sidebar mode - use full height
 	maxY := paneMaxY + pane.StatusBarHeight
 	fullHeight := maxY
+
+	// Jobs take the bottom row while they run
+	if shown, err := a.layoutJobsBar(g, maxX, maxY); err != nil {
+		return err
+	} else if shown {
+		fullHeight -= pane.StatusBarHeight
+	}
 	layout := pane.CalculateUnifiedSidebarLayout(maxX, fullHeight)
 
 	// Delete old sidebar view if it exists (we now have repos and sessions views)
@@ -561,41 +806,13 @@ func (a *StructuredApp) layoutWithSidebar(g *gocui.Gui, maxX, paneMaxY int, curr
 	}
 	a.renderSessionsPanel(sessionsView)
 
-	// Render main session view if we have a session loaded
-	if len(a.sessions) > 0 && a.activeIdx < len(a.sessions) {
-		session := a.sessions[a.activeIdx]
-		view := a.views[session]
-
-		// Handle resize
-		width := layout.Main.Width()
-		height := layout.Main.Height()
-		view.Resize(width, height)
-
-		mainView, err := g.SetView("main-view", layout.Main.X0, layout.Main.Y0,
-			layout.Main.X1, layout.Main.Y1, 0)
-		if err != nil {
-			if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
-				return err
-			}
-		}
-
-		a.configureStructuredView(mainView, session, true, currentMode)
-		mainView.Clear()
-		fmt.Fprint(mainView, view.Render())
-	} else {
-		// No session loaded, show empty main view
-		mainView, err := g.SetView("main-view", layout.Main.X0, layout.Main.Y0,
-			layout.Main.X1, layout.Main.Y1, 0)
-		if err != nil {
-			if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
-				return err
-			}
+	// Render the diff panel or the session tiles in the main area
+	if a.diff != nil {
+		if err := a.layoutDiff(g, layout.Main); err != nil {
+			return err
 		}
-		mainView.Title = " No Session "
-		mainView.FrameColor = gocui.ColorDefault
-		mainView.TitleColor = gocui.ColorDefault
-		mainView.Clear()
-		fmt.Fprint(mainView, "\n  Select a repository and\n  session from the sidebar\n\n  Press 'n' to create\n  a new session")
+	} else if err := a.layoutTiles(g, layout.Main, currentMode); err != nil {
+		return err
 	}
 
 	// Delete status bar if it exists (not needed in sidebar mode)
@@ -654,7 +871,11 @@ func (a *StructuredApp) layoutWithSidebar(g *gocui.Gui, maxX, paneMaxY int, curr
 
 		if currentMode.IsInput() {
 			inputBuffer := a.input.InputBuffer()
-			x0, y0, x1, y1 := ui.ModalDimensions(maxX, maxY, 50, 3)
+			modalWidth := 50
+			if a.inputPurpose == "commit" {
+				modalWidth = min(80, maxX-4) // Room for a full commit subject
+			}
+			x0, y0, x1, y1 := ui.ModalDimensions(maxX, maxY, modalWidth, 3)
 			v, err := g.SetView("input-modal", x0, y0, x1, y1, 0)
 			if err != nil {
 				if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
@@ -664,8 +885,15 @@ func (a *StructuredApp) layoutWithSidebar(g *gocui.Gui, maxX, paneMaxY int, curr
 
 			// Custom input modal for session/repo creation
 			title := " New Session (Enter=confirm, Esc=cancel) "
-			if a.inputPurpose == "add_repo" {
+			switch a.inputPurpose {
+			case "add_repo":
 				title = " Add Repository Path (Enter=confirm, Esc=cancel) "
+			case "save_workspace":
+				title = " Save Workspace As (Enter=confirm, Esc=cancel) "
+			case "commit":
+				title = " Commit Message (Enter=commit, Ctrl+E=edit all, Esc=cancel) "
+			case "template_issue", "template_branch":
+				title = a.templateInputTitle()
 			}
 			v.Title = title
 			v.Frame = true
@@ -683,12 +911,26 @@ func (a *StructuredApp) layoutWithSidebar(g *gocui.Gui, maxX, paneMaxY int, curr
 			v.SetCursor(len(inputBuffer)+1, 0)
 		} else {
 			g.DeleteView("input-modal")
+			shown, err := a.layoutTemplatePicker(g, maxX, maxY)
+			if err != nil {
+				return err
+			}
+			if !shown {
+				if shown, err = a.layoutDeliveries(g, maxX, maxY); err != nil {
+					return err
+				}
+			}
 			// Set focus based on which pane is focused
-			switch a.focusedPane {
-			case "repos":
+			switch {
+			case shown:
+			case a.focusedPane == "repos":
 				g.SetCurrentView("repos-panel")
-			case "sessions":
-				g.SetCurrentView("sessions-panel")
+			case a.focusedPane == "main", a.focusedPane == "diff":
+				if a.diff != nil {
+					g.SetCurrentView("diff-view")
+				} else if _, err := g.SetCurrentView(a.activeTileName()); err != nil {
+					g.SetCurrentView("sessions-panel")
+				}
 			default:
 				g.SetCurrentView("sessions-panel")
 			}
@@ -765,6 +1007,7 @@ func (a *StructuredApp) renderSessionsPanel(v *gocui.View) {
 		return
 	}
 
+	lines := 0
 	for i, sess := range a.sessionsForRepo {
 		prefix := "  "
 		if i == a.sessionSelectedIdx {
@@ -792,17 +1035,41 @@ func (a *StructuredApp) renderSessionsPanel(v *gocui.View) {
 		if sess.Attached {
 			statusIcon = " [attached]"
 		}
+		if sess.Dormant {
+			statusIcon = " [dormant]"
+		}
+		if sess.HasDevServer() {
+			statusIcon += " +dev"
+		}
+		if a.pinned[sess.Name] {
+			statusIcon += " [pin]"
+		}
+		if sess.PRURL != "" {
+			statusIcon += " " + prLabel(sess.PRURL)
+		}
+		if sess.ClaudePID != 0 && !sess.Dormant {
+			statusIcon += " " + ui.ColorDim + ui.FormatUsage(sess.CPU, sess.RSS) + ui.ColorReset
+		}
 
 		fmt.Fprintf(v, "%s%s%s\n", prefix, branchDisplay, statusIcon)
+		lines++
+
+		// Git state on a second line, once checked
+		if sess.Git != nil {
+			fmt.Fprintf(v, "    %s\n", ui.GitStatusLine(sess.Git, v.InnerWidth()-4))
+			lines++
+		}
 	}
 
 	// Add footer with hints
 	height := v.InnerHeight()
-	sessionCount := len(a.sessionsForRepo)
-	if height > sessionCount+3 {
+	if height > lines+5 {
 		fmt.Fprint(v, "\n───────────────────────\n")
-		fmt.Fprint(v, " j/k:nav i:term n:new\n")
-		fmt.Fprint(v, " x:del Ctrl+U/D:scroll")
+		fmt.Fprint(v, " j/k:nav i:term/resume n:new\n")
+		fmt.Fprint(v, " x:del Ctrl+U/D:scroll\n")
+		fmt.Fprint(v, " p:pin Tab:tile z:zoom\n")
+		fmt.Fprint(v, " L:layout </>:split D:diff\n")
+		fmt.Fprint(v, " c:commit P:push+PR B:rebase")
 	}
 }
 
@@ -891,18 +1158,32 @@ func (a *StructuredApp) setupKeybindings() error {
 					// Unified sidebar navigation based on focused pane
 					switch k {
 					case 'k':
-						a.navigateUp()
+						if a.focusedPane == "main" {
+							a.focusTile(-1)
+						} else {
+							a.navigateUp()
+						}
 					case 'j':
-						a.navigateDown()
+						if a.focusedPane == "main" {
+							a.focusTile(1)
+						} else {
+							a.navigateDown()
+						}
 					case 'h':
-						// Move focus left (sessions -> repos)
-						if a.focusedPane == "sessions" {
+						// Move focus left (main -> sessions -> repos)
+						switch a.focusedPane {
+						case "main":
+							a.focusedPane = "sessions"
+						case "sessions":
 							a.focusedPane = "repos"
 						}
 					case 'l':
-						// Move focus right (repos -> sessions)
-						if a.focusedPane == "repos" {
+						// Move focus right (repos -> sessions -> main)
+						switch a.focusedPane {
+						case "repos":
 							a.focusedPane = "sessions"
+						case "sessions":
+							a.focusTile(0)
 						}
 					}
 				} else {
@@ -942,7 +1223,7 @@ func (a *StructuredApp) setupKeybindings() error {
 					if sess != nil && sess.Status == claude.StatusNeedsInput {
 						// Send permission response (1=yes, 2=always, 3=no)
 						if num >= 1 && num <= 3 {
-							claude.SendKeys(session, fmt.Sprintf("%d", num))
+							claude.SendKeys(view.Target(), fmt.Sprintf("%d", num))
 							return nil
 						}
 					}
@@ -981,13 +1262,24 @@ func (a *StructuredApp) setupKeybindings() error {
 		if a.input.Mode().IsInput() {
 			// Handle input submission
 			inputText := a.input.ConsumeInputBuffer()
-			switch a.inputPurpose {
+			purpose := a.inputPurpose
+			a.inputPurpose = ""
+			switch purpose {
 			case "new_session":
 				a.createNewSessionForRepo(inputText)
 			case "add_repo":
 				a.addRepository(inputText)
+			case "save_workspace":
+				if err := a.saveWorkspace(inputText); err != nil {
+					a.jobs.Start("save workspace "+inputText, func(func(string)) (string, error) {
+						return "", err
+					})
+				}
+			case "commit":
+				a.commit(inputText)
+			case "template_issue", "template_branch":
+				a.setTemplateInput(purpose, inputText)
 			}
-			a.inputPurpose = ""
 			return nil
 		}
 		if a.input.Mode().IsNormal() {
@@ -1060,6 +1352,23 @@ func (a *StructuredApp) setupKeybindings() error {
 		return err
 	}
 
+	// Tiled main area: pin, zoom, presets and split resizing
+	if err := a.setupTileKeybindings(); err != nil {
+		return err
+	}
+
+	if err := a.setupDiffKeybindings(); err != nil {
+		return err
+	}
+
+	if err := a.setupTemplateKeybindings(); err != nil {
+		return err
+	}
+
+	if err := a.setupDeliveriesKeybindings(); err != nil {
+		return err
+	}
+
 	// Escape key
 	if err := a.gui.SetKeybinding("", gocui.KeyEsc, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
@@ -1067,6 +1376,7 @@ func (a *StructuredApp) setupKeybindings() error {
 			a.terminalCtrl.SendKeys("Escape")
 		} else if a.input.Mode().IsInput() {
 			a.input.ExitInputMode()
+			a.pendingTemplate = nil
 		}
 		return nil
 	}); err != nil {
@@ -1113,8 +1423,7 @@ func (a *StructuredApp) setupKeybindings() error {
 	if err := a.gui.SetKeybinding("", 'n', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		if a.input.Mode().IsNormal() && a.sidebarEnabled {
 			if a.focusedPane == "sessions" && len(a.repositories) > 0 {
-				a.inputPurpose = "new_session"
-				a.input.EnterInputMode()
+				a.startNewSession()
 			}
 		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
 			a.terminalCtrl.SendLiteralKeys("n")
@@ -1172,11 +1481,61 @@ func (a *StructuredApp) setupKeybindings() error {
 		return err
 	}
 
+	// 'w' - Save the tiled sessions as a named workspace
+	if err := a.gui.SetKeybinding("", 'w', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			if len(a.sessions) > 0 {
+				a.inputPurpose = "save_workspace"
+				a.input.EnterInputMode()
+			}
+		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+			a.terminalCtrl.SendLiteralKeys("w")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// 'c' - Commit the focused session's changes with a drafted message
+	if err := a.gui.SetKeybinding("", 'c', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			a.startCommit()
+		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+			a.terminalCtrl.SendLiteralKeys("c")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// 'P' - Push the focused session's branch and open a pull request
+	if err := a.gui.SetKeybinding("", 'P', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			a.startPushAndPR()
+		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+			a.terminalCtrl.SendLiteralKeys("P")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	// 'B' - Rebase the focused session's worktree onto its base branch
+	if err := a.gui.SetKeybinding("", 'B', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			a.startRebase()
+		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+			a.terminalCtrl.SendLiteralKeys("B")
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
 	// 'R' - Refresh repos and sessions
 	if err := a.gui.SetKeybinding("", 'R', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		if a.input.Mode().IsNormal() && a.sidebarEnabled {
-			a.refreshRepositories()
-			a.refreshAvailableSessions()
+			a.discoveryService.Refresh()
 		} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
 			a.terminalCtrl.SendLiteralKeys("R")
 		}
@@ -1220,10 +1579,12 @@ func (a *StructuredApp) setupTerminalModalPassthrough() error {
 		return err
 	}
 
-	// Tab
+	// Tab (cycles tile focus in normal mode)
 	if err := a.gui.SetKeybinding("", gocui.KeyTab, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
 			a.terminalCtrl.SendKeys("Tab")
+		} else if a.input.Mode().IsNormal() && a.sidebarEnabled {
+			a.focusTile(1)
 		}
 		return nil
 	}); err != nil {
@@ -1309,39 +1670,64 @@ func (a *StructuredApp) navigateDown() {
 	}
 }
 
-// createNewSessionForRepo creates a new session for the currently selected repository.
+// createNewSessionForRepo creates a new session for the currently selected
+// repository. The input may also be a remote branch or a pull request.
+// Creating the worktree runs the repository's setup hooks, so it is a job.
 func (a *StructuredApp) createNewSessionForRepo(branchName string) {
 	if branchName == "" || len(a.repositories) == 0 || a.repoSelectedIdx >= len(a.repositories) {
 		return
 	}
 
-	repo := a.repositories[a.repoSelectedIdx]
+	repoPath := a.repositories[a.repoSelectedIdx].Path
 
-	// Check if branch exists
-	branches, _ := git.ListBranches(repo.Path)
-	branchExists := false
-	for _, b := range branches {
-		if b == branchName {
-			branchExists = true
-			break
-		}
+	// Pull requests ("#12" or a URL) are fetched and reviewed
+	if ref, number, ok := session.ParsePRRef(branchName, false); ok {
+		a.createSessionForPR(repoPath, ref, number)
+		return
 	}
 
-	sessionName, err := a.sessionManager.CreateSession(repo.Path, branchName, !branchExists)
-	if err != nil {
-		return // Silently fail
-	}
+	a.jobs.Start("new "+branchName, func(progress func(string)) (string, error) {
+		branches, _ := a.git.ListBranches(repoPath)
+		branchExists := slices.Contains(branches, branchName)
 
-	// Refresh and load
-	a.refreshSessionsForSelectedRepo()
-	a.refreshAvailableSessions()
+		// A failed worktree setup still leaves a usable session
+		var sessionName string
+		var err error
+		if !branchExists && a.isRemoteBranch(repoPath, branchName) {
+			progress("fetching")
+			sessionName, err = a.sessionManager.CreateSessionFromRemote(repoPath, branchName)
+		} else {
+			progress("creating worktree")
+			sessionName, err = a.sessionManager.CreateSession(repoPath, branchName, !branchExists)
+		}
+		if sessionName == "" {
+			return "", err
+		}
+		a.gui.Update(func(g *gocui.Gui) error {
+			a.selectNewSession(sessionName)
+			return nil
+		})
+		return sessionName, nil
+	})
+}
+
+// selectNewSession selects a session that was just created, once discovery
+// has found it.
+func (a *StructuredApp) selectNewSession(sessionName string) {
+	a.pendingSession = sessionName
+	a.selectPendingSession()
+	a.discoveryService.Refresh()
+}
 
-	// Find and select the new session
+// selectPendingSession selects and loads the session waiting to be
+// discovered, if it has been.
+func (a *StructuredApp) selectPendingSession() {
 	for i, sess := range a.sessionsForRepo {
-		if sess.Name == sessionName {
+		if sess.Name == a.pendingSession {
 			a.sessionSelectedIdx = i
-			a.loadSession(sessionName)
-			break
+			a.loadSession(sess.Name)
+			a.pendingSession = ""
+			return
 		}
 	}
 }
@@ -1353,7 +1739,7 @@ func (a *StructuredApp) addRepository(path string) {
 	}
 
 	// Validate it's a git repo
-	if _, err := git.FindRepoRoot(path); err != nil {
+	if _, err := a.git.FindRepoRoot(path); err != nil {
 		return // Silently fail - not a git repo
 	}
 
@@ -1361,14 +1747,22 @@ func (a *StructuredApp) addRepository(path string) {
 		return // Silently fail
 	}
 
-	a.refreshRepositories()
+	// Select the newly added repo once discovered
+	a.pendingRepo = path
+	a.discoveryService.Refresh()
+}
 
-	// Select the newly added repo
+// selectPendingRepo selects the repository waiting to be discovered, if it
+// has been.
+func (a *StructuredApp) selectPendingRepo() {
+	if a.pendingRepo == "" {
+		return
+	}
 	for i, repo := range a.repositories {
-		if strings.HasSuffix(repo.Path, strings.TrimPrefix(path, "~")) || repo.Path == path {
+		if strings.HasSuffix(repo.Path, strings.TrimPrefix(a.pendingRepo, "~")) || repo.Path == a.pendingRepo {
 			a.repoSelectedIdx = i
-			a.refreshSessionsForSelectedRepo()
-			break
+			a.pendingRepo = ""
+			return
 		}
 	}
 }
@@ -1384,43 +1778,52 @@ func (a *StructuredApp) deleteSelectedRepo() {
 		return // Silently fail
 	}
 
-	// Adjust selection
+	// Drop it now rather than at the next discovery pass
+	a.repositories = append(a.repositories[:a.repoSelectedIdx:a.repoSelectedIdx], a.repositories[a.repoSelectedIdx+1:]...)
 	if a.repoSelectedIdx > 0 {
 		a.repoSelectedIdx--
 	}
 	a.refreshRepositories()
+	a.discoveryService.Refresh()
 }
 
-// deleteSelectedSessionFromRepo kills the selected session in the sessions pane.
+// deleteSelectedSessionFromRepo kills the selected session in the sessions
+// pane, as a job, and drops it from the views once it is gone.
 func (a *StructuredApp) deleteSelectedSessionFromRepo() {
 	if len(a.sessionsForRepo) == 0 || a.sessionSelectedIdx >= len(a.sessionsForRepo) {
 		return
 	}
 
-	sess := a.sessionsForRepo[a.sessionSelectedIdx]
+	name := a.sessionsForRepo[a.sessionSelectedIdx].Name
+	a.jobs.Start("delete "+name, func(progress func(string)) (string, error) {
+		// Kills the tmux session; the worktree is kept
+		if err := a.sessionManager.DeleteSession(name, false); err != nil {
+			return "", err
+		}
+		a.gui.Update(func(g *gocui.Gui) error {
+			a.dropDeletedSession(name)
+			return nil
+		})
+		return "deleted " + name, nil
+	})
+}
 
-	// Delete via session manager (kills tmux session, optionally removes worktree)
-	a.sessionManager.DeleteSession(sess.Name, false) // Don't remove worktree by default
+// dropDeletedSession drops a deleted session from the app.
+func (a *StructuredApp) dropDeletedSession(name string) {
+	a.forgetSession(name)
 
 	// Remove from views if loaded
-	if _, ok := a.views[sess.Name]; ok {
-		delete(a.views, sess.Name)
-	}
-
-	// Remove from sessions list if it's the current one
-	for i, s := range a.sessions {
-		if s == sess.Name {
-			a.sessions = append(a.sessions[:i], a.sessions[i+1:]...)
-			if a.activeIdx >= len(a.sessions) && a.activeIdx > 0 {
-				a.activeIdx--
-			}
-			break
-		}
+	if _, ok := a.views[name]; ok {
+		delete(a.views, name)
+		a.pollViews()
 	}
+	delete(a.pinned, name)
+	a.removeSession(name)
 
 	// Refresh sessions
+	a.state.RemoveSession(name)
 	a.refreshSessionsForSelectedRepo()
-	a.refreshAvailableSessions()
+	a.discoveryService.Refresh()
 }
 
 // makeInputEditor creates an editor function for the input modal.
@@ -1430,6 +1833,12 @@ func (a *StructuredApp) makeInputEditor() func(v *gocui.View, key gocui.Key, ch
 			return false
 		}
 
+		// The whole commit message is edited in the user's editor
+		if key == gocui.KeyCtrlE && a.inputPurpose == "commit" {
+			a.editCommitMessage()
+			return true
+		}
+
 		if ch != 0 && mod == gocui.ModNone {
 			a.input.AppendToInputBuffer(ch)
 			return true
@@ -1471,9 +1880,11 @@ func (a *StructuredApp) ActiveView() *claude.View {
 	return a.views[session]
 }
 
-// DiscoveryService returns the discovery service for external use.
+// DiscoveryService returns the discovery service for external use, or nil
+// when attached to a cmux daemon.
 func (a *StructuredApp) DiscoveryService() *discovery.Service {
-	return a.discoveryService
+	svc, _ := a.discoveryService.(*discovery.Service)
+	return svc
 }
 
 // SessionManager returns the session manager for external use.
@@ -1522,6 +1933,13 @@ func (a *StructuredApp) enterTerminalModal() error {
 		return nil
 	}
 
+	// Dormant sessions are resumed first
+	if a.isDormant(session) {
+		if err := a.resumeSession(session); err != nil {
+			return nil // Silently fail
+		}
+	}
+
 	// Calculate modal dimensions (80% of screen)
 	maxX, maxY := a.gui.Size()
 	width := maxX * 80 / 100
@@ -1533,8 +1951,8 @@ func (a *StructuredApp) enterTerminalModal() error {
 		height = 10
 	}
 
-	// Create control mode connection
-	a.terminalCtrl = terminal.NewControlMode(session)
+	// Create control mode connection to the pane running Claude
+	a.terminalCtrl = terminal.NewControlMode(a.claudeTarget(session))
 	a.terminalTerm = pane.NewSafeTerminal(height-2, width-2) // Account for borders
 
 	if err := a.terminalCtrl.Start(width-2, height-2); err != nil {
@@ -1544,12 +1962,27 @@ func (a *StructuredApp) enterTerminalModal() error {
 	}
 
 	// Start output processing goroutine
-	go a.processTerminalOutput()
+	go a.processTerminalOutput(a.terminalCtrl, a.terminalTerm)
 
 	a.input.SetMode(input.ModeTerminal)
 	return nil
 }
 
+// claudeTarget returns the session:window.pane target running Claude in a
+// session. The pane reported by hooks wins, then process detection, then the
+// session itself (its active pane).
+func (a *StructuredApp) claudeTarget(session string) string {
+	if view, ok := a.views[session]; ok {
+		if target := view.Target(); target != session {
+			return target
+		}
+	}
+	if p, err := a.tmuxClient.FindClaudePane(session); err == nil {
+		return p.Target()
+	}
+	return session
+}
+
 // exitTerminalModal closes the terminal modal and cleans up.
 func (a *StructuredApp) exitTerminalModal() {
 	if a.terminalCtrl != nil {
@@ -1561,17 +1994,19 @@ func (a *StructuredApp) exitTerminalModal() {
 }
 
 // processTerminalOutput reads from the terminal control mode and writes to the emulator.
-func (a *StructuredApp) processTerminalOutput() {
-	if a.terminalCtrl == nil {
-		return
+// When the connection ends (e.g. the tmux session was killed) the modal is closed.
+func (a *StructuredApp) processTerminalOutput(ctrl *terminal.ControlMode, term *pane.SafeTerminal) {
+	for data := range ctrl.OutputChan() {
+		term.Write(data)
+		a.gui.Update(func(g *gocui.Gui) error { return nil })
 	}
 
-	for data := range a.terminalCtrl.OutputChan() {
-		if a.terminalTerm != nil {
-			a.terminalTerm.Write(data)
-			a.gui.Update(func(g *gocui.Gui) error { return nil })
+	a.gui.Update(func(g *gocui.Gui) error {
+		if a.terminalCtrl == ctrl {
+			a.exitTerminalModal()
 		}
-	}
+		return nil
+	})
 }
 
 // getTmuxSessionCwd gets the working directory of a tmux session's active pane.
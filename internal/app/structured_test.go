@@ -0,0 +1,226 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/git/gittest"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/tmux/tmuxtest"
+	"github.com/gdamore/tcell/v2"
+	"github.com/jesseduffield/gocui"
+)
+
+// newHeadlessApp creates an app drawing to a simulated screen, reading key
+// presses from gui.ReplayedEvents and hook events from a temporary dir.
+func newHeadlessApp(t *testing.T) *StructuredApp {
+	t.Helper()
+	t.Setenv("TMPDIR", t.TempDir())
+
+	cfg := config.Default()
+	cfg.DataDir = t.TempDir()
+
+	g, err := gocui.NewGui(gocui.NewGuiOpts{
+		OutputMode:    gocui.OutputTrue,
+		Headless:      true,
+		PlayRecording: true,
+		Width:         120,
+		Height:        40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := claude.NewEventWatcher(claude.EventsDir())
+	if err != nil {
+		g.Close()
+		t.Fatal(err)
+	}
+	app, err := newStructuredApp(g, cfg, gittest.New().Client(), tmuxtest.NewServer(), watcher, nil)
+	if err != nil {
+		g.Close()
+		t.Fatal(err)
+	}
+	return app
+}
+
+// testSnapshot is a discovery pass finding sessions first to first+n-1 of
+// one repository.
+func testSnapshot(first, n int) discovery.Snapshot {
+	snap := discovery.Snapshot{
+		Repositories: []discovery.RepositoryInfo{{Path: "/code/project", Name: "project"}},
+		Time:         time.Now(),
+	}
+	created := time.Unix(1700000000, 0)
+	for i := first; i < first+n; i++ {
+		name := fmt.Sprintf("project-%d", i)
+		snap.Sessions = append(snap.Sessions, &state.Session{
+			Name:     name,
+			RepoPath: "/code/project",
+			RepoName: "project",
+			Branch:   "main",
+			Created:  created.Add(time.Duration(i) * time.Second),
+		})
+		snap.Claude = append(snap.Claude, name)
+		snap.Live = append(snap.Live, name)
+	}
+	snap.All = snap.Sessions
+	return snap
+}
+
+// writeHookEvent appends a hook event to a session's event file.
+func writeHookEvent(t *testing.T, session string, event claude.HookEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	f, err := os.OpenFile(filepath.Join(claude.EventsDir(), session+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// pressKey replays a key press through the GUI's event loop.
+func pressKey(g *gocui.Gui, key tcell.Key, ch rune) {
+	g.ReplayedEvents.Keys <- gocui.NewTcellKeyEventWrapper(tcell.NewEventKey(key, ch, tcell.ModNone), 0)
+}
+
+// onUI runs fn on the gocui goroutine and waits for it.
+func onUI(a *StructuredApp, fn func()) {
+	done := make(chan struct{})
+	a.gui.Update(func(g *gocui.Gui) error {
+		fn()
+		close(done)
+		return nil
+	})
+	<-done
+}
+
+// TestStructuredAppConcurrentEvents drives hook events, transcript polling,
+// discovery snapshots and key presses at once. Run it with -race.
+func TestStructuredAppConcurrentEvents(t *testing.T) {
+	a := newHeadlessApp(t)
+	a.sidebarEnabled = true
+	a.applySnapshot(testSnapshot(0, 3))
+
+	// A transcript for the poller to read
+	transcript := filepath.Join(t.TempDir(), "transcript.jsonl")
+	if err := os.WriteFile(transcript, []byte(`{"type":"user","message":{"role":"user","content":"hello"}}`+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Run() }()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 30; i++ {
+			session := fmt.Sprintf("project-%d", i)
+			writeHookEvent(t, session, claude.HookEvent{
+				SessionID:      "abc",
+				TranscriptPath: transcript,
+				EventName:      "PreToolUse",
+				ToolName:       "Bash",
+				ToolUseID:      fmt.Sprint(i),
+			})
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 30; i++ {
+			// New sessions come and go, adding views
+			snap := testSnapshot(i, 1+i%3)
+			a.gui.Update(func(g *gocui.Gui) error {
+				a.applySnapshot(snap)
+				return nil
+			})
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			for _, ch := range []rune{'j', 'k', 'l', 'j', 'h', 'h', 'j'} {
+				pressKey(a.gui, tcell.KeyRune, ch)
+			}
+			pressKey(a.gui, tcell.KeyTab, 0)
+		}
+	}()
+	wg.Wait()
+
+	// Once loaded, every session's view follows its hook events
+	onUI(a, func() {
+		a.applySnapshot(testSnapshot(0, 3))
+		for i := 0; i < 3; i++ {
+			a.loadSession(fmt.Sprintf("project-%d", i))
+		}
+	})
+	for i := 0; i < 3; i++ {
+		writeHookEvent(t, fmt.Sprintf("project-%d", i), claude.HookEvent{SessionID: "abc", EventName: "UserPromptSubmit", Prompt: "go"})
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var thinking int
+		onUI(a, func() {
+			for _, view := range a.views {
+				if view.Session().Status == claude.StatusThinking {
+					thinking++
+				}
+			}
+		})
+		if thinking == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 3 thinking sessions, got %d", thinking)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	pressKey(a.gui, tcell.KeyRune, 'q')
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, gocui.ErrQuit) {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("app did not quit")
+	}
+}
+
+func TestApplySnapshotKeepsSessionsOnError(t *testing.T) {
+	a := newHeadlessApp(t)
+	defer a.gui.Close()
+	a.applySnapshot(testSnapshot(0, 3))
+
+	failed := discovery.Snapshot{
+		Repositories: []discovery.RepositoryInfo{
+			{Path: "/code/project", Name: "project"},
+			{Path: "/code/other", Name: "other"},
+		},
+		Err:  errors.New("no server running"),
+		Time: time.Now(),
+	}
+	a.applySnapshot(failed)
+	if len(a.repositories) != 2 {
+		t.Errorf("expected the new repositories, got %d", len(a.repositories))
+	}
+	if len(a.sessionsForRepo) != 3 || len(a.snapshot.Live) != 3 {
+		t.Errorf("expected the last good sessions, got %d listed and %d live", len(a.sessionsForRepo), len(a.snapshot.Live))
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/gitstatus"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/jesseduffield/gocui"
+)
+
+// gitStatusTTL is how long a worktree's git status is shown before it is
+// checked again.
+const gitStatusTTL = 10 * time.Second
+
+// gitStatusWorkers bounds how many worktrees are checked at once.
+const gitStatusWorkers = 4
+
+// newGitStatusRefresher creates the refresher checking the sessions'
+// worktrees, redrawing the sidebar whenever a check finishes.
+func (a *StructuredApp) newGitStatusRefresher() *gitstatus.Refresher {
+	r := gitstatus.NewRefresher(gitStatusWorkers, gitStatusTTL, func(dir string) (git.WorktreeStatus, error) {
+		return session.CheckWorktree(a.git, a.config, dir)
+	})
+	r.OnUpdate(func(dir string) {
+		a.gui.Update(func(g *gocui.Gui) error {
+			a.applyGitStatus(a.sessionsForRepo)
+			return nil
+		})
+	})
+	return r
+}
+
+// applyGitStatus copies the cached git status of each session's directory
+// onto it, queueing checks for missing and stale ones.
+func (a *StructuredApp) applyGitStatus(sessions []*state.Session) {
+	for _, sess := range sessions {
+		if sess.Dormant || sess.RepoPath == "" {
+			continue
+		}
+		if status, ok := a.gitStatus.Get(sessionInfo(sess).Dir()); ok {
+			sess.Git = &status
+		}
+	}
+}
+
+// startRebase rebases the focused session's worktree onto its base branch
+// as a job. Conflicts are handed to the session's Claude, or the rebase is
+// aborted, as configured.
+func (a *StructuredApp) startRebase() {
+	sess := a.focusedSession()
+	if sess == nil || sess.RepoPath == "" || sess.Dormant {
+		return
+	}
+	info := sessionInfo(sess)
+	claudePane := sess.ClaudePane
+
+	a.jobs.Start("rebase "+info.SessionName, func(progress func(string)) (string, error) {
+		defer a.gitStatus.Invalidate(info.Dir())
+
+		progress("rebasing " + info.BranchName)
+		base, err := a.sessionManager.Rebase(info)
+		var conflict *git.ConflictError
+		switch {
+		case err == nil:
+			return "rebased onto " + base, nil
+		case !errors.As(err, &conflict) || a.config.Sync.OnConflict == "abort":
+			return "", err
+		}
+
+		// The session may have a shell or server pane focused
+		target := claudePane
+		if target == "" {
+			target = info.SessionName
+		}
+		progress("handing conflicts to Claude")
+		prompt := session.ConflictPrompt(a.config, info.BranchName, base, conflict.Files)
+		if err := session.SubmitPrompt(a.tmuxClient, target, prompt); err != nil {
+			return "", err
+		}
+		return "Claude is resolving " + conflict.Error(), nil
+	})
+}
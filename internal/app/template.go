@@ -0,0 +1,203 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/ui"
+	"github.com/jesseduffield/gocui"
+)
+
+// templatePicker lists the session templates offered for a new session.
+// Entry 0 is a blank session named by hand.
+type templatePicker struct {
+	names []string
+	idx   int
+}
+
+// pendingTemplate is a template session waiting for its issue or branch.
+type pendingTemplate struct {
+	name string
+	tmpl config.SessionTemplate
+	vars session.TemplateVars
+}
+
+// startNewSession begins creating a session in the selected repository,
+// offering the session templates first when there are any.
+func (a *StructuredApp) startNewSession() {
+	names := a.config.TemplateNames()
+	if len(names) == 0 {
+		a.inputPurpose = "new_session"
+		a.input.EnterInputMode()
+		return
+	}
+	a.templatePicker = &templatePicker{names: names}
+}
+
+// pickTemplate starts the selected entry of the template picker.
+func (a *StructuredApp) pickTemplate() {
+	p := a.templatePicker
+	a.templatePicker = nil
+	if p.idx == 0 {
+		a.inputPurpose = "new_session"
+		a.input.EnterInputMode()
+		return
+	}
+
+	name := p.names[p.idx-1]
+	a.pendingTemplate = &pendingTemplate{name: name, tmpl: a.config.Templates[name]}
+	a.continueTemplate()
+}
+
+// continueTemplate asks for what the pending template still needs, then
+// creates its session.
+func (a *StructuredApp) continueTemplate() {
+	t := a.pendingTemplate
+	switch {
+	case t == nil:
+		return
+	case session.TemplateNeedsIssue(t.tmpl) && t.vars.Issue == "":
+		a.inputPurpose = "template_issue"
+		a.input.EnterInputMode()
+	case t.tmpl.Branch == "" && t.vars.Branch == "":
+		a.inputPurpose = "template_branch"
+		a.input.EnterInputMode()
+	default:
+		a.pendingTemplate = nil
+		a.createSessionFromTemplate(t)
+	}
+}
+
+// setTemplateInput records the answer to a template's input modal.
+func (a *StructuredApp) setTemplateInput(purpose, text string) {
+	t := a.pendingTemplate
+	if t == nil || text == "" {
+		a.pendingTemplate = nil
+		return
+	}
+	if purpose == "template_issue" {
+		t.vars.Issue = text
+	} else {
+		t.vars.Branch = text
+	}
+	a.continueTemplate()
+}
+
+// createSessionFromTemplate creates the template's session in the selected
+// repository and submits its prompt as a job once Claude is ready.
+func (a *StructuredApp) createSessionFromTemplate(t *pendingTemplate) {
+	if len(a.repositories) == 0 || a.repoSelectedIdx >= len(a.repositories) {
+		return
+	}
+	repo := a.repositories[a.repoSelectedIdx]
+
+	// A failed worktree setup still leaves a usable session
+	sessionName, prompt, _ := a.sessionManager.CreateSessionFromTemplate(repo.Path, t.tmpl, t.vars)
+	if sessionName == "" {
+		return // Silently fail
+	}
+	a.selectNewSession(sessionName)
+
+	if prompt == "" {
+		return
+	}
+	a.jobs.Start(t.name+" "+sessionName, func(progress func(string)) (string, error) {
+		progress("waiting for Claude")
+		if err := session.SubmitPrompt(a.tmuxClient, sessionName, prompt); err != nil {
+			return "", err
+		}
+		return "prompt submitted", nil
+	})
+}
+
+// templateInputTitle returns the input modal title of a template input.
+func (a *StructuredApp) templateInputTitle() string {
+	name := ""
+	if a.pendingTemplate != nil {
+		name = a.pendingTemplate.name
+	}
+	if a.inputPurpose == "template_issue" {
+		return fmt.Sprintf(" %s: Issue (Enter=confirm, Esc=cancel) ", name)
+	}
+	return fmt.Sprintf(" %s: Branch (Enter=confirm, Esc=cancel) ", name)
+}
+
+// layoutTemplatePicker draws the template picker over the main area and
+// gives it focus. It reports whether the picker is shown.
+func (a *StructuredApp) layoutTemplatePicker(g *gocui.Gui, maxX, maxY int) (bool, error) {
+	p := a.templatePicker
+	if p == nil {
+		g.DeleteView("template-picker")
+		return false, nil
+	}
+
+	width := min(70, maxX-4)
+	x0, y0, x1, y1 := ui.ModalDimensions(maxX, maxY, width, len(p.names)+3)
+	v, err := g.SetView("template-picker", x0, y0, x1, y1, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+			return false, err
+		}
+	}
+	v.Title = " New Session "
+	v.Footer = " j/k select  Enter start  Esc cancel "
+	v.Frame = true
+	v.FrameRunes = []rune{'━', '┃', '┏', '┓', '┗', '┛'}
+	v.FrameColor = gocui.ColorYellow
+	v.Clear()
+
+	labels := []string{"Blank session"}
+	for _, name := range p.names {
+		label := name
+		if desc := a.config.Templates[name].Description; desc != "" {
+			label += " \033[90m" + desc + "\033[0m"
+		}
+		labels = append(labels, label)
+	}
+	for i, label := range labels {
+		marker := "  "
+		if i == p.idx {
+			marker = "\033[33m▶\033[0m "
+		}
+		fmt.Fprintf(v, " %s%s\n", marker, label)
+	}
+
+	if _, err := g.SetCurrentView("template-picker"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// setupTemplateKeybindings configures the template picker. Its bindings are
+// on its view so they take precedence over the global ones.
+func (a *StructuredApp) setupTemplateKeybindings() error {
+	move := func(delta int) func() {
+		return func() {
+			p := a.templatePicker
+			p.idx = max(min(p.idx+delta, len(p.names)), 0)
+		}
+	}
+	keys := map[any]func(){
+		'j':                move(1),
+		gocui.KeyArrowDown: move(1),
+		'k':                move(-1),
+		gocui.KeyArrowUp:   move(-1),
+		gocui.KeyEnter:     a.pickTemplate,
+		gocui.KeyEsc:       func() { a.templatePicker = nil },
+		'q':                func() { a.templatePicker = nil },
+	}
+	for key, action := range keys {
+		fn := action
+		if err := a.gui.SetKeybinding("template-picker", key, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			if a.templatePicker != nil && a.input.Mode().IsNormal() {
+				fn()
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
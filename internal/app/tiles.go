@@ -0,0 +1,334 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/input"
+	"github.com/abdullathedruid/cmux/internal/pane"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/jesseduffield/gocui"
+)
+
+// splitRatioStep is how far '<' and '>' move the main split, in percent.
+const splitRatioStep = 5
+
+// tileViewName returns the gocui view name for the tile at index i.
+func tileViewName(i int) string {
+	return fmt.Sprintf("tile-%d", i)
+}
+
+// tileIndex returns the tile index for a tile view name.
+func tileIndex(viewName string) (int, bool) {
+	s, ok := strings.CutPrefix(viewName, "tile-")
+	if !ok {
+		return 0, false
+	}
+	i, err := strconv.Atoi(s)
+	return i, err == nil
+}
+
+// activeTileName returns the view name of the active session's tile.
+func (a *StructuredApp) activeTileName() string {
+	if a.zoomed {
+		return tileViewName(0)
+	}
+	return tileViewName(a.activeIdx)
+}
+
+// layoutTiles renders the loaded sessions as tiles inside area using the
+// current preset, or only the active session when zoomed.
+func (a *StructuredApp) layoutTiles(g *gocui.Gui, area pane.Layout, mode input.Mode) error {
+	a.mainArea = area
+
+	if len(a.sessions) == 0 || a.activeIdx >= len(a.sessions) {
+		a.deleteTiles(g, 0)
+
+		// No session loaded, show empty main view
+		mainView, err := g.SetView("main-view", area.X0, area.Y0, area.X1, area.Y1, 0)
+		if err != nil {
+			if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+				return err
+			}
+		}
+		mainView.Title = " No Session "
+		mainView.FrameColor = gocui.ColorDefault
+		mainView.TitleColor = gocui.ColorDefault
+		mainView.Clear()
+		fmt.Fprint(mainView, "\n  Select a repository and\n  session from the sidebar\n\n  Press 'n' to create\n  a new session")
+		return nil
+	}
+	g.DeleteView("main-view")
+
+	sessions := a.sessions
+	layouts := pane.CalculatePresetLayouts(a.preset, len(sessions), area, a.splitRatio())
+	if a.zoomed {
+		sessions = sessions[a.activeIdx : a.activeIdx+1]
+		layouts = []pane.Layout{area}
+	}
+
+	for i, session := range sessions {
+		layout := layouts[i]
+		view := a.views[session]
+
+		// Handle resize
+		view.Resize(layout.Width(), layout.Height())
+
+		v, err := g.SetView(tileViewName(i), layout.X0, layout.Y0, layout.X1, layout.Y1, 0)
+		if err != nil {
+			if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+				return err
+			}
+		}
+
+		isActive := a.zoomed || i == a.activeIdx
+		a.configureStructuredView(v, session, isActive, mode)
+		if a.pinned[session] {
+			v.Title += "[pin] "
+		}
+		if a.zoomed {
+			v.Title += "[zoom] "
+		}
+
+		v.Clear()
+		fmt.Fprint(v, view.Render())
+	}
+
+	a.deleteTiles(g, len(sessions))
+	return nil
+}
+
+// deleteTiles removes tile views from index from onwards.
+func (a *StructuredApp) deleteTiles(g *gocui.Gui, from int) {
+	for i := from; i < a.tileCount; i++ {
+		g.DeleteView(tileViewName(i))
+	}
+	a.tileCount = from
+}
+
+// selectedSessionName returns the session selected in the sessions panel.
+func (a *StructuredApp) selectedSessionName() string {
+	if a.sessionSelectedIdx < len(a.sessionsForRepo) {
+		return a.sessionsForRepo[a.sessionSelectedIdx].Name
+	}
+	return ""
+}
+
+// togglePin pins the session under focus into the tile grid, or unpins it.
+// The sessions panel acts on its selection, the main area on the active tile.
+func (a *StructuredApp) togglePin() {
+	name := a.selectedSessionName()
+	if a.focusedPane == "main" {
+		name = a.ActiveSession()
+	}
+	if name == "" {
+		return
+	}
+
+	if !a.pinned[name] {
+		a.pinned[name] = true
+		a.loadSession(name)
+		return
+	}
+
+	delete(a.pinned, name)
+	// The sidebar selection stays visible as the unpinned tile
+	if name != a.selectedSessionName() {
+		a.removeSession(name)
+	}
+}
+
+// removeSession drops a session from the tiles, keeping the active index valid.
+func (a *StructuredApp) removeSession(name string) {
+	for i, s := range a.sessions {
+		if s != name {
+			continue
+		}
+		a.sessions = append(a.sessions[:i], a.sessions[i+1:]...)
+		if i < a.activeIdx || (a.activeIdx >= len(a.sessions) && a.activeIdx > 0) {
+			a.activeIdx--
+		}
+		break
+	}
+	if len(a.sessions) == 0 {
+		a.zoomed = false
+		if a.focusedPane == "main" {
+			a.focusedPane = "sessions"
+		}
+	}
+}
+
+// prunePinned unpins sessions that no longer exist.
+func (a *StructuredApp) prunePinned(alive []*state.Session) {
+	exists := make(map[string]bool, len(alive))
+	for _, sess := range alive {
+		exists[sess.Name] = true
+	}
+	for name := range a.pinned {
+		if !exists[name] {
+			delete(a.pinned, name)
+			a.removeSession(name)
+		}
+	}
+}
+
+// focusTile moves focus to the main area, stepping the active tile by delta
+// if the main area already had focus.
+func (a *StructuredApp) focusTile(delta int) {
+	if len(a.sessions) == 0 {
+		return
+	}
+	if a.focusedPane == "main" {
+		a.activeIdx = (a.activeIdx + delta + len(a.sessions)) % len(a.sessions)
+	}
+	a.focusedPane = "main"
+}
+
+// toggleZoom toggles the active tile filling the main area.
+func (a *StructuredApp) toggleZoom() {
+	if len(a.sessions) == 0 {
+		a.zoomed = false
+		return
+	}
+	a.zoomed = !a.zoomed
+}
+
+// cyclePreset switches to the next layout preset and saves it.
+func (a *StructuredApp) cyclePreset() {
+	a.preset = pane.NextPreset(a.preset)
+	a.layoutDirty = true
+	a.saveLayout()
+}
+
+// splitRatio returns the main split ratio for the current preset: the open
+// workspace's, or else the config's.
+func (a *StructuredApp) splitRatio() int {
+	if ratio, ok := a.workspaceRatios[string(a.preset)]; ok {
+		return ratio
+	}
+	return pane.ClampRatio(a.config.Layout.Ratios[string(a.preset)])
+}
+
+// setSplitRatio stores the main split ratio for the current preset, in the
+// open workspace if it set one and in the config otherwise.
+func (a *StructuredApp) setSplitRatio(ratio int) {
+	ratio = pane.ClampRatio(ratio)
+	if ratio == a.splitRatio() {
+		return
+	}
+	if _, ok := a.workspaceRatios[string(a.preset)]; ok {
+		a.workspaceRatios[string(a.preset)] = ratio
+		return
+	}
+	if a.config.Layout.Ratios == nil {
+		a.config.Layout.Ratios = make(map[string]int)
+	}
+	a.config.Layout.Ratios[string(a.preset)] = ratio
+	a.layoutDirty = true
+}
+
+// saveLayout writes the preset and split ratios to the config file if they changed.
+func (a *StructuredApp) saveLayout() {
+	if !a.layoutDirty {
+		return
+	}
+	a.config.Layout.Preset = string(a.preset)
+	if err := a.config.Save(); err != nil {
+		return // Silently fail, retried on the next change
+	}
+	a.layoutDirty = false
+}
+
+// mouseX returns the screen column of the last mouse event on v.
+func mouseX(v *gocui.View) int {
+	x0, _, _, _ := v.Dimensions()
+	cx, _ := v.Cursor()
+	return x0 + 1 + cx
+}
+
+// handleMouseDown focuses the clicked panel or tile, or starts dragging the
+// main split when its border is clicked.
+func (a *StructuredApp) handleMouseDown(g *gocui.Gui, v *gocui.View) error {
+	if !a.input.Mode().IsNormal() || !a.sidebarEnabled || v == nil {
+		return nil
+	}
+
+	// gocui reports no release event, so a new click ends any previous drag
+	a.dragging = false
+	a.saveLayout()
+
+	idx, isTile := tileIndex(v.Name())
+	if !isTile {
+		switch v.Name() {
+		case "repos-panel":
+			a.focusedPane = "repos"
+		case "sessions-panel":
+			a.focusedPane = "sessions"
+		case "diff-files", "diff-view":
+			a.focusedPane = "diff"
+		}
+		return nil
+	}
+
+	if split, ok := pane.SplitPosition(a.preset, len(a.sessions), a.mainArea, a.splitRatio()); ok && !a.zoomed {
+		x := mouseX(v)
+		x0, _, x1, _ := v.Dimensions()
+		if (x == x1 && x1 == split-1) || (x == x0 && x0 == split) {
+			a.dragging = true
+			return nil
+		}
+	}
+
+	if !a.zoomed && idx < len(a.sessions) {
+		a.activeIdx = idx
+	}
+	a.focusedPane = "main"
+	return nil
+}
+
+// handleMouseDrag moves the main split while a drag is in progress.
+func (a *StructuredApp) handleMouseDrag(g *gocui.Gui, v *gocui.View) error {
+	if !a.dragging || v == nil {
+		return nil
+	}
+	a.setSplitRatio(pane.RatioAt(a.mainArea, mouseX(v)))
+	return nil
+}
+
+// setupTileKeybindings configures pinning, tile focus, zoom, presets and
+// split resizing for the sidebar's main area.
+func (a *StructuredApp) setupTileKeybindings() error {
+	keys := map[rune]func(){
+		'p': a.togglePin,
+		'z': a.toggleZoom,
+		'L': a.cyclePreset,
+		'<': func() {
+			a.setSplitRatio(a.splitRatio() - splitRatioStep)
+			a.saveLayout()
+		},
+		'>': func() {
+			a.setSplitRatio(a.splitRatio() + splitRatioStep)
+			a.saveLayout()
+		},
+	}
+	for key, action := range keys {
+		k, fn := key, action
+		if err := a.gui.SetKeybinding("", k, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+			if a.input.Mode().IsNormal() && a.sidebarEnabled {
+				fn()
+			} else if a.input.Mode().IsTerminal() && a.terminalCtrl != nil {
+				a.terminalCtrl.SendLiteralKeys(string(k))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := a.gui.SetKeybinding("", gocui.MouseLeft, gocui.ModNone, a.handleMouseDown); err != nil {
+		return err
+	}
+	return a.gui.SetKeybinding("", gocui.MouseLeft, gocui.ModMotion, a.handleMouseDrag)
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/jobs"
+	"github.com/abdullathedruid/cmux/internal/pane"
+	"github.com/abdullathedruid/cmux/internal/registry"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/jesseduffield/gocui"
+)
+
+// jobLinger is how long a finished job stays in the status bar.
+const jobLinger = 8 * time.Second
+
+// pendingCommit is a commit waiting for its message to be confirmed.
+type pendingCommit struct {
+	info *session.SessionInfo
+	body string // drafted message without the subject line
+}
+
+// focusedSession returns the session under focus: the active tile when the
+// main area has focus, else the sessions panel's selection.
+func (a *StructuredApp) focusedSession() *state.Session {
+	name := a.selectedSessionName()
+	if a.focusedPane == "main" {
+		name = a.ActiveSession()
+	}
+	for _, sess := range a.sessionsForRepo {
+		if sess.Name == name {
+			return sess
+		}
+	}
+	return nil
+}
+
+// sessionInfo describes a discovered session for the session manager.
+func sessionInfo(sess *state.Session) *session.SessionInfo {
+	return &session.SessionInfo{
+		SessionName:  sess.Name,
+		RepoName:     sess.RepoName,
+		RepoPath:     sess.RepoPath,
+		BranchName:   sess.Branch,
+		IsMainBranch: sess.Worktree == "" || sess.Worktree == sess.RepoPath,
+		HasWorktree:  sess.Worktree != "" && sess.Worktree != sess.RepoPath,
+		WorktreePath: sess.Worktree,
+		PRURL:        sess.PRURL,
+	}
+}
+
+// startCommit drafts a commit message for the focused session and opens
+// the input modal to edit its subject, or with Ctrl+E the whole message.
+func (a *StructuredApp) startCommit() {
+	sess := a.focusedSession()
+	if sess == nil || sess.RepoPath == "" {
+		return
+	}
+
+	var prompts []string
+	summary := ""
+	for _, msg := range a.sessionMessages(sess.Name) {
+		switch {
+		case msg.Role == "user":
+			prompts = append(prompts, msg.Content)
+		case msg.TextPreview != "":
+			summary = msg.TextPreview
+		}
+	}
+	draft := session.DraftCommitMessage(prompts, summary)
+	subject, body, _ := strings.Cut(draft, "\n")
+
+	a.pendingCommit = &pendingCommit{info: sessionInfo(sess), body: body}
+	a.inputPurpose = "commit"
+	a.input.EnterInputMode()
+	a.input.SetInputBuffer(subject)
+}
+
+// commit runs the pending commit with the edited subject as a job.
+func (a *StructuredApp) commit(subject string) {
+	pending := a.pendingCommit
+	a.pendingCommit = nil
+	if pending == nil || strings.TrimSpace(subject) == "" {
+		return
+	}
+	message := strings.TrimSpace(subject) + "\n" + pending.body
+
+	a.jobs.Start("commit "+pending.info.SessionName, func(progress func(string)) (string, error) {
+		progress("committing")
+		if err := a.sessionManager.Commit(pending.info, message); err != nil {
+			return "", err
+		}
+		return "committed", nil
+	})
+}
+
+// commitMessageHelp ends a commit message opened in the user's editor.
+const commitMessageHelp = `
+# Edit the commit message. Lines starting with '#' are ignored, and an
+# empty message aborts the commit.
+`
+
+// editCommitMessage opens the pending commit's message, with the subject
+// as typed, in the user's editor in a tmux popup, and commits what is
+// saved as a job.
+func (a *StructuredApp) editCommitMessage() {
+	pending := a.pendingCommit
+	subject := a.input.ConsumeInputBuffer()
+	a.pendingCommit = nil
+	a.inputPurpose = ""
+	if pending == nil {
+		return
+	}
+	draft := strings.TrimSpace(subject) + "\n" + pending.body + commitMessageHelp
+
+	a.jobs.Start("commit "+pending.info.SessionName, func(progress func(string)) (string, error) {
+		if !a.tmuxClient.SupportsPopup() {
+			return "", errors.New("editing the message needs tmux 3.2 or later")
+		}
+		file, err := os.CreateTemp("", "cmux-commit-*.txt")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(file.Name())
+		_, err = file.WriteString(draft)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return "", err
+		}
+
+		progress("editing message")
+		if err := a.tmuxClient.DisplayEditorPopup(file.Name()); err != nil {
+			return "", err
+		}
+		edited, err := os.ReadFile(file.Name())
+		if err != nil {
+			return "", err
+		}
+		message := session.CleanCommitMessage(string(edited))
+		if message == "" {
+			return "", errors.New("empty commit message, nothing committed")
+		}
+
+		progress("committing")
+		if err := a.sessionManager.Commit(pending.info, message); err != nil {
+			return "", err
+		}
+		return "committed", nil
+	})
+}
+
+// startPushAndPR pushes the focused session's branch and opens a pull
+// request for it as a job, recording the PR URL on the session.
+func (a *StructuredApp) startPushAndPR() {
+	sess := a.focusedSession()
+	if sess == nil || sess.RepoPath == "" {
+		return
+	}
+	info := sessionInfo(sess)
+
+	a.jobs.Start("PR "+info.SessionName, func(progress func(string)) (string, error) {
+		if info.IsMainBranch {
+			return "", errors.New("refusing to open a PR from the main branch")
+		}
+
+		progress("pushing " + info.BranchName)
+		if err := a.sessionManager.Push(info); err != nil {
+			return "", err
+		}
+
+		progress("opening pull request")
+		url, err := a.sessionManager.CreatePullRequest(info)
+		if err != nil {
+			return "", err
+		}
+		if url == "" {
+			return "pull request opened", nil
+		}
+
+		if a.registry.Record(registry.Entry{Name: info.SessionName, PRURL: url}) {
+			a.registry.Save()
+		}
+		return url, nil
+	})
+}
+
+// onJobUpdate redraws when a job changes, refreshing the sessions and
+// their git state once it has finished so commits and PR URLs show up.
+func (a *StructuredApp) onJobUpdate(job jobs.Job) {
+	a.gui.Update(func(g *gocui.Gui) error {
+		if job.Status != jobs.Running {
+			a.gitStatus.InvalidateAll()
+			a.refreshSessionsForSelectedRepo()
+			a.discoveryService.Refresh()
+		}
+		return nil
+	})
+
+	// Clear the finished job from the status bar once it expires
+	if job.Status != jobs.Running {
+		time.AfterFunc(jobLinger, func() {
+			a.gui.Update(func(g *gocui.Gui) error { return nil })
+		})
+	}
+}
+
+// applyRegistryInfo copies what the registry knows about sessions onto them.
+func (a *StructuredApp) applyRegistryInfo(sessions []*state.Session) {
+	for _, sess := range sessions {
+		if entry, ok := a.registry.Get(sess.Name); ok {
+			sess.PRURL = entry.PRURL
+		}
+	}
+}
+
+// layoutJobsBar shows running and recently finished jobs on the bottom row
+// of the screen. It reports whether the bar is shown.
+func (a *StructuredApp) layoutJobsBar(g *gocui.Gui, maxX, maxY int) (bool, error) {
+	visible := a.jobs.Visible(jobLinger)
+	if len(visible) == 0 {
+		g.DeleteView("jobs-bar")
+		return false, nil
+	}
+
+	v, err := g.SetView("jobs-bar", 0, maxY-pane.StatusBarHeight, maxX-1, maxY, 0)
+	if err != nil {
+		if !errors.Is(err, gocui.ErrUnknownView) && err.Error() != "unknown view" {
+			return false, err
+		}
+	}
+	v.Frame = false
+	v.FgColor = gocui.ColorBlack
+	v.BgColor = gocui.ColorWhite
+	v.Clear()
+
+	var parts []string
+	for _, job := range visible {
+		switch job.Status {
+		case jobs.Running:
+			msg := job.Progress
+			if msg == "" {
+				msg = "starting"
+			}
+			parts = append(parts, fmt.Sprintf("⟳ %s: %s", job.Name, msg))
+		case jobs.Done:
+			parts = append(parts, fmt.Sprintf("✓ %s: %s", job.Name, job.Progress))
+		case jobs.Failed:
+			parts = append(parts, fmt.Sprintf("✗ %s: %s", job.Name, firstLine(job.Err.Error())))
+		}
+	}
+	fmt.Fprint(v, " "+strings.Join(parts, "  │  "))
+	return true, nil
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// prLabel returns a short label for a PR URL, e.g. "#12".
+func prLabel(url string) string {
+	if base := filepath.Base(url); base != "" && base != "." && base != "/" {
+		return "#" + base
+	}
+	return "PR"
+}
@@ -0,0 +1,101 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/notes"
+	"github.com/abdullathedruid/cmux/internal/pane"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/workspace"
+)
+
+// OpenWorkspace restores a saved workspace and starts in discovery mode with
+// its sessions pinned in the saved layout. If only some sessions could be
+// restored the app is still initialized and a *workspace.RestoreError is
+// returned.
+func (a *StructuredApp) OpenWorkspace(name string) error {
+	ws, err := workspace.NewStore(a.config.WorkspacesDir()).Load(name)
+	if err != nil {
+		return err
+	}
+
+	noteStore := notes.NewStore(a.config.NotesFile())
+	if err := noteStore.Load(); err != nil {
+		noteStore = nil
+	}
+
+	opened, restoreErr := workspace.Restore(ws, a.sessionManager, a.tmuxClient, noteStore)
+
+	if err := a.InitWithDiscovery(); err != nil {
+		return err
+	}
+
+	if ws.Layout.Preset != "" {
+		a.preset = pane.ParsePreset(ws.Layout.Preset)
+	}
+	if ws.Layout.Ratio != 0 {
+		// Workspace ratios don't overwrite the config
+		a.workspaceRatios = map[string]int{string(a.preset): pane.ClampRatio(ws.Layout.Ratio)}
+	}
+
+	// Replace the initial selection with the workspace's sessions
+	a.sessions = nil
+	for _, session := range opened {
+		a.pinned[session] = true
+		a.loadSession(session)
+	}
+	if len(a.sessions) > 0 {
+		a.activeIdx = 0
+		a.focusedPane = "main"
+	}
+
+	return restoreErr
+}
+
+// saveWorkspace records the pinned sessions (or every tile if none are
+// pinned) and the current layout as a named workspace.
+func (a *StructuredApp) saveWorkspace(name string) error {
+	var names []string
+	for _, session := range a.sessions {
+		if a.pinned[session] {
+			names = append(names, session)
+		}
+	}
+	if len(names) == 0 {
+		names = a.sessions
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("no sessions to save")
+	}
+
+	noteStore := notes.NewStore(a.config.NotesFile())
+	noteStore.Load()
+
+	ws := &workspace.Workspace{
+		Name:    name,
+		Layout:  workspace.Layout{Preset: string(a.preset), Ratio: a.splitRatio()},
+		SavedAt: time.Now(),
+	}
+
+	// Repo, branch and worktree come from discovery, preferring configured
+	// repositories (whose RepoPath is the main checkout) over the rest
+	known := append(append([]*state.Session{}, a.snapshot.Sessions...), a.snapshot.All...)
+	for _, session := range names {
+		spec := workspace.SessionSpec{Name: session, Note: noteStore.Get(session)}
+		for _, sess := range known {
+			if sess.Name == session {
+				spec.RepoPath = sess.RepoPath
+				spec.Branch = sess.Branch
+				spec.Worktree = sess.Worktree
+				break
+			}
+		}
+		if spec.Worktree == "" {
+			spec.Worktree = getTmuxSessionCwd(session)
+		}
+		ws.Sessions = append(ws.Sessions, spec)
+	}
+
+	return workspace.NewStore(a.config.WorkspacesDir()).Save(ws)
+}
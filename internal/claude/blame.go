@@ -0,0 +1,130 @@
+package claude
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileEdit is a change a tool call made to one file.
+type FileEdit struct {
+	Path string   // cleaned absolute path of the file
+	Tool ToolCall // the Edit, MultiEdit, Write or NotebookEdit call
+	Turn int      // index of the turn in History.Turns
+}
+
+// Turn is a user prompt and the file edits Claude made while answering it.
+type Turn struct {
+	Prompt Message // zero for edits made before the first prompt
+	Edits  []FileEdit
+}
+
+// Files returns the paths the turn changed, in first-edit order.
+func (t Turn) Files() []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, e := range t.Edits {
+		if !seen[e.Path] {
+			seen[e.Path] = true
+			files = append(files, e.Path)
+		}
+	}
+	return files
+}
+
+// History attributes the file edits of a session to the prompts that
+// started each turn.
+type History struct {
+	Turns []Turn
+	files map[string][]FileEdit
+}
+
+// BuildHistory groups the file-editing tool calls in messages by turn. Each
+// user message starts a new turn; edits before the first prompt belong to a
+// turn with an empty prompt.
+func BuildHistory(messages []Message) *History {
+	h := &History{files: make(map[string][]FileEdit)}
+
+	for _, msg := range messages {
+		if msg.Role == "user" {
+			h.Turns = append(h.Turns, Turn{Prompt: msg})
+			continue
+		}
+		for _, tool := range msg.ToolCalls {
+			for _, path := range EditedPaths(tool) {
+				if len(h.Turns) == 0 {
+					h.Turns = append(h.Turns, Turn{})
+				}
+				idx := len(h.Turns) - 1
+				edit := FileEdit{Path: path, Tool: tool, Turn: idx}
+				h.Turns[idx].Edits = append(h.Turns[idx].Edits, edit)
+				h.files[path] = append(h.files[path], edit)
+			}
+		}
+	}
+
+	return h
+}
+
+// FileEdits returns the edits made to path, oldest first.
+func (h *History) FileEdits(path string) []FileEdit {
+	return h.files[filepath.Clean(path)]
+}
+
+// FileTurns returns the indexes of the turns that changed path, oldest first.
+func (h *History) FileTurns(path string) []int {
+	var turns []int
+	for _, e := range h.FileEdits(path) {
+		if len(turns) == 0 || turns[len(turns)-1] != e.Turn {
+			turns = append(turns, e.Turn)
+		}
+	}
+	return turns
+}
+
+// Files returns every path edited during the session, sorted.
+func (h *History) Files() []string {
+	files := make([]string, 0, len(h.files))
+	for path := range h.files {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// EditedPaths returns the files a tool call writes to, or nil if the tool
+// doesn't edit files. Relative paths are kept relative.
+func EditedPaths(tool ToolCall) []string {
+	switch tool.Name {
+	case "Edit", "MultiEdit", "Write", "NotebookEdit":
+	default:
+		return nil
+	}
+
+	var data struct {
+		FilePath     string `json:"file_path"`
+		NotebookPath string `json:"notebook_path"`
+	}
+	if err := json.Unmarshal(tool.Input, &data); err != nil {
+		return nil
+	}
+	path := data.FilePath
+	if path == "" {
+		path = data.NotebookPath
+	}
+	if path == "" {
+		return nil
+	}
+	return []string{filepath.Clean(path)}
+}
+
+// PromptSummary returns the first line of the turn's prompt, at most maxLen
+// characters long.
+func (t Turn) PromptSummary(maxLen int) string {
+	if t.Prompt.Content == "" {
+		return "(before first prompt)"
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(t.Prompt.Content), "\n")
+	return collapseAndTruncate(line, maxLen)
+}
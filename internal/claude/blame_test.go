@@ -0,0 +1,73 @@
+package claude
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func toolCall(name string, input map[string]any) ToolCall {
+	data, _ := json.Marshal(input)
+	return ToolCall{Name: name, Input: data}
+}
+
+func TestBuildHistory(t *testing.T) {
+	messages := []Message{
+		{Role: "assistant", ToolCalls: []ToolCall{
+			toolCall("Write", map[string]any{"file_path": "/repo/early.go", "content": "x"}),
+		}},
+		{Role: "user", Content: "add a parser"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			toolCall("Read", map[string]any{"file_path": "/repo/main.go"}),
+			toolCall("Edit", map[string]any{"file_path": "/repo/main.go", "old_string": "a", "new_string": "b"}),
+		}},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			toolCall("MultiEdit", map[string]any{"file_path": "/repo/./parse.go", "edits": []any{}}),
+			toolCall("Edit", map[string]any{"file_path": "/repo/main.go", "old_string": "b", "new_string": "c"}),
+		}},
+		{Role: "user", Content: "now test it\nplease"},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			toolCall("Bash", map[string]any{"command": "go test"}),
+			toolCall("NotebookEdit", map[string]any{"notebook_path": "/repo/nb.ipynb"}),
+			toolCall("Write", map[string]any{"file_path": "/repo/main.go", "content": "d"}),
+		}},
+	}
+
+	h := BuildHistory(messages)
+
+	if len(h.Turns) != 3 {
+		t.Fatalf("got %d turns, want 3", len(h.Turns))
+	}
+	if h.Turns[0].Prompt.Content != "" || len(h.Turns[0].Edits) != 1 {
+		t.Errorf("turn 0 = %+v, want one edit without a prompt", h.Turns[0])
+	}
+	if got := h.Turns[1].Files(); !reflect.DeepEqual(got, []string{"/repo/main.go", "/repo/parse.go"}) {
+		t.Errorf("turn 1 files = %v", got)
+	}
+	if got := h.Turns[2].PromptSummary(40); got != "now test it" {
+		t.Errorf("turn 2 summary = %q", got)
+	}
+
+	if got := h.FileTurns("/repo/main.go"); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("main.go turns = %v, want [1 2]", got)
+	}
+	if got := len(h.FileEdits("/repo/main.go")); got != 3 {
+		t.Errorf("main.go has %d edits, want 3", got)
+	}
+	want := []string{"/repo/early.go", "/repo/main.go", "/repo/nb.ipynb", "/repo/parse.go"}
+	if got := h.Files(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Files() = %v, want %v", got, want)
+	}
+}
+
+func TestEditedPaths(t *testing.T) {
+	if got := EditedPaths(toolCall("Read", map[string]any{"file_path": "/a"})); got != nil {
+		t.Errorf("Read edited %v", got)
+	}
+	if got := EditedPaths(ToolCall{Name: "Edit", Input: []byte("not json")}); got != nil {
+		t.Errorf("bad input edited %v", got)
+	}
+	if got := EditedPaths(toolCall("Edit", map[string]any{"file_path": "/a/b/../c.go"})); !reflect.DeepEqual(got, []string{"/a/c.go"}) {
+		t.Errorf("Edit edited %v", got)
+	}
+}
@@ -244,22 +244,63 @@ func (r *Renderer) renderEditDiff(input []byte) []string {
 	if err := json.Unmarshal(input, &data); err != nil {
 		return nil
 	}
+	return r.renderStringDiff(data.FilePath, data.OldString, data.NewString)
+}
+
+// RenderFileEdit renders the diff of a file-editing tool call: each edit of
+// an Edit or MultiEdit, or the whole content of a Write.
+func (r *Renderer) RenderFileEdit(tool ToolCall) []string {
+	switch tool.Name {
+	case "Edit":
+		return r.renderEditDiff(tool.Input)
+
+	case "MultiEdit":
+		var data struct {
+			FilePath string `json:"file_path"`
+			Edits    []struct {
+				OldString string `json:"old_string"`
+				NewString string `json:"new_string"`
+			} `json:"edits"`
+		}
+		if err := json.Unmarshal(tool.Input, &data); err != nil {
+			return nil
+		}
+		var lines []string
+		for _, e := range data.Edits {
+			lines = append(lines, r.renderStringDiff(data.FilePath, e.OldString, e.NewString)...)
+		}
+		return lines
+
+	case "Write":
+		var data struct {
+			FilePath string `json:"file_path"`
+			Content  string `json:"content"`
+		}
+		if err := json.Unmarshal(tool.Input, &data); err != nil {
+			return nil
+		}
+		return r.renderStringDiff(data.FilePath, "", data.Content)
+	}
+	return nil
+}
 
-	if data.OldString == "" && data.NewString == "" {
+// renderStringDiff renders the unified diff between two versions of a file's text.
+func (r *Renderer) renderStringDiff(path, oldString, newString string) []string {
+	if oldString == "" && newString == "" {
 		return nil
 	}
 
 	// Get lexer based on file extension
-	lexer := lexers.Match(data.FilePath)
+	lexer := lexers.Match(path)
 	if lexer == nil {
 		lexer = lexers.Fallback
 	}
 	lexer = chroma.Coalesce(lexer)
 
 	// Generate unified diff using Myers algorithm
-	filename := filepath.Base(data.FilePath)
-	edits := myers.ComputeEdits(span.URIFromPath(filename), data.OldString, data.NewString)
-	unified := gotextdiff.ToUnified(filename, filename, data.OldString, edits)
+	filename := filepath.Base(path)
+	edits := myers.ComputeEdits(span.URIFromPath(filename), oldString, newString)
+	unified := gotextdiff.ToUnified(filename, filename, oldString, edits)
 
 	var lines []string
 
@@ -277,45 +318,83 @@ func (r *Renderer) renderEditDiff(input []byte) []string {
 
 		// Render each line in the hunk
 		for _, line := range hunk.Lines {
-			content := line.Content
-			// Strip all newlines and carriage returns
-			content = strings.ReplaceAll(content, "\n", "")
-			content = strings.ReplaceAll(content, "\r", "")
-			content = expandTabs(content) // Expand tabs for consistent width
-
-			// Truncate if too long to prevent wrapping
-			visibleLen := r.visibleLength(content)
-			if visibleLen > diffWidth-3 {
-				content = r.truncateToWidth(content, diffWidth-6) + "..."
-				visibleLen = r.visibleLength(content)
-			}
-
-			highlighted := r.highlightLine(lexer, content)
-
-			switch line.Kind {
-			case gotextdiff.Delete:
-				// Red background (dark) for deletions
-				bgCode := "\033[48;5;52m"
-				// Replace resets to maintain background, also handle background-specific resets
-				h := strings.ReplaceAll(highlighted, "\033[0m", "\033[0m"+bgCode)
-				h = strings.ReplaceAll(h, "\033[49m", bgCode) // default background reset
-				lines = append(lines, fmt.Sprintf("      %s- %s%s\033[K\033[0m", bgCode, h, bgCode))
-			case gotextdiff.Insert:
-				// Green background (dark) for additions
-				bgCode := "\033[48;5;22m"
-				h := strings.ReplaceAll(highlighted, "\033[0m", "\033[0m"+bgCode)
-				h = strings.ReplaceAll(h, "\033[49m", bgCode)
-				lines = append(lines, fmt.Sprintf("      %s+ %s%s\033[K\033[0m", bgCode, h, bgCode))
-			default:
-				// Context lines (unchanged)
-				lines = append(lines, fmt.Sprintf("        %s", highlighted))
-			}
+			lines = append(lines, r.renderDiffLine(lexer, line.Kind, line.Content, diffWidth, "      "))
 		}
 	}
 
 	return lines
 }
 
+// renderDiffLine renders one diff line with syntax highlighting on a red
+// (deleted) or green (inserted) background.
+func (r *Renderer) renderDiffLine(lexer chroma.Lexer, kind gotextdiff.OpKind, content string, diffWidth int, indent string) string {
+	// Strip all newlines and carriage returns
+	content = strings.ReplaceAll(content, "\n", "")
+	content = strings.ReplaceAll(content, "\r", "")
+	content = expandTabs(content) // Expand tabs for consistent width
+
+	// Truncate if too long to prevent wrapping
+	visibleLen := r.visibleLength(content)
+	if visibleLen > diffWidth-3 {
+		content = r.truncateToWidth(content, diffWidth-6) + "..."
+	}
+
+	highlighted := r.highlightLine(lexer, content)
+
+	switch kind {
+	case gotextdiff.Delete:
+		// Red background (dark) for deletions
+		bgCode := "\033[48;5;52m"
+		// Replace resets to maintain background, also handle background-specific resets
+		h := strings.ReplaceAll(highlighted, "\033[0m", "\033[0m"+bgCode)
+		h = strings.ReplaceAll(h, "\033[49m", bgCode) // default background reset
+		return fmt.Sprintf("%s%s- %s%s\033[K\033[0m", indent, bgCode, h, bgCode)
+	case gotextdiff.Insert:
+		// Green background (dark) for additions
+		bgCode := "\033[48;5;22m"
+		h := strings.ReplaceAll(highlighted, "\033[0m", "\033[0m"+bgCode)
+		h = strings.ReplaceAll(h, "\033[49m", bgCode)
+		return fmt.Sprintf("%s%s+ %s%s\033[K\033[0m", indent, bgCode, h, bgCode)
+	default:
+		// Context lines (unchanged)
+		return fmt.Sprintf("%s  %s", indent, highlighted)
+	}
+}
+
+// RenderUnifiedLines renders the body lines of a unified diff hunk (each
+// prefixed with ' ', '+' or '-') for path, highlighted like Edit diffs.
+func (r *Renderer) RenderUnifiedLines(path string, hunkLines []string) []string {
+	lexer := lexers.Match(path)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	diffWidth := r.width - 2
+	if diffWidth < 20 {
+		diffWidth = 20
+	}
+
+	lines := make([]string, 0, len(hunkLines))
+	for _, line := range hunkLines {
+		if line == "" {
+			line = " "
+		}
+		kind := gotextdiff.Equal
+		switch line[0] {
+		case '+':
+			kind = gotextdiff.Insert
+		case '-':
+			kind = gotextdiff.Delete
+		case '\\':
+			lines = append(lines, "  \033[2m"+line+"\033[0m")
+			continue
+		}
+		lines = append(lines, r.renderDiffLine(lexer, kind, line[1:], diffWidth, ""))
+	}
+	return lines
+}
+
 // visibleLength returns the visible terminal width of a string (excluding ANSI codes)
 func (r *Renderer) visibleLength(s string) int {
 	// Strip ANSI escape codes
@@ -0,0 +1,124 @@
+package claude
+
+import (
+	"sync"
+	"time"
+)
+
+// TransitionKind is a change in a session's status worth acting on.
+type TransitionKind string
+
+const (
+	TransitionNeedsInput TransitionKind = "needs_input" // a permission prompt appeared
+	TransitionStop       TransitionKind = "stop"        // a turn ended
+	TransitionToolFailed TransitionKind = "tool_failed" // a tool call returned an error
+)
+
+// Transition describes a status change of a session.
+type Transition struct {
+	Kind     TransitionKind
+	Session  string        // tmux session
+	Cwd      string        // Claude's working directory
+	Tool     string        // tool asking permission or failing
+	Message  string        // permission prompt or tool error
+	Duration time.Duration // length of the turn that ended, zero if its start wasn't seen
+	Time     time.Time
+
+	// Replayed is set for events written before the tracker started, read
+	// back from the events file
+	Replayed bool
+}
+
+// Tracker follows the status of sessions through their hook events and
+// reports the transitions that need attention, each once.
+type Tracker struct {
+	mu       sync.Mutex
+	sessions map[string]*trackedSession
+	started  time.Time
+	now      func() time.Time
+}
+
+// trackedSession is what a Tracker knows of a session.
+type trackedSession struct {
+	status    SessionStatus
+	turnStart time.Time // prompt of the running turn, zero when idle
+}
+
+// NewTracker creates a tracker; events from before now count as replayed.
+func NewTracker() *Tracker {
+	return &Tracker{
+		sessions: make(map[string]*trackedSession),
+		started:  time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Observe updates a session's status from a hook event and returns the
+// transition it makes, if any. A permission prompt reported by several
+// events is one transition, and tools the user interrupts don't fail.
+func (t *Tracker) Observe(tmuxSession string, event HookEvent) (Transition, bool) {
+	at := event.Timestamp
+	if at.IsZero() {
+		at = t.now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[tmuxSession]
+	if !ok {
+		s = &trackedSession{status: StatusIdle}
+		t.sessions[tmuxSession] = s
+	}
+	prev := s.status
+	tr := Transition{
+		Session: tmuxSession,
+		Cwd:     event.Cwd,
+		Tool:    event.ToolName,
+		Time:    at,
+		// date -Iseconds timestamps have whole seconds
+		Replayed: at.Before(t.started.Truncate(time.Second)),
+	}
+
+	switch event.EventName {
+	case "UserPromptSubmit":
+		s.status = StatusThinking
+		s.turnStart = at
+		return tr, false
+
+	case "PreToolUse":
+		s.status = StatusTool
+		return tr, false
+
+	case "PostToolUse":
+		s.status = StatusActive
+		return tr, false
+
+	case "PostToolUseFailure":
+		s.status = StatusActive
+		tr.Kind = TransitionToolFailed
+		tr.Message = event.Error
+		return tr, !event.IsInterrupt
+
+	case "PermissionRequest", "Notification":
+		if event.EventName == "Notification" && event.NotificationType != "permission_prompt" {
+			return tr, false
+		}
+		s.status = StatusNeedsInput
+		tr.Kind = TransitionNeedsInput
+		tr.Message = event.Message
+		return tr, prev != StatusNeedsInput
+
+	case "Stop":
+		// SubagentStop ends a subagent, not the turn
+		s.status = StatusIdle
+		start := s.turnStart
+		s.turnStart = time.Time{}
+		tr.Kind = TransitionStop
+		if !start.IsZero() {
+			tr.Duration = at.Sub(start)
+		}
+		return tr, prev != StatusIdle
+	}
+	return tr, false
+}
@@ -0,0 +1,52 @@
+package claude
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerObserve(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker()
+	tracker.started = start
+
+	observe := func(name string, at time.Duration, edit func(*HookEvent)) (Transition, bool) {
+		event := HookEvent{EventName: name, Timestamp: start.Add(at), Cwd: "/repo"}
+		if edit != nil {
+			edit(&event)
+		}
+		return tracker.Observe("dev", event)
+	}
+
+	if _, ok := observe("UserPromptSubmit", 0, nil); ok {
+		t.Error("prompt is no transition")
+	}
+	tr, ok := observe("PermissionRequest", time.Second, func(e *HookEvent) { e.ToolName = "Bash" })
+	if !ok || tr.Kind != TransitionNeedsInput || tr.Tool != "Bash" || tr.Cwd != "/repo" {
+		t.Errorf("permission request = %+v, %v", tr, ok)
+	}
+	if _, ok := observe("Notification", 2*time.Second, func(e *HookEvent) { e.NotificationType = "permission_prompt" }); ok {
+		t.Error("the same prompt was a second transition")
+	}
+	if _, ok := observe("PostToolUseFailure", 3*time.Second, func(e *HookEvent) { e.IsInterrupt = true }); ok {
+		t.Error("interrupted tool was a failure")
+	}
+	tr, ok = observe("PostToolUseFailure", 4*time.Second, func(e *HookEvent) { e.Error = "exit 1" })
+	if !ok || tr.Kind != TransitionToolFailed || tr.Message != "exit 1" {
+		t.Errorf("tool failure = %+v, %v", tr, ok)
+	}
+	if _, ok := observe("SubagentStop", 5*time.Second, nil); ok {
+		t.Error("subagent stop ended the turn")
+	}
+	tr, ok = observe("Stop", time.Minute, nil)
+	if !ok || tr.Kind != TransitionStop || tr.Duration != time.Minute {
+		t.Errorf("stop = %+v, %v", tr, ok)
+	}
+	if _, ok := observe("Stop", 2*time.Minute, nil); ok {
+		t.Error("stop while idle was a transition")
+	}
+
+	if tr, _ := observe("PermissionRequest", -time.Hour, nil); !tr.Replayed {
+		t.Error("event from before the tracker started not marked replayed")
+	}
+}
@@ -24,6 +24,7 @@ type Session struct {
 	ID             string        `json:"session_id"`
 	TranscriptPath string        `json:"transcript_path"`
 	TmuxSession    string        `json:"tmux_session"`
+	TmuxPane       string        `json:"tmux_pane,omitempty"` // pane ID Claude runs in, e.g. "%3"
 	Cwd            string        `json:"cwd"`
 	PermissionMode string        `json:"permission_mode"`
 	Status         SessionStatus `json:"status"`
@@ -100,8 +101,9 @@ type Usage struct {
 type HookEvent struct {
 	// Added by hook script
 	TmuxSession string    `json:"tmux_session,omitempty"`
-	TS          string    `json:"ts,omitempty"` // ISO timestamp string from hook
-	Timestamp   time.Time `json:"-"`            // Parsed timestamp (not from JSON)
+	TmuxPane    string    `json:"tmux_pane,omitempty"` // $TMUX_PANE of the hook, e.g. "%3"
+	TS          string    `json:"ts,omitempty"`        // ISO timestamp string from hook
+	Timestamp   time.Time `json:"-"`                   // Parsed timestamp (not from JSON)
 
 	// From Claude Code
 	SessionID      string `json:"session_id"`
@@ -115,6 +117,8 @@ type HookEvent struct {
 	ToolInput    json.RawMessage `json:"tool_input,omitempty"`
 	ToolUseID    string          `json:"tool_use_id,omitempty"`
 	ToolResponse json.RawMessage `json:"tool_response,omitempty"`
+	Error        string          `json:"error,omitempty"`        // PostToolUseFailure
+	IsInterrupt  bool            `json:"is_interrupt,omitempty"` // the user stopped the tool
 
 	// User prompt
 	Prompt string `json:"prompt,omitempty"`
@@ -75,6 +75,13 @@ func (v *View) Session() *Session {
 	return v.session
 }
 
+// Messages returns a copy of the conversation loaded so far.
+func (v *View) Messages() []Message {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]Message(nil), v.session.Messages...)
+}
+
 // UpdateFromStatus updates the view from a status file update.
 func (v *View) UpdateFromStatus(status SessionStatus, tool string, sessionID, transcriptPath string) {
 	v.mu.Lock()
@@ -102,6 +109,17 @@ func (v *View) UpdateFromStatus(status SessionStatus, tool string, sessionID, tr
 	v.dirty = true
 }
 
+// Target returns the tmux target for sending input to Claude: the pane
+// reported by hooks if known, otherwise the tmux session.
+func (v *View) Target() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	if v.session.TmuxPane != "" {
+		return v.session.TmuxPane
+	}
+	return v.tmuxSession
+}
+
 // SetCwdFilter sets a working directory filter.
 // Only events from Claude sessions with a matching cwd will be accepted.
 func (v *View) SetCwdFilter(cwd string) {
@@ -142,6 +160,9 @@ func (v *View) UpdateFromHookEvent(event HookEvent) {
 	v.session.Cwd = event.Cwd
 	v.session.PermissionMode = event.PermissionMode
 	v.session.LastUpdate = time.Now()
+	if event.TmuxPane != "" {
+		v.session.TmuxPane = event.TmuxPane
+	}
 
 	if event.TranscriptPath != "" && v.session.TranscriptPath != event.TranscriptPath {
 		// New transcript = new Claude session, switch to it
@@ -182,6 +203,15 @@ func (v *View) UpdateFromHookEvent(event HookEvent) {
 		}
 		v.session.CurrentTool = nil
 
+	case "PostToolUseFailure":
+		v.session.Status = StatusActive
+		if v.session.CurrentTool != nil && v.session.CurrentTool.ID == event.ToolUseID {
+			v.session.CurrentTool.Status = ToolFailed
+			v.session.CurrentTool.EndTime = time.Now()
+			v.session.CurrentTool.Error = event.Error
+		}
+		v.session.CurrentTool = nil
+
 	case "PermissionRequest":
 		v.session.Status = StatusNeedsInput
 		v.session.PendingPermission = &PermissionRequest{
@@ -0,0 +1,230 @@
+// Package cleanup classifies the worktrees cmux leaves behind and removes
+// them without losing work.
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/session"
+)
+
+// Report describes the state of a linked worktree.
+type Report struct {
+	RepoName string
+	RepoPath string
+	Worktree git.Worktree
+	Base     string // branch merges are checked against
+
+	Missing      bool // directory is gone, only its metadata is left
+	Dirty        bool // modified or untracked files
+	Empty        bool // branch has no commits of its own, e.g. a fresh one
+	Merged       bool // branch is reachable from base
+	SquashMerged bool // branch changes are on base without its commits
+	Unpushed     int  // commits on no remote and not on base
+	Age          time.Duration
+}
+
+// Labels returns the report's states for display, e.g. "squash-merged" and
+// "dirty".
+func (r Report) Labels() []string {
+	var labels []string
+	switch {
+	case r.Merged:
+		labels = append(labels, "merged")
+	case r.SquashMerged:
+		labels = append(labels, "squash-merged")
+	}
+	if r.Empty {
+		labels = append(labels, "empty")
+	}
+	if r.Unpushed > 0 {
+		labels = append(labels, fmt.Sprintf("%d unpushed", r.Unpushed))
+	}
+	if r.Dirty {
+		labels = append(labels, "dirty")
+	}
+	if r.Missing {
+		labels = append(labels, "missing")
+	}
+	if len(labels) == 0 {
+		labels = append(labels, "unmerged")
+	}
+	return labels
+}
+
+// merged reports whether the branch's work is on base either way.
+func (r Report) merged() bool {
+	return r.Worktree.Branch != "" && (r.Merged || r.SquashMerged)
+}
+
+// Plan is what cleaning up a worktree does, in order.
+type Plan struct {
+	Stash        bool // stash modified and untracked files first
+	Remove       bool // remove the worktree directory
+	Prune        bool // drop the metadata of a missing directory
+	DeleteBranch bool // delete the branch, which must be merged
+}
+
+// Empty reports whether the plan does nothing.
+func (p Plan) Empty() bool {
+	return p == Plan{}
+}
+
+// String describes the plan, e.g. "stash, remove, delete branch".
+func (p Plan) String() string {
+	var steps []string
+	if p.Stash {
+		steps = append(steps, "stash")
+	}
+	if p.Remove {
+		steps = append(steps, "remove")
+	}
+	if p.Prune {
+		steps = append(steps, "prune")
+	}
+	if p.DeleteBranch {
+		steps = append(steps, "delete branch")
+	}
+	if len(steps) == 0 {
+		return "keep"
+	}
+	return strings.Join(steps, ", ")
+}
+
+// SafePlan returns what may be cleaned up unasked: missing worktrees are
+// pruned and merged ones removed, stashing their changes, along with their
+// branches. Unmerged work is kept.
+func (r Report) SafePlan() Plan {
+	if r.Missing {
+		return Plan{Prune: true, DeleteBranch: r.merged()}
+	}
+	if !r.merged() {
+		return Plan{}
+	}
+	return Plan{Stash: r.Dirty, Remove: true, DeleteBranch: true}
+}
+
+// RemovalPlan returns what removing a chosen worktree does. Changes are
+// stashed and unmerged branches kept, so nothing is lost.
+func (r Report) RemovalPlan() Plan {
+	if r.Missing {
+		return Plan{Prune: true, DeleteBranch: r.merged()}
+	}
+	return Plan{Stash: r.Dirty, Remove: true, DeleteBranch: r.merged()}
+}
+
+// Apply carries out a plan for a worktree.
+func Apply(g *git.Client, r Report, p Plan) error {
+	if p.DeleteBranch && !r.merged() {
+		return fmt.Errorf("branch %s is not merged into %s", r.Worktree.Branch, r.Base)
+	}
+	if p.Stash {
+		if err := g.StashAll(r.Worktree.Path, "cmux cleanup: "+r.Name()); err != nil {
+			return err
+		}
+	}
+	if p.Remove {
+		if err := g.RemoveWorktree(r.RepoPath, r.Worktree.Path); err != nil {
+			return err
+		}
+	}
+	if p.Prune {
+		if err := g.PruneWorktrees(r.RepoPath); err != nil {
+			return err
+		}
+	}
+	if p.DeleteBranch {
+		return g.DeleteBranch(r.RepoPath, r.Worktree.Branch)
+	}
+	return nil
+}
+
+// Name names the worktree by its branch, or its path when detached.
+func (r Report) Name() string {
+	if r.Worktree.Branch != "" {
+		return r.Worktree.Branch
+	}
+	return r.Worktree.Path
+}
+
+// Classify inspects a linked worktree of a repository against base.
+func Classify(g *git.Client, repoPath, base string, wt git.Worktree) Report {
+	r := Report{RepoPath: repoPath, Worktree: wt, Base: base}
+
+	if _, err := os.Stat(wt.Path); errors.Is(err, os.ErrNotExist) {
+		r.Missing = true
+	} else {
+		if changes, err := g.Status(wt.Path); err == nil {
+			r.Dirty = len(changes) > 0
+		}
+		if lastCommit, err := g.GetLastCommitTime(wt.Path); err == nil {
+			r.Age = time.Since(lastCommit)
+		}
+	}
+
+	// A detached worktree has only its HEAD, which a missing one has lost
+	rev := wt.Branch
+	if rev == "" {
+		if r.Missing {
+			return r
+		}
+		head, err := g.RevParse(wt.Path, "HEAD")
+		if err != nil {
+			return r
+		}
+		rev = head
+	}
+
+	// A branch with nothing past base is reachable from it too, but nothing
+	// of it was merged, so it is kept
+	if merged, err := g.IsAncestor(repoPath, rev, base); err == nil && merged {
+		if empty, err := g.IsFirstParent(repoPath, rev, base); err == nil && empty {
+			r.Empty = true
+		} else {
+			r.Merged = true
+		}
+	}
+	if !r.Merged && !r.Empty {
+		if squashed, err := g.IsSquashMerged(repoPath, rev, base); err == nil {
+			r.SquashMerged = squashed
+		}
+	}
+	if n, err := g.CountUnpushed(repoPath, rev, base); err == nil && !r.SquashMerged {
+		r.Unpushed = n
+	}
+	return r
+}
+
+// Scan classifies the linked worktrees of repositories, skipping those for
+// which active returns true.
+func Scan(g *git.Client, cfg *config.Config, repos []string, active func(path string) bool) []Report {
+	var reports []Report
+	for _, repoPath := range repos {
+		worktrees, err := g.ListWorktrees(repoPath)
+		if err != nil {
+			continue
+		}
+
+		repoName := ""
+		if info, err := g.GetRepoInfo(repoPath); err == nil {
+			repoName = info.Name
+		}
+		base := session.BaseBranch(g, cfg, repoPath)
+
+		for _, wt := range worktrees {
+			if wt.IsMain || active(wt.Path) {
+				continue
+			}
+			r := Classify(g, repoPath, base, wt)
+			r.RepoName = repoName
+			reports = append(reports, r)
+		}
+	}
+	return reports
+}
@@ -0,0 +1,73 @@
+package cleanup
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+func TestReport_Plans(t *testing.T) {
+	branch := git.Worktree{Path: "/repo/.worktrees/feat", Branch: "feat"}
+	detached := git.Worktree{Path: "/repo/.worktrees/detached"}
+	tests := []struct {
+		name    string
+		report  Report
+		safe    Plan
+		removal Plan
+	}{
+		{"merged", Report{Worktree: branch, Merged: true},
+			Plan{Remove: true, DeleteBranch: true}, Plan{Remove: true, DeleteBranch: true}},
+		{"squash-merged and dirty", Report{Worktree: branch, SquashMerged: true, Dirty: true},
+			Plan{Stash: true, Remove: true, DeleteBranch: true}, Plan{Stash: true, Remove: true, DeleteBranch: true}},
+		{"unpushed", Report{Worktree: branch, Unpushed: 2},
+			Plan{}, Plan{Remove: true}},
+		{"dirty and unmerged", Report{Worktree: branch, Dirty: true},
+			Plan{}, Plan{Stash: true, Remove: true}},
+		{"missing and merged", Report{Worktree: branch, Missing: true, Merged: true},
+			Plan{Prune: true, DeleteBranch: true}, Plan{Prune: true, DeleteBranch: true}},
+		{"missing and unmerged", Report{Worktree: branch, Missing: true},
+			Plan{Prune: true}, Plan{Prune: true}},
+		{"detached at a merged commit", Report{Worktree: detached, Merged: true},
+			Plan{}, Plan{Remove: true}},
+		{"empty", Report{Worktree: branch, Empty: true},
+			Plan{}, Plan{Remove: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.report.SafePlan(); got != tt.safe {
+				t.Errorf("SafePlan() = %+v, want %+v", got, tt.safe)
+			}
+			if got := tt.report.RemovalPlan(); got != tt.removal {
+				t.Errorf("RemovalPlan() = %+v, want %+v", got, tt.removal)
+			}
+		})
+	}
+}
+
+func TestReport_Labels(t *testing.T) {
+	tests := []struct {
+		report Report
+		want   []string
+	}{
+		{Report{Merged: true, SquashMerged: true}, []string{"merged"}},
+		{Report{SquashMerged: true, Dirty: true}, []string{"squash-merged", "dirty"}},
+		{Report{Unpushed: 3, Missing: true}, []string{"3 unpushed", "missing"}},
+		{Report{Empty: true}, []string{"empty"}},
+		{Report{}, []string{"unmerged"}},
+	}
+	for _, tt := range tests {
+		if got := tt.report.Labels(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Labels() of %+v = %v, want %v", tt.report, got, tt.want)
+		}
+	}
+}
+
+func TestPlan_String(t *testing.T) {
+	if got := (Plan{Stash: true, Remove: true, DeleteBranch: true}).String(); got != "stash, remove, delete branch" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := (Plan{}).String(); got != "keep" {
+		t.Errorf("String() of empty plan = %q, want keep", got)
+	}
+}
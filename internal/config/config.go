@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"github.com/abdullathedruid/cmux/internal/process"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,12 +22,27 @@ type Config struct {
 	// ClaudeCommand is the command to run Claude Code
 	ClaudeCommand string `yaml:"claude_command"`
 
+	// ClaudePatterns are globs matching the executable, or the script an
+	// interpreter runs, of Claude processes in panes
+	ClaudePatterns []string `yaml:"claude_patterns"`
+
 	// DefaultShell is the shell to use when Claude exits
 	DefaultShell string `yaml:"default_shell"`
 
-	// WorktreeDir is the subdirectory name for worktrees inside repos
+	// WorktreeDir is where new worktrees are created: a directory inside the
+	// repo (".worktrees") or a path template such as "~/wt/{repo}/{branch}"
 	WorktreeDir string `yaml:"worktree_dir"`
 
+	// WorktreeSetup prepares every new worktree so it is ready to build
+	WorktreeSetup WorktreeSetup `yaml:"worktree_setup"`
+
+	// RepoWorktrees overrides worktree placement and adds setup steps per
+	// repository, keyed by repository path or name.
+	//
+	// Deprecated: set worktree_dir and setup on the repositories entries.
+	// Load moves these settings there.
+	RepoWorktrees map[string]RepoWorktree `yaml:"repo_worktrees,omitempty"`
+
 	// RefreshInterval is how often to refresh session state (in seconds)
 	RefreshInterval int `yaml:"refresh_interval"`
 
@@ -35,8 +52,118 @@ type Config struct {
 	// Theme contains theme/appearance configuration
 	Theme Theme `yaml:"theme"`
 
-	// Repositories is a list of git repository paths to track
-	Repositories []string `yaml:"repositories"`
+	// Repositories lists the git repositories to track and their settings
+	Repositories []Repository `yaml:"repositories"`
+
+	// repoMu guards Repositories, which the UI changes while background
+	// discovery and git checks read it
+	repoMu sync.RWMutex
+
+	// Layout holds the arrangement of pinned session views
+	Layout LayoutConfig `yaml:"layout"`
+
+	// Forge holds the commands used to open pull requests
+	Forge ForgeConfig `yaml:"forge"`
+
+	// Templates are the named session templates offered for new sessions
+	Templates map[string]SessionTemplate `yaml:"templates"`
+
+	// Sync holds how worktrees are rebased onto their base branch
+	Sync SyncConfig `yaml:"sync"`
+
+	// Notify holds the notifications sent when sessions need attention
+	Notify NotifyConfig `yaml:"notify"`
+
+	// Rules post webhooks and run commands on session events
+	Rules []Rule `yaml:"rules"`
+}
+
+// NotifyConfig holds the notification configuration.
+type NotifyConfig struct {
+	// Events lists what is notified: needs_input, finished and tool_failed
+	Events []string `yaml:"events"`
+
+	// Sinks lists where notifications go: osc9, osc777, bell, command and
+	// tmux. An empty list turns notifications off.
+	Sinks []string `yaml:"sinks"`
+
+	// Command is run by the command sink, one argument per element. It may
+	// use {title}, {body} and {session}.
+	Command []string `yaml:"command"`
+
+	// MinRun is how long, in seconds, a turn must run for its end to be
+	// notified
+	MinRun int `yaml:"min_run"`
+
+	// RateLimit is the least time, in seconds, between notifications of one
+	// session
+	RateLimit int `yaml:"rate_limit"`
+
+	// QuietHours is a daily local time window without notifications, e.g.
+	// "22:00-08:00"
+	QuietHours string `yaml:"quiet_hours"`
+}
+
+// SyncConfig holds the rebase workflow configuration.
+type SyncConfig struct {
+	// OnConflict is what a rebase that stops on conflicts does: "claude"
+	// hands them to the session's Claude with ConflictPrompt, "abort"
+	// aborts the rebase
+	OnConflict string `yaml:"on_conflict"`
+
+	// ConflictPrompt is submitted to Claude when a rebase stops on
+	// conflicts. It may use {branch}, {base} and {files}.
+	ConflictPrompt string `yaml:"conflict_prompt"`
+}
+
+// ForgeConfig holds the pull request workflow configuration.
+type ForgeConfig struct {
+	// PRCommand is run by the shell in the session's worktree to open a pull
+	// request. It gets CMUX_PR_TITLE, CMUX_PR_BODY, CMUX_BRANCH and
+	// CMUX_BASE_BRANCH in its environment and should print the PR URL.
+	PRCommand string `yaml:"pr_command"`
+
+	// PRHeadCommand is run by the shell in the repository to find the head
+	// of a pull request given as CMUX_PR, a number or URL. It prints the
+	// head branch name, optionally followed by a ref to fetch the head from
+	// when the branch isn't on origin (e.g. "feature refs/pull/12/head").
+	PRHeadCommand string `yaml:"pr_head_command"`
+
+	// ReviewPrompt is submitted to Claude in sessions opened for a pull
+	// request. It may use {pr}, {branch} and {repo}.
+	ReviewPrompt string `yaml:"review_prompt"`
+}
+
+// WorktreeSetup lists the steps run after a worktree is created. Paths are
+// relative to the repository's main checkout and the new worktree.
+type WorktreeSetup struct {
+	// Copy lists files copied from the main checkout, e.g. ".env"
+	Copy []string `yaml:"copy"`
+
+	// Symlink lists paths linked to the main checkout, e.g. "node_modules"
+	Symlink []string `yaml:"symlink"`
+
+	// Run lists shell commands run in the new worktree, e.g. "npm ci"
+	Run []string `yaml:"run"`
+}
+
+// RepoWorktree holds the worktree settings of one repository in the
+// deprecated repo_worktrees.
+type RepoWorktree struct {
+	// Dir replaces WorktreeDir for the repository
+	Dir string `yaml:"dir"`
+
+	// Setup runs after the global WorktreeSetup
+	Setup WorktreeSetup `yaml:"setup"`
+}
+
+// LayoutConfig holds the tiled session view configuration.
+type LayoutConfig struct {
+	// Preset is the tile arrangement: grid, main-vertical or even-horizontal
+	Preset string `yaml:"preset"`
+
+	// Ratios is the main split position per preset, as a percentage of width
+	Ratios map[string]int `yaml:"ratios"`
 }
 
 // KeyBindings holds all configurable keybindings.
@@ -87,11 +214,57 @@ func Default() *Config {
 		DataDir:         defaultDataDir(),
 		SessionPrefix:   "",
 		ClaudeCommand:   "claude",
+		ClaudePatterns:  append([]string(nil), process.DefaultClaudePatterns...),
 		DefaultShell:    getDefaultShell(),
 		WorktreeDir:     ".worktrees",
 		RefreshInterval: 2,
 		Keys:            DefaultKeyBindings(),
 		Theme:           DefaultTheme(),
+		Layout:          DefaultLayout(),
+		Forge:           DefaultForge(),
+		Sync:            DefaultSync(),
+		Notify:          DefaultNotify(),
+	}
+}
+
+// DefaultNotify returns the default notifications: every event, as a
+// terminal notification and in tmux's status line.
+func DefaultNotify() NotifyConfig {
+	return NotifyConfig{
+		Events:    []string{"needs_input", "finished", "tool_failed"},
+		Sinks:     []string{"osc9", "tmux"},
+		Command:   []string{"notify-send", "{title}", "{body}"},
+		MinRun:    30,
+		RateLimit: 10,
+	}
+}
+
+// DefaultSync returns the default rebase workflow, which hands conflicts to
+// Claude.
+func DefaultSync() SyncConfig {
+	return SyncConfig{
+		OnConflict:     "claude",
+		ConflictPrompt: "Rebasing {branch} onto {base} stopped with conflicts in: {files}. Resolve the conflicts, stage the files and run `git rebase --continue` until the rebase finishes.",
+	}
+}
+
+// DefaultForge returns the default pull request workflow, using the GitHub CLI.
+func DefaultForge() ForgeConfig {
+	return ForgeConfig{
+		PRCommand:     `gh pr create --title "$CMUX_PR_TITLE" --body "$CMUX_PR_BODY" --head "$CMUX_BRANCH" --base "$CMUX_BASE_BRANCH"`,
+		PRHeadCommand: `gh pr view "$CMUX_PR" --json number,headRefName --jq '.headRefName + " refs/pull/" + (.number | tostring) + "/head"'`,
+		ReviewPrompt:  "Review pull request {pr} on branch {branch}. Summarize what it changes, then point out bugs, risky changes and missing tests.",
+	}
+}
+
+// DefaultLayout returns the default tiled view layout.
+func DefaultLayout() LayoutConfig {
+	return LayoutConfig{
+		Preset: "grid",
+		Ratios: map[string]int{
+			"grid":          50,
+			"main-vertical": 60,
+		},
 	}
 }
 
@@ -189,6 +362,7 @@ func Load() (*Config, error) {
 
 	// Merge file config with defaults (file values override defaults)
 	mergeConfig(cfg, &fileCfg)
+	cfg.migrateRepoWorktrees()
 
 	// Validate keybindings
 	if err := ValidateKeys(&cfg.Keys); err != nil {
@@ -196,7 +370,7 @@ func Load() (*Config, error) {
 	}
 
 	// Validate repositories
-	if err := ValidateRepositories(cfg.Repositories); err != nil {
+	if err := ValidateRepositories(cfg.ExpandedRepositories()); err != nil {
 		return nil, err
 	}
 
@@ -212,12 +386,21 @@ func mergeConfig(dst, src *Config) {
 	if src.ClaudeCommand != "" {
 		dst.ClaudeCommand = src.ClaudeCommand
 	}
+	if len(src.ClaudePatterns) > 0 {
+		dst.ClaudePatterns = src.ClaudePatterns
+	}
 	if src.DefaultShell != "" {
 		dst.DefaultShell = src.DefaultShell
 	}
 	if src.WorktreeDir != "" {
 		dst.WorktreeDir = src.WorktreeDir
 	}
+	if src.WorktreeSetup.Copy != nil || src.WorktreeSetup.Symlink != nil || src.WorktreeSetup.Run != nil {
+		dst.WorktreeSetup = src.WorktreeSetup
+	}
+	if len(src.RepoWorktrees) > 0 {
+		dst.RepoWorktrees = src.RepoWorktrees
+	}
 	if src.RefreshInterval != 0 {
 		dst.RefreshInterval = src.RefreshInterval
 	}
@@ -232,6 +415,63 @@ func mergeConfig(dst, src *Config) {
 	if len(src.Repositories) > 0 {
 		dst.Repositories = src.Repositories
 	}
+
+	// Merge layout
+	if src.Layout.Preset != "" {
+		dst.Layout.Preset = src.Layout.Preset
+	}
+	for preset, ratio := range src.Layout.Ratios {
+		dst.Layout.Ratios[preset] = ratio
+	}
+
+	// Merge forge
+	if src.Forge.PRCommand != "" {
+		dst.Forge.PRCommand = src.Forge.PRCommand
+	}
+	if src.Forge.PRHeadCommand != "" {
+		dst.Forge.PRHeadCommand = src.Forge.PRHeadCommand
+	}
+	if src.Forge.ReviewPrompt != "" {
+		dst.Forge.ReviewPrompt = src.Forge.ReviewPrompt
+	}
+
+	// Merge templates
+	if len(src.Templates) > 0 {
+		dst.Templates = src.Templates
+	}
+
+	// Merge sync
+	if src.Sync.OnConflict != "" {
+		dst.Sync.OnConflict = src.Sync.OnConflict
+	}
+	if src.Sync.ConflictPrompt != "" {
+		dst.Sync.ConflictPrompt = src.Sync.ConflictPrompt
+	}
+
+	// Merge notifications; an empty list of sinks is kept to turn them off
+	if src.Notify.Events != nil {
+		dst.Notify.Events = src.Notify.Events
+	}
+	if src.Notify.Sinks != nil {
+		dst.Notify.Sinks = src.Notify.Sinks
+	}
+	if len(src.Notify.Command) > 0 {
+		dst.Notify.Command = src.Notify.Command
+	}
+	if src.Notify.MinRun != 0 {
+		dst.Notify.MinRun = src.Notify.MinRun
+	}
+	if src.Notify.RateLimit != 0 {
+		dst.Notify.RateLimit = src.Notify.RateLimit
+	}
+	if src.Notify.QuietHours != "" {
+		dst.Notify.QuietHours = src.Notify.QuietHours
+	}
+
+	// Merge rules (replace entirely if specified)
+	if len(src.Rules) > 0 {
+		dst.Rules = src.Rules
+	}
 }
 
 // mergeKeyBindings merges keybindings from src into dst.
@@ -353,6 +593,16 @@ func (c *Config) NotesFile() string {
 	return filepath.Join(c.DataDir, "notes.json")
 }
 
+// RegistryFile returns the path to the registry of seen sessions.
+func (c *Config) RegistryFile() string {
+	return filepath.Join(c.DataDir, "sessions.json")
+}
+
+// WorkspacesDir returns the directory holding saved workspaces.
+func (c *Config) WorkspacesDir() string {
+	return filepath.Join(c.DataDir, "workspaces")
+}
+
 // ConfigFile returns the path to the config file.
 func (c *Config) ConfigFile() string {
 	return filepath.Join(c.DataDir, "config.yaml")
@@ -365,13 +615,38 @@ func (c *Config) EnsureDataDir() error {
 
 // ExpandedRepositories returns the repositories with ~ expanded to the home directory.
 func (c *Config) ExpandedRepositories() []string {
+	c.repoMu.RLock()
+	defer c.repoMu.RUnlock()
+
 	expanded := make([]string, len(c.Repositories))
 	for i, repo := range c.Repositories {
-		expanded[i] = expandPath(repo)
+		expanded[i] = expandPath(repo.Path)
 	}
 	return expanded
 }
 
+// WorktreeFor returns where worktrees of a repository are created and the
+// setup steps to run in them: the global settings followed by the
+// repository's own profile.
+func (c *Config) WorktreeFor(repoPath string) (dir string, setup WorktreeSetup) {
+	dir = c.WorktreeDir
+	setup = WorktreeSetup{
+		Copy:    append([]string(nil), c.WorktreeSetup.Copy...),
+		Symlink: append([]string(nil), c.WorktreeSetup.Symlink...),
+		Run:     append([]string(nil), c.WorktreeSetup.Run...),
+	}
+
+	if profile, ok := c.RepositoryFor(repoPath); ok {
+		if profile.WorktreeDir != "" {
+			dir = profile.WorktreeDir
+		}
+		setup.Copy = append(setup.Copy, profile.Setup.Copy...)
+		setup.Symlink = append(setup.Symlink, profile.Setup.Symlink...)
+		setup.Run = append(setup.Run, profile.Setup.Run...)
+	}
+	return dir, setup
+}
+
 // expandPath expands ~ to the user's home directory.
 func expandPath(path string) string {
 	if len(path) > 0 && path[0] == '~' {
@@ -392,7 +667,9 @@ func (c *Config) Save() error {
 	}
 
 	// Create a config struct for YAML output (exclude DataDir which is computed)
+	c.repoMu.RLock()
 	data, err := yaml.Marshal(c)
+	c.repoMu.RUnlock()
 	if err != nil {
 		return err
 	}
@@ -410,11 +687,14 @@ func (c *Config) AddRepository(path string) error {
 		return err
 	}
 
+	c.repoMu.Lock()
+
 	// Check if already exists
 	for _, repo := range c.Repositories {
-		existingExpanded := expandPath(repo)
+		existingExpanded := expandPath(repo.Path)
 		existingAbs, _ := filepath.Abs(existingExpanded)
 		if existingAbs == absPath {
+			c.repoMu.Unlock()
 			return nil // Already exists, no-op
 		}
 	}
@@ -426,7 +706,8 @@ func (c *Config) AddRepository(path string) error {
 		storePath = "~" + absPath[len(home):]
 	}
 
-	c.Repositories = append(c.Repositories, storePath)
+	c.Repositories = append(c.Repositories, Repository{Path: storePath})
+	c.repoMu.Unlock()
 	return c.Save()
 }
 
@@ -436,9 +717,10 @@ func (c *Config) RemoveRepository(path string) error {
 	expanded := expandPath(path)
 	absPath, _ := filepath.Abs(expanded)
 
-	var filtered []string
+	c.repoMu.Lock()
+	var filtered []Repository
 	for _, repo := range c.Repositories {
-		repoExpanded := expandPath(repo)
+		repoExpanded := expandPath(repo.Path)
 		repoAbs, _ := filepath.Abs(repoExpanded)
 		if repoAbs != absPath {
 			filtered = append(filtered, repo)
@@ -446,10 +728,12 @@ func (c *Config) RemoveRepository(path string) error {
 	}
 
 	if len(filtered) == len(c.Repositories) {
+		c.repoMu.Unlock()
 		return nil // Not found, no-op
 	}
 
 	c.Repositories = filtered
+	c.repoMu.Unlock()
 	return c.Save()
 }
 
@@ -458,8 +742,10 @@ func (c *Config) HasRepository(path string) bool {
 	expanded := expandPath(path)
 	absPath, _ := filepath.Abs(expanded)
 
+	c.repoMu.RLock()
+	defer c.repoMu.RUnlock()
 	for _, repo := range c.Repositories {
-		repoExpanded := expandPath(repo)
+		repoExpanded := expandPath(repo.Path)
 		repoAbs, _ := filepath.Abs(repoExpanded)
 		if repoAbs == absPath {
 			return true
@@ -229,10 +229,10 @@ func TestExpandedRepositories(t *testing.T) {
 	}
 
 	cfg := &Config{
-		Repositories: []string{
-			"~/Coding/project1",
-			"/absolute/path",
-			"~/another/repo",
+		Repositories: []Repository{
+			{Path: "~/Coding/project1"},
+			{Path: "/absolute/path"},
+			{Path: "~/another/repo"},
 		},
 	}
 
@@ -3,7 +3,11 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoad_NoConfigFile(t *testing.T) {
@@ -196,3 +200,429 @@ func TestLoad_StatusStyle(t *testing.T) {
 		t.Error("idle.Icon should have default value")
 	}
 }
+
+func TestLoad_Layout(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `layout:
+  preset: main-vertical
+  ratios:
+    main-vertical: 70
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if cfg.Layout.Preset != "main-vertical" {
+		t.Errorf("cfg.Layout.Preset = %q, want %q", cfg.Layout.Preset, "main-vertical")
+	}
+	if got := cfg.Layout.Ratios["main-vertical"]; got != 70 {
+		t.Errorf("main-vertical ratio = %d, want 70", got)
+	}
+	// Presets not in the file keep their defaults
+	if got := cfg.Layout.Ratios["grid"]; got != 50 {
+		t.Errorf("grid ratio = %d, want 50", got)
+	}
+}
+
+func TestLoad_Forge(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `forge:
+  pr_command: glab mr create --title "$CMUX_PR_TITLE" --yes
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	want := `glab mr create --title "$CMUX_PR_TITLE" --yes`
+	if cfg.Forge.PRCommand != want {
+		t.Errorf("cfg.Forge.PRCommand = %q, want %q", cfg.Forge.PRCommand, want)
+	}
+	if Default().Forge.PRCommand == "" {
+		t.Error("default PR command should not be empty")
+	}
+}
+
+func TestLoad_ClaudePatterns(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `claude_patterns: [claude, "*/agents/claude.js"]
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if len(cfg.ClaudePatterns) != 2 || cfg.ClaudePatterns[1] != "*/agents/claude.js" {
+		t.Errorf("cfg.ClaudePatterns = %q", cfg.ClaudePatterns)
+	}
+	if len(Default().ClaudePatterns) == 0 {
+		t.Error("default Claude patterns should not be empty")
+	}
+}
+
+func TestLoad_Notify(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `notify:
+  sinks: []
+  min_run: 120
+  quiet_hours: "22:00-08:00"
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if cfg.Notify.Sinks == nil || len(cfg.Notify.Sinks) != 0 {
+		t.Errorf("cfg.Notify.Sinks = %q, want empty to turn notifications off", cfg.Notify.Sinks)
+	}
+	if cfg.Notify.MinRun != 120 || cfg.Notify.QuietHours != "22:00-08:00" {
+		t.Errorf("cfg.Notify = %+v", cfg.Notify)
+	}
+	if len(cfg.Notify.Events) != 3 || cfg.Notify.RateLimit != 10 {
+		t.Errorf("unset notify settings should keep defaults, got %+v", cfg.Notify)
+	}
+}
+
+func TestLoad_Rules(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `rules:
+  - name: chat
+    on: [needs_input]
+    url: http://localhost:8080/hook
+    headers:
+      Authorization: Bearer token
+  - on: [stop]
+    command: make test
+    retries: 0
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if len(cfg.Rules) != 2 {
+		t.Fatalf("len(cfg.Rules) = %d, want 2", len(cfg.Rules))
+	}
+	if r := cfg.Rules[0]; r.Name != "chat" || r.URL != "http://localhost:8080/hook" || r.Headers["Authorization"] != "Bearer token" || r.Retries != nil {
+		t.Errorf("cfg.Rules[0] = %+v", r)
+	}
+	if r := cfg.Rules[1]; r.Command != "make test" || r.Retries == nil || *r.Retries != 0 {
+		t.Errorf("cfg.Rules[1] = %+v, want retries set to 0", r)
+	}
+}
+
+func TestLoad_Worktrees(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	web, api := filepath.Join(tmpDir, "code", "web"), filepath.Join(tmpDir, "src", "api")
+	for _, dir := range []string{dataDir, web, api} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configContent := `worktree_dir: ~/wt/{repo}/{branch}
+worktree_setup:
+  copy: [.env]
+repositories:
+  - path: ~/code/web
+    setup:
+      run: [make]
+  - ~/src/api
+repo_worktrees:
+  web:
+    setup:
+      symlink: [node_modules]
+      run: [npm ci]
+  ~/src/api:
+    dir: .trees
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	// repo_worktrees moves into the repositories entries
+	if cfg.RepoWorktrees != nil {
+		t.Errorf("RepoWorktrees = %v, want it migrated", cfg.RepoWorktrees)
+	}
+	if repo, _ := cfg.RepositoryFor(web); repo.WorktreeDir != "" || !slices.Equal(repo.Setup.Run, []string{"npm ci", "make"}) {
+		t.Errorf("web entry = %+v, want repo_worktrees setup before its own", repo)
+	}
+	if repo := cfg.Repositories[1]; repo.WorktreeDir != ".trees" {
+		t.Errorf("api entry = %+v, want repo_worktrees dir", repo)
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(configPath); strings.Contains(string(data), "repo_worktrees") || !strings.Contains(string(data), "npm ci") {
+		t.Errorf("saved config = %s, want the settings moved into repositories", data)
+	}
+
+	dir, setup := cfg.WorktreeFor(web)
+	if dir != "~/wt/{repo}/{branch}" {
+		t.Errorf("web dir = %q, want the global template", dir)
+	}
+	if len(setup.Copy) != 1 || len(setup.Symlink) != 1 || len(setup.Run) != 2 {
+		t.Errorf("web setup = %+v, want global copy plus repo symlink and runs", setup)
+	}
+
+	dir, setup = cfg.WorktreeFor(api)
+	if dir != ".trees" {
+		t.Errorf("api dir = %q, want %q", dir, ".trees")
+	}
+	if len(setup.Copy) != 1 || len(setup.Run) != 0 {
+		t.Errorf("api setup = %+v, want only the global copy", setup)
+	}
+
+	// Default placement is inside the repo
+	if dir, _ := Default().WorktreeFor("/src/other"); dir != ".worktrees" {
+		t.Errorf("default dir = %q, want %q", dir, ".worktrees")
+	}
+}
+
+func TestLoad_Repositories(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	for _, dir := range []string{dataDir, filepath.Join(tmpDir, "tool"), filepath.Join(tmpDir, "app")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configContent := `repositories:
+  - ~/tool
+  - path: ~/app
+    model: opus
+    permission_mode: acceptEdits
+    env:
+      NODE_ENV: test
+    base_branch: develop
+    worktree_dir: .trees
+    setup:
+      run: [npm ci]
+    session_name: "app-{branch}"
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	want := []string{filepath.Join(tmpDir, "tool"), filepath.Join(tmpDir, "app")}
+	if got := cfg.ExpandedRepositories(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("ExpandedRepositories() = %v, want %v", got, want)
+	}
+
+	tool, ok := cfg.RepositoryFor(want[0])
+	if !ok || tool.Model != "" || tool.BaseBranch != "" {
+		t.Errorf("tool = %+v, %v; want a plain entry", tool, ok)
+	}
+	app, ok := cfg.RepositoryFor(want[1])
+	if !ok || app.Model != "opus" || app.Env["NODE_ENV"] != "test" || app.BaseBranch != "develop" {
+		t.Errorf("app = %+v, %v; want its overrides", app, ok)
+	}
+	if _, ok := cfg.RepositoryFor(filepath.Join(tmpDir, "other")); ok {
+		t.Error("unconfigured repository should not match")
+	}
+
+	dir, setup := cfg.WorktreeFor(want[1])
+	if dir != ".trees" || len(setup.Run) != 1 {
+		t.Errorf("app worktree = %q %+v, want the profile's", dir, setup)
+	}
+}
+
+func TestRepository_MarshalYAML(t *testing.T) {
+	repos := []Repository{
+		{Path: "~/tool"},
+		{Path: "~/app", Model: "opus"},
+	}
+	data, err := yaml.Marshal(repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "- ~/tool\n- path: ~/app\n  model: opus\n"
+	if string(data) != want {
+		t.Errorf("Marshal() = %q, want %q", data, want)
+	}
+
+	var back []Repository
+	if err := yaml.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != 2 || back[0].Path != "~/tool" || back[1].Model != "opus" {
+		t.Errorf("round trip = %+v", back)
+	}
+}
+
+func TestLoad_Templates(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "cmux-test-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dataDir := filepath.Join(tmpDir, ".config", "cmux")
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	configContent := `templates:
+  tests:
+    description: Write tests
+    prompt: Write tests for {branch}
+  ci:
+    branch: fix/ci-{issue}
+    model: opus
+    prompt: |
+      CI is failing, see {issue}.
+    checklist:
+      - Reproduce the failure
+      - Run the tests
+`
+	configPath := filepath.Join(dataDir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", oldHome)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if got := cfg.TemplateNames(); len(got) != 2 || got[0] != "ci" || got[1] != "tests" {
+		t.Errorf("TemplateNames() = %v, want [ci tests]", got)
+	}
+	ci := cfg.Templates["ci"]
+	if ci.Branch != "fix/ci-{issue}" || ci.Model != "opus" || ci.Prompt != "CI is failing, see {issue}.\n" || len(ci.Checklist) != 2 {
+		t.Errorf("ci template = %+v", ci)
+	}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Repository is a tracked repository and the settings its sessions use. In
+// config.yaml an entry is either a plain path or a mapping with a path and
+// overrides:
+//
+//	repositories:
+//	  - ~/code/tool
+//	  - path: ~/code/app
+//	    model: opus
+//	    base_branch: develop
+type Repository struct {
+	// Path is the repository's main checkout
+	Path string `yaml:"path"`
+
+	// ClaudeCommand replaces the global claude_command
+	ClaudeCommand string `yaml:"claude_command,omitempty"`
+
+	// Model is passed to Claude with --model
+	Model string `yaml:"model,omitempty"`
+
+	// PermissionMode is passed to Claude with --permission-mode
+	PermissionMode string `yaml:"permission_mode,omitempty"`
+
+	// AddDirs are extra directories Claude may access, each passed with --add-dir
+	AddDirs []string `yaml:"add_dirs,omitempty"`
+
+	// ClaudeArgs are appended to the Claude command line
+	ClaudeArgs []string `yaml:"claude_args,omitempty"`
+
+	// Env is set in the environment of the repository's sessions
+	Env map[string]string `yaml:"env,omitempty"`
+
+	// BaseBranch is the branch new branches start from and pull requests
+	// target; the repository's main branch when empty
+	BaseBranch string `yaml:"base_branch,omitempty"`
+
+	// WorktreeDir replaces worktree_dir for the repository
+	WorktreeDir string `yaml:"worktree_dir,omitempty"`
+
+	// Setup runs after the global setup steps
+	Setup WorktreeSetup `yaml:"setup,omitempty"`
+
+	// SessionName is a template for session names using {repo} and {branch}
+	SessionName string `yaml:"session_name,omitempty"`
+
+	// InitialPrompt is given to Claude when a new session starts
+	InitialPrompt string `yaml:"initial_prompt,omitempty"`
+}
+
+// UnmarshalYAML accepts a plain path as well as a mapping.
+func (r *Repository) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*r = Repository{Path: node.Value}
+		return nil
+	}
+	type plain Repository
+	return node.Decode((*plain)(r))
+}
+
+// MarshalYAML writes entries without overrides as plain paths.
+func (r Repository) MarshalYAML() (any, error) {
+	if r.isPlain() {
+		return r.Path, nil
+	}
+	type plain Repository
+	return plain(r), nil
+}
+
+// isPlain reports whether the entry has nothing but a path.
+func (r Repository) isPlain() bool {
+	return r.ClaudeCommand == "" && r.Model == "" && r.PermissionMode == "" &&
+		len(r.AddDirs) == 0 && len(r.ClaudeArgs) == 0 && len(r.Env) == 0 &&
+		r.BaseBranch == "" && r.WorktreeDir == "" && r.SessionName == "" &&
+		r.InitialPrompt == "" && len(r.Setup.Copy) == 0 &&
+		len(r.Setup.Symlink) == 0 && len(r.Setup.Run) == 0
+}
+
+// RepositoryFor returns the repositories entry for repoPath, or an entry
+// with just the path when the repository isn't configured.
+func (c *Config) RepositoryFor(repoPath string) (Repository, bool) {
+	repoAbs := resolvePath(repoPath)
+	c.repoMu.RLock()
+	defer c.repoMu.RUnlock()
+	for _, repo := range c.Repositories {
+		if resolvePath(repo.Path) == repoAbs {
+			return repo, true
+		}
+	}
+	return Repository{Path: repoPath}, false
+}
+
+// resolvePath returns the absolute form of a configured path, following
+// symlinks when it exists so it matches the paths git reports.
+func resolvePath(path string) string {
+	abs, _ := filepath.Abs(expandPath(path))
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved
+	}
+	return abs
+}
+
+// ClaudeCommandFor returns the command that starts Claude in a repository.
+func (c *Config) ClaudeCommandFor(repoPath string) string {
+	if repo, _ := c.RepositoryFor(repoPath); repo.ClaudeCommand != "" {
+		return repo.ClaudeCommand
+	}
+	return c.ClaudeCommand
+}
+
+// migrateRepoWorktrees moves the deprecated repo_worktrees settings into the
+// repositories entries they are keyed by, by path or else by name. Their
+// setup steps run before the entry's own, as they did, and the entry's
+// worktree_dir wins over their dir. Settings of untracked repositories are
+// dropped.
+func (c *Config) migrateRepoWorktrees() {
+	c.repoMu.Lock()
+	defer c.repoMu.Unlock()
+	for i := range c.Repositories {
+		repo := &c.Repositories[i]
+		old, ok := repoWorktree(c.RepoWorktrees, repo.Path)
+		if !ok {
+			continue
+		}
+		if repo.WorktreeDir == "" {
+			repo.WorktreeDir = old.Dir
+		}
+		repo.Setup = WorktreeSetup{
+			Copy:    append(slices.Clone(old.Setup.Copy), repo.Setup.Copy...),
+			Symlink: append(slices.Clone(old.Setup.Symlink), repo.Setup.Symlink...),
+			Run:     append(slices.Clone(old.Setup.Run), repo.Setup.Run...),
+		}
+	}
+	c.RepoWorktrees = nil
+}
+
+// repoWorktree finds the repo_worktrees entry for a repository, preferring
+// an entry keyed by path over one keyed by name.
+func repoWorktree(entries map[string]RepoWorktree, repoPath string) (RepoWorktree, bool) {
+	repoAbs, _ := filepath.Abs(expandPath(repoPath))
+	for key, repo := range entries {
+		if keyAbs, err := filepath.Abs(expandPath(key)); err == nil && strings.ContainsRune(key, filepath.Separator) && keyAbs == repoAbs {
+			return repo, true
+		}
+	}
+	repo, ok := entries[filepath.Base(repoAbs)]
+	return repo, ok
+}
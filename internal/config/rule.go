@@ -0,0 +1,44 @@
+package config
+
+// Rule posts a webhook or runs a command when a session event happens.
+// Repo, Session and Tool narrow the rule down with shell globs; empty ones
+// match anything.
+type Rule struct {
+	// Name identifies the rule in the delivery log
+	Name string `yaml:"name"`
+
+	// On lists the events the rule fires on: needs_input, stop,
+	// tool_failed, session_created and session_deleted
+	On []string `yaml:"on"`
+
+	// Repo matches the repository name
+	Repo string `yaml:"repo"`
+
+	// Session matches the tmux session name
+	Session string `yaml:"session"`
+
+	// Tool matches the tool asking permission or failing
+	Tool string `yaml:"tool"`
+
+	// MinRun skips stop events of turns shorter than this many seconds
+	MinRun int `yaml:"min_run"`
+
+	// URL receives the event as an HTTP POST
+	URL string `yaml:"url"`
+
+	// Headers are added to the POST, e.g. Authorization
+	Headers map[string]string `yaml:"headers"`
+
+	// Payload is the JSON body posted, which may use {event}, {session},
+	// {repo}, {tool}, {message}, {duration} and {dir}. Empty posts the
+	// event itself.
+	Payload string `yaml:"payload"`
+
+	// Command is run by the shell in the session's directory, with the
+	// event as JSON on stdin
+	Command string `yaml:"command"`
+
+	// Retries is how many times a failed delivery is tried again, with
+	// growing delays
+	Retries *int `yaml:"retries"`
+}
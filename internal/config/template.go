@@ -0,0 +1,42 @@
+package config
+
+import "sort"
+
+// SessionTemplate describes a kind of session, such as "fix failing CI",
+// started on its own branch with a prepared first prompt. Branch and Prompt
+// may use {repo}, {branch} and {issue}.
+type SessionTemplate struct {
+	// Description is shown when picking a template
+	Description string `yaml:"description"`
+
+	// Branch is the branch name pattern, e.g. "fix/ci-{issue}"; the branch
+	// is asked for when empty
+	Branch string `yaml:"branch"`
+
+	// Model is passed to Claude with --model, replacing the repository's
+	Model string `yaml:"model"`
+
+	// PermissionMode is passed to Claude with --permission-mode, replacing
+	// the repository's
+	PermissionMode string `yaml:"permission_mode"`
+
+	// ClaudeArgs are appended to the repository's Claude arguments
+	ClaudeArgs []string `yaml:"claude_args"`
+
+	// Prompt is submitted to Claude once the session is ready
+	Prompt string `yaml:"prompt"`
+
+	// Checklist items are appended to the prompt as a list for Claude to
+	// work through
+	Checklist []string `yaml:"checklist"`
+}
+
+// TemplateNames returns the names of the session templates, sorted.
+func (c *Config) TemplateNames() []string {
+	names := make([]string, 0, len(c.Templates))
+	for name := range c.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
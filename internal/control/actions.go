@@ -0,0 +1,90 @@
+package control
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+// The actions of the control API, which the cmux session commands take too
+// when no cmux serves them.
+
+// ErrNotFound is returned for sessions and repositories that don't exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrNoPrompt is returned answering a session that isn't waiting on a
+// permission prompt.
+var ErrNoPrompt = errors.New("not waiting on a permission prompt")
+
+// FindSession returns the Claude session with a name among sessions, as a
+// discovery pass lists them.
+func FindSession(sessions []*state.Session, name string) (*state.Session, error) {
+	for _, sess := range sessions {
+		if sess.Name == name {
+			return sess, nil
+		}
+	}
+	return nil, fmt.Errorf("no Claude session %q: %w", name, ErrNotFound)
+}
+
+// FindRepository returns the path of the configured repository named repo,
+// or at that path.
+func FindRepository(repos []discovery.RepositoryInfo, repo string) (string, error) {
+	for _, r := range repos {
+		if r.Name == repo || r.Path == repo {
+			return r.Path, nil
+		}
+	}
+	return "", fmt.Errorf("no configured repository %q: %w", repo, ErrNotFound)
+}
+
+// CreateSession creates a session on a branch of a repository, and the
+// branch if the repository has none by that name. A session whose worktree
+// setup failed is still created, with the failure as its warning.
+func CreateSession(m *session.Manager, repoPath, branch string) (api.Created, error) {
+	name, err := m.CreateBranchSession(repoPath, branch)
+	if name == "" {
+		return api.Created{}, fmt.Errorf("creating session: %w", err)
+	}
+	created := api.Created{Name: name}
+	var setupErr *session.SetupError
+	if errors.As(err, &setupErr) {
+		created.Warning = setupErr.Error()
+	}
+	return created, nil
+}
+
+// DeleteSession kills a session, and removes its worktree if worktree is
+// set.
+func DeleteSession(m *session.Manager, name string, worktree bool) error {
+	if err := m.DeleteSession(name, worktree); err != nil {
+		return fmt.Errorf("deleting session: %w", err)
+	}
+	return nil
+}
+
+// Prompt sends text to Claude at target, the pane Target finds, with
+// submit.
+func Prompt(target, text string, submit func(target, text string) error) error {
+	if err := submit(target, text); err != nil {
+		return fmt.Errorf("sending prompt: %w", err)
+	}
+	return nil
+}
+
+// Answer allows or denies the permission prompt of a session, as Describe
+// shows it, by sending answer to target, the pane Target finds. It fails
+// with ErrNoPrompt unless the session is waiting on one.
+func Answer(sess api.Session, target string, allow bool, answer func(target string, allow bool) error) error {
+	if sess.Status != api.StatusNeedsInput {
+		return fmt.Errorf("%s is %s, %w", sess.Name, sess.Status, ErrNoPrompt)
+	}
+	if err := answer(target, allow); err != nil {
+		return fmt.Errorf("answering permission prompt: %w", err)
+	}
+	return nil
+}
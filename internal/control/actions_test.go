@@ -0,0 +1,47 @@
+package control
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+)
+
+func TestFind(t *testing.T) {
+	repos := []discovery.RepositoryInfo{{Name: "api", Path: "/src/api"}, {Name: "web", Path: "/src/web"}}
+	if path, err := FindRepository(repos, "web"); err != nil || path != "/src/web" {
+		t.Errorf("FindRepository(web) = %q, %v", path, err)
+	}
+	if path, err := FindRepository(repos, "/src/api"); err != nil || path != "/src/api" {
+		t.Errorf("FindRepository(/src/api) = %q, %v", path, err)
+	}
+	if _, err := FindRepository(repos, "cli"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindRepository(cli) error = %v, want ErrNotFound", err)
+	}
+
+	all := snapshot("dev", "web").All
+	if sess, err := FindSession(all, "web"); err != nil || sess != all[1] {
+		t.Errorf("FindSession(web) = %v, %v", sess, err)
+	}
+	if _, err := FindSession(all, "gone"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("FindSession(gone) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	var answered []string
+	answer := func(target string, allow bool) error {
+		answered = append(answered, target)
+		return nil
+	}
+
+	idle := api.Session{Name: "dev", Status: api.StatusIdle}
+	if err := Answer(idle, "dev:0.1", true, answer); !errors.Is(err, ErrNoPrompt) || len(answered) != 0 {
+		t.Errorf("Answer() of an idle session = %v, answered %v", err, answered)
+	}
+	waiting := api.Session{Name: "dev", Status: api.StatusNeedsInput}
+	if err := Answer(waiting, "dev:0.1", true, answer); err != nil || len(answered) != 1 || answered[0] != "dev:0.1" {
+		t.Errorf("Answer() of a waiting session = %v, answered %v", err, answered)
+	}
+}
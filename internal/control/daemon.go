@@ -0,0 +1,280 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/rules"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/version"
+)
+
+// A daemon's server also serves the TUIs attached to it, through routes
+// that aren't part of the public API:
+//
+//	GET  /internal/hooks       every session's current turn of hook events, then each new one
+//	GET  /internal/snapshots   the latest discovery snapshot, then each new one
+//	GET  /internal/snapshot    the latest discovery snapshot
+//	POST /internal/refresh     ask for a discovery pass now
+//	GET  /internal/deliveries  the rules' delivery log
+//	POST /internal/focus       the session on a TUI's screen
+//
+// A TUI names itself with ?client= when it follows the hooks, and its
+// focus is kept while it does.
+
+// maxTurnEvents bounds the hook events kept of one turn, which a runaway
+// agent could otherwise grow without limit.
+const maxTurnEvents = 1000
+
+// hookBuffer is how many hook events an attached TUI may fall behind by.
+// Past that it is disconnected, and reconnects to a consistent replay
+// rather than missing events.
+const hookBuffer = 256
+
+// hookMessage is a hook event as the hooks stream sends it.
+type hookMessage struct {
+	Session string           `json:"session"`
+	Event   claude.HookEvent `json:"event"`
+}
+
+// focusMessage is what an attached TUI shows: the session on its screen,
+// inside Host, the tmux session the TUI runs in.
+type focusMessage struct {
+	Client  string `json:"client"`
+	Host    string `json:"host"`
+	Session string `json:"session"`
+}
+
+// snapshotMessage is a discovery snapshot as it is sent to TUIs.
+type snapshotMessage struct {
+	Repositories []discovery.RepositoryInfo `json:"repositories"`
+	Sessions     []*state.Session           `json:"sessions"`
+	All          []*state.Session           `json:"all"`
+	Claude       []string                   `json:"claude"`
+	Live         []string                   `json:"live"`
+	Err          string                     `json:"error,omitempty"`
+	Time         time.Time                  `json:"time"`
+}
+
+func encodeSnapshot(snap discovery.Snapshot) snapshotMessage {
+	msg := snapshotMessage{
+		Repositories: snap.Repositories,
+		Sessions:     snap.Sessions,
+		All:          snap.All,
+		Claude:       snap.Claude,
+		Live:         snap.Live,
+		Time:         snap.Time,
+	}
+	if snap.Err != nil {
+		msg.Err = snap.Err.Error()
+	}
+	return msg
+}
+
+func (msg snapshotMessage) decode() discovery.Snapshot {
+	snap := discovery.Snapshot{
+		Repositories: msg.Repositories,
+		Sessions:     msg.Sessions,
+		All:          msg.All,
+		Claude:       msg.Claude,
+		Live:         msg.Live,
+		Time:         msg.Time,
+	}
+	if msg.Err != "" {
+		snap.Err = errors.New(msg.Err)
+	}
+	return snap
+}
+
+// Daemon marks the server as a cmux daemon's, which TUIs attach to rather
+// than watching hooks and discovering themselves, and which runs
+// ruleEngine's rules on its discovery passes.
+func (s *Server) Daemon(ruleEngine *rules.Engine) {
+	s.daemon = true
+	s.rules = ruleEngine
+}
+
+// relay keeps a hook event with its session's current turn, which is what
+// the session's status is built from, and hands it to the attached TUIs.
+// The caller holds s.mu.
+func (s *Server) relay(tmuxSession string, event claude.HookEvent) {
+	turn := s.turns[tmuxSession]
+	if event.EventName == "UserPromptSubmit" {
+		turn = nil
+	}
+	if len(turn) >= maxTurnEvents {
+		turn = turn[1:]
+	}
+	s.turns[tmuxSession] = append(turn, event)
+
+	msg := hookMessage{Session: tmuxSession, Event: event}
+	for ch := range s.hookSubs {
+		select {
+		case ch <- msg:
+		default:
+			delete(s.hookSubs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.Info{Version: version.Short(), Daemon: s.daemon, PID: os.Getpid()})
+}
+
+func (s *Server) handleHooks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var replay []hookMessage
+	for session, turn := range s.turns {
+		for _, event := range turn {
+			replay = append(replay, hookMessage{Session: session, Event: event})
+		}
+	}
+	ch := make(chan hookMessage, hookBuffer)
+	s.hookSubs[ch] = true
+	client := r.URL.Query().Get("client")
+	focus := &focusMessage{Client: client}
+	if client != "" {
+		s.focus[client] = focus
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.hookSubs, ch)
+		if s.focus[client] == focus {
+			delete(s.focus, client)
+		}
+		s.mu.Unlock()
+	}()
+
+	stream(w, r, s.done, replay, ch)
+}
+
+func (s *Server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	var replay []snapshotMessage
+	if s.known {
+		replay = append(replay, encodeSnapshot(s.snapshot))
+	}
+	ch := make(chan discovery.Snapshot, 1)
+	s.snapSubs[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.snapSubs, ch)
+		s.mu.Unlock()
+	}()
+
+	// A TUI only wants the latest snapshot, so the channel holds one
+	messages := make(chan snapshotMessage)
+	go func() {
+		for {
+			select {
+			case snap := <-ch:
+				select {
+				case messages <- encodeSnapshot(snap):
+				case <-r.Context().Done():
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}()
+	stream(w, r, s.done, replay, messages)
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	snap, ok := s.current(w)
+	if ok {
+		writeJSON(w, http.StatusOK, encodeSnapshot(snap))
+	}
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	s.discovery.Refresh()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleFocus(w http.ResponseWriter, r *http.Request) {
+	var msg focusMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		writeError(w, http.StatusBadRequest, "decoding request: %v", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	focus, ok := s.focus[msg.Client]
+	if !ok {
+		writeError(w, http.StatusNotFound, "client %q isn't following hooks", msg.Client)
+		return
+	}
+	*focus = msg
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShownIn returns the tmux sessions of the attached TUIs that have session
+// on screen, for notify.Notifier.ShowOn.
+func (s *Server) ShownIn(session string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var hosts []string
+	for _, focus := range s.focus {
+		if focus.Session == session && focus.Host != "" {
+			hosts = append(hosts, focus.Host)
+		}
+	}
+	return hosts
+}
+
+func (s *Server) handleDeliveries(w http.ResponseWriter, r *http.Request) {
+	var log []rules.Delivery
+	if s.rules != nil {
+		log = s.rules.Log()
+	}
+	writeJSON(w, http.StatusOK, log)
+}
+
+// stream writes replay and then what arrives on ch as JSON lines, until the
+// client goes away, the server closes or ch is closed.
+func stream[T any](w http.ResponseWriter, r *http.Request, done <-chan struct{}, replay []T, ch <-chan T) {
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	for _, v := range replay {
+		if err := enc.Encode(v); err != nil {
+			return
+		}
+	}
+	flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-done:
+			return
+		case v, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := enc.Encode(v); err != nil {
+				return
+			}
+			flush()
+		}
+	}
+}
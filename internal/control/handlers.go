@@ -0,0 +1,263 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+// Timeouts waiting on discovery. Requests wait for the first pass, and
+// creating or deleting a session waits for discovery to see it done so the
+// next request does too.
+const (
+	firstSnapshotTimeout = 10 * time.Second
+	changeTimeout        = 5 * time.Second
+)
+
+// Handler returns the API's routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/sessions", s.handleSessions)
+	mux.HandleFunc("POST /v1/sessions", s.handleCreate)
+	mux.HandleFunc("GET /v1/session", s.handleSession)
+	mux.HandleFunc("DELETE /v1/session", s.handleDelete)
+	mux.HandleFunc("GET /v1/messages", s.handleMessages)
+	mux.HandleFunc("POST /v1/prompt", s.handlePrompt)
+	mux.HandleFunc("POST /v1/permission", s.handlePermission)
+	mux.HandleFunc("GET /v1/events", s.handleEvents)
+	mux.HandleFunc("GET /v1/info", s.handleInfo)
+
+	mux.HandleFunc("GET /internal/hooks", s.handleHooks)
+	mux.HandleFunc("GET /internal/snapshots", s.handleSnapshots)
+	mux.HandleFunc("GET /internal/snapshot", s.handleSnapshot)
+	mux.HandleFunc("POST /internal/refresh", s.handleRefresh)
+	mux.HandleFunc("GET /internal/deliveries", s.handleDeliveries)
+	mux.HandleFunc("POST /internal/focus", s.handleFocus)
+	return mux
+}
+
+// writeJSON replies with v as JSON.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError replies with an api.ErrorResponse.
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, api.ErrorResponse{Error: fmt.Sprintf(format, args...)})
+}
+
+// current returns the latest snapshot, waiting for the first. It replies
+// with an error and returns false if there is none.
+func (s *Server) current(w http.ResponseWriter) (discovery.Snapshot, bool) {
+	snap, ok := s.awaitSnapshot(func(discovery.Snapshot) bool { return true }, firstSnapshotTimeout)
+	if ok {
+		return snap, true
+	}
+	s.mu.Lock()
+	failed := s.failed
+	s.mu.Unlock()
+	if failed != nil {
+		writeError(w, http.StatusServiceUnavailable, "discovering sessions: %v", failed)
+	} else {
+		writeError(w, http.StatusServiceUnavailable, "sessions have not been discovered yet")
+	}
+	return snap, false
+}
+
+// lookup returns a Claude session of the latest snapshot, replying 404 and
+// returning nil if there is no such session.
+func (s *Server) lookup(w http.ResponseWriter, name string) *state.Session {
+	snap, ok := s.current(w)
+	if !ok {
+		return nil
+	}
+	sess, err := FindSession(snap.All, name)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "%v", err)
+	}
+	return sess
+}
+
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
+	snap, ok := s.current(w)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	sessions := make([]api.Session, 0, len(snap.All))
+	for _, sess := range snap.All {
+		sessions = append(sessions, s.describe(sess))
+	}
+	s.mu.Unlock()
+	slices.SortFunc(sessions, func(a, b api.Session) int { return strings.Compare(a.Name, b.Name) })
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request) {
+	sess := s.lookup(w, r.URL.Query().Get("name"))
+	if sess == nil {
+		return
+	}
+	s.mu.Lock()
+	out := s.describe(sess)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (s *Server) handleMessages(w http.ResponseWriter, r *http.Request) {
+	sess := s.lookup(w, r.URL.Query().Get("session"))
+	if sess == nil {
+		return
+	}
+
+	s.mu.Lock()
+	view := s.view(sess.Name)
+	err := view.PollTranscript()
+	messages := view.Messages()
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "reading transcript: %v", err)
+		return
+	}
+
+	out := make([]api.Message, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, message(m))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// message returns a conversation turn as the API shows it.
+func message(m claude.Message) api.Message {
+	out := api.Message{Role: m.Role, Text: m.Content, Time: m.Timestamp, Complete: m.IsComplete}
+	if m.Role == "assistant" {
+		out.Text = m.TextPreview
+	}
+	for _, tc := range m.ToolCalls {
+		out.Tools = append(out.Tools, api.ToolCall{
+			Name:    tc.Name,
+			Summary: tc.InputSummary,
+			Status:  string(tc.Status),
+			Error:   tc.Error,
+		})
+	}
+	return out
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req api.CreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "decoding request: %v", err)
+		return
+	}
+	if req.Repo == "" || req.Branch == "" {
+		writeError(w, http.StatusBadRequest, "repo and branch are required")
+		return
+	}
+
+	snap, ok := s.current(w)
+	if !ok {
+		return
+	}
+	repoPath, err := FindRepository(snap.Repositories, req.Repo)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "%v", err)
+		return
+	}
+	created, err := CreateSession(s.manager, repoPath, req.Branch)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+
+	s.discovery.Refresh()
+	s.awaitSnapshot(func(snap discovery.Snapshot) bool { return slices.Contains(snap.Live, created.Name) }, changeTimeout)
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	// Only Claude sessions are cmux's to kill
+	sess := s.lookup(w, r.URL.Query().Get("name"))
+	if sess == nil {
+		return
+	}
+	name := sess.Name
+
+	if err := DeleteSession(s.manager, name, r.URL.Query().Get("worktree") == "1"); err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+
+	s.discovery.Refresh()
+	s.awaitSnapshot(func(snap discovery.Snapshot) bool { return !slices.Contains(snap.Live, name) }, changeTimeout)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePrompt(w http.ResponseWriter, r *http.Request) {
+	var req api.PromptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "decoding request: %v", err)
+		return
+	}
+	if req.Text == "" {
+		writeError(w, http.StatusBadRequest, "text is required")
+		return
+	}
+	sess := s.lookup(w, req.Session)
+	if sess == nil {
+		return
+	}
+
+	s.mu.Lock()
+	target := s.target(sess)
+	s.mu.Unlock()
+	if err := Prompt(target, req.Text, s.submit); err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePermission(w http.ResponseWriter, r *http.Request) {
+	var req api.PermissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "decoding request: %v", err)
+		return
+	}
+	sess := s.lookup(w, req.Session)
+	if sess == nil {
+		return
+	}
+
+	s.mu.Lock()
+	out := s.describe(sess)
+	target := s.target(sess)
+	s.mu.Unlock()
+	err := Answer(out, target, req.Allow, s.answer)
+	if errors.Is(err, ErrNoPrompt) {
+		writeError(w, http.StatusConflict, "%v", err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe := s.subscribe(r.URL.Query().Get("session"))
+	defer unsubscribe()
+	stream(w, r, s.done, nil, events)
+}
@@ -0,0 +1,313 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/rules"
+)
+
+// reconnectDelay is how long an attached TUI waits before following a
+// stream again after losing the daemon.
+const reconnectDelay = 2 * time.Second
+
+// Remote is a TUI's connection to a cmux daemon.
+type Remote struct {
+	http *http.Client
+	id   string // names the TUI to the daemon
+
+	// What the TUI shows, for the daemon's notifications
+	mu      sync.Mutex
+	focus   focusMessage
+	changed chan struct{}
+}
+
+// Attach connects to the cmux daemon serving socket. It returns nil if
+// nothing serves it, or only a TUI does.
+func Attach(socket string) *Remote {
+	r := &Remote{
+		http: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}},
+		id:      strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		changed: make(chan struct{}, 1),
+	}
+	r.focus.Client = r.id
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	info, err := api.NewClient(socket).Info(ctx)
+	if err != nil || !info.Daemon {
+		return nil
+	}
+	go r.sendFocus()
+	return r
+}
+
+// ShowIn tells the daemon the TUI runs inside the host tmux session, so
+// what it shows counts as focused while host is.
+func (r *Remote) ShowIn(host string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.focus.Host != host {
+		r.focus.Host = host
+		r.focusChanged()
+	}
+}
+
+// Show tells the daemon which session is on the TUI's screen, so it isn't
+// notified about. It never blocks, and only sends changes.
+func (r *Remote) Show(session string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.focus.Session != session {
+		r.focus.Session = session
+		r.focusChanged()
+	}
+}
+
+// focusChanged has the focus sent again.
+func (r *Remote) focusChanged() {
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// sendFocus posts the focus to the daemon whenever it changes, one post
+// at a time so the latest arrives last.
+func (r *Remote) sendFocus() {
+	for range r.changed {
+		r.mu.Lock()
+		body, _ := json.Marshal(r.focus)
+		r.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://cmux/internal/focus", bytes.NewReader(body))
+		if err == nil {
+			if resp, err := r.http.Do(req); err == nil {
+				resp.Body.Close()
+			}
+		}
+		cancel()
+	}
+}
+
+// get fetches a path and decodes its JSON reply into out.
+func (r *Remote) get(ctx context.Context, path string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://cmux"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("cmux daemon: GET %s: %s", path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// follow decodes each JSON line of a stream with handle, reconnecting
+// whenever the stream breaks, until ctx is cancelled. connected, if not
+// nil, is called each time the stream opens.
+func (r *Remote) follow(ctx context.Context, path string, connected func(), handle func(*json.Decoder) error) {
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://cmux"+path, nil)
+		if err != nil {
+			return
+		}
+		if resp, err := r.http.Do(req); err == nil {
+			if connected != nil && resp.StatusCode == http.StatusOK {
+				connected()
+			}
+			dec := json.NewDecoder(resp.Body)
+			for handle(dec) == nil {
+			}
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+// Deliveries returns the daemon's rule delivery log, newest first.
+func (r *Remote) Deliveries() ([]rules.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var log []rules.Delivery
+	err := r.get(ctx, "/internal/deliveries", &log)
+	return log, err
+}
+
+// Hooks returns the daemon's hook events, as a claude.EventWatcher would
+// deliver them.
+func (r *Remote) Hooks() *RemoteHooks {
+	return &RemoteHooks{remote: r}
+}
+
+// RemoteHooks follows the hook events a daemon watches. On connecting it
+// replays each session's current turn, so views built from it start where
+// the daemon's are.
+type RemoteHooks struct {
+	remote    *Remote
+	mu        sync.Mutex
+	callbacks []func(tmuxSession string, event claude.HookEvent)
+	cancel    context.CancelFunc
+}
+
+// OnEvent registers a callback for every hook event.
+func (h *RemoteHooks) OnEvent(cb func(tmuxSession string, event claude.HookEvent)) {
+	h.mu.Lock()
+	h.callbacks = append(h.callbacks, cb)
+	h.mu.Unlock()
+}
+
+// Start follows the daemon's hook events in the background.
+func (h *RemoteHooks) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	h.mu.Lock()
+	h.cancel = cancel
+	h.mu.Unlock()
+
+	// The daemon keeps the TUI's focus while it follows, so it is sent
+	// again on reconnecting
+	r := h.remote
+	go r.follow(ctx, "/internal/hooks?client="+r.id, r.focusChanged, func(dec *json.Decoder) error {
+		var msg hookMessage
+		if err := dec.Decode(&msg); err != nil {
+			return err
+		}
+		msg.Event.ParseTimestamp()
+
+		h.mu.Lock()
+		callbacks := h.callbacks
+		h.mu.Unlock()
+		for _, cb := range callbacks {
+			cb(msg.Session, msg.Event)
+		}
+		return nil
+	})
+	return nil
+}
+
+// Stop stops following hook events.
+func (h *RemoteHooks) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+}
+
+// Discovery returns the daemon's discovery passes, as a discovery.Service
+// would publish them.
+func (r *Remote) Discovery() *RemoteDiscovery {
+	return &RemoteDiscovery{remote: r}
+}
+
+// RemoteDiscovery follows the snapshots of a daemon's discovery.
+type RemoteDiscovery struct {
+	remote *Remote
+	mu     sync.Mutex
+	subs   []chan discovery.Snapshot
+	cancel context.CancelFunc
+}
+
+// Discover returns the daemon's latest snapshot.
+func (d *RemoteDiscovery) Discover() discovery.Snapshot {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var msg snapshotMessage
+	if err := d.remote.get(ctx, "/internal/snapshot", &msg); err != nil {
+		return discovery.Snapshot{Err: err, Time: time.Now()}
+	}
+	return msg.decode()
+}
+
+// Subscribe returns a channel receiving each snapshot the daemon takes. A
+// subscriber that falls behind only gets the latest one.
+func (d *RemoteDiscovery) Subscribe() <-chan discovery.Snapshot {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ch := make(chan discovery.Snapshot, 1)
+	d.subs = append(d.subs, ch)
+	return ch
+}
+
+// Start follows the daemon's snapshots in the background. The daemon
+// discovers at its own interval.
+func (d *RemoteDiscovery) Start(time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+
+	go d.remote.follow(ctx, "/internal/snapshots", nil, func(dec *json.Decoder) error {
+		var msg snapshotMessage
+		if err := dec.Decode(&msg); err != nil {
+			return err
+		}
+		snap := msg.decode()
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		for _, ch := range d.subs {
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- snap
+		}
+		return nil
+	})
+}
+
+// Refresh asks the daemon for a discovery pass now. It never blocks.
+func (d *RemoteDiscovery) Refresh() {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://cmux/internal/refresh", nil)
+		if err != nil {
+			return
+		}
+		if resp, err := d.remote.http.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+}
+
+// Stop stops following snapshots. Subscribers' channels stay open.
+func (d *RemoteDiscovery) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+}
@@ -0,0 +1,135 @@
+package control
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+)
+
+// receive waits for the next value on ch.
+func receive[T any](t *testing.T, ch <-chan T) T {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+		var zero T
+		return zero
+	}
+}
+
+// waitFor polls cond until it holds or five seconds pass.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	s, socket, _ := serveTemp(t)
+	if Attach(socket) != nil {
+		t.Error("attached to a TUI's server")
+	}
+	s.Daemon(nil)
+	if Attach(socket) == nil {
+		t.Error("could not attach to a daemon")
+	}
+}
+
+func TestRemoteHooks(t *testing.T) {
+	s, socket, _ := serveTemp(t)
+	s.Daemon(nil)
+
+	// Only the current turn is replayed
+	now := time.Now()
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "UserPromptSubmit", Timestamp: now})
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "Stop", Timestamp: now})
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "UserPromptSubmit", Timestamp: now})
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "PermissionRequest", TS: now.Format(time.RFC3339), Timestamp: now})
+
+	hooks := Attach(socket).Hooks()
+	received := make(chan hookMessage, 10)
+	hooks.OnEvent(func(session string, event claude.HookEvent) {
+		received <- hookMessage{session, event}
+	})
+	if err := hooks.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer hooks.Stop()
+
+	if got := receive(t, received); got.Session != "dev" || got.Event.EventName != "UserPromptSubmit" {
+		t.Errorf("first replayed %s %s, want dev's prompt", got.Session, got.Event.EventName)
+	}
+	got := receive(t, received)
+	if got.Event.EventName != "PermissionRequest" || !got.Event.Timestamp.Equal(now.Truncate(time.Second)) {
+		t.Errorf("second replayed %s at %v, want the permission request at %v", got.Event.EventName, got.Event.Timestamp, now)
+	}
+
+	s.OnHookEvent("web", claude.HookEvent{EventName: "PreToolUse"})
+	if got := receive(t, received); got.Session != "web" || got.Event.EventName != "PreToolUse" {
+		t.Errorf("live event %s %s", got.Session, got.Event.EventName)
+	}
+}
+
+func TestRemoteFocus(t *testing.T) {
+	s, socket, _ := serveTemp(t)
+	s.Daemon(nil)
+
+	remote := Attach(socket)
+	remote.ShowIn("cmux")
+	remote.Show("dev")
+	hooks := remote.Hooks()
+	if err := hooks.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Focus sent before the hooks stream opened is sent again once it has
+	waitFor(t, func() bool { return slices.Equal(s.ShownIn("dev"), []string{"cmux"}) })
+	remote.Show("web")
+	waitFor(t, func() bool { return len(s.ShownIn("dev")) == 0 && len(s.ShownIn("web")) == 1 })
+
+	// A TUI that stops following is shown nothing
+	hooks.Stop()
+	waitFor(t, func() bool { return len(s.ShownIn("web")) == 0 })
+}
+
+func TestRemoteDiscovery(t *testing.T) {
+	s, socket, _ := serveTemp(t)
+	s.Daemon(nil)
+	s.apply(snapshot("dev"))
+
+	remote := Attach(socket).Discovery()
+	if snap := remote.Discover(); snap.Err != nil || len(snap.All) != 1 || snap.All[0].ClaudePane != "dev:0.1" {
+		t.Errorf("Discover() = %+v", snap)
+	}
+
+	snapshots := remote.Subscribe()
+	remote.Start(0)
+	defer remote.Stop()
+	if snap := receive(t, snapshots); len(snap.Live) != 1 || snap.Live[0] != "dev" {
+		t.Errorf("first snapshot = %+v, want the latest", snap)
+	}
+
+	s.apply(snapshot("dev", "web"))
+	if snap := receive(t, snapshots); len(snap.Live) != 2 {
+		t.Errorf("next snapshot = %+v", snap)
+	}
+
+	// Refreshing asks the daemon's discovery, which isn't running here
+	remote.Refresh()
+
+	s.apply(discovery.Snapshot{Err: errors.New("tmux: not found")})
+	if snap := remote.Discover(); snap.Err != nil {
+		t.Errorf("Discover() after a failed pass = %v, want the last good one", snap.Err)
+	}
+}
@@ -0,0 +1,361 @@
+// Package control serves the control API described in package api: it
+// lists and drives the Claude sessions cmux discovers, over HTTP on a Unix
+// socket. It runs inside a TUI, or in "cmux daemon", whose server TUIs
+// also attach to through a Remote.
+package control
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/rules"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// ErrInUse is returned by Start when another cmux serves the socket.
+var ErrInUse = errors.New("another cmux is serving the control API")
+
+// streamBuffer is how many events a subscriber may fall behind by before
+// further events are dropped for it.
+const streamBuffer = 64
+
+// Server serves the control API.
+type Server struct {
+	cfg       *config.Config
+	discovery *discovery.Service
+	manager   *session.Manager
+	http      *http.Server
+	started   time.Time
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// Replaced in tests, which have no tmux
+	submit func(target, text string) error
+	answer func(target string, allow bool) error
+
+	// Set for a daemon's server, which TUIs attach to
+	daemon bool
+	rules  *rules.Engine
+
+	mu       sync.Mutex
+	snapshot discovery.Snapshot
+	known    bool          // a snapshot has been taken
+	failed   error         // why discovery failed, until a snapshot is taken
+	seen     chan struct{} // closed when the next snapshot is taken
+	views    map[string]*claude.View
+	subs     map[chan api.Event]string // subscriber to the session it follows, or ""
+	turns    map[string][]claude.HookEvent
+	hookSubs map[chan hookMessage]bool
+	snapSubs map[chan discovery.Snapshot]bool
+	focus    map[string]*focusMessage // by attached TUI, while it follows hooks
+
+	// The status file, and the sessions last written to it
+	statusPath string
+	lastStatus []api.Session
+}
+
+// NewServer creates a server for the sessions discovery finds. Hook events
+// reach it through OnHookEvent.
+func NewServer(cfg *config.Config, tmuxClient tmux.Client, discoverySvc *discovery.Service, manager *session.Manager) *Server {
+	s := &Server{
+		cfg:       cfg,
+		discovery: discoverySvc,
+		manager:   manager,
+		started:   time.Now(),
+		done:      make(chan struct{}),
+		submit:    tmuxClient.SubmitText,
+		answer:    claude.SendPermissionResponse,
+		seen:      make(chan struct{}),
+		views:     make(map[string]*claude.View),
+		subs:      make(map[chan api.Event]string),
+		turns:     make(map[string][]claude.HookEvent),
+		hookSubs:  make(map[chan hookMessage]bool),
+		snapSubs:  make(map[chan discovery.Snapshot]bool),
+		focus:     make(map[string]*focusMessage),
+	}
+	s.http = &http.Server{Handler: s.Handler()}
+	return s
+}
+
+// Start serves the API on a Unix socket in the background, and starts
+// discovery if nothing has yet. It fails with ErrInUse if another cmux
+// answers on the socket.
+func (s *Server) Start(socket string) error {
+	l, err := listen(socket)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.statusPath = StatusPath(socket)
+	s.mu.Unlock()
+
+	snapshots := s.discovery.Subscribe()
+	s.discovery.Start(time.Duration(s.cfg.RefreshInterval) * time.Second)
+	go s.watch(snapshots)
+	go s.http.Serve(l)
+	return nil
+}
+
+// Close stops serving, ends every event stream and removes the status
+// file.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.http.Close()
+
+		s.mu.Lock()
+		if s.statusPath != "" {
+			os.Remove(s.statusPath)
+			s.statusPath = ""
+		}
+		s.mu.Unlock()
+	})
+}
+
+// listen creates the socket, readable only by the user, replacing one left
+// by a cmux that died.
+func listen(socket string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(socket), 0o700); err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+	if conn, err := net.DialTimeout("unix", socket, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%w on %s", ErrInUse, socket)
+	}
+	os.Remove(socket)
+
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0o600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("securing %s: %w", socket, err)
+	}
+	return l, nil
+}
+
+// watch applies each discovery snapshot until the server closes.
+func (s *Server) watch(snapshots <-chan discovery.Snapshot) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case snap := <-snapshots:
+			s.apply(snap)
+		}
+	}
+}
+
+// apply records a snapshot, sending created and deleted events for the
+// tmux sessions that came and went since the one before.
+func (s *Server) apply(snap discovery.Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snap.Err != nil {
+		// Keep the last good view of a tmux hiccup, but don't leave
+		// requests waiting on a first snapshot that isn't coming
+		if !s.known {
+			s.failed = snap.Err
+			close(s.seen)
+			s.seen = make(chan struct{})
+		}
+		return
+	}
+
+	if s.known {
+		for _, name := range snap.Live {
+			if !slices.Contains(s.snapshot.Live, name) {
+				s.broadcast(api.Event{Type: api.EventCreated, Session: name, Time: snap.Time})
+			}
+		}
+		for _, name := range s.snapshot.Live {
+			if !slices.Contains(snap.Live, name) {
+				delete(s.views, name)
+				delete(s.turns, name)
+				s.broadcast(api.Event{Type: api.EventDeleted, Session: name, Time: snap.Time})
+			}
+		}
+	}
+
+	s.snapshot = snap
+	s.known = true
+	close(s.seen)
+	s.seen = make(chan struct{})
+
+	if s.rules != nil {
+		s.rules.Discovered(snap)
+	}
+	for ch := range s.snapSubs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snap
+	}
+	s.writeStatus()
+}
+
+// awaitSnapshot waits up to timeout for a snapshot satisfying cond, and
+// returns the latest one either way, and whether it satisfied cond. It
+// gives up early if discovery fails before the first snapshot.
+func (s *Server) awaitSnapshot(cond func(discovery.Snapshot) bool, timeout time.Duration) (discovery.Snapshot, bool) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		snap, known, failed, seen := s.snapshot, s.known, s.failed, s.seen
+		s.mu.Unlock()
+		if known && cond(snap) {
+			return snap, true
+		}
+		if !known && failed != nil {
+			return snap, false
+		}
+
+		select {
+		case <-seen:
+		case <-deadline:
+			return snap, false
+		case <-s.done:
+			return snap, false
+		}
+	}
+}
+
+// OnHookEvent updates a session's status from a hook event, and sends a
+// status event unless the event was written before the server started.
+func (s *Server) OnHookEvent(tmuxSession string, event claude.HookEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	view := s.view(tmuxSession)
+	view.UpdateFromHookEvent(event)
+	s.relay(tmuxSession, event)
+	s.writeStatus()
+
+	// date -Iseconds timestamps have whole seconds
+	if !event.Timestamp.IsZero() && event.Timestamp.Before(s.started.Truncate(time.Second)) {
+		return
+	}
+	at := event.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	s.broadcast(api.Event{
+		Type:    api.EventStatus,
+		Session: tmuxSession,
+		Hook:    event.EventName,
+		Status:  string(view.Session().Status),
+		Tool:    event.ToolName,
+		Time:    at,
+	})
+}
+
+// view returns a session's view, creating it with the conversation so far.
+// The caller holds s.mu, which guards every view's session.
+func (s *Server) view(name string) *claude.View {
+	view, ok := s.views[name]
+	if !ok {
+		view = claude.NewView(name, 80, 24)
+		view.InitTranscript(claude.GetLatestTranscriptPath(name))
+		s.views[name] = view
+	}
+	return view
+}
+
+// subscribe returns a channel receiving events of a session, or of every
+// session if it is empty, and a function ending the subscription.
+func (s *Server) subscribe(session string) (<-chan api.Event, func()) {
+	ch := make(chan api.Event, streamBuffer)
+	s.mu.Lock()
+	s.subs[ch] = session
+	s.mu.Unlock()
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}
+}
+
+// broadcast hands an event to its subscribers, dropping it for those that
+// have fallen behind. The caller holds s.mu.
+func (s *Server) broadcast(ev api.Event) {
+	for ch, session := range s.subs {
+		if session != "" && session != ev.Session {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// describe returns a discovered session as the API shows it. The caller
+// holds s.mu.
+func (s *Server) describe(sess *state.Session) api.Session {
+	return Describe(sess, s.views[sess.Name])
+}
+
+// Describe returns a discovered session as the API shows it, with its
+// status from view, which may be nil for a session no hook has reported.
+func Describe(sess *state.Session, view *claude.View) api.Session {
+	out := api.Session{
+		Name:     sess.Name,
+		Repo:     sess.RepoName,
+		Branch:   sess.Branch,
+		Dir:      sess.Worktree,
+		Attached: sess.Attached,
+		Status:   api.StatusIdle,
+	}
+	if view == nil {
+		return out
+	}
+
+	cs := view.Session()
+	out.Status = string(cs.Status)
+	out.ClaudeID = cs.ID
+	out.Updated = cs.LastUpdate
+	if cs.CurrentTool != nil {
+		out.Tool = cs.CurrentTool.Name
+	}
+	if p := cs.PendingPermission; p != nil && cs.Status == claude.StatusNeedsInput {
+		out.Permission = &api.Permission{Tool: p.ToolName, Input: p.ToolInput, Message: p.Message}
+	}
+	return out
+}
+
+// target returns the tmux target running Claude in a session. The caller
+// holds s.mu.
+func (s *Server) target(sess *state.Session) string {
+	return Target(sess, s.views[sess.Name])
+}
+
+// Target returns the tmux target running Claude in a session: the pane
+// reported by hooks, then the one discovery found, then the session. view
+// may be nil.
+func Target(sess *state.Session, view *claude.View) string {
+	if view != nil {
+		if target := view.Target(); target != sess.Name {
+			return target
+		}
+	}
+	if sess.ClaudePane != "" {
+		return sess.ClaudePane
+	}
+	return sess.Name
+}
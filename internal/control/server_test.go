@@ -0,0 +1,258 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/git/gittest"
+	"github.com/abdullathedruid/cmux/internal/state"
+	"github.com/abdullathedruid/cmux/internal/tmux/tmuxtest"
+)
+
+// sent is a prompt or permission answer the server sent to tmux.
+type sent struct {
+	target string
+	text   string
+}
+
+// startServer serves a server without tmux on a temporary socket, and
+// returns a client for it and the channel what it sends to tmux goes to.
+func startServer(t *testing.T) (*Server, *api.Client, chan sent) {
+	t.Helper()
+	s, socket, out := serveTemp(t)
+	return s, api.NewClient(socket), out
+}
+
+// serveTemp serves a server without tmux on a temporary socket, and returns
+// the socket and the channel what it sends to tmux goes to.
+func serveTemp(t *testing.T) (*Server, string, chan sent) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "cmux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "cmux.sock")
+
+	cfg := config.Default()
+	tmuxServer := tmuxtest.NewServer()
+	s := NewServer(cfg, tmuxServer, discovery.NewService(tmuxServer, gittest.New().Client(), cfg), nil)
+	out := make(chan sent, 10)
+	s.submit = func(target, text string) error {
+		out <- sent{target, text}
+		return nil
+	}
+	s.answer = func(target string, allow bool) error {
+		text := "n"
+		if allow {
+			text = "y"
+		}
+		out <- sent{target, text}
+		return nil
+	}
+
+	l, err := listen(socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.http.Serve(l)
+	t.Cleanup(s.Close)
+	return s, socket, out
+}
+
+// snapshot returns a discovery pass finding Claude in the named sessions.
+func snapshot(names ...string) discovery.Snapshot {
+	snap := discovery.Snapshot{Live: names, Claude: names, Time: time.Now()}
+	for _, name := range names {
+		snap.All = append(snap.All, &state.Session{
+			Name:       name,
+			RepoName:   "api",
+			Branch:     "main",
+			Worktree:   "/src/api",
+			ClaudePane: name + ":0.1",
+		})
+	}
+	return snap
+}
+
+func TestSessions(t *testing.T) {
+	s, client, _ := startServer(t)
+	ctx := context.Background()
+	s.apply(snapshot("api-main", "api-fix"))
+	s.OnHookEvent("api-fix", claude.HookEvent{
+		EventName: "PermissionRequest",
+		SessionID: "abc",
+		ToolName:  "Bash",
+		ToolInput: []byte(`{"command":"make"}`),
+		Timestamp: time.Now(),
+	})
+
+	sessions, err := client.Sessions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].Name != "api-fix" || sessions[1].Name != "api-main" {
+		t.Fatalf("Sessions() = %+v, want api-fix and api-main", sessions)
+	}
+	fix := sessions[0]
+	if fix.Status != api.StatusNeedsInput || fix.ClaudeID != "abc" || fix.Permission == nil || fix.Permission.Tool != "Bash" {
+		t.Errorf("api-fix = %+v, want waiting on Bash", fix)
+	}
+	if main := sessions[1]; main.Status != api.StatusIdle || main.Repo != "api" || main.Dir != "/src/api" {
+		t.Errorf("api-main = %+v", main)
+	}
+
+	if _, err := client.Session(ctx, "web-main"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("Session(web-main) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestPromptAndPermission(t *testing.T) {
+	s, client, out := startServer(t)
+	ctx := context.Background()
+	s.apply(snapshot("dev"))
+
+	if err := client.Answer(ctx, "dev", true); !errors.Is(err, api.ErrConflict) {
+		t.Errorf("Answer() with no prompt error = %v, want ErrConflict", err)
+	}
+
+	if err := client.Prompt(ctx, "dev", "run the tests"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-out; got != (sent{"dev:0.1", "run the tests"}) {
+		t.Errorf("prompt sent %+v, want it in Claude's pane", got)
+	}
+
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "PermissionRequest", TmuxPane: "%3", Timestamp: time.Now()})
+	if err := client.Answer(ctx, "dev", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-out; got != (sent{"%3", "n"}) {
+		t.Errorf("answer sent %+v, want n to the hook's pane", got)
+	}
+
+	if err := client.Prompt(ctx, "gone", "hello"); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("Prompt(gone) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeleteOnlyClaudeSessions(t *testing.T) {
+	s, client, _ := startServer(t)
+	snap := snapshot("dev")
+	snap.Live = append(snap.Live, "shell")
+	s.apply(snap)
+
+	if err := client.Delete(context.Background(), "shell", false); !errors.Is(err, api.ErrNotFound) {
+		t.Errorf("Delete(shell) error = %v, want ErrNotFound for a session without Claude", err)
+	}
+}
+
+func TestMessages(t *testing.T) {
+	s, client, _ := startServer(t)
+	s.apply(snapshot("dev"))
+
+	transcript := filepath.Join(t.TempDir(), "transcript.jsonl")
+	lines := `{"type":"user","uuid":"1","timestamp":"2026-01-02T10:00:00Z","message":{"content":"fix the build"}}
+{"type":"assistant","uuid":"2","timestamp":"2026-01-02T10:00:05Z","message":{"id":"m1","stop_reason":"end_turn","content":[{"type":"text","text":"Fixed."},{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go build"}}]}}
+`
+	if err := os.WriteFile(transcript, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "Stop", TranscriptPath: transcript})
+
+	messages, err := client.Messages(context.Background(), "dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("Messages() = %+v, want 2", messages)
+	}
+	if messages[0].Role != "user" || messages[0].Text != "fix the build" {
+		t.Errorf("first message = %+v", messages[0])
+	}
+	reply := messages[1]
+	if reply.Text != "Fixed." || !reply.Complete || len(reply.Tools) != 1 || reply.Tools[0].Name != "Bash" {
+		t.Errorf("reply = %+v", reply)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	s, client, _ := startServer(t)
+	s.apply(snapshot("dev"))
+
+	stream, err := client.Events(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	// Replayed from before the server started, so not streamed
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "Stop", Timestamp: time.Now().Add(-time.Hour)})
+	s.OnHookEvent("dev", claude.HookEvent{EventName: "PreToolUse", ToolName: "Edit", Timestamp: time.Now()})
+	s.apply(snapshot("web"))
+
+	want := []api.Event{
+		{Type: api.EventStatus, Session: "dev", Hook: "PreToolUse", Status: api.StatusTool, Tool: "Edit"},
+		{Type: api.EventCreated, Session: "web"},
+		{Type: api.EventDeleted, Session: "dev"},
+	}
+	for _, w := range want {
+		ev, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev.Time = time.Time{}
+		if ev != w {
+			t.Errorf("event = %+v, want %+v", ev, w)
+		}
+	}
+}
+
+func TestNotRunning(t *testing.T) {
+	client := api.NewClient(filepath.Join(t.TempDir(), "cmux.sock"))
+	if _, err := client.Sessions(context.Background()); !errors.Is(err, api.ErrNotRunning) {
+		t.Errorf("Sessions() error = %v, want ErrNotRunning", err)
+	}
+}
+
+func TestListenInUse(t *testing.T) {
+	dir, err := os.MkdirTemp("", "cmux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "cmux.sock")
+
+	// A socket left by a cmux that died is replaced
+	if err := os.WriteFile(socket, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := listen(socket)
+	if err != nil {
+		t.Fatalf("listen() over a stale socket: %v", err)
+	}
+	defer l.Close()
+
+	if _, err := listen(socket); !errors.Is(err, ErrInUse) {
+		t.Errorf("second listen() error = %v, want ErrInUse", err)
+	}
+}
+
+func TestDiscoveryFailed(t *testing.T) {
+	s, client, _ := startServer(t)
+	s.apply(discovery.Snapshot{Err: errors.New("tmux: not found")})
+
+	var apiErr *api.Error
+	_, err := client.Sessions(context.Background())
+	if !errors.As(err, &apiErr) || apiErr.Status != 503 || apiErr.Message != "discovering sessions: tmux: not found" {
+		t.Errorf("Sessions() error = %v, want discovery's", err)
+	}
+}
@@ -0,0 +1,93 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/abdullathedruid/cmux/api"
+)
+
+// ErrStale is returned by ReadStatus when the cmux that wrote the status
+// is no longer running.
+var ErrStale = errors.New("the cmux that wrote the status has exited")
+
+// Status is what a server last knew of its Claude sessions. It is written
+// to a file beside the socket, so that "cmux status-line", which tmux runs
+// every few seconds, can read it rather than discovering sessions itself.
+type Status struct {
+	PID      int           `json:"pid"`
+	Sessions []api.Session `json:"sessions"`
+	Time     time.Time     `json:"time"`
+}
+
+// StatusPath returns the status file of the server serving socket.
+func StatusPath(socket string) string {
+	return filepath.Join(filepath.Dir(socket), "status.json")
+}
+
+// ReadStatus reads a status file, failing with ErrStale if its writer has
+// exited without removing it.
+func ReadStatus(path string) (*Status, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var status Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	if status.PID <= 0 || syscall.Kill(status.PID, 0) == syscall.ESRCH {
+		return nil, fmt.Errorf("%s: %w", path, ErrStale)
+	}
+	return &status, nil
+}
+
+// writeStatus writes the status file if a session's status changed since
+// it was last written. The caller holds s.mu.
+func (s *Server) writeStatus() {
+	if s.statusPath == "" || !s.known {
+		return
+	}
+	sessions := make([]api.Session, 0, len(s.snapshot.All))
+	for _, sess := range s.snapshot.All {
+		sessions = append(sessions, s.describe(sess))
+	}
+	slices.SortFunc(sessions, func(a, b api.Session) int { return strings.Compare(a.Name, b.Name) })
+
+	// Tools and times change with every hook event; the status line only
+	// shows statuses, and whether Claude has run a turn
+	if s.lastStatus != nil && slices.EqualFunc(sessions, s.lastStatus, func(a, b api.Session) bool {
+		return a.Name == b.Name && a.Status == b.Status && a.ClaudeID == b.ClaudeID
+	}) {
+		return
+	}
+
+	data, err := json.Marshal(Status{PID: os.Getpid(), Sessions: sessions, Time: time.Now()})
+	if err != nil {
+		return
+	}
+	// tmux may read it at any moment, so replace it whole
+	tmp, err := os.CreateTemp(filepath.Dir(s.statusPath), ".status-*.json")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.statusPath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	s.lastStatus = sessions
+}
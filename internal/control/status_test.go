@@ -0,0 +1,55 @@
+package control
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/api"
+	"github.com/abdullathedruid/cmux/internal/claude"
+)
+
+func TestStatusFile(t *testing.T) {
+	s, socket, _ := serveTemp(t)
+	path := StatusPath(socket)
+	s.statusPath = path
+
+	s.apply(snapshot("web", "api"))
+	status, err := ReadStatus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.Sessions) != 2 || status.Sessions[0].Name != "api" || status.Sessions[1].Status != api.StatusIdle {
+		t.Errorf("sessions = %+v, want api and web, idle", status.Sessions)
+	}
+
+	s.OnHookEvent("web", claude.HookEvent{EventName: "PreToolUse", ToolName: "Bash"})
+	if status, _ := ReadStatus(path); status.Sessions[1].Status != api.StatusTool {
+		t.Errorf("web is %s after a tool started, want tool", status.Sessions[1].Status)
+	}
+
+	// Another tool doesn't change the status, so the file is left alone
+	info, _ := os.Stat(path)
+	s.OnHookEvent("web", claude.HookEvent{EventName: "PreToolUse", ToolName: "Read"})
+	if again, _ := os.Stat(path); !again.ModTime().Equal(info.ModTime()) || !os.SameFile(info, again) {
+		t.Error("status file rewritten without a status change")
+	}
+
+	s.Close()
+	if _, err := ReadStatus(path); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("ReadStatus() after Close = %v, want it removed", err)
+	}
+}
+
+func TestReadStatusStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "status.json")
+	// PIDs are below 2^22 on Linux
+	if err := os.WriteFile(path, []byte(`{"pid": 99999999, "sessions": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadStatus(path); !errors.Is(err, ErrStale) {
+		t.Errorf("ReadStatus() = %v, want ErrStale", err)
+	}
+}
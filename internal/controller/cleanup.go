@@ -2,12 +2,13 @@ package controller
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-errors/errors"
 	"github.com/jesseduffield/gocui"
 
-	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/cleanup"
 )
 
 const cleanupViewName = "cleanup"
@@ -22,12 +23,8 @@ const (
 
 // OrphanedWorktree represents a worktree without an active tmux session.
 type OrphanedWorktree struct {
-	RepoName   string
-	RepoPath   string
-	Worktree   git.Worktree
-	IsMerged   bool
-	Age        time.Duration
-	Selected   bool
+	cleanup.Report
+	Selected bool
 }
 
 // CleanupController manages the worktree cleanup modal.
@@ -40,6 +37,7 @@ type CleanupController struct {
 	cursor       int
 	scrollOffset int
 	viewHeight   int
+	failures     []string
 }
 
 // NewCleanupController creates a new cleanup controller.
@@ -118,6 +116,7 @@ func (c *CleanupController) Show(g *gocui.Gui) error {
 	c.step = stepSelectWorktrees
 	c.cursor = 0
 	c.scrollOffset = 0
+	c.failures = nil
 
 	// Find orphaned worktrees
 	if err := c.findOrphanedWorktrees(); err != nil {
@@ -281,16 +280,7 @@ func (c *CleanupController) renderSelectStep(v *gocui.View) {
 				checkbox = "[x]"
 			}
 
-			// Status label
-			status := "orphan"
-			if orphan.IsMerged {
-				status = "merged"
-			}
-
-			// Age
-			age := formatAge(orphan.Age)
-
-			fmt.Fprintf(v, "  %s%s %s (%s, %s)\n", prefix, checkbox, orphan.Worktree.Branch, status, age)
+			fmt.Fprintf(v, "  %s%s %s\n", prefix, checkbox, FormatOrphanLabel(orphan))
 			currentLine++
 		}
 	}
@@ -300,6 +290,10 @@ func (c *CleanupController) renderSelectStep(v *gocui.View) {
 		fmt.Fprintln(v, "  ↓ more below")
 	}
 
+	for _, failure := range c.failures {
+		fmt.Fprintf(v, "  \033[31m%s\033[0m\n", failure)
+	}
+
 	fmt.Fprintln(v, "")
 	fmt.Fprintln(v, "  ─────────────────────────────────────────────────")
 	selectedCount := c.selectedCount()
@@ -313,14 +307,15 @@ func (c *CleanupController) renderSelectStep(v *gocui.View) {
 
 func (c *CleanupController) renderConfirmStep(v *gocui.View) {
 	fmt.Fprintln(v, "")
-	fmt.Fprintln(v, "  The following worktrees will be removed:")
+	fmt.Fprintln(v, "  The following worktrees will be cleaned up:")
 	fmt.Fprintln(v, "")
 
 	// Collect selected items
 	var selected []string
 	for i := range c.orphans {
 		if c.orphans[i].Selected {
-			selected = append(selected, fmt.Sprintf("    - %s/%s", c.orphans[i].RepoName, c.orphans[i].Worktree.Branch))
+			orphan := &c.orphans[i]
+			selected = append(selected, fmt.Sprintf("    - %s/%s: %s", orphan.RepoName, orphan.Worktree.Branch, orphan.RemovalPlan()))
 		}
 	}
 
@@ -339,7 +334,7 @@ func (c *CleanupController) renderConfirmStep(v *gocui.View) {
 	}
 
 	fmt.Fprintln(v, "")
-	fmt.Fprintln(v, "  This action cannot be undone!")
+	fmt.Fprintln(v, "  Changes are stashed; unmerged branches are kept.")
 	fmt.Fprintln(v, "")
 	fmt.Fprintln(v, "  ─────────────────────────────────────────────────")
 	fmt.Fprintln(v, "  y: Confirm and delete  n: Cancel")
@@ -372,49 +367,13 @@ func (c *CleanupController) findOrphanedWorktrees() error {
 		}
 	}
 
-	// Check each repo for orphaned worktrees
-	for _, repoPath := range repos {
-		worktrees, err := git.ListWorktrees(repoPath)
-		if err != nil {
-			continue
-		}
-
-		repoName := ""
-		if info, err := git.GetRepoInfo(repoPath); err == nil {
-			repoName = info.Name
-		}
-
-		for _, wt := range worktrees {
-			// Skip main worktree
-			if wt.IsMain {
-				continue
-			}
-
-			// Check if this worktree has an active session
-			if activeWorktrees[wt.Path] {
-				continue
-			}
-
-			// This is an orphaned worktree
-			orphan := OrphanedWorktree{
-				RepoName: repoName,
-				RepoPath: repoPath,
-				Worktree: wt,
-				Selected: false,
-			}
-
-			// Check if branch is merged
-			if merged, err := git.IsBranchMerged(repoPath, wt.Branch); err == nil {
-				orphan.IsMerged = merged
-			}
-
-			// Get last commit time
-			if lastCommit, err := git.GetLastCommitTime(wt.Path); err == nil {
-				orphan.Age = time.Since(lastCommit)
-			}
-
-			c.orphans = append(c.orphans, orphan)
-		}
+	// Worktrees with nothing to lose start selected
+	active := func(path string) bool { return activeWorktrees[path] }
+	for _, report := range cleanup.Scan(c.ctx.Git, c.ctx.Config, repos, active) {
+		c.orphans = append(c.orphans, OrphanedWorktree{
+			Report:   report,
+			Selected: !report.SafePlan().Empty(),
+		})
 	}
 
 	return nil
@@ -472,15 +431,27 @@ func (c *CleanupController) selectedCount() int {
 }
 
 func (c *CleanupController) performDelete() {
-	// Delete selected worktrees
+	// Clean up selected worktrees, keeping failures on screen
+	c.failures = nil
 	for i := range c.orphans {
-		if c.orphans[i].Selected {
-			_ = git.RemoveWorktree(c.orphans[i].RepoPath, c.orphans[i].Worktree.Path)
+		orphan := &c.orphans[i]
+		if !orphan.Selected {
+			continue
+		}
+		if err := cleanup.Apply(c.ctx.Git, orphan.Report, orphan.RemovalPlan()); err != nil {
+			c.failures = append(c.failures, fmt.Sprintf("%s: %v", orphan.Worktree.Branch, err))
 		}
 	}
 
-	// Hide modal and refresh
-	c.Hide(c.gui)
+	if len(c.failures) > 0 {
+		c.findOrphanedWorktrees()
+		c.step = stepSelectWorktrees
+		c.cursor = 0
+		c.scrollOffset = 0
+		c.Render(c.gui)
+	} else {
+		c.Hide(c.gui)
+	}
 	if c.ctx.OnRefresh != nil {
 		c.ctx.OnRefresh()
 	}
@@ -504,9 +475,9 @@ func formatAge(d time.Duration) string {
 
 // FormatOrphanLabel creates a display label for an orphaned worktree.
 func FormatOrphanLabel(orphan *OrphanedWorktree) string {
-	status := "orphan"
-	if orphan.IsMerged {
-		status = "merged"
+	status := strings.Join(orphan.Labels(), ", ")
+	if orphan.Missing {
+		return fmt.Sprintf("%s (%s)", orphan.Worktree.Branch, status)
 	}
 	return fmt.Sprintf("%s (%s, %s)", orphan.Worktree.Branch, status, formatAge(orphan.Age))
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/jesseduffield/gocui"
 
 	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
 	"github.com/abdullathedruid/cmux/internal/state"
 	"github.com/abdullathedruid/cmux/internal/tmux"
 )
@@ -26,6 +27,7 @@ type Context struct {
 	Config        *config.Config
 	State         *state.State
 	TmuxClient    tmux.Client
+	Git           *git.Client
 	OnAttach      func(sessionName string) error
 	OnPopupAttach func(sessionName string) error
 	OnShowDiff    func(sessionName string) error
@@ -38,10 +40,11 @@ type Context struct {
 }
 
 // NewContext creates a new controller context.
-func NewContext(cfg *config.Config, s *state.State, t tmux.Client) *Context {
+func NewContext(cfg *config.Config, s *state.State, t tmux.Client, g *git.Client) *Context {
 	return &Context{
 		Config:     cfg,
 		State:      s,
 		TmuxClient: t,
+		Git:        g,
 	}
 }
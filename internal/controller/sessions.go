@@ -208,6 +208,32 @@ func (c *SessionsController) renderDetails(g *gocui.Gui) error {
 
 	fmt.Fprintf(v, "  Branch:   %s\n", sess.Branch)
 
+	// Git state, once checked
+	if st := sess.Git; st != nil {
+		fmt.Fprintf(v, "  Changes:  %d files\n", st.Dirty)
+		if st.Sync.Base != "" {
+			sync := st.Sync.Label()
+			if sync == "" {
+				sync = "up to date"
+			}
+			fmt.Fprintf(v, "  Base:     %s (%s)\n", st.Sync.Base, sync)
+		}
+		if len(st.Sync.Conflicts) > 0 {
+			fmt.Fprintf(v, "  Conflict: %s\n", ui.Truncate(strings.Join(st.Sync.Conflicts, ", "), max(width-14, 20)))
+		} else if st.Sync.ConflictsUnknown {
+			fmt.Fprintf(v, "  Conflict: unknown\n")
+		}
+		upstream := "yes"
+		if !st.Upstream {
+			upstream = "no"
+		}
+		fmt.Fprintf(v, "  Pushed:   %s\n", upstream)
+		if st.Subject != "" {
+			commit := fmt.Sprintf("%s (%s)", st.Subject, ui.FormatDuration(int64(time.Since(st.Committed).Seconds())))
+			fmt.Fprintf(v, "  Commit:   %s\n", ui.Truncate(commit, max(width-14, 20)))
+		}
+	}
+
 	// Status with current tool if present
 	statusText := ui.StatusText(sess.Attached, sess.Status)
 	if sess.CurrentTool != "" {
@@ -215,6 +241,11 @@ func (c *SessionsController) renderDetails(g *gocui.Gui) error {
 	}
 	fmt.Fprintf(v, "  Status:   %s\n", statusText)
 
+	// Claude process resources, once found
+	if sess.ClaudePID != 0 {
+		fmt.Fprintf(v, "  Claude:   pid %d, %s\n", sess.ClaudePID, ui.FormatUsage(sess.CPU, sess.RSS))
+	}
+
 	// Tool summary (shown when using a tool)
 	if sess.ToolSummary != "" {
 		maxLen := width - 14 // "  Activity: " prefix + margin
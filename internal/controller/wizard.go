@@ -9,7 +9,10 @@ import (
 	"github.com/go-errors/errors"
 	"github.com/jesseduffield/gocui"
 
+	"github.com/abdullathedruid/cmux/internal/config"
 	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/tmux"
 )
 
 const wizardViewName = "wizard"
@@ -22,6 +25,9 @@ const (
 	stepSelectBranch
 	stepEnterBranchName
 	stepAddRepo
+	stepSelectTemplate
+	stepEnterTemplateInput
+	stepEnterPR
 )
 
 // WizardController manages the session creation wizard.
@@ -32,12 +38,18 @@ type WizardController struct {
 	recentRepos   []string
 	selectedRepo  string
 	branches      []string
+	remoteBranches []string // remote-tracking branches without a local branch
 	worktrees     []git.Worktree
 	selected      int
 	inputBuffer   string
 	createNew     bool // true = create new worktree, false = use existing
 	gui           *gocui.Gui
 	preSelectedRepo string // If set, skip repo selection step
+
+	templates     []string              // template names offered by the template step
+	template      string                // chosen template
+	templateVars  session.TemplateVars  // values entered for the chosen template
+	templateInput string                // "issue" or "branch" while asking for it
 }
 
 // Edit handles key input for the wizard modal.
@@ -52,13 +64,16 @@ func (c *WizardController) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui
 	case key == gocui.KeyBackspace || key == gocui.KeyBackspace2:
 		c.backspace(c.gui, v)
 		return true
-	case key == gocui.KeyArrowDown || ch == 'j':
+	case key == gocui.KeyArrowDown || (ch == 'j' && !c.isTextStep()):
 		c.cursorDown(c.gui, v)
 		return true
-	case key == gocui.KeyArrowUp || ch == 'k':
+	case key == gocui.KeyArrowUp || (ch == 'k' && !c.isTextStep()):
 		c.cursorUp(c.gui, v)
 		return true
-	case ch != 0 && mod == gocui.ModNone && (c.step == stepEnterBranchName || c.step == stepAddRepo):
+	case ch == 'f' && c.step == stepSelectBranch:
+		c.fetchRemotes(c.gui)
+		return true
+	case ch != 0 && mod == gocui.ModNone && c.isTextStep():
 		// Accept character input in text entry modes
 		c.inputBuffer += string(ch)
 		c.Render(c.gui)
@@ -67,6 +82,12 @@ func (c *WizardController) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui
 	return false
 }
 
+// isTextStep reports whether the current step takes typed text.
+func (c *WizardController) isTextStep() bool {
+	return c.step == stepEnterBranchName || c.step == stepAddRepo || c.step == stepEnterTemplateInput ||
+		c.step == stepEnterPR
+}
+
 // NewWizardController creates a new wizard controller.
 func NewWizardController(ctx *Context) *WizardController {
 	return &WizardController{ctx: ctx}
@@ -97,11 +118,7 @@ func (c *WizardController) Show(g *gocui.Gui) error {
 		c.preSelectedRepo = ""
 		c.step = stepSelectAction
 
-		// Load branches and worktrees
-		worktrees, _ := git.ListWorktrees(c.selectedRepo)
-		c.worktrees = worktrees
-		branches, _ := git.ListBranches(c.selectedRepo)
-		c.branches = branches
+		c.loadBranches()
 	} else {
 		// Gather recent repos from existing sessions
 		c.recentRepos = c.gatherRecentRepos()
@@ -142,7 +159,7 @@ func (c *WizardController) gatherRecentRepos() []string {
 
 	// 1. Add current directory first (if it's a git repo)
 	if cwd, err := os.Getwd(); err == nil {
-		if root, err := git.FindRepoRoot(cwd); err == nil {
+		if root, err := c.ctx.Git.FindRepoRoot(cwd); err == nil {
 			addRepo(root)
 		}
 	}
@@ -150,7 +167,7 @@ func (c *WizardController) gatherRecentRepos() []string {
 	// 2. Add configured repositories
 	for _, repo := range c.ctx.Config.ExpandedRepositories() {
 		// Resolve to git root in case user specified a subdirectory
-		if root, err := git.FindRepoRoot(repo); err == nil {
+		if root, err := c.ctx.Git.FindRepoRoot(repo); err == nil {
 			addRepo(root)
 		}
 	}
@@ -214,6 +231,12 @@ func (c *WizardController) getTitle() string {
 		return " New Session - Enter Branch Name "
 	case stepAddRepo:
 		return " Add Repository "
+	case stepSelectTemplate:
+		return " New Session - Select Template "
+	case stepEnterTemplateInput:
+		return fmt.Sprintf(" New Session - %s ", c.template)
+	case stepEnterPR:
+		return " New Session - Review Pull Request "
 	default:
 		return " New Session "
 	}
@@ -245,6 +268,12 @@ func (c *WizardController) Render(g *gocui.Gui) error {
 		c.renderBranchInput(v)
 	case stepAddRepo:
 		c.renderAddRepoInput(v)
+	case stepSelectTemplate:
+		c.renderTemplateSelection(v)
+	case stepEnterTemplateInput:
+		c.renderTemplateInput(v)
+	case stepEnterPR:
+		c.renderPRInput(v)
 	}
 
 	return nil
@@ -281,13 +310,7 @@ func (c *WizardController) renderActionSelection(v *gocui.View) {
 	fmt.Fprintln(v, "  What would you like to do?")
 	fmt.Fprintln(v, "")
 
-	actions := []string{
-		"Create session on main branch",
-		"Create session from existing branch/worktree",
-		"Create session with new branch",
-	}
-
-	for i, action := range actions {
+	for i, action := range c.actions() {
 		prefix := "  "
 		if i == c.selected {
 			prefix = "> "
@@ -301,6 +324,20 @@ func (c *WizardController) renderActionSelection(v *gocui.View) {
 	fmt.Fprintln(v, "  Enter: Select  Esc: Back")
 }
 
+// actions returns the choices of the action step.
+func (c *WizardController) actions() []string {
+	actions := []string{
+		"Create session on main branch",
+		"Create session from existing branch/worktree",
+		"Create session with new branch",
+		"Review a pull request",
+	}
+	if len(c.ctx.Config.Templates) > 0 {
+		actions = append(actions, "Create session from template")
+	}
+	return actions
+}
+
 func (c *WizardController) renderBranchSelection(v *gocui.View) {
 	fmt.Fprintln(v, "")
 
@@ -356,7 +393,7 @@ func (c *WizardController) renderBranchSelection(v *gocui.View) {
 
 	fmt.Fprintln(v, "")
 	fmt.Fprintln(v, "  ────────────────────────────────────────────")
-	fmt.Fprintln(v, "  j/k or arrows: Navigate")
+	fmt.Fprintln(v, "  j/k or arrows: Navigate  f: Fetch remotes")
 	fmt.Fprintln(v, "  Enter: Select  Esc: Back")
 }
 
@@ -370,6 +407,16 @@ func (c *WizardController) renderBranchInput(v *gocui.View) {
 	fmt.Fprintln(v, "  Enter: Create  Esc: Back")
 }
 
+func (c *WizardController) renderPRInput(v *gocui.View) {
+	fmt.Fprintln(v, "")
+	fmt.Fprintln(v, "  Enter pull request number or URL:")
+	fmt.Fprintln(v, "")
+	fmt.Fprintf(v, "  > %s_\n", c.inputBuffer)
+	fmt.Fprintln(v, "")
+	fmt.Fprintln(v, "  ────────────────────────────────────────────")
+	fmt.Fprintln(v, "  Enter: Review  Esc: Back")
+}
+
 func (c *WizardController) renderAddRepoInput(v *gocui.View) {
 	fmt.Fprintln(v, "")
 	fmt.Fprintln(v, "  Enter repository path:")
@@ -382,6 +429,45 @@ func (c *WizardController) renderAddRepoInput(v *gocui.View) {
 	fmt.Fprintln(v, "  Enter: Add  Esc: Cancel")
 }
 
+func (c *WizardController) renderTemplateSelection(v *gocui.View) {
+	fmt.Fprintln(v, "")
+	fmt.Fprintf(v, "  Repository: %s\n", filepath.Base(c.selectedRepo))
+	fmt.Fprintln(v, "")
+	fmt.Fprintln(v, "  Select a template:")
+	fmt.Fprintln(v, "")
+
+	for i, name := range c.templates {
+		prefix := "  "
+		if i == c.selected {
+			prefix = "> "
+		}
+		label := name
+		if desc := c.ctx.Config.Templates[name].Description; desc != "" {
+			label += " - " + desc
+		}
+		fmt.Fprintf(v, "%s%s\n", prefix, label)
+	}
+
+	fmt.Fprintln(v, "")
+	fmt.Fprintln(v, "  ────────────────────────────────────────────")
+	fmt.Fprintln(v, "  j/k or arrows: Navigate")
+	fmt.Fprintln(v, "  Enter: Select  Esc: Back")
+}
+
+func (c *WizardController) renderTemplateInput(v *gocui.View) {
+	fmt.Fprintln(v, "")
+	if c.templateInput == "issue" {
+		fmt.Fprintln(v, "  Enter issue:")
+	} else {
+		fmt.Fprintln(v, "  Enter branch name:")
+	}
+	fmt.Fprintln(v, "")
+	fmt.Fprintf(v, "  > %s_\n", c.inputBuffer)
+	fmt.Fprintln(v, "")
+	fmt.Fprintln(v, "  ────────────────────────────────────────────")
+	fmt.Fprintln(v, "  Enter: Continue  Esc: Back")
+}
+
 func (c *WizardController) getBranchItems() []string {
 	var items []string
 
@@ -408,9 +494,45 @@ func (c *WizardController) getBranchItems() []string {
 		}
 	}
 
+	// Remote branches last, checked out on demand
+	for _, branch := range c.remoteBranches {
+		items = append(items, branch+" [remote]")
+	}
+
 	return items
 }
 
+// loadBranches loads the selected repository's worktrees and branches.
+func (c *WizardController) loadBranches() {
+	worktrees, _ := c.ctx.Git.ListWorktrees(c.selectedRepo)
+	c.worktrees = worktrees
+	branches, _ := c.ctx.Git.ListBranches(c.selectedRepo)
+	c.branches = branches
+
+	local := make(map[string]bool)
+	for _, b := range branches {
+		local[b] = true
+	}
+	remotes, _ := c.ctx.Git.ListRemotes(c.selectedRepo)
+	remoteBranches, _ := c.ctx.Git.ListRemoteBranches(c.selectedRepo)
+	c.remoteBranches = nil
+	for _, rb := range remoteBranches {
+		if _, branch, ok := git.SplitRemoteBranch(remotes, rb); ok && !local[branch] {
+			c.remoteBranches = append(c.remoteBranches, rb)
+		}
+	}
+}
+
+// fetchRemotes fetches every remote and reloads the branch list.
+func (c *WizardController) fetchRemotes(g *gocui.Gui) error {
+	if err := c.ctx.Git.FetchAll(c.selectedRepo); err != nil {
+		return err
+	}
+	c.loadBranches()
+	c.selected = 0
+	return c.Render(g)
+}
+
 // Navigation handlers
 func (c *WizardController) cursorDown(g *gocui.Gui, v *gocui.View) error {
 	maxItems := c.getMaxItems()
@@ -432,9 +554,11 @@ func (c *WizardController) getMaxItems() int {
 	case stepSelectRepo:
 		return len(c.recentRepos)
 	case stepSelectAction:
-		return 3
+		return len(c.actions())
 	case stepSelectBranch:
 		return len(c.getBranchItems())
+	case stepSelectTemplate:
+		return len(c.templates)
 	default:
 		return 0
 	}
@@ -452,6 +576,12 @@ func (c *WizardController) select_(g *gocui.Gui, v *gocui.View) error {
 		return c.createWithNewBranch(g)
 	case stepAddRepo:
 		return c.addRepository(g)
+	case stepSelectTemplate:
+		return c.selectTemplate(g)
+	case stepEnterTemplateInput:
+		return c.enterTemplateInput(g)
+	case stepEnterPR:
+		return c.reviewPR(g)
 	}
 	return nil
 }
@@ -465,12 +595,7 @@ func (c *WizardController) selectRepo(g *gocui.Gui) error {
 	c.step = stepSelectAction
 	c.selected = 0
 
-	// Load branches and worktrees
-	worktrees, _ := git.ListWorktrees(c.selectedRepo)
-	c.worktrees = worktrees
-
-	branches, _ := git.ListBranches(c.selectedRepo)
-	c.branches = branches
+	c.loadBranches()
 
 	v, _ := g.View(wizardViewName)
 	v.Title = c.getTitle()
@@ -489,6 +614,13 @@ func (c *WizardController) selectAction(g *gocui.Gui) error {
 		c.createNew = true
 		c.step = stepEnterBranchName
 		c.inputBuffer = ""
+	case 3: // Pull request
+		c.step = stepEnterPR
+		c.inputBuffer = ""
+	case 4: // Template
+		c.templates = c.ctx.Config.TemplateNames()
+		c.step = stepSelectTemplate
+		c.selected = 0
 	}
 
 	v, _ := g.View(wizardViewName)
@@ -526,18 +658,71 @@ func (c *WizardController) selectBranch(g *gocui.Gui) error {
 	}
 
 	if branchIndex >= len(branchesWithoutWorktree) {
-		return nil
+		remoteIndex := branchIndex - len(branchesWithoutWorktree)
+		if remoteIndex >= len(c.remoteBranches) {
+			return nil
+		}
+		return c.checkoutRemote(g, c.remoteBranches[remoteIndex])
 	}
 
 	branchName := branchesWithoutWorktree[branchIndex]
-	worktreePath, err := git.CreateWorktree(c.selectedRepo, branchName, false)
-	if err != nil {
+	// A failed setup still leaves the worktree usable
+	worktreePath, err := session.CreateWorktree(c.ctx.Git, c.ctx.Config, c.selectedRepo, branchName, false)
+	if worktreePath == "" {
 		return err
 	}
 
 	return c.createSessionForPath(g, worktreePath, branchName)
 }
 
+// checkoutRemote fetches a remote branch and creates a session on a local
+// branch tracking it.
+func (c *WizardController) checkoutRemote(g *gocui.Gui, remoteBranch string) error {
+	co, err := session.PrepareRemoteBranch(c.ctx.Git, c.selectedRepo, remoteBranch)
+	if err != nil {
+		return err
+	}
+	return c.checkout(g, co, tmux.SessionOptions{}, "")
+}
+
+// reviewPR checks out the entered pull request and starts Claude on it
+// with the review prompt.
+func (c *WizardController) reviewPR(g *gocui.Gui) error {
+	ref, number, ok := session.ParsePRRef(c.inputBuffer, true)
+	if !ok {
+		return nil
+	}
+	pr, err := session.ResolvePR(c.ctx.Config, c.selectedRepo, ref, number)
+	if err != nil {
+		return err
+	}
+	co, err := session.PreparePR(c.ctx.Git, c.selectedRepo, pr)
+	if err != nil {
+		return err
+	}
+
+	opts := session.TemplateOptions(c.ctx.Config, c.selectedRepo, config.SessionTemplate{})
+	prompt := session.ReviewPrompt(c.ctx.Config, filepath.Base(c.selectedRepo), co.Branch, ref)
+	return c.checkout(g, co, opts, prompt)
+}
+
+// checkout creates a worktree for a prepared branch unless it has one and
+// starts a session in it.
+func (c *WizardController) checkout(g *gocui.Gui, co session.Checkout, opts tmux.SessionOptions, prompt string) error {
+	for _, wt := range c.worktrees {
+		if wt.Branch == co.Branch {
+			return c.startSession(g, wt.Path, co.Branch, opts, prompt)
+		}
+	}
+
+	// A failed setup still leaves the worktree usable
+	worktreePath, err := session.CreateWorktreeFrom(c.ctx.Git, c.ctx.Config, c.selectedRepo, co.Branch, co.NewBranch, co.StartPoint)
+	if worktreePath == "" {
+		return err
+	}
+	return c.startSession(g, worktreePath, co.Branch, opts, prompt)
+}
+
 func (c *WizardController) createOnMainBranch(g *gocui.Gui) error {
 	// Find main worktree
 	for _, wt := range c.worktrees {
@@ -547,7 +732,7 @@ func (c *WizardController) createOnMainBranch(g *gocui.Gui) error {
 	}
 
 	// Fallback to repo root
-	info, err := git.GetRepoInfo(c.selectedRepo)
+	info, err := c.ctx.Git.GetRepoInfo(c.selectedRepo)
 	if err != nil {
 		return err
 	}
@@ -560,15 +745,101 @@ func (c *WizardController) createWithNewBranch(g *gocui.Gui) error {
 	}
 
 	// Create worktree with new branch
-	worktreePath, err := git.CreateWorktree(c.selectedRepo, c.inputBuffer, true)
-	if err != nil {
+	worktreePath, err := session.CreateWorktree(c.ctx.Git, c.ctx.Config, c.selectedRepo, c.inputBuffer, true)
+	if worktreePath == "" {
 		return err
 	}
 
 	return c.createSessionForPath(g, worktreePath, c.inputBuffer)
 }
 
+func (c *WizardController) selectTemplate(g *gocui.Gui) error {
+	if c.selected >= len(c.templates) {
+		return nil
+	}
+	c.template = c.templates[c.selected]
+	c.templateVars = session.TemplateVars{Repo: filepath.Base(c.selectedRepo)}
+	return c.nextTemplateInput(g)
+}
+
+func (c *WizardController) enterTemplateInput(g *gocui.Gui) error {
+	if c.inputBuffer == "" {
+		return nil
+	}
+	if c.templateInput == "issue" {
+		c.templateVars.Issue = c.inputBuffer
+	} else {
+		c.templateVars.Branch = c.inputBuffer
+	}
+	return c.nextTemplateInput(g)
+}
+
+// nextTemplateInput asks for what the chosen template still needs, then
+// creates its session.
+func (c *WizardController) nextTemplateInput(g *gocui.Gui) error {
+	tmpl := c.ctx.Config.Templates[c.template]
+	c.inputBuffer = ""
+	switch {
+	case session.TemplateNeedsIssue(tmpl) && c.templateVars.Issue == "":
+		c.templateInput = "issue"
+	case tmpl.Branch == "" && c.templateVars.Branch == "":
+		c.templateInput = "branch"
+	default:
+		return c.createFromTemplate(g, tmpl)
+	}
+	c.step = stepEnterTemplateInput
+
+	v, _ := g.View(wizardViewName)
+	v.Title = c.getTitle()
+	return c.Render(g)
+}
+
+// createFromTemplate creates the template's branch and worktree, starts
+// Claude with its flags and submits its prompt once Claude is ready.
+func (c *WizardController) createFromTemplate(g *gocui.Gui, tmpl config.SessionTemplate) error {
+	vars := c.templateVars
+	if tmpl.Branch != "" {
+		vars.Branch = session.TemplateBranch(tmpl, vars)
+	}
+	if vars.Branch == "" {
+		return nil
+	}
+
+	path := ""
+	for _, wt := range c.worktrees {
+		if wt.Branch == vars.Branch {
+			path = wt.Path
+			break
+		}
+	}
+	if path == "" {
+		exists := false
+		for _, branch := range c.branches {
+			if branch == vars.Branch {
+				exists = true
+				break
+			}
+		}
+		// A failed setup still leaves the worktree usable
+		worktreePath, err := session.CreateWorktree(c.ctx.Git, c.ctx.Config, c.selectedRepo, vars.Branch, !exists)
+		if worktreePath == "" {
+			return err
+		}
+		path = worktreePath
+	}
+
+	opts := session.TemplateOptions(c.ctx.Config, c.selectedRepo, tmpl)
+	return c.startSession(g, path, vars.Branch, opts, session.TemplatePrompt(tmpl, vars))
+}
+
 func (c *WizardController) createSessionForPath(g *gocui.Gui, path, branch string) error {
+	return c.startSession(g, path, branch, tmux.SessionOptions{}, "")
+}
+
+// startSession creates and attaches to a session for path. A zero opts
+// starts Claude the client's default way; a prompt is submitted in the
+// background once Claude is ready.
+func (c *WizardController) startSession(g *gocui.Gui, path, branch string, opts tmux.SessionOptions, prompt string) error {
 	// Hide wizard
 	if err := c.Hide(g); err != nil {
 		return err
@@ -588,9 +859,16 @@ func (c *WizardController) createSessionForPath(g *gocui.Gui, path, branch strin
 	}
 
 	// Create new session
-	if err := c.ctx.TmuxClient.CreateSession(sessionName, path, true); err != nil {
+	if opts.Command == "" {
+		if err := c.ctx.TmuxClient.CreateSession(sessionName, path, true); err != nil {
+			return err
+		}
+	} else if err := c.ctx.TmuxClient.CreateSessionWithOptions(sessionName, path, opts); err != nil {
 		return err
 	}
+	if prompt != "" {
+		go session.SubmitPrompt(c.ctx.TmuxClient, sessionName, prompt)
+	}
 
 	// Refresh and select the new session
 	if c.ctx.OnRefresh != nil {
@@ -617,6 +895,17 @@ func (c *WizardController) back(g *gocui.Gui, v *gocui.View) error {
 		c.inputBuffer = ""
 	case stepAddRepo:
 		return c.Hide(g)
+	case stepSelectTemplate:
+		c.step = stepSelectAction
+		c.selected = 0
+	case stepEnterTemplateInput:
+		c.step = stepSelectTemplate
+		c.selected = 0
+		c.inputBuffer = ""
+	case stepEnterPR:
+		c.step = stepSelectAction
+		c.selected = 0
+		c.inputBuffer = ""
 	}
 
 	v.Title = c.getTitle()
@@ -624,7 +913,7 @@ func (c *WizardController) back(g *gocui.Gui, v *gocui.View) error {
 }
 
 func (c *WizardController) backspace(g *gocui.Gui, v *gocui.View) error {
-	if (c.step == stepEnterBranchName || c.step == stepAddRepo) && len(c.inputBuffer) > 0 {
+	if c.isTextStep() && len(c.inputBuffer) > 0 {
 		c.inputBuffer = c.inputBuffer[:len(c.inputBuffer)-1]
 		return c.Render(g)
 	}
@@ -639,7 +928,7 @@ func (c *WizardController) addRepository(g *gocui.Gui) error {
 	path := c.inputBuffer
 
 	// Validate it's a git repo
-	if _, err := git.FindRepoRoot(path); err != nil {
+	if _, err := c.ctx.Git.FindRepoRoot(path); err != nil {
 		// TODO: Show error message in UI
 		return nil
 	}
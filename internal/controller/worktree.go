@@ -9,6 +9,7 @@ import (
 	"github.com/jesseduffield/gocui"
 
 	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/session"
 )
 
 const worktreeViewName = "worktree"
@@ -90,7 +91,7 @@ func (c *WorktreeController) Show(g *gocui.Gui) error {
 		return err
 	}
 
-	repoPath, err := git.FindRepoRoot(cwd)
+	repoPath, err := c.ctx.Git.FindRepoRoot(cwd)
 	if err != nil {
 		// Not in a git repo - try to use the selected session's repo
 		sess := c.ctx.State.GetSelectedSession()
@@ -108,14 +109,14 @@ func (c *WorktreeController) Show(g *gocui.Gui) error {
 	c.gui = g
 
 	// Load worktrees
-	worktrees, err := git.ListWorktrees(repoPath)
+	worktrees, err := c.ctx.Git.ListWorktrees(repoPath)
 	if err != nil {
 		return err
 	}
 	c.worktrees = worktrees
 
 	// Load branches
-	branches, err := git.ListBranches(repoPath)
+	branches, err := c.ctx.Git.ListBranches(repoPath)
 	if err != nil {
 		branches = []string{}
 	}
@@ -363,7 +364,7 @@ func (c *WorktreeController) createSessionForWorktree(g *gocui.Gui, path, branch
 	}
 
 	// Generate session name
-	info, err := git.GetRepoInfo(path)
+	info, err := c.ctx.Git.GetRepoInfo(path)
 	if err != nil {
 		return err
 	}
@@ -395,9 +396,9 @@ func (c *WorktreeController) createSessionForWorktree(g *gocui.Gui, path, branch
 }
 
 func (c *WorktreeController) createWorktreeAndSession(g *gocui.Gui, branch string, createBranch bool) error {
-	// Create the worktree
-	worktreePath, err := git.CreateWorktree(c.repoPath, branch, createBranch)
-	if err != nil {
+	// Create the worktree; a failed setup still leaves it usable
+	worktreePath, err := session.CreateWorktree(c.ctx.Git, c.ctx.Config, c.repoPath, branch, createBranch)
+	if worktreePath == "" {
 		return err
 	}
 
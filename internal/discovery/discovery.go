@@ -3,24 +3,33 @@ package discovery
 
 import (
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/abdullathedruid/cmux/internal/config"
 	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/process"
 	"github.com/abdullathedruid/cmux/internal/state"
 	"github.com/abdullathedruid/cmux/internal/tmux"
 )
 
 // Service discovers tmux sessions and groups them by repository.
 type Service struct {
-	tmux   *tmux.RealClient
+	tmux   tmux.Client
+	git    *git.Client
 	config *config.Config
+	pub    publisher
+
+	// Process table of the previous scan, for Claude's CPU use since
+	mu        sync.Mutex
+	lastProcs *process.Table
 }
 
 // NewService creates a new discovery service.
-func NewService(t *tmux.RealClient, cfg *config.Config) *Service {
+func NewService(t tmux.Client, g *git.Client, cfg *config.Config) *Service {
 	return &Service{
 		tmux:   t,
+		git:    g,
 		config: cfg,
 	}
 }
@@ -28,90 +37,33 @@ func NewService(t *tmux.RealClient, cfg *config.Config) *Service {
 // DiscoverSessions discovers all tmux sessions and returns those that belong
 // to configured repositories. Sessions are enriched with repository info.
 func (s *Service) DiscoverSessions() ([]*state.Session, error) {
-	// Get all Claude sessions from tmux
-	tmuxSessions, err := s.tmux.DiscoverClaudeSessions()
+	scan, err := s.tmux.Scan()
 	if err != nil {
 		return nil, err
 	}
-
-	// Get expanded repository paths for comparison
-	configuredRepos := make(map[string]bool)
-	for _, repo := range s.config.ExpandedRepositories() {
-		absPath, err := filepath.Abs(repo)
-		if err != nil {
-			continue
-		}
-		configuredRepos[absPath] = true
-	}
-
-	var sessions []*state.Session
-
-	for _, ts := range tmuxSessions {
-		// Get working directory for this session
-		workDir, err := s.tmux.GetSessionWorkingDir(ts.Name)
-		if err != nil || workDir == "" {
-			continue
-		}
-
-		// Find the repository root
-		repoRoot, err := git.FindRepoRoot(workDir)
-		if err != nil {
-			// Not a git repo, skip
-			continue
-		}
-
-		absRepoRoot, err := filepath.Abs(repoRoot)
-		if err != nil {
-			continue
-		}
-
-		// Filter: only include sessions from configured repositories
-		if !configuredRepos[absRepoRoot] {
-			continue
-		}
-
-		// Get branch info
-		branch, err := git.GetCurrentBranch(workDir)
-		if err != nil {
-			branch = "unknown"
-		}
-
-		// Determine if this is a worktree
-		isWorktree := git.IsWorktreePath(workDir)
-
-		sess := &state.Session{
-			Name:       ts.Name,
-			RepoPath:   repoRoot,
-			RepoName:   filepath.Base(repoRoot),
-			Worktree:   workDir,
-			Branch:     branch,
-			Attached:   ts.Attached,
-			Created:    ts.Created,
-			LastActive: time.Now(),
-		}
-
-		// Mark if it's a worktree path vs main repo
-		if !isWorktree {
-			sess.Worktree = "" // Main repo, not a worktree
-		}
-
-		sessions = append(sessions, sess)
-	}
-
-	return sessions, nil
+	return s.configuredSessions(s.GetConfiguredRepositories(), s.sessionsFrom(scan)), nil
 }
 
 // DiscoverAllSessions discovers all Claude sessions regardless of repository configuration.
 // This is useful for the sidebar view which may show sessions outside configured repos.
 func (s *Service) DiscoverAllSessions() ([]*state.Session, error) {
-	tmuxSessions, err := s.tmux.DiscoverClaudeSessions()
+	scan, err := s.tmux.Scan()
 	if err != nil {
 		return nil, err
 	}
+	return s.sessionsFrom(scan), nil
+}
+
+// sessionsFrom builds the Claude sessions of a scan.
+func (s *Service) sessionsFrom(scan tmux.Scan) []*state.Session {
+	s.mu.Lock()
+	prev := s.lastProcs
+	s.lastProcs = scan.Processes
+	s.mu.Unlock()
 
 	var sessions []*state.Session
 
-	for _, ts := range tmuxSessions {
+	for _, ts := range scan.ClaudeSessions() {
 		sess := &state.Session{
 			Name:       ts.Name,
 			Attached:   ts.Attached,
@@ -120,31 +72,84 @@ func (s *Service) DiscoverAllSessions() ([]*state.Session, error) {
 		}
 
 		// Try to get working directory and repo info
-		workDir, err := s.tmux.GetSessionWorkingDir(ts.Name)
-		if err == nil && workDir != "" {
+		workDir := ""
+		if p, ok := scan.ActivePane(ts.Name); ok {
+			workDir = p.Path
+		} else if dir, err := s.tmux.GetSessionWorkingDir(ts.Name); err == nil {
+			workDir = dir
+		}
+		if workDir != "" {
 			sess.Worktree = workDir
 
 			// Try to find repo root
-			if repoRoot, err := git.FindRepoRoot(workDir); err == nil {
+			if repoRoot, err := s.git.FindRepoRoot(workDir); err == nil {
 				sess.RepoPath = repoRoot
 				sess.RepoName = filepath.Base(repoRoot)
 
 				// Get branch
-				if branch, err := git.GetCurrentBranch(workDir); err == nil {
+				if branch, err := s.git.GetCurrentBranch(workDir); err == nil {
 					sess.Branch = branch
 				}
 
 				// Determine if worktree
-				if !git.IsWorktreePath(workDir) {
+				if !s.git.IsWorktreePath(workDir) {
 					sess.Worktree = ""
 				}
 			}
 		}
 
+		attachPanes(sess, scan.Panes[ts.Name])
+		if claude, ok := scan.ClaudePane(ts.Name); ok && claude.ClaudePID != 0 && scan.Processes != nil {
+			usage := scan.Processes.Usage(claude.ClaudePID, prev)
+			sess.ClaudePID = claude.ClaudePID
+			sess.CPU, sess.RSS = usage.CPU, usage.RSS
+		}
+
 		sessions = append(sessions, sess)
 	}
 
-	return sessions, nil
+	return sessions
+}
+
+// configuredSessions returns copies of the sessions belonging to configured
+// repositories.
+func (s *Service) configuredSessions(repos []RepositoryInfo, all []*state.Session) []*state.Session {
+	configured := make(map[string]bool)
+	for _, repo := range repos {
+		configured[repo.Path] = true
+	}
+
+	var sessions []*state.Session
+	for _, sess := range all {
+		if sess.RepoPath == "" {
+			continue
+		}
+		if absPath, err := filepath.Abs(sess.RepoPath); err != nil || !configured[absPath] {
+			continue
+		}
+		c := *sess
+		if c.Branch == "" {
+			c.Branch = "unknown"
+		}
+		sessions = append(sessions, &c)
+	}
+	return sessions
+}
+
+// attachPanes records the session's panes and which one runs Claude.
+func attachPanes(sess *state.Session, panes []tmux.Pane) {
+	sess.Panes = make([]state.PaneInfo, 0, len(panes))
+	for _, p := range panes {
+		sess.Panes = append(sess.Panes, state.PaneInfo{
+			Target:  p.Target(),
+			Command: p.Command,
+			Role:    string(p.Role),
+		})
+		// Prefer the active pane when several panes run Claude
+		if p.Role == tmux.RoleClaude && (sess.ClaudePane == "" || p.WindowActive && p.Active) {
+			sess.ClaudePane = p.Target()
+		}
+	}
 }
 
 // GetConfiguredRepositories returns all configured repository paths with their info.
@@ -158,7 +163,7 @@ func (s *Service) GetConfiguredRepositories() []RepositoryInfo {
 		}
 
 		// Verify it's a git repo
-		if _, err := git.FindRepoRoot(absPath); err != nil {
+		if _, err := s.git.FindRepoRoot(absPath); err != nil {
 			continue
 		}
 
@@ -0,0 +1,157 @@
+package discovery
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+// DefaultInterval is how often the service discovers when the configured
+// refresh interval isn't positive.
+const DefaultInterval = 2 * time.Second
+
+// Snapshot is the outcome of one discovery pass.
+type Snapshot struct {
+	Repositories []RepositoryInfo // configured repositories that exist
+	Sessions     []*state.Session // Claude sessions of configured repositories
+	All          []*state.Session // every Claude session
+	Claude       []string         // names of every Claude session, sorted
+	Live         []string         // names of every tmux session
+	Err          error            // why tmux could not be queried, if it couldn't
+	Time         time.Time
+}
+
+// clone copies the snapshot's sessions so each subscriber may change its own.
+func (snap Snapshot) clone() Snapshot {
+	copySessions := func(sessions []*state.Session) []*state.Session {
+		out := make([]*state.Session, len(sessions))
+		for i, sess := range sessions {
+			c := *sess
+			out[i] = &c
+		}
+		return out
+	}
+	snap.Sessions = copySessions(snap.Sessions)
+	snap.All = copySessions(snap.All)
+	return snap
+}
+
+// Discover runs one discovery pass over tmux and the configured
+// repositories.
+func (s *Service) Discover() Snapshot {
+	snap := Snapshot{Repositories: s.GetConfiguredRepositories(), Time: time.Now()}
+
+	scan, err := s.tmux.Scan()
+	if err != nil {
+		snap.Err = err
+		return snap
+	}
+	for _, ts := range scan.Sessions {
+		snap.Live = append(snap.Live, ts.Name)
+	}
+
+	snap.All = s.sessionsFrom(scan)
+	for _, sess := range snap.All {
+		snap.Claude = append(snap.Claude, sess.Name)
+	}
+	sort.Strings(snap.Claude)
+
+	// Sessions of configured repositories, as DiscoverSessions finds them
+	snap.Sessions = s.configuredSessions(snap.Repositories, snap.All)
+
+	return snap
+}
+
+// publisher runs discovery in the background and hands each snapshot to
+// its subscribers.
+type publisher struct {
+	mu      sync.Mutex
+	subs    []chan Snapshot
+	refresh chan struct{}
+	stop    chan struct{}
+	running bool
+}
+
+// Subscribe returns a channel receiving each snapshot the service takes.
+// A subscriber that falls behind only gets the latest one.
+func (s *Service) Subscribe() <-chan Snapshot {
+	s.pub.mu.Lock()
+	defer s.pub.mu.Unlock()
+	ch := make(chan Snapshot, 1)
+	s.pub.subs = append(s.pub.subs, ch)
+	return ch
+}
+
+// Start discovers every interval in the background until Stop is called.
+func (s *Service) Start(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	s.pub.mu.Lock()
+	if s.pub.running {
+		s.pub.mu.Unlock()
+		return
+	}
+	s.pub.running = true
+	s.pub.refresh = make(chan struct{}, 1)
+	s.pub.stop = make(chan struct{})
+	refresh, stop := s.pub.refresh, s.pub.stop
+	s.pub.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.publish(s.Discover())
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			case <-refresh:
+			}
+		}
+	}()
+}
+
+// Refresh asks for a discovery pass now rather than at the next interval.
+// It never blocks; requests made during a pass are merged.
+func (s *Service) Refresh() {
+	s.pub.mu.Lock()
+	refresh := s.pub.refresh
+	s.pub.mu.Unlock()
+	if refresh == nil {
+		return
+	}
+	select {
+	case refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Stop ends background discovery. Subscribers' channels stay open.
+func (s *Service) Stop() {
+	s.pub.mu.Lock()
+	defer s.pub.mu.Unlock()
+	if s.pub.running {
+		close(s.pub.stop)
+		s.pub.running = false
+		s.pub.refresh = nil
+	}
+}
+
+// publish hands a snapshot to every subscriber, replacing one it hasn't
+// read yet.
+func (s *Service) publish(snap Snapshot) {
+	s.pub.mu.Lock()
+	defer s.pub.mu.Unlock()
+	for _, ch := range s.pub.subs {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- snap.clone()
+	}
+}
@@ -0,0 +1,45 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+func TestPublishKeepsLatest(t *testing.T) {
+	s := &Service{}
+	ch := s.Subscribe()
+
+	s.publish(Snapshot{Claude: []string{"old"}})
+	s.publish(Snapshot{Claude: []string{"new"}})
+
+	snap := <-ch
+	if len(snap.Claude) != 1 || snap.Claude[0] != "new" {
+		t.Errorf("expected the latest snapshot, got %v", snap.Claude)
+	}
+	select {
+	case snap := <-ch:
+		t.Errorf("expected no more snapshots, got %v", snap.Claude)
+	default:
+	}
+}
+
+func TestPublishClonesSessions(t *testing.T) {
+	s := &Service{}
+	a, b := s.Subscribe(), s.Subscribe()
+
+	sess := &state.Session{Name: "project-main"}
+	s.publish(Snapshot{Sessions: []*state.Session{sess}})
+
+	snapA, snapB := <-a, <-b
+	snapA.Sessions[0].Note = "changed"
+	if snapB.Sessions[0].Note != "" || sess.Note != "" {
+		t.Error("expected each subscriber to get its own sessions")
+	}
+}
+
+func TestRefreshBeforeStart(t *testing.T) {
+	s := &Service{}
+	s.Refresh() // must not block
+	s.Stop()
+}
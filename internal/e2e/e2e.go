@@ -0,0 +1,256 @@
+// Package e2e is a harness for end-to-end tests of cmux flows. It runs them
+// against in-memory tmux and git backends: tests create repositories,
+// sessions and worktrees, and replay recorded hook events and transcripts
+// as if Claude worked in the sessions.
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/git/gittest"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/tmux/tmuxtest"
+)
+
+// Env is a test's world: a tmux server, git repositories and a config
+// listing them, all in a temporary directory that also holds hook events.
+// Client runs git commands against the repositories of Git.
+type Env struct {
+	t      testing.TB
+	Dir    string
+	Tmux   *tmuxtest.Server
+	Git    *gittest.Git
+	Client *git.Client
+	Config *config.Config
+}
+
+// New creates an Env whose hook events and config live in a temporary
+// directory until the test ends.
+func New(t testing.TB) *Env {
+	t.Helper()
+	// Paths are compared with those git reports, which are resolved
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TMPDIR", dir)
+
+	cfg := config.Default()
+	cfg.DataDir = filepath.Join(dir, "config")
+	g := gittest.New()
+	return &Env{
+		t:      t,
+		Dir:    dir,
+		Tmux:   tmuxtest.NewServer(),
+		Git:    g,
+		Client: g.Client(),
+		Config: cfg,
+	}
+}
+
+// Repo creates a repository named name with files and adds it to the
+// config. It returns the repository's path.
+func (e *Env) Repo(name string, files map[string]string) string {
+	e.t.Helper()
+	root := filepath.Join(e.Dir, "code", name)
+	if err := e.Git.Init(root, files); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := e.Config.AddRepository(root); err != nil {
+		e.t.Fatal(err)
+	}
+	return root
+}
+
+// Manager returns a session manager for the Env.
+func (e *Env) Manager() *session.Manager {
+	return session.NewManager(e.Tmux, e.Client, e.Config)
+}
+
+// Discovery returns a discovery service for the Env.
+func (e *Env) Discovery() *discovery.Service {
+	return discovery.NewService(e.Tmux, e.Client, e.Config)
+}
+
+// ReadFile returns a file of a worktree, or "" if it's missing.
+func (e *Env) ReadFile(dir, path string) string {
+	data, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+	return string(data)
+}
+
+// Hook appends a hook event to a session's event file as the hook script
+// would, stamped with the session, its Claude pane and, unless set, its
+// directory and the current time.
+func (e *Env) Hook(name string, event claude.HookEvent) {
+	e.t.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	e.appendEvent(name, data)
+}
+
+// appendEvent stamps a raw hook event like Hook and appends it.
+func (e *Env) appendEvent(name string, data []byte) {
+	e.t.Helper()
+	var event map[string]any
+	if err := json.Unmarshal(data, &event); err != nil {
+		e.t.Fatalf("hook event %s: %v", data, err)
+	}
+	dir, err := e.Tmux.GetSessionWorkingDir(name)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	event["tmux_session"] = name
+	if pane, err := e.Tmux.FindClaudePane(name); err == nil {
+		event["tmux_pane"] = pane.ID
+	}
+	if cwd, _ := event["cwd"].(string); cwd == "" {
+		event["cwd"] = dir
+	}
+	if ts, _ := event["ts"].(string); ts == "" {
+		event["ts"] = time.Now().Format(time.RFC3339)
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+
+	path := filepath.Join(claude.EventsDir(), name+".jsonl")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		e.t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+// Replay replays a recording of Claude working in a session: the
+// directory recording holds the hook events in events.jsonl and the
+// transcript in transcript.jsonl. $CWD in them stands for the session's
+// directory and $TRANSCRIPT for where the transcript is copied. The files
+// Claude wrote or edited are changed as each tool finishes. Replay returns
+// the transcript's path.
+func (e *Env) Replay(name, recording string) string {
+	e.t.Helper()
+	dir, err := e.Tmux.GetSessionWorkingDir(name)
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	transcript := filepath.Join(e.Dir, "transcripts", strings.ReplaceAll(name, "/", "-")+".jsonl")
+	expand := strings.NewReplacer("$CWD", jsonString(dir), "$TRANSCRIPT", jsonString(transcript)).Replace
+
+	data, err := os.ReadFile(filepath.Join(recording, "transcript.jsonl"))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(transcript), 0755); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.WriteFile(transcript, []byte(expand(string(data))), 0644); err != nil {
+		e.t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(recording, "events.jsonl"))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		line = []byte(expand(string(line)))
+		var event claude.HookEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			e.t.Fatalf("%s: %v", recording, err)
+		}
+		if event.EventName == "PostToolUse" {
+			e.applyTool(event)
+		}
+		e.appendEvent(name, line)
+	}
+	if err := scanner.Err(); err != nil {
+		e.t.Fatal(err)
+	}
+	return transcript
+}
+
+// applyTool makes the change a Write or Edit tool made to a file.
+func (e *Env) applyTool(event claude.HookEvent) {
+	e.t.Helper()
+	var input struct {
+		FilePath  string `json:"file_path"`
+		Content   string `json:"content"`
+		OldString string `json:"old_string"`
+		NewString string `json:"new_string"`
+	}
+	if err := json.Unmarshal(event.ToolInput, &input); err != nil {
+		return
+	}
+
+	var content string
+	switch event.ToolName {
+	case "Write":
+		content = input.Content
+	case "Edit":
+		data, err := os.ReadFile(input.FilePath)
+		if err != nil {
+			e.t.Fatalf("replaying Edit: %v", err)
+		}
+		if !bytes.Contains(data, []byte(input.OldString)) {
+			e.t.Fatalf("replaying Edit: %s has no %q", input.FilePath, input.OldString)
+		}
+		content = strings.Replace(string(data), input.OldString, input.NewString, 1)
+	default:
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(input.FilePath), 0755); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.WriteFile(input.FilePath, []byte(content), 0644); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+// jsonString returns s as it appears inside a JSON string.
+func jsonString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data[1 : len(data)-1])
+}
+
+// View returns a view of a session built from its hook events and
+// transcript so far, as cmux shows it.
+func (e *Env) View(name string) *claude.View {
+	e.t.Helper()
+	view := claude.NewView(name, 120, 40)
+	events, err := claude.NewEventReader(filepath.Join(claude.EventsDir(), name+".jsonl")).Poll()
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	for _, event := range events {
+		view.UpdateFromHookEvent(event)
+	}
+	if err := view.PollTranscript(); err != nil {
+		e.t.Fatal(err)
+	}
+	return view
+}
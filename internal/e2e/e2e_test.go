@@ -0,0 +1,289 @@
+package e2e
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/cleanup"
+	"github.com/abdullathedruid/cmux/internal/session"
+)
+
+// greeting is a repository whose greeting has the typo testdata/fix-typo
+// fixes.
+var greeting = map[string]string{
+	"go.mod":  "module example.com/greeting\n",
+	"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"Helo, world\")\n}\n",
+}
+
+// fixTypo creates a session on a new branch of a greeting repository,
+// prompts it and replays Claude fixing the typo. It returns the repository
+// and the session.
+func fixTypo(t *testing.T, e *Env) (repo, name string) {
+	t.Helper()
+	repo = e.Repo("greeting", greeting)
+	name, err := e.Manager().CreateSession(repo, "fix-typo", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.SubmitPrompt(e.Tmux, name, "Fix the typo in the greeting"); err != nil {
+		t.Fatal(err)
+	}
+	e.Replay(name, filepath.Join("testdata", "fix-typo"))
+	return repo, name
+}
+
+// messages returns the prompts and the last reply of a session's view, as
+// drafting a commit message does.
+func messages(view *claude.View) (prompts []string, summary string) {
+	for _, msg := range view.Messages() {
+		switch {
+		case msg.Role == "user":
+			prompts = append(prompts, msg.Content)
+		case msg.TextPreview != "":
+			summary = msg.TextPreview
+		}
+	}
+	return prompts, summary
+}
+
+// neverActive is a cleanup filter treating no worktree as in use.
+func neverActive(string) bool { return false }
+
+func TestCreateWorkCleanup(t *testing.T) {
+	e := New(t)
+	repo, name := fixTypo(t, e)
+	worktree := filepath.Join(repo, ".worktrees", "fix-typo")
+
+	// Create: the session runs Claude in a new worktree
+	if name != "greeting/fix-typo" {
+		t.Errorf("session = %q, want greeting/fix-typo", name)
+	}
+	if opts := e.Tmux.Options(name); opts.Command != "claude" {
+		t.Errorf("session runs %q, want claude", opts.Command)
+	}
+	if input := e.Tmux.Input(name); !slices.Equal(input, []string{"Fix the typo in the greeting"}) {
+		t.Errorf("typed into session: %q", input)
+	}
+	sessions, err := e.Discovery().DiscoverSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("discovered %d sessions, want 1", len(sessions))
+	}
+	if s := sessions[0]; s.Name != name || s.RepoPath != repo || s.Branch != "fix-typo" || s.Worktree != worktree || s.ClaudePane == "" {
+		t.Errorf("discovered %+v", s)
+	}
+
+	// Work: Claude fixed the typo and went idle
+	view := e.View(name)
+	if status := view.Session().Status; status != claude.StatusIdle {
+		t.Errorf("status = %s, want idle", status)
+	}
+	if got := e.ReadFile(worktree, "main.go"); !strings.Contains(got, "Hello, world") {
+		t.Errorf("main.go = %q, want the typo fixed", got)
+	}
+	if got := e.ReadFile(repo, "main.go"); !strings.Contains(got, "Helo, world") {
+		t.Errorf("main checkout's main.go = %q, want it untouched", got)
+	}
+	changes, err := e.Client.Status(worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Path != "main.go" {
+		t.Errorf("changes = %+v, want main.go", changes)
+	}
+
+	manager := e.Manager()
+	info, err := manager.GetSessionInfo(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Commit(info, session.DraftCommitMessage(messages(view))); err != nil {
+		t.Fatal(err)
+	}
+	subject, body, err := e.Client.LastCommitMessage(worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Fix the typo in the greeting" || !strings.Contains(body, "Hello, world") {
+		t.Errorf("commit = %q, %q", subject, body)
+	}
+
+	// Cleanup: nothing goes before the branch is merged
+	reports := cleanup.Scan(e.Client, e.Config, []string{repo}, neverActive)
+	if len(reports) != 1 {
+		t.Fatalf("cleanup found %d worktrees, want 1", len(reports))
+	}
+	if r := reports[0]; r.Merged || r.Dirty || r.Unpushed != 1 || !r.SafePlan().Empty() {
+		t.Errorf("before merging: %v, plan %s", r.Labels(), r.SafePlan())
+	}
+
+	if _, err := e.Git.Merge(repo, "fix-typo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.DeleteSession(name, false); err != nil {
+		t.Fatal(err)
+	}
+	reports = cleanup.Scan(e.Client, e.Config, []string{repo}, neverActive)
+	if len(reports) != 1 {
+		t.Fatalf("cleanup found %d worktrees, want 1", len(reports))
+	}
+	r := reports[0]
+	plan := r.SafePlan()
+	if !r.Merged || plan.String() != "remove, delete branch" {
+		t.Fatalf("after merging: %v, plan %s", r.Labels(), plan)
+	}
+	if err := cleanup.Apply(e.Client, r, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Tmux.HasSession(name) {
+		t.Error("session still running")
+	}
+	if _, err := os.Stat(worktree); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("worktree left behind: %v", err)
+	}
+	if branches, _ := e.Client.ListBranches(repo); !slices.Equal(branches, []string{"main"}) {
+		t.Errorf("branches = %v, want [main]", branches)
+	}
+	if got := e.ReadFile(repo, "main.go"); !strings.Contains(got, "Hello, world") {
+		t.Errorf("main.go on main = %q, want the fix merged", got)
+	}
+}
+
+func TestCleanupStashesSquashMergedWork(t *testing.T) {
+	e := New(t)
+	repo, name := fixTypo(t, e)
+	worktree := filepath.Join(repo, ".worktrees", "fix-typo")
+
+	if _, err := e.Git.Commit(worktree, "Fix the typo in the greeting\n", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Git.Squash(repo, "fix-typo"); err != nil {
+		t.Fatal(err)
+	}
+	// Claude left notes behind after the pull request was merged
+	e.Hook(name, claude.HookEvent{EventName: "UserPromptSubmit", Prompt: "Write down what's left"})
+	os.WriteFile(filepath.Join(worktree, "NOTES.md"), []byte("- add a test\n"), 0644)
+	e.Hook(name, claude.HookEvent{EventName: "Stop"})
+	if err := e.Manager().DeleteSession(name, false); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := cleanup.Scan(e.Client, e.Config, []string{repo}, neverActive)
+	if len(reports) != 1 {
+		t.Fatalf("cleanup found %d worktrees, want 1", len(reports))
+	}
+	r := reports[0]
+	plan := r.SafePlan()
+	if !r.SquashMerged || !r.Dirty || r.Unpushed != 0 || plan.String() != "stash, remove, delete branch" {
+		t.Fatalf("squash-merged: %v, plan %s", r.Labels(), plan)
+	}
+	if err := cleanup.Apply(e.Client, r, plan); err != nil {
+		t.Fatal(err)
+	}
+	if stashes := e.Git.Stashes(repo); !slices.Equal(stashes, []string{"On fix-typo: cmux cleanup: fix-typo"}) {
+		t.Errorf("stashes = %q", stashes)
+	}
+	if _, err := os.Stat(worktree); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("worktree left behind: %v", err)
+	}
+}
+
+func TestDeleteSessionRemovesWorktree(t *testing.T) {
+	e := New(t)
+	repo := e.Repo("greeting", greeting)
+	manager := e.Manager()
+
+	main, err := manager.CreateSession(repo, "main", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := manager.CreateSession(repo, "feature/login", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree := filepath.Join(repo, ".worktrees", "feature-login")
+	if dir, _ := e.Tmux.GetSessionWorkingDir(name); dir != worktree {
+		t.Errorf("session works in %s, want %s", dir, worktree)
+	}
+	if dir, _ := e.Tmux.GetSessionWorkingDir(main); dir != repo {
+		t.Errorf("main session works in %s, want %s", dir, repo)
+	}
+
+	if err := manager.DeleteSession(name, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.DeleteSession(main, true); err != nil {
+		t.Fatal(err)
+	}
+	if e.Tmux.HasSession(name) || e.Tmux.HasSession(main) {
+		t.Error("sessions still running")
+	}
+	worktrees, err := e.Client.ListWorktrees(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worktrees) != 1 || !worktrees[0].IsMain {
+		t.Errorf("worktrees = %+v, want only the main checkout", worktrees)
+	}
+	if e.ReadFile(repo, "main.go") == "" {
+		t.Error("deleting the main branch session removed the repository")
+	}
+}
+
+func TestDeleteSessionRemovesItsOwnWorktree(t *testing.T) {
+	e := New(t)
+	repo := e.Repo("greeting", greeting)
+	manager := e.Manager()
+
+	name, err := manager.CreateSession(repo, "feature/login", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.CreateSession(repo, "fix-typo", true); err != nil {
+		t.Fatal(err)
+	}
+	// The user went to look at the other branch from this session
+	other := filepath.Join(repo, ".worktrees", "fix-typo")
+	if err := e.Tmux.Chdir(name, other); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := manager.DeleteSession(name, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("removed the worktree the session had moved to: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repo, ".worktrees", "feature-login")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("session's own worktree left behind: %v", err)
+	}
+}
+
+func TestCleanupKeepsNewWorktree(t *testing.T) {
+	e := New(t)
+	repo := e.Repo("greeting", greeting)
+	name, err := e.Manager().CreateSession(repo, "fix-typo", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Manager().DeleteSession(name, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The branch is where main is, like a merged one, but has nothing merged
+	reports := cleanup.Scan(e.Client, e.Config, []string{repo}, neverActive)
+	if len(reports) != 1 {
+		t.Fatalf("cleanup found %d worktrees, want 1", len(reports))
+	}
+	if r := reports[0]; r.Merged || r.SquashMerged || !r.Empty || !r.SafePlan().Empty() {
+		t.Errorf("new worktree: %v, plan %s", r.Labels(), r.SafePlan())
+	}
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// IsAncestor reports whether commit is reachable from base, i.e. merged
+// into it by a regular merge or fast-forward.
+func (c *Client) IsAncestor(repoPath, commit, base string) (bool, error) {
+	out, err := c.gitOutput(repoPath, "rev-list", "--count", "--max-count=1", commit, "^"+base)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "0", nil
+}
+
+// IsSquashMerged reports whether the changes of branch reached base without
+// its commits, as a squash merge or rebase does. The branch's changes since
+// the merge base are matched against base's commits by patch id; failing
+// that, every file the branch changed must have the branch's content on
+// base. Nothing is written to the repository.
+func (c *Client) IsSquashMerged(repoPath, branch, base string) (bool, error) {
+	out, err := c.gitOutput(repoPath, "merge-base", base, branch)
+	if err != nil {
+		return false, err
+	}
+	mergeBase := strings.TrimSpace(out)
+
+	out, err = c.gitOutput(repoPath, "diff", "--name-only", "--no-renames", "-z", mergeBase, branch)
+	if err != nil {
+		return false, err
+	}
+	if out == "" {
+		return false, nil // nothing to merge
+	}
+	files := strings.Split(strings.TrimRight(out, "\x00"), "\x00")
+
+	squashed, err := c.patchIDs(repoPath, "diff", "--no-color", "--no-ext-diff", "--no-renames", mergeBase, branch)
+	if err != nil {
+		return false, err
+	}
+	landed, err := c.patchIDs(repoPath, "log", "-p", "--no-merges", "--no-color", "--no-ext-diff", "--no-renames", "--format=commit %H", mergeBase+".."+base)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range squashed {
+		if slices.Contains(landed, id) {
+			return true, nil
+		}
+	}
+
+	args := []string{"diff", "--name-only", base, branch, "--"}
+	for _, f := range files {
+		args = append(args, ":(literal)"+f)
+	}
+	out, err = c.gitOutput(repoPath, args...)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(out) == "", nil
+}
+
+// patchIDs returns the stable patch ids of the patches a git command prints.
+func (c *Client) patchIDs(repoPath string, args ...string) ([]string, error) {
+	patches, err := c.gitOutput(repoPath, args...)
+	if err != nil || patches == "" {
+		return nil, err
+	}
+	stdout, stderr, err := c.run(repoPath, patches, "patch-id", "--stable")
+	if err != nil {
+		return nil, fmt.Errorf("git patch-id: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return parsePatchIDs(stdout), nil
+}
+
+// parsePatchIDs parses git patch-id output, a patch id and a commit per line.
+func parsePatchIDs(output string) []string {
+	var ids []string
+	for _, line := range strings.Split(output, "\n") {
+		if id, _, ok := strings.Cut(line, " "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IsFirstParent reports whether commit is on the first-parent history of
+// base, base's own line of work. A branch that was created from base and
+// got no commits of its own points there, as does one fast-forwarded into
+// base, whereas a branch merged with a merge commit doesn't.
+func (c *Client) IsFirstParent(repoPath, commit, base string) (bool, error) {
+	id, err := c.RevParse(repoPath, commit)
+	if err != nil {
+		return false, err
+	}
+	// Only base's commits since commit's parents are walked
+	out, err := c.gitOutput(repoPath, "rev-list", "--first-parent", base, "--not", id+"^@")
+	if err != nil {
+		return false, err
+	}
+	return slices.Contains(strings.Fields(out), id), nil
+}
+
+// CountUnpushed returns how many commits of rev are on no remote branch
+// and not on base.
+func (c *Client) CountUnpushed(repoPath, rev, base string) (int, error) {
+	out, err := c.gitOutput(repoPath, "rev-list", "--count", rev, "--not", "--remotes", base)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(out))
+}
+
+// StashAll stashes the modified and untracked files of a worktree. Stashes
+// are shared by all worktrees of a repository, so they outlive it.
+func (c *Client) StashAll(worktreePath, message string) error {
+	_, err := c.gitOutput(worktreePath, "stash", "push", "--include-untracked", "-m", message)
+	return err
+}
+
+// DeleteBranch deletes a local branch whether or not git considers it
+// merged; callers check that first.
+func (c *Client) DeleteBranch(repoPath, branch string) error {
+	_, err := c.gitOutput(repoPath, "branch", "-D", branch)
+	return err
+}
+
+// PruneWorktrees removes the metadata of worktrees whose directories are gone.
+func (c *Client) PruneWorktrees(repoPath string) error {
+	_, err := c.gitOutput(repoPath, "worktree", "prune")
+	return err
+}
@@ -0,0 +1,18 @@
+package git
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParsePatchIDs(t *testing.T) {
+	output := "f7f1a8c4e9b1d8b0a1c5e6f1a2b3c4d5e6f7a8b9 30b8ff0ca9c683f8e6ca0473a23afc8daa67b9d8\n" +
+		"0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d 0000000000000000000000000000000000000000\n"
+	want := []string{"f7f1a8c4e9b1d8b0a1c5e6f1a2b3c4d5e6f7a8b9", "0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d"}
+	if got := parsePatchIDs(output); !slices.Equal(got, want) {
+		t.Errorf("parsePatchIDs() = %q, want %q", got, want)
+	}
+	if got := parsePatchIDs(""); got != nil {
+		t.Errorf("parsePatchIDs(\"\") = %q, want nil", got)
+	}
+}
@@ -0,0 +1,35 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CommitAll stages every change in dir, including untracked files, and
+// commits them with message.
+func (c *Client) CommitAll(dir, message string) error {
+	if _, err := c.gitOutput(dir, "add", "-A"); err != nil {
+		return err
+	}
+
+	if _, stderr, err := c.run(dir, message, "commit", "-F", "-"); err != nil {
+		return fmt.Errorf("git commit: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// Push pushes branch to origin and sets it as the upstream.
+func (c *Client) Push(dir, branch string) error {
+	_, err := c.gitOutput(dir, "push", "--set-upstream", "origin", branch)
+	return err
+}
+
+// LastCommitMessage returns the subject and body of HEAD's commit message.
+func (c *Client) LastCommitMessage(dir string) (subject, body string, err error) {
+	out, err := c.gitOutput(dir, "log", "-1", "--format=%B")
+	if err != nil {
+		return "", "", err
+	}
+	subject, body, _ = strings.Cut(strings.TrimSpace(out), "\n")
+	return subject, strings.TrimSpace(body), nil
+}
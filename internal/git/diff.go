@@ -0,0 +1,316 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hexops/gotextdiff"
+	"github.com/hexops/gotextdiff/myers"
+	"github.com/hexops/gotextdiff/span"
+)
+
+// maxUntrackedDiffSize is the largest untracked file shown as a diff.
+const maxUntrackedDiffSize = 1 << 20
+
+// FileChange is one entry of git status.
+type FileChange struct {
+	Path     string // path relative to the worktree root
+	OrigPath string // source path of a rename or copy
+	Index    byte   // staged status: ' ', 'M', 'A', 'D', 'R', 'C', 'U' or '?'
+	Worktree byte   // unstaged status, same letters
+}
+
+// Untracked reports whether the file is not known to git.
+func (c FileChange) Untracked() bool {
+	return c.Index == '?'
+}
+
+// Staged reports whether the file has changes in the index.
+func (c FileChange) Staged() bool {
+	return c.Index != ' ' && c.Index != '?'
+}
+
+// Unstaged reports whether the file has changes not yet staged.
+func (c FileChange) Unstaged() bool {
+	return c.Worktree != ' ' && c.Worktree != '?'
+}
+
+// Hunk is a single @@ block of a unified diff.
+type Hunk struct {
+	Header   string // the full @@ line
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []string // body lines, each starting with ' ', '+', '-' or '\'
+}
+
+// FileDiff is the unified diff of one file.
+type FileDiff struct {
+	Path    string   // new path (old path for deletions)
+	OldPath string   // old path, differs from Path for renames
+	Header  []string // "diff --git" line through "+++"
+	Hunks   []Hunk
+	Binary  bool // binary or too large to show
+}
+
+// HunkPatch returns a patch containing only hunk i, suitable for git apply.
+func (f FileDiff) HunkPatch(i int) string {
+	var b strings.Builder
+	for _, line := range f.Header {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	h := f.Hunks[i]
+	b.WriteString(h.Header)
+	b.WriteByte('\n')
+	for _, line := range h.Lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// gitOutput runs git in dir and returns its stdout.
+func (c *Client) gitOutput(dir string, args ...string) (string, error) {
+	stdout, stderr, err := c.run(dir, "", args...)
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr))
+	}
+	return stdout, nil
+}
+
+// TopLevel returns the root of the worktree containing path.
+func (c *Client) TopLevel(path string) (string, error) {
+	out, err := c.gitOutput(path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// Status returns the changed, staged and untracked files of a worktree.
+func (c *Client) Status(dir string) ([]FileChange, error) {
+	out, err := c.gitOutput(dir, "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(out), nil
+}
+
+// parseStatus parses NUL-separated git status --porcelain=v1 -z output.
+func parseStatus(output string) []FileChange {
+	var changes []FileChange
+
+	entries := strings.Split(output, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		c := FileChange{Index: entry[0], Worktree: entry[1], Path: entry[3:]}
+
+		// Renames and copies are followed by their source path
+		if (c.Index == 'R' || c.Index == 'C') && i+1 < len(entries) {
+			c.OrigPath = entries[i+1]
+			i++
+		}
+		changes = append(changes, c)
+	}
+
+	return changes
+}
+
+// DiffWorktree returns the unstaged changes of dir, or the staged ones.
+func (c *Client) DiffWorktree(dir string, staged bool) ([]FileDiff, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		args = append(args, "--cached")
+	}
+	out, err := c.gitOutput(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiff(out), nil
+}
+
+// DiffAgainst returns the changes in dir's working tree relative to ref.
+func (c *Client) DiffAgainst(dir, ref string) ([]FileDiff, error) {
+	out, err := c.gitOutput(dir, "diff", "--no-color", "--no-ext-diff", ref)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiff(out), nil
+}
+
+// MergeBase returns the merge base of HEAD and branch, trying the local
+// branch first and then origin/branch.
+func (c *Client) MergeBase(dir, branch string) (string, error) {
+	var lastErr error
+	for _, ref := range []string{branch, "origin/" + branch} {
+		out, err := c.gitOutput(dir, "merge-base", "HEAD", ref)
+		if err == nil {
+			return strings.TrimSpace(out), nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+// ParseDiff parses git diff output into per-file diffs.
+func ParseDiff(output string) []FileDiff {
+	var diffs []FileDiff
+	var current *FileDiff
+	var hunk *Hunk
+
+	flushHunk := func() {
+		if current != nil && hunk != nil {
+			current.Hunks = append(current.Hunks, *hunk)
+		}
+		hunk = nil
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			diffs = append(diffs, *current)
+		}
+		current = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			current = &FileDiff{Header: []string{line}}
+			if a, b, ok := strings.Cut(strings.TrimPrefix(line, "diff --git "), " b/"); ok {
+				current.OldPath = strings.TrimPrefix(a, "a/")
+				current.Path = b
+			}
+
+		case current == nil:
+			continue
+
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			hunk = parseHunkHeader(line)
+
+		case hunk != nil:
+			if line == "" {
+				// Trailing newline of the output; blank context lines start with ' '
+				continue
+			}
+			hunk.Lines = append(hunk.Lines, line)
+
+		default:
+			current.Header = append(current.Header, line)
+			switch {
+			case strings.HasPrefix(line, "--- a/"):
+				current.OldPath = strings.TrimPrefix(line, "--- a/")
+			case strings.HasPrefix(line, "+++ b/"):
+				current.Path = strings.TrimPrefix(line, "+++ b/")
+			case line == "+++ /dev/null":
+				current.Path = current.OldPath
+			case strings.HasPrefix(line, "Binary files "):
+				current.Binary = true
+			}
+		}
+	}
+	flushFile()
+
+	return diffs
+}
+
+// parseHunkHeader parses "@@ -a,b +c,d @@ context".
+func parseHunkHeader(line string) *Hunk {
+	h := &Hunk{Header: line, OldLines: 1, NewLines: 1}
+
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return h
+	}
+	parseRange := func(s string, start, count *int) {
+		first, second, hasCount := strings.Cut(s[1:], ",")
+		*start, _ = strconv.Atoi(first)
+		if hasCount {
+			*count, _ = strconv.Atoi(second)
+		}
+	}
+	parseRange(fields[1], &h.OldStart, &h.OldLines)
+	parseRange(fields[2], &h.NewStart, &h.NewLines)
+	return h
+}
+
+// UntrackedDiff returns a new-file diff for an untracked file, computed
+// with gotextdiff since git diff ignores untracked files.
+func UntrackedDiff(root, path string) (FileDiff, error) {
+	f := FileDiff{
+		Path:    path,
+		OldPath: path,
+		Header: []string{
+			fmt.Sprintf("diff --git a/%s b/%s", path, path),
+			"new file mode 100644",
+			"--- /dev/null",
+			"+++ b/" + path,
+		},
+	}
+
+	info, err := os.Stat(filepath.Join(root, path))
+	if err != nil {
+		return f, err
+	}
+	if info.Size() > maxUntrackedDiffSize {
+		f.Binary = true
+		return f, nil
+	}
+	data, err := os.ReadFile(filepath.Join(root, path))
+	if err != nil {
+		return f, err
+	}
+	if bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0 {
+		f.Binary = true
+		return f, nil
+	}
+
+	content := string(data)
+	edits := myers.ComputeEdits(span.URIFromPath(path), "", content)
+	unified := gotextdiff.ToUnified("/dev/null", "b/"+path, "", edits)
+
+	for _, uh := range unified.Hunks {
+		h := Hunk{NewStart: uh.ToLine}
+		for _, l := range uh.Lines {
+			text := strings.TrimSuffix(l.Content, "\n")
+			h.Lines = append(h.Lines, "+"+text)
+			h.NewLines++
+			if !strings.HasSuffix(l.Content, "\n") {
+				h.Lines = append(h.Lines, `\ No newline at end of file`)
+			}
+		}
+		h.Header = fmt.Sprintf("@@ -0,0 +%d,%d @@", h.NewStart, h.NewLines)
+		f.Hunks = append(f.Hunks, h)
+	}
+
+	return f, nil
+}
+
+// ApplyPatch applies a patch to the index (cached) or the working tree,
+// optionally in reverse. Reverse-applying to the working tree discards the
+// patch's changes.
+func (c *Client) ApplyPatch(root, patch string, cached, reverse bool) error {
+	args := []string{"apply", "--whitespace=nowarn"}
+	if cached {
+		args = append(args, "--cached")
+	}
+	if reverse {
+		args = append(args, "-R")
+	}
+	args = append(args, "-")
+
+	if _, stderr, err := c.run(root, patch, args...); err != nil {
+		return fmt.Errorf("git apply: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	output := "M  staged.go\x00 M unstaged.go\x00MM both.go\x00R  new.go\x00old.go\x00?? notes/todo.md\x00"
+
+	got := parseStatus(output)
+	want := []FileChange{
+		{Path: "staged.go", Index: 'M', Worktree: ' '},
+		{Path: "unstaged.go", Index: ' ', Worktree: 'M'},
+		{Path: "both.go", Index: 'M', Worktree: 'M'},
+		{Path: "new.go", OrigPath: "old.go", Index: 'R', Worktree: ' '},
+		{Path: "notes/todo.md", Index: '?', Worktree: '?'},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseStatus() = %+v, want %+v", got, want)
+	}
+
+	if !got[2].Staged() || !got[2].Unstaged() || got[2].Untracked() {
+		t.Errorf("both.go flags wrong: %+v", got[2])
+	}
+	if got[4].Staged() || got[4].Unstaged() || !got[4].Untracked() {
+		t.Errorf("untracked flags wrong: %+v", got[4])
+	}
+}
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -1,4 +1,4 @@ package main
+ import "fmt"
+-func old() {}
++func renamed() {}
+ 
+@@ -10 +10,2 @@ func main() {
+ 	fmt.Println("hi")
++	fmt.Println("there")
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 3b18e51..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+\ No newline at end of file
+diff --git a/logo.png b/logo.png
+index 1111111..2222222 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	diffs := ParseDiff(sampleDiff)
+	if len(diffs) != 3 {
+		t.Fatalf("expected 3 file diffs, got %d", len(diffs))
+	}
+
+	main := diffs[0]
+	if main.Path != "main.go" || len(main.Hunks) != 2 || len(main.Header) != 4 {
+		t.Fatalf("unexpected main.go diff: %+v", main)
+	}
+	h := main.Hunks[1]
+	if h.OldStart != 10 || h.OldLines != 1 || h.NewStart != 10 || h.NewLines != 2 {
+		t.Errorf("unexpected hunk range: %+v", h)
+	}
+	if len(main.Hunks[0].Lines) != 4 || main.Hunks[0].Lines[3] != " " {
+		t.Errorf("blank context line lost: %q", main.Hunks[0].Lines)
+	}
+
+	gone := diffs[1]
+	if gone.Path != "gone.txt" || len(gone.Hunks) != 1 || len(gone.Hunks[0].Lines) != 2 {
+		t.Errorf("unexpected deletion diff: %+v", gone)
+	}
+
+	if !diffs[2].Binary || len(diffs[2].Hunks) != 0 {
+		t.Errorf("expected binary diff, got %+v", diffs[2])
+	}
+}
+
+func TestHunkPatch(t *testing.T) {
+	diffs := ParseDiff(sampleDiff)
+	patch := diffs[0].HunkPatch(1)
+
+	want := `diff --git a/main.go b/main.go
+index 83db48f..bf269f4 100644
+--- a/main.go
++++ b/main.go
+@@ -10 +10,2 @@ func main() {
+ 	fmt.Println("hi")
++	fmt.Println("there")
+`
+	if patch != want {
+		t.Errorf("HunkPatch() =\n%s\nwant\n%s", patch, want)
+	}
+}
+
+func TestUntrackedDiff(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "new.txt"), []byte("one\ntwo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := UntrackedDiff(root, "new.txt")
+	if err != nil {
+		t.Fatalf("UntrackedDiff() error = %v", err)
+	}
+	if len(f.Hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(f.Hunks))
+	}
+	h := f.Hunks[0]
+	if h.Header != "@@ -0,0 +1,2 @@" {
+		t.Errorf("hunk header = %q", h.Header)
+	}
+	want := []string{"+one", "+two", `\ No newline at end of file`}
+	if !reflect.DeepEqual(h.Lines, want) {
+		t.Errorf("hunk lines = %q, want %q", h.Lines, want)
+	}
+	if !strings.Contains(strings.Join(f.Header, "\n"), "--- /dev/null") {
+		t.Errorf("header should describe a new file: %q", f.Header)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "blob.bin"), []byte{0x89, 0, 1, 2}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if f, err := UntrackedDiff(root, "blob.bin"); err != nil || !f.Binary {
+		t.Errorf("UntrackedDiff(binary) = %+v, %v; want Binary", f, err)
+	}
+}
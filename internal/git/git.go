@@ -1,11 +1,10 @@
-// Package git provides git and worktree operations.
+// Package git provides git and worktree operations. A Client runs them
+// with a Runner; the package-level functions use Default, the git binary.
 package git
 
 import (
-	"bytes"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -28,7 +27,7 @@ type Worktree struct {
 
 // FindRepoRoot finds the main git repository root from the given path.
 // For worktrees, this returns the main repository root, not the worktree path.
-func FindRepoRoot(path string) (string, error) {
+func (c *Client) FindRepoRoot(path string) (string, error) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return "", fmt.Errorf("getting absolute path: %w", err)
@@ -36,16 +35,12 @@ func FindRepoRoot(path string) (string, error) {
 
 	// Use --git-common-dir to get the common .git directory
 	// This returns the main repo's .git dir even when in a worktree
-	cmd := exec.Command("git", "-C", absPath, "rev-parse", "--git-common-dir")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	stdout, _, err := c.run(absPath, "", "rev-parse", "--git-common-dir")
+	if err != nil {
 		return "", fmt.Errorf("not a git repository: %s", absPath)
 	}
 
-	gitDir := strings.TrimSpace(stdout.String())
+	gitDir := strings.TrimSpace(stdout)
 
 	// If gitDir is relative (like ".git"), we need to make it absolute
 	if !filepath.IsAbs(gitDir) {
@@ -62,14 +57,14 @@ func FindRepoRoot(path string) (string, error) {
 }
 
 // GetRepoInfo returns information about the repository containing the given path.
-func GetRepoInfo(path string) (*RepoInfo, error) {
-	root, err := FindRepoRoot(path)
+func (c *Client) GetRepoInfo(path string) (*RepoInfo, error) {
+	root, err := c.FindRepoRoot(path)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get branch from the original path (not root) to get the correct branch for worktrees
-	branch, err := GetCurrentBranch(path)
+	branch, err := c.GetCurrentBranch(path)
 	if err != nil {
 		branch = "unknown"
 	}
@@ -82,21 +77,17 @@ func GetRepoInfo(path string) (*RepoInfo, error) {
 }
 
 // GetCurrentBranch returns the current branch name for the given path.
-func GetCurrentBranch(path string) (string, error) {
-	cmd := exec.Command("git", "-C", path, "branch", "--show-current")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
+func (c *Client) GetCurrentBranch(path string) (string, error) {
+	stdout, _, err := c.run(path, "", "branch", "--show-current")
+	if err != nil {
 		// Detached HEAD
-		cmd = exec.Command("git", "-C", path, "rev-parse", "--short", "HEAD")
-		cmd.Stdout = &stdout
-		if err := cmd.Run(); err != nil {
+		stdout, _, err = c.run(path, "", "rev-parse", "--short", "HEAD")
+		if err != nil {
 			return "", err
 		}
 	}
 
-	branch := strings.TrimSpace(stdout.String())
+	branch := strings.TrimSpace(stdout)
 	if branch == "" {
 		return "HEAD", nil
 	}
@@ -104,17 +95,13 @@ func GetCurrentBranch(path string) (string, error) {
 }
 
 // ListWorktrees returns all worktrees for the repository at the given path.
-func ListWorktrees(repoPath string) ([]Worktree, error) {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "list", "--porcelain")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git worktree list: %w: %s", err, stderr.String())
+func (c *Client) ListWorktrees(repoPath string) ([]Worktree, error) {
+	stdout, stderr, err := c.run(repoPath, "", "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("git worktree list: %w: %s", err, stderr)
 	}
 
-	return parseWorktrees(stdout.String()), nil
+	return parseWorktrees(stdout), nil
 }
 
 // parseWorktrees parses git worktree list --porcelain output.
@@ -152,47 +139,37 @@ func parseWorktrees(output string) []Worktree {
 	return worktrees
 }
 
-// CreateWorktree creates a new worktree in the repository's .worktrees directory.
-func CreateWorktree(repoPath, branchName string, createBranch bool) (string, error) {
-	worktreeDir := filepath.Join(repoPath, ".worktrees")
-
-	// Ensure .worktrees directory exists
-	if err := os.MkdirAll(worktreeDir, 0755); err != nil {
-		return "", fmt.Errorf("creating worktrees dir: %w", err)
+// CreateWorktreeAt creates a worktree for branchName at worktreePath,
+// creating parent directories as needed. A new branch starts at startPoint,
+// or at HEAD when startPoint is empty.
+func (c *Client) CreateWorktreeAt(repoPath, worktreePath, branchName string, createBranch bool, startPoint string) error {
+	// Ensure the parent directory exists
+	if err := os.MkdirAll(filepath.Dir(worktreePath), 0755); err != nil {
+		return fmt.Errorf("creating worktrees dir: %w", err)
 	}
 
-	// Sanitize branch name for directory
-	dirName := sanitizeBranchName(branchName)
-	worktreePath := filepath.Join(worktreeDir, dirName)
-
-	args := []string{"-C", repoPath, "worktree", "add"}
+	args := []string{"worktree", "add"}
 	if createBranch {
 		args = append(args, "-b", branchName)
 	}
 	args = append(args, worktreePath)
 	if !createBranch {
 		args = append(args, branchName)
+	} else if startPoint != "" {
+		args = append(args, startPoint)
 	}
 
-	cmd := exec.Command("git", args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("git worktree add: %w: %s", err, stderr.String())
+	if _, stderr, err := c.run(repoPath, "", args...); err != nil {
+		return fmt.Errorf("git worktree add: %w: %s", err, stderr)
 	}
 
-	return worktreePath, nil
+	return nil
 }
 
 // RemoveWorktree removes a worktree.
-func RemoveWorktree(repoPath, worktreePath string) error {
-	cmd := exec.Command("git", "-C", repoPath, "worktree", "remove", worktreePath)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git worktree remove: %w: %s", err, stderr.String())
+func (c *Client) RemoveWorktree(repoPath, worktreePath string) error {
+	if _, stderr, err := c.run(repoPath, "", "worktree", "remove", worktreePath); err != nil {
+		return fmt.Errorf("git worktree remove: %w: %s", err, stderr)
 	}
 	return nil
 }
@@ -224,44 +201,22 @@ func ShortenPath(path string) string {
 	return path
 }
 
-// IsWorktreePath checks if the given path is inside a .worktrees directory.
-func IsWorktreePath(path string) bool {
-	return strings.Contains(path, "/.worktrees/")
-}
-
-// ExtractWorktreeName extracts the worktree name from a path.
-func ExtractWorktreeName(path string) string {
-	if !IsWorktreePath(path) {
-		return ""
-	}
-
-	// Find .worktrees/ and get the next component
-	parts := strings.Split(path, "/.worktrees/")
-	if len(parts) < 2 {
-		return ""
-	}
-
-	// Get first component after .worktrees/
-	remaining := parts[1]
-	if idx := strings.Index(remaining, "/"); idx != -1 {
-		return remaining[:idx]
-	}
-	return remaining
+// IsWorktreePath reports whether path is inside a linked worktree, as
+// opposed to a repository's main checkout, according to git worktree list.
+func (c *Client) IsWorktreePath(path string) bool {
+	wt, ok := c.FindWorktree(path)
+	return ok && !wt.IsMain
 }
 
 // ListBranches returns all local branches for the repository.
-func ListBranches(repoPath string) ([]string, error) {
-	cmd := exec.Command("git", "-C", repoPath, "branch", "--format=%(refname:short)")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git branch: %w: %s", err, stderr.String())
+func (c *Client) ListBranches(repoPath string) ([]string, error) {
+	stdout, stderr, err := c.run(repoPath, "", "branch", "--format=%(refname:short)")
+	if err != nil {
+		return nil, fmt.Errorf("git branch: %w: %s", err, stderr)
 	}
 
 	var branches []string
-	for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
 		if line != "" {
 			branches = append(branches, line)
 		}
@@ -271,14 +226,10 @@ func ListBranches(repoPath string) ([]string, error) {
 
 // GetMainBranch returns the main branch name for the repository.
 // It first tries to get it from the remote HEAD, then falls back to main/master.
-func GetMainBranch(repoPath string) string {
+func (c *Client) GetMainBranch(repoPath string) string {
 	// Try to get the default branch from remote HEAD
-	cmd := exec.Command("git", "-C", repoPath, "symbolic-ref", "refs/remotes/origin/HEAD")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err == nil {
-		ref := strings.TrimSpace(stdout.String())
+	if stdout, _, err := c.run(repoPath, "", "symbolic-ref", "refs/remotes/origin/HEAD"); err == nil {
+		ref := strings.TrimSpace(stdout)
 		// refs/remotes/origin/main -> main
 		if parts := strings.Split(ref, "/"); len(parts) > 0 {
 			return parts[len(parts)-1]
@@ -286,7 +237,7 @@ func GetMainBranch(repoPath string) string {
 	}
 
 	// Fallback: check if main or master exists
-	branches, err := ListBranches(repoPath)
+	branches, err := c.ListBranches(repoPath)
 	if err != nil {
 		return "main"
 	}
@@ -305,19 +256,15 @@ func GetMainBranch(repoPath string) string {
 }
 
 // IsBranchMerged checks if branch is merged to the main branch.
-func IsBranchMerged(repoPath, branchName string) (bool, error) {
-	mainBranch := GetMainBranch(repoPath)
-
-	cmd := exec.Command("git", "-C", repoPath, "branch", "--merged", mainBranch)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+func (c *Client) IsBranchMerged(repoPath, branchName string) (bool, error) {
+	mainBranch := c.GetMainBranch(repoPath)
 
-	if err := cmd.Run(); err != nil {
-		return false, fmt.Errorf("git branch --merged: %w: %s", err, stderr.String())
+	stdout, stderr, err := c.run(repoPath, "", "branch", "--merged", mainBranch)
+	if err != nil {
+		return false, fmt.Errorf("git branch --merged: %w: %s", err, stderr)
 	}
 
-	mergedBranches := stdout.String()
+	mergedBranches := stdout
 	for _, line := range strings.Split(mergedBranches, "\n") {
 		// Lines may start with "* " or "  "
 		branch := strings.TrimSpace(strings.TrimPrefix(line, "*"))
@@ -329,17 +276,13 @@ func IsBranchMerged(repoPath, branchName string) (bool, error) {
 }
 
 // GetLastCommitTime returns the last commit timestamp for a worktree.
-func GetLastCommitTime(worktreePath string) (time.Time, error) {
-	cmd := exec.Command("git", "-C", worktreePath, "log", "-1", "--format=%ct")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return time.Time{}, fmt.Errorf("git log: %w: %s", err, stderr.String())
+func (c *Client) GetLastCommitTime(worktreePath string) (time.Time, error) {
+	stdout, stderr, err := c.run(worktreePath, "", "log", "-1", "--format=%ct")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git log: %w: %s", err, stderr)
 	}
 
-	timestamp, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	timestamp, err := strconv.ParseInt(strings.TrimSpace(stdout), 10, 64)
 	if err != nil {
 		return time.Time{}, fmt.Errorf("parsing timestamp: %w", err)
 	}
@@ -38,46 +38,6 @@ func TestShortenPath(t *testing.T) {
 	}
 }
 
-func TestIsWorktreePath(t *testing.T) {
-	tests := []struct {
-		path string
-		want bool
-	}{
-		{"/repo/.worktrees/feature", true},
-		{"/repo/.worktrees/feature/src", true},
-		{"/repo/src", false},
-		{"/repo/.git", false},
-		{"/home/user/.worktrees/test", true},
-	}
-
-	for _, tt := range tests {
-		got := IsWorktreePath(tt.path)
-		if got != tt.want {
-			t.Errorf("IsWorktreePath(%q) = %v, want %v", tt.path, got, tt.want)
-		}
-	}
-}
-
-func TestExtractWorktreeName(t *testing.T) {
-	tests := []struct {
-		path string
-		want string
-	}{
-		{"/repo/.worktrees/feature", "feature"},
-		{"/repo/.worktrees/feature-auth/src", "feature-auth"},
-		{"/repo/src", ""},
-		{"/repo/.worktrees/", ""},
-		{"/repo/.worktrees/test", "test"},
-	}
-
-	for _, tt := range tests {
-		got := ExtractWorktreeName(tt.path)
-		if got != tt.want {
-			t.Errorf("ExtractWorktreeName(%q) = %q, want %q", tt.path, got, tt.want)
-		}
-	}
-}
-
 func TestParseWorktrees(t *testing.T) {
 	output := `worktree /Users/test/project
 branch refs/heads/main
@@ -0,0 +1,1500 @@
+// Package gittest provides in-memory git repositories for tests. Commits,
+// branches and worktrees live in memory while worktree files are real, so
+// code reading and writing a checkout behaves as it would with git. Merges
+// and rebases conflict on whole files: those both sides changed.
+package gittest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// MainBranch is the branch Init creates.
+const MainBranch = "main"
+
+// Git runs the git commands cmux uses against in-memory repositories. It
+// implements git.Runner for the git.Client Client returns; commands it
+// doesn't know fail.
+type Git struct {
+	mu    sync.Mutex
+	repos []*repo
+	seq   int
+	now   time.Time // commit time; zero means the current time
+}
+
+// repo is a repository: its commits, branches and worktrees.
+type repo struct {
+	root      string
+	commits   map[string]*commit
+	branches  map[string]string // name to commit
+	worktrees []*worktree       // the main checkout first
+	stashes   []string
+}
+
+// commit is a snapshot of every file, by slash-separated path.
+type commit struct {
+	id      string
+	seq     int
+	parents []string
+	message string
+	time    time.Time
+	files   map[string]string
+}
+
+// worktree is a checkout of a branch, or of a commit when detached.
+type worktree struct {
+	path   string
+	branch string
+	head   string // the commit of a detached worktree
+	index  map[string]string
+	rebase *rebase // a rebase stopped on conflicts
+}
+
+// rebase is a rebase stopped on conflicts. HEAD stays where it was; the
+// conflicted files hold conflict markers.
+type rebase struct {
+	conflicts []string
+	stash     map[string]string // local changes, restored on abort
+	removed   []string          // files deleted locally
+}
+
+// ExitError is the error of a git command that failed, with the status
+// git would have exited with.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the status.
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// errUnsupported fails commands the fake doesn't know.
+var errUnsupported = errors.New("gittest: unsupported git command")
+
+// New returns a Git without repositories.
+func New() *Git {
+	return &Git{}
+}
+
+// Client returns a git client running commands with g.
+func (g *Git) Client() *git.Client {
+	return git.NewClient(g)
+}
+
+// SetTime sets the time of the commits made from now on; the zero time
+// makes them at the current time.
+func (g *Git) SetTime(t time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.now = t
+}
+
+// Init creates a repository at root with files, by slash-separated path,
+// committed on MainBranch.
+func (g *Git) Init(root string, files map[string]string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(root, ".git"), 0755); err != nil {
+		return err
+	}
+	if err := writeFiles(root, files); err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if r, _ := g.find(root); r != nil {
+		return fmt.Errorf("gittest: %s is already in repository %s", root, r.root)
+	}
+	r := &repo{
+		root:     root,
+		commits:  make(map[string]*commit),
+		branches: make(map[string]string),
+	}
+	c := g.newCommit(r, nil, "Initial commit\n", maps.Clone(files))
+	r.branches[MainBranch] = c.id
+	r.worktrees = []*worktree{{path: root, branch: MainBranch, index: maps.Clone(files)}}
+	g.repos = append(g.repos, r)
+	return nil
+}
+
+// Commit writes files into the worktree containing dir and commits every
+// change there with message. It returns the new commit.
+func (g *Git) Commit(dir, message string, files map[string]string) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, wt := g.find(dir)
+	if r == nil {
+		return "", fmt.Errorf("gittest: %s is in no repository", dir)
+	}
+	if err := writeFiles(wt.path, files); err != nil {
+		return "", err
+	}
+	disk, err := g.disk(wt)
+	if err != nil {
+		return "", err
+	}
+	wt.index = disk
+	c := g.commitIndex(r, wt, message)
+	return c.id, nil
+}
+
+// Merge merges branch into the branch checked out in the worktree
+// containing dir with a merge commit, as a pull request would. Changes of
+// branch win over conflicting ones. It returns the merge commit.
+func (g *Git) Merge(dir, branch string) (string, error) {
+	return g.merge(dir, branch, false)
+}
+
+// Squash merges branch into the branch checked out in the worktree
+// containing dir as a single commit without branch's history, as a squash
+// merge or rebase would. It returns the new commit.
+func (g *Git) Squash(dir, branch string) (string, error) {
+	return g.merge(dir, branch, true)
+}
+
+func (g *Git) merge(dir, branch string, squash bool) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, wt := g.find(dir)
+	if r == nil {
+		return "", fmt.Errorf("gittest: %s is in no repository", dir)
+	}
+	theirs, ok := r.branches[branch]
+	if !ok {
+		return "", fmt.Errorf("gittest: no branch %s", branch)
+	}
+	ours := r.head(wt)
+	base := r.mergeBase(ours, theirs)
+
+	files := maps.Clone(r.commits[ours].files)
+	for path, change := range r.changes(base, theirs) {
+		if change.deleted {
+			delete(files, path)
+		} else {
+			files[path] = change.content
+		}
+	}
+	parents := []string{ours, theirs}
+	message := fmt.Sprintf("Merge branch '%s'\n", branch)
+	if squash {
+		parents = parents[:1]
+		message = r.commits[theirs].subject() + "\n"
+	}
+	c := g.newCommit(r, parents, message, files)
+	if err := r.checkout(wt, c.id); err != nil {
+		return "", err
+	}
+	return c.id, nil
+}
+
+// Stashes returns the messages of a repository's stashes, newest first.
+func (g *Git) Stashes(dir string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, _ := g.find(dir)
+	if r == nil {
+		return nil
+	}
+	stashes := slices.Clone(r.stashes)
+	slices.Reverse(stashes)
+	return stashes
+}
+
+// Run runs a git command in dir.
+func (g *Git) Run(dir, stdin string, args ...string) (string, string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, err := os.Stat(dir); err != nil {
+		return fail(128, "fatal: cannot change to '%s': No such file or directory", dir)
+	}
+	r, wt := g.find(dir)
+	if r == nil {
+		return fail(128, "fatal: not a git repository (or any of the parent directories): .git")
+	}
+	if len(args) == 0 {
+		return unsupported(args)
+	}
+
+	c := &cmd{g: g, r: r, wt: wt, dir: dir, stdin: stdin, args: args}
+	switch args[0] {
+	case "rev-parse":
+		return c.revParse()
+	case "branch":
+		return c.branch()
+	case "symbolic-ref":
+		return fail(128, "fatal: ref %s is not a symbolic ref", args[len(args)-1])
+	case "worktree":
+		return c.worktree()
+	case "log":
+		return c.log()
+	case "status":
+		return c.status()
+	case "add":
+		return c.add()
+	case "commit":
+		return c.commit()
+	case "rev-list":
+		return c.revList()
+	case "merge-base":
+		return c.mergeBase()
+	case "diff":
+		return c.diff()
+	case "merge-tree":
+		return c.mergeTree()
+	case "rebase":
+		return c.rebase()
+	case "patch-id":
+		return c.patchID()
+	case "stash":
+		return c.stash()
+	case "merge":
+		return c.merge()
+	case "remote", "for-each-ref":
+		return "", "", nil // no remotes
+	case "fetch", "push":
+		return fail(128, "fatal: 'origin' does not appear to be a git repository")
+	}
+	return unsupported(args)
+}
+
+// fail returns the result of a command exiting with code.
+func fail(code int, format string, a ...any) (string, string, error) {
+	return "", fmt.Sprintf(format, a...) + "\n", &ExitError{Code: code}
+}
+
+// unsupported fails a command the fake doesn't know.
+func unsupported(args []string) (string, string, error) {
+	return "", "", fmt.Errorf("%w: git %s", errUnsupported, strings.Join(args, " "))
+}
+
+// find returns the repository and worktree containing dir: the worktree
+// with the deepest path containing it. The caller holds g.mu.
+func (g *Git) find(dir string) (*repo, *worktree) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil
+	}
+	var bestRepo *repo
+	var best *worktree
+	for _, r := range g.repos {
+		for _, wt := range r.worktrees {
+			if dir != wt.path && !strings.HasPrefix(dir, wt.path+string(filepath.Separator)) {
+				continue
+			}
+			if best == nil || len(wt.path) > len(best.path) {
+				bestRepo, best = r, wt
+			}
+		}
+	}
+	return bestRepo, best
+}
+
+// newCommit adds a commit of files to r. The caller holds g.mu.
+func (g *Git) newCommit(r *repo, parents []string, message string, files map[string]string) *commit {
+	if files == nil {
+		files = make(map[string]string)
+	}
+	g.seq++
+	t := g.now
+	if t.IsZero() {
+		t = time.Now()
+	}
+	h := sha1.New()
+	fmt.Fprintf(h, "%d\x00%s\x00%s\x00%s", g.seq, r.root, strings.Join(parents, " "), message)
+	c := &commit{
+		id:      hex.EncodeToString(h.Sum(nil)),
+		seq:     g.seq,
+		parents: parents,
+		message: message,
+		time:    t.Truncate(time.Second),
+		files:   files,
+	}
+	r.commits[c.id] = c
+	return c
+}
+
+// commitIndex commits a worktree's index on its HEAD. The caller holds g.mu.
+func (g *Git) commitIndex(r *repo, wt *worktree, message string) *commit {
+	c := g.newCommit(r, []string{r.head(wt)}, message, maps.Clone(wt.index))
+	r.setHead(wt, c.id)
+	return c
+}
+
+// disk returns the files of a worktree, leaving out .git and the worktrees
+// inside it. The caller holds g.mu.
+func (g *Git) disk(wt *worktree) (map[string]string, error) {
+	nested := make(map[string]bool)
+	for _, r := range g.repos {
+		for _, other := range r.worktrees {
+			nested[other.path] = other != wt
+		}
+	}
+
+	files := make(map[string]string)
+	err := filepath.WalkDir(wt.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Name() == ".git" && filepath.Dir(path) == wt.path {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if nested[path] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(wt.path, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	return files, err
+}
+
+// writeFiles writes files, by slash-separated path, into dir.
+func writeFiles(dir string, files map[string]string) error {
+	for path, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subject returns the first line of a commit's message.
+func (c *commit) subject() string {
+	subject, _, _ := strings.Cut(c.message, "\n")
+	return subject
+}
+
+// head returns the commit a worktree has checked out.
+func (r *repo) head(wt *worktree) string {
+	if wt.branch != "" {
+		return r.branches[wt.branch]
+	}
+	return wt.head
+}
+
+// setHead moves a worktree's branch, or its HEAD when detached, to id.
+func (r *repo) setHead(wt *worktree, id string) {
+	if wt.branch != "" {
+		r.branches[wt.branch] = id
+	} else {
+		wt.head = id
+	}
+}
+
+// checkout moves a worktree to commit id, updating the files that differ
+// between its HEAD and id and resetting its index.
+func (r *repo) checkout(wt *worktree, id string) error {
+	old := r.commits[r.head(wt)].files
+	files := r.commits[id].files
+	for path := range old {
+		if _, ok := files[path]; !ok {
+			if err := os.Remove(filepath.Join(wt.path, filepath.FromSlash(path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	changed := make(map[string]string)
+	for path, content := range files {
+		if prev, ok := old[path]; !ok || prev != content {
+			changed[path] = content
+		}
+	}
+	if err := writeFiles(wt.path, changed); err != nil {
+		return err
+	}
+	r.setHead(wt, id)
+	wt.index = maps.Clone(files)
+	return nil
+}
+
+// resolve returns the commit a revision names: HEAD, a branch or a commit
+// ID or prefix of one, optionally followed by ^{commit}.
+func (r *repo) resolve(wt *worktree, rev string) (string, bool) {
+	rev = strings.TrimSuffix(rev, "^{commit}")
+	switch {
+	case rev == "HEAD":
+		return r.head(wt), true
+	case r.branches[strings.TrimPrefix(rev, "refs/heads/")] != "":
+		return r.branches[strings.TrimPrefix(rev, "refs/heads/")], true
+	case len(rev) >= 4:
+		var found string
+		for id := range r.commits {
+			if strings.HasPrefix(id, rev) {
+				if found != "" {
+					return "", false // ambiguous
+				}
+				found = id
+			}
+		}
+		return found, found != ""
+	}
+	return "", false
+}
+
+// reachable returns the commits reachable from ids.
+func (r *repo) reachable(ids ...string) map[string]bool {
+	seen := make(map[string]bool)
+	stack := slices.Clone(ids)
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[id] || r.commits[id] == nil {
+			continue
+		}
+		seen[id] = true
+		stack = append(stack, r.commits[id].parents...)
+	}
+	return seen
+}
+
+// firstParents returns the commits reachable from ids by first parents.
+func (r *repo) firstParents(ids ...string) map[string]bool {
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		for id != "" && !seen[id] && r.commits[id] != nil {
+			seen[id] = true
+			parents := r.commits[id].parents
+			id = ""
+			if len(parents) > 0 {
+				id = parents[0]
+			}
+		}
+	}
+	return seen
+}
+
+// mergeBase returns the newest common ancestor of two commits, or "".
+func (r *repo) mergeBase(a, b string) string {
+	fromB := r.reachable(b)
+	var best *commit
+	for id := range r.reachable(a) {
+		if fromB[id] && (best == nil || r.commits[id].seq > best.seq) {
+			best = r.commits[id]
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return best.id
+}
+
+// change is a file a commit changed.
+type change struct {
+	content string
+	deleted bool
+}
+
+// changes returns the files that differ from commit from to commit to.
+func (r *repo) changes(from, to string) map[string]change {
+	var old map[string]string
+	if c := r.commits[from]; c != nil {
+		old = c.files
+	}
+	files := r.commits[to].files
+	out := make(map[string]change)
+	for path, content := range files {
+		if prev, ok := old[path]; !ok || prev != content {
+			out[path] = change{content: content}
+		}
+	}
+	for path := range old {
+		if _, ok := files[path]; !ok {
+			out[path] = change{deleted: true}
+		}
+	}
+	return out
+}
+
+// merge returns the files of commit ours with the changes of commit theirs
+// since their merge base, and the files both changed differently, which
+// conflict and keep ours' content.
+func (r *repo) merge(ours, theirs string) (map[string]string, []string) {
+	base := r.mergeBase(ours, theirs)
+	files := maps.Clone(r.commits[ours].files)
+	mine := r.changes(base, ours)
+	var conflicts []string
+	for path, change := range r.changes(base, theirs) {
+		if other, ok := mine[path]; ok && other != change {
+			conflicts = append(conflicts, path)
+			continue
+		}
+		if change.deleted {
+			delete(files, path)
+		} else {
+			files[path] = change.content
+		}
+	}
+	slices.Sort(conflicts)
+	return files, conflicts
+}
+
+// treeID names a snapshot of files.
+func treeID(files map[string]string) string {
+	h := sha1.New()
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		fmt.Fprintf(h, "%s\x00%s\x00", path, files[path])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// patch renders the changes from commit from to commit to as a diff that
+// replaces each changed file whole.
+func (r *repo) patch(from, to string) string {
+	var old map[string]string
+	if c := r.commits[from]; c != nil {
+		old = c.files
+	}
+	changes := r.changes(from, to)
+	var b strings.Builder
+	for _, path := range slices.Sorted(maps.Keys(changes)) {
+		prev, existed := old[path]
+		change := changes[path]
+		fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+		oldName, newName := "a/"+path, "b/"+path
+		if !existed {
+			oldName = "/dev/null"
+		}
+		if change.deleted {
+			newName = "/dev/null"
+		}
+		oldLines, newLines := lines(prev), lines(change.content)
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n@@ -1,%d +1,%d @@\n", oldName, newName, len(oldLines), len(newLines))
+		for _, line := range oldLines {
+			b.WriteString("-" + line + "\n")
+		}
+		for _, line := range newLines {
+			b.WriteString("+" + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// lines splits a file's content into lines.
+func lines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// byNewest sorts commits from the newest.
+func (r *repo) byNewest(ids map[string]bool) []string {
+	out := slices.Collect(maps.Keys(ids))
+	slices.SortFunc(out, func(a, b string) int { return r.commits[b].seq - r.commits[a].seq })
+	return out
+}
+
+// cmd is a git command being run.
+type cmd struct {
+	g     *Git
+	r     *repo
+	wt    *worktree
+	dir   string
+	stdin string
+	args  []string
+}
+
+// is reports whether the command's arguments are exactly args.
+func (c *cmd) is(args ...string) bool {
+	return slices.Equal(c.args, args)
+}
+
+// badRevision fails a command given a revision that names nothing.
+func badRevision(rev string) (string, string, error) {
+	return fail(128, "fatal: ambiguous argument '%s': unknown revision or path not in the working tree.", rev)
+}
+
+func (c *cmd) revParse() (string, string, error) {
+	args := c.args[1:]
+	switch {
+	case c.is("rev-parse", "--git-common-dir"):
+		return filepath.Join(c.r.root, ".git") + "\n", "", nil
+	case c.is("rev-parse", "--show-toplevel"):
+		return c.wt.path + "\n", "", nil
+	case c.is("rev-parse", "--short", "HEAD"):
+		return c.r.head(c.wt)[:7] + "\n", "", nil
+	}
+
+	verify, quiet := false, false
+	var revs []string
+	for _, arg := range args {
+		switch arg {
+		case "--verify":
+			verify = true
+		case "--quiet", "-q":
+			quiet = true
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return unsupported(c.args)
+			}
+			revs = append(revs, arg)
+		}
+	}
+	if verify && len(revs) != 1 {
+		return fail(128, "fatal: Needed a single revision")
+	}
+	var out strings.Builder
+	for _, rev := range revs {
+		id, ok := c.r.resolve(c.wt, rev)
+		switch {
+		case !ok && quiet:
+			return "", "", &ExitError{Code: 1}
+		case !ok && verify:
+			return fail(128, "fatal: Needed a single revision")
+		case !ok:
+			return badRevision(rev)
+		}
+		out.WriteString(id + "\n")
+	}
+	return out.String(), "", nil
+}
+
+func (c *cmd) branch() (string, string, error) {
+	r := c.r
+	switch {
+	case c.is("branch", "--show-current"):
+		return c.wt.branch + "\n", "", nil
+	case c.is("branch", "--format=%(refname:short)"):
+		var out strings.Builder
+		for _, name := range slices.Sorted(maps.Keys(r.branches)) {
+			out.WriteString(name + "\n")
+		}
+		return out.String(), "", nil
+	case len(c.args) == 3 && c.args[1] == "--merged":
+		base, ok := r.resolve(c.wt, c.args[2])
+		if !ok {
+			return fail(129, "error: malformed object name %s", c.args[2])
+		}
+		fromBase := r.reachable(base)
+		var out strings.Builder
+		for _, name := range slices.Sorted(maps.Keys(r.branches)) {
+			if !fromBase[r.branches[name]] {
+				continue
+			}
+			if name == c.wt.branch {
+				out.WriteString("* " + name + "\n")
+			} else {
+				out.WriteString("  " + name + "\n")
+			}
+		}
+		return out.String(), "", nil
+	case len(c.args) == 4 && c.args[1] == "-f":
+		name := c.args[2]
+		id, ok := r.resolve(c.wt, c.args[3])
+		if !ok {
+			return fail(128, "fatal: not a valid object name: '%s'", c.args[3])
+		}
+		for _, wt := range r.worktrees {
+			if wt.branch == name {
+				return fail(128, "fatal: cannot force update the branch '%s' used by worktree at '%s'", name, wt.path)
+			}
+		}
+		r.branches[name] = id
+		return "", "", nil
+	case len(c.args) == 3 && c.args[1] == "-D":
+		name := c.args[2]
+		id, ok := r.branches[name]
+		if !ok {
+			return fail(1, "error: branch '%s' not found", name)
+		}
+		for _, wt := range r.worktrees {
+			if wt.branch == name {
+				return fail(1, "error: cannot delete branch '%s' used by worktree at '%s'", name, wt.path)
+			}
+		}
+		delete(r.branches, name)
+		return fmt.Sprintf("Deleted branch %s (was %s).\n", name, id[:7]), "", nil
+	}
+	return unsupported(c.args)
+}
+
+func (c *cmd) worktree() (string, string, error) {
+	switch {
+	case c.is("worktree", "list", "--porcelain"):
+		var out strings.Builder
+		for _, wt := range c.r.worktrees {
+			fmt.Fprintf(&out, "worktree %s\nHEAD %s\n", wt.path, c.r.head(wt))
+			if wt.branch != "" {
+				fmt.Fprintf(&out, "branch refs/heads/%s\n\n", wt.branch)
+			} else {
+				out.WriteString("detached\n\n")
+			}
+		}
+		return out.String(), "", nil
+	case c.is("worktree", "prune"):
+		main := c.r.worktrees[0]
+		c.r.worktrees = slices.DeleteFunc(c.r.worktrees, func(wt *worktree) bool {
+			_, err := os.Stat(wt.path)
+			return wt != main && errors.Is(err, os.ErrNotExist)
+		})
+		return "", "", nil
+	case len(c.args) >= 3 && c.args[1] == "add":
+		return c.worktreeAdd(c.args[2:])
+	case len(c.args) >= 3 && c.args[1] == "remove":
+		return c.worktreeRemove(c.args[2:])
+	}
+	return unsupported(c.args)
+}
+
+// absPath returns a path argument relative to the command's directory.
+func (c *cmd) absPath(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// worktreeAdd runs git worktree add [-b branch] path [commit-ish].
+func (c *cmd) worktreeAdd(args []string) (string, string, error) {
+	r := c.r
+	newBranch := ""
+	if len(args) >= 2 && args[0] == "-b" {
+		newBranch, args = args[1], args[2:]
+	}
+	if len(args) < 1 || len(args) > 2 {
+		return unsupported(c.args)
+	}
+	path := c.absPath(args[0])
+	if entries, err := os.ReadDir(path); err == nil && len(entries) > 0 {
+		return fail(128, "fatal: '%s' already exists", path)
+	}
+	if other, _ := c.g.find(path); other != nil && other != r {
+		return fail(128, "fatal: '%s' is inside repository %s", path, other.root)
+	}
+
+	wt := &worktree{path: path}
+	start := "HEAD"
+	if len(args) == 2 {
+		start = args[1]
+	}
+	id, ok := r.resolve(c.wt, start)
+	if !ok {
+		return fail(128, "fatal: invalid reference: %s", start)
+	}
+	switch {
+	case newBranch != "":
+		if _, exists := r.branches[newBranch]; exists {
+			return fail(128, "fatal: a branch named '%s' already exists", newBranch)
+		}
+		r.branches[newBranch] = id
+		wt.branch = newBranch
+	case r.branches[start] != "":
+		for _, other := range r.worktrees {
+			if other.branch == start {
+				return fail(128, "fatal: '%s' is already used by worktree at '%s'", start, other.path)
+			}
+		}
+		wt.branch = start
+	default:
+		wt.head = id
+	}
+
+	files := r.commits[id].files
+	if err := writeFiles(path, files); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	gitdir := filepath.Join(r.root, ".git", "worktrees", filepath.Base(path))
+	if err := os.WriteFile(filepath.Join(path, ".git"), []byte("gitdir: "+gitdir+"\n"), 0644); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	wt.index = maps.Clone(files)
+	r.worktrees = append(r.worktrees, wt)
+	return "", fmt.Sprintf("Preparing worktree (%s)\n", start), nil
+}
+
+// worktreeRemove runs git worktree remove [--force] path.
+func (c *cmd) worktreeRemove(args []string) (string, string, error) {
+	force := false
+	if args[0] == "--force" || args[0] == "-f" {
+		force, args = true, args[1:]
+	}
+	if len(args) != 1 {
+		return unsupported(c.args)
+	}
+	path := c.absPath(args[0])
+	i := slices.IndexFunc(c.r.worktrees, func(wt *worktree) bool { return wt.path == path })
+	switch {
+	case i < 0:
+		return fail(128, "fatal: '%s' is not a working tree", path)
+	case i == 0:
+		return fail(128, "fatal: '%s' is a main working tree", path)
+	}
+	wt := c.r.worktrees[i]
+	if !force {
+		changes, err := c.changes(wt)
+		if err != nil {
+			return fail(128, "fatal: %v", err)
+		}
+		if len(changes) > 0 {
+			return fail(128, "fatal: '%s' contains modified or untracked files, use --force to delete it", path)
+		}
+	}
+	if err := os.RemoveAll(path); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	c.r.worktrees = slices.Delete(c.r.worktrees, i, i+1)
+	return "", "", nil
+}
+
+func (c *cmd) log() (string, string, error) {
+	if len(c.args) > 1 && c.args[1] == "-p" {
+		return c.logPatches()
+	}
+	if len(c.args) != 3 || c.args[1] != "-1" || !strings.HasPrefix(c.args[2], "--format=") {
+		return unsupported(c.args)
+	}
+	commit := c.r.commits[c.r.head(c.wt)]
+	format := strings.TrimPrefix(c.args[2], "--format=")
+	out := strings.NewReplacer(
+		"%ct", strconv.FormatInt(commit.time.Unix(), 10),
+		"%x00", "\x00",
+		"%H", commit.id,
+		"%s", commit.subject(),
+		"%B", commit.message,
+	).Replace(format)
+	return out + "\n", "", nil
+}
+
+// logPatches runs git log -p --no-merges --format="commit %H" from..to,
+// printing each commit not merging others with its diff, newest first.
+func (c *cmd) logPatches() (string, string, error) {
+	args := c.args[2:]
+	if len(args) == 0 || !slices.Contains(args, "--no-merges") || !slices.Contains(args, "--format=commit %H") {
+		return unsupported(c.args)
+	}
+	from, to, ok := strings.Cut(args[len(args)-1], "..")
+	if !ok {
+		return unsupported(c.args)
+	}
+	fromID, ok := c.r.resolve(c.wt, from)
+	if !ok {
+		return badRevision(from)
+	}
+	toID, ok := c.r.resolve(c.wt, to)
+	if !ok {
+		return badRevision(to)
+	}
+
+	commits := c.r.reachable(toID)
+	for id := range c.r.reachable(fromID) {
+		delete(commits, id)
+	}
+	var out strings.Builder
+	for _, id := range c.r.byNewest(commits) {
+		commit := c.r.commits[id]
+		if len(commit.parents) > 1 {
+			continue
+		}
+		parent := ""
+		if len(commit.parents) == 1 {
+			parent = commit.parents[0]
+		}
+		fmt.Fprintf(&out, "commit %s\n\n%s", id, c.r.patch(parent, id))
+	}
+	return out.String(), "", nil
+}
+
+// changes returns the status --porcelain=v1 entries of a worktree, sorted
+// by path: the index against HEAD, then the files against the index.
+func (c *cmd) changes(wt *worktree) ([]string, error) {
+	disk, err := c.g.disk(wt)
+	if err != nil {
+		return nil, err
+	}
+	head := c.r.commits[c.r.head(wt)].files
+
+	paths := make(map[string]bool)
+	for _, files := range []map[string]string{head, wt.index, disk} {
+		for path := range files {
+			paths[path] = true
+		}
+	}
+	var entries []string
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		headContent, inHead := head[path]
+		indexContent, inIndex := wt.index[path]
+		diskContent, onDisk := disk[path]
+
+		if wt.rebase != nil && slices.Contains(wt.rebase.conflicts, path) {
+			entries = append(entries, "UU "+path)
+			continue
+		}
+		if !inIndex {
+			if inHead {
+				entries = append(entries, "D  "+path)
+			}
+			if onDisk {
+				entries = append(entries, "?? "+path)
+			}
+			continue
+		}
+		x, y := byte(' '), byte(' ')
+		switch {
+		case !inHead:
+			x = 'A'
+		case headContent != indexContent:
+			x = 'M'
+		}
+		switch {
+		case !onDisk:
+			y = 'D'
+		case diskContent != indexContent:
+			y = 'M'
+		}
+		if x != ' ' || y != ' ' {
+			entries = append(entries, string([]byte{x, y, ' '})+path)
+		}
+	}
+	return entries, nil
+}
+
+func (c *cmd) status() (string, string, error) {
+	if !c.is("status", "--porcelain=v1", "-z", "--untracked-files=all") {
+		return unsupported(c.args)
+	}
+	entries, err := c.changes(c.wt)
+	if err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	var out strings.Builder
+	for _, entry := range entries {
+		out.WriteString(entry + "\x00")
+	}
+	return out.String(), "", nil
+}
+
+func (c *cmd) add() (string, string, error) {
+	if !c.is("add", "-A") {
+		return unsupported(c.args)
+	}
+	disk, err := c.g.disk(c.wt)
+	if err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	c.wt.index = disk
+	return "", "", nil
+}
+
+func (c *cmd) commit() (string, string, error) {
+	var message string
+	switch {
+	case c.is("commit", "-F", "-"):
+		message = c.stdin
+	case len(c.args) == 3 && c.args[1] == "-m":
+		message = c.args[2] + "\n"
+	default:
+		return unsupported(c.args)
+	}
+	if maps.Equal(c.wt.index, c.r.commits[c.r.head(c.wt)].files) {
+		return "nothing to commit, working tree clean\n", "", &ExitError{Code: 1}
+	}
+	commit := c.g.commitIndex(c.r, c.wt, message)
+	return fmt.Sprintf("[%s %s] %s\n", c.wt.branch, commit.id[:7], commit.subject()), "", nil
+}
+
+// revList runs git rev-list with --count, --max-count, --left-right,
+// --not and --remotes, of which there are none, over revisions, ^excluded
+// ones and a symmetric difference.
+func (c *cmd) revList() (string, string, error) {
+	count, leftRight, not, firstParent := false, false, false, false
+	maxCount := -1
+	var include, exclude []string
+	var symmetric [2]string
+	for _, arg := range c.args[1:] {
+		switch {
+		case arg == "--count":
+			count = true
+		case arg == "--left-right":
+			leftRight = true
+		case arg == "--first-parent":
+			firstParent = true
+		case arg == "--not":
+			not = !not
+		case arg == "--remotes":
+		case strings.HasPrefix(arg, "--max-count="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--max-count="))
+			if err != nil {
+				return unsupported(c.args)
+			}
+			maxCount = n
+		case strings.HasPrefix(arg, "-"):
+			return unsupported(c.args)
+		case strings.Contains(arg, "..."):
+			left, right, _ := strings.Cut(arg, "...")
+			var ok bool
+			if symmetric[0], ok = c.r.resolve(c.wt, left); !ok {
+				return badRevision(left)
+			}
+			if symmetric[1], ok = c.r.resolve(c.wt, right); !ok {
+				return badRevision(right)
+			}
+		default:
+			rev, negated := strings.CutPrefix(arg, "^")
+			rev, parents := strings.CutSuffix(rev, "^@")
+			id, ok := c.r.resolve(c.wt, rev)
+			if !ok {
+				return badRevision(rev)
+			}
+			ids := []string{id}
+			if parents {
+				ids = c.r.commits[id].parents
+			}
+			if negated != not {
+				exclude = append(exclude, ids...)
+			} else {
+				include = append(include, ids...)
+			}
+		}
+	}
+
+	if symmetric[0] != "" {
+		if !count || !leftRight {
+			return unsupported(c.args)
+		}
+		left := c.r.reachable(symmetric[0])
+		right := c.r.reachable(symmetric[1])
+		ahead, behind := 0, 0
+		for id := range left {
+			if !right[id] {
+				ahead++
+			}
+		}
+		for id := range right {
+			if !left[id] {
+				behind++
+			}
+		}
+		return fmt.Sprintf("%d\t%d\n", ahead, behind), "", nil
+	}
+
+	excluded := c.r.reachable(exclude...)
+	commits := c.r.reachable(include...)
+	if firstParent {
+		commits = c.r.firstParents(include...)
+	}
+	for id := range excluded {
+		delete(commits, id)
+	}
+	ids := c.r.byNewest(commits)
+	if maxCount >= 0 && len(ids) > maxCount {
+		ids = ids[:maxCount]
+	}
+	if count {
+		return strconv.Itoa(len(ids)) + "\n", "", nil
+	}
+	if len(ids) == 0 {
+		return "", "", nil
+	}
+	return strings.Join(ids, "\n") + "\n", "", nil
+}
+
+func (c *cmd) mergeBase() (string, string, error) {
+	if len(c.args) != 3 {
+		return unsupported(c.args)
+	}
+	a, ok := c.r.resolve(c.wt, c.args[1])
+	if !ok {
+		return badRevision(c.args[1])
+	}
+	b, ok := c.r.resolve(c.wt, c.args[2])
+	if !ok {
+		return badRevision(c.args[2])
+	}
+	base := c.r.mergeBase(a, b)
+	if base == "" {
+		return "", "", &ExitError{Code: 1}
+	}
+	return base + "\n", "", nil
+}
+
+// diff runs git diff between two commits, printing the patch or, with
+// --name-only, the changed files optionally limited to literal paths. It
+// lists the files a stopped rebase left unmerged.
+func (c *cmd) diff() (string, string, error) {
+	if c.is("diff", "--name-only", "--diff-filter=U", "-z") {
+		if c.wt.rebase == nil {
+			return "", "", nil
+		}
+		return strings.Join(c.wt.rebase.conflicts, "\x00") + "\x00", "", nil
+	}
+	nameOnly, nul := false, false
+	var revs, paths []string
+	pathspecs := false
+	for _, arg := range c.args[1:] {
+		switch {
+		case pathspecs:
+			paths = append(paths, strings.TrimPrefix(arg, ":(literal)"))
+		case arg == "--":
+			pathspecs = true
+		case arg == "--name-only":
+			nameOnly = true
+		case arg == "-z":
+			nul = true
+		case arg == "--no-renames", arg == "--no-color", arg == "--no-ext-diff":
+		case strings.HasPrefix(arg, "-"):
+			return unsupported(c.args)
+		default:
+			revs = append(revs, arg)
+		}
+	}
+	if len(revs) != 2 || !nameOnly && len(paths) > 0 {
+		return unsupported(c.args)
+	}
+	var ids [2]string
+	for i, rev := range revs {
+		id, ok := c.r.resolve(c.wt, rev)
+		if !ok {
+			return badRevision(rev)
+		}
+		ids[i] = id
+	}
+
+	if !nameOnly {
+		return c.r.patch(ids[0], ids[1]), "", nil
+	}
+	sep := "\n"
+	if nul {
+		sep = "\x00"
+	}
+	var out strings.Builder
+	for _, path := range slices.Sorted(maps.Keys(c.r.changes(ids[0], ids[1]))) {
+		if len(paths) == 0 || slices.Contains(paths, path) {
+			out.WriteString(path + sep)
+		}
+	}
+	return out.String(), "", nil
+}
+
+// patchID runs git patch-id --stable on the diffs on stdin, each after a
+// "commit <id>" line or, for a plain diff, none. Equal diffs have equal
+// patch ids.
+func (c *cmd) patchID() (string, string, error) {
+	if !c.is("patch-id", "--stable") {
+		return unsupported(c.args)
+	}
+	zero := strings.Repeat("0", 40)
+	var out strings.Builder
+	id, diff := zero, ""
+	flush := func() {
+		if strings.TrimSpace(diff) != "" {
+			sum := sha1.Sum([]byte(strings.TrimSpace(diff)))
+			fmt.Fprintf(&out, "%s %s\n", hex.EncodeToString(sum[:]), id)
+		}
+	}
+	for _, line := range strings.SplitAfter(c.stdin, "\n") {
+		if next, ok := strings.CutPrefix(line, "commit "); ok {
+			flush()
+			id, diff = strings.TrimSpace(next), ""
+			continue
+		}
+		diff += line
+	}
+	flush()
+	return out.String(), "", nil
+}
+
+// mergeTree runs git merge-tree --write-tree --name-only --no-messages,
+// printing the merged tree and the conflicted files, and exiting 1 if
+// there are any.
+func (c *cmd) mergeTree() (string, string, error) {
+	if len(c.args) != 6 || !slices.Equal(c.args[:4], []string{"merge-tree", "--write-tree", "--name-only", "--no-messages"}) {
+		return unsupported(c.args)
+	}
+	var ids [2]string
+	for i, rev := range c.args[4:] {
+		id, ok := c.r.resolve(c.wt, rev)
+		if !ok {
+			return fail(128, "fatal: %s - not something we can merge", rev)
+		}
+		ids[i] = id
+	}
+	files, conflicts := c.r.merge(ids[0], ids[1])
+	out := treeID(files) + "\n"
+	if len(conflicts) == 0 {
+		return out, "", nil
+	}
+	out += strings.Join(conflicts, "\n") + "\n"
+	return out, "", &ExitError{Code: 1}
+}
+
+// rebase runs git rebase [--autostash] onto, replaying HEAD's commits past
+// onto on top of it, or git rebase --abort. A rebase with conflicts, the
+// files both sides changed, stops before replaying anything and writes
+// conflict markers into them.
+// merge only fast-forwards, refusing to overwrite local changes.
+func (c *cmd) merge() (string, string, error) {
+	if len(c.args) != 3 || c.args[1] != "--ff-only" {
+		return unsupported(c.args)
+	}
+	rev := c.args[2]
+	id, ok := c.r.resolve(c.wt, rev)
+	if !ok {
+		return fail(1, "merge: %s - not something we can merge", rev)
+	}
+	head := c.r.head(c.wt)
+	if c.r.reachable(head)[id] {
+		return "Already up to date.\n", "", nil
+	}
+	if !c.r.reachable(id)[head] {
+		return fail(128, "fatal: Not possible to fast-forward, aborting.")
+	}
+
+	entries, err := c.changes(c.wt)
+	if err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	incoming := c.r.changes(head, id)
+	for _, entry := range entries {
+		if _, ok := incoming[entry[3:]]; ok {
+			return fail(1, "error: Your local changes to the following files would be overwritten by merge:\n\t%s", entry[3:])
+		}
+	}
+	// Checkout leaves the other files alone; their staged changes stay too
+	index := maps.Clone(c.wt.index)
+	if err := c.r.checkout(c.wt, id); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	for _, entry := range entries {
+		path := entry[3:]
+		if content, ok := index[path]; ok {
+			c.wt.index[path] = content
+		} else {
+			delete(c.wt.index, path)
+		}
+	}
+	return fmt.Sprintf("Updating %s..%s\nFast-forward\n", head[:7], id[:7]), "", nil
+}
+
+func (c *cmd) rebase() (string, string, error) {
+	if c.is("rebase", "--abort") {
+		return c.abortRebase()
+	}
+	autostash := c.is("rebase", "--autostash", c.args[len(c.args)-1])
+	if !autostash && len(c.args) != 2 {
+		return unsupported(c.args)
+	}
+	if c.wt.rebase != nil {
+		return fail(128, "fatal: It seems that there is already a rebase-merge directory")
+	}
+	rev := c.args[len(c.args)-1]
+	onto, ok := c.r.resolve(c.wt, rev)
+	if !ok {
+		return fail(128, "fatal: invalid upstream '%s'", rev)
+	}
+
+	// Local changes are set aside and put back on top of the result
+	entries, err := c.changes(c.wt)
+	if err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	entries = slices.DeleteFunc(entries, func(e string) bool { return strings.HasPrefix(e, "??") })
+	if len(entries) > 0 && !autostash {
+		return fail(1, "error: cannot rebase: You have unstaged changes.")
+	}
+	disk, err := c.g.disk(c.wt)
+	if err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	stash := make(map[string]string)
+	var removed []string
+	for _, entry := range entries {
+		path := entry[3:]
+		if content, ok := disk[path]; ok {
+			stash[path] = content
+		} else {
+			removed = append(removed, path)
+		}
+	}
+	head := c.r.head(c.wt)
+	if c.r.reachable(head)[onto] {
+		return "Current branch " + c.wt.branch + " is up to date.\n", "", nil
+	}
+	if err := c.reset(entries); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+
+	if _, conflicts := c.r.merge(head, onto); len(conflicts) > 0 {
+		ours, theirs := c.r.commits[head].files, c.r.commits[onto].files
+		marked := make(map[string]string)
+		var msg strings.Builder
+		for _, path := range conflicts {
+			marked[path] = fmt.Sprintf("<<<<<<< %s\n%s=======\n%s>>>>>>> %s\n", rev, theirs[path], ours[path], c.r.commits[head].subject())
+			fmt.Fprintf(&msg, "CONFLICT (content): Merge conflict in %s\n", path)
+		}
+		if err := writeFiles(c.wt.path, marked); err != nil {
+			return fail(128, "fatal: %v", err)
+		}
+		c.wt.rebase = &rebase{conflicts: conflicts, stash: stash, removed: removed}
+		return fail(1, "%serror: could not apply %s... %s", msg.String(), head[:7], c.r.commits[head].subject())
+	}
+
+	tip := onto
+	commits := c.r.reachable(head)
+	for id := range c.r.reachable(onto) {
+		delete(commits, id)
+	}
+	replay := c.r.byNewest(commits)
+	slices.Reverse(replay)
+	for _, id := range replay {
+		commit := c.r.commits[id]
+		if len(commit.parents) > 1 {
+			continue
+		}
+		files := maps.Clone(c.r.commits[tip].files)
+		for path, change := range c.r.changes(commit.parents[0], id) {
+			if change.deleted {
+				delete(files, path)
+			} else {
+				files[path] = change.content
+			}
+		}
+		tip = c.g.newCommit(c.r, []string{tip}, commit.message, files).id
+	}
+	if err := c.r.checkout(c.wt, tip); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	if err := c.restore(stash, removed); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	return "Successfully rebased and updated refs/heads/" + c.wt.branch + ".\n", "", nil
+}
+
+// abortRebase runs git rebase --abort, putting back the conflicted files
+// and the local changes of a stopped rebase.
+func (c *cmd) abortRebase() (string, string, error) {
+	if c.wt.rebase == nil {
+		return fail(128, "fatal: No rebase in progress?")
+	}
+	head := c.r.commits[c.r.head(c.wt)].files
+	var removed []string
+	restored := make(map[string]string)
+	for _, path := range c.wt.rebase.conflicts {
+		if content, ok := head[path]; ok {
+			restored[path] = content
+		} else {
+			removed = append(removed, path)
+		}
+	}
+	if err := c.restore(restored, removed); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	if err := c.restore(c.wt.rebase.stash, c.wt.rebase.removed); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	c.wt.rebase = nil
+	return "", "", nil
+}
+
+// reset puts the files of status entries back as they are at HEAD, and
+// resets the index.
+func (c *cmd) reset(entries []string) error {
+	head := c.r.commits[c.r.head(c.wt)].files
+	files := make(map[string]string)
+	var removed []string
+	for _, entry := range entries {
+		path := entry[3:]
+		if content, ok := head[path]; ok {
+			files[path] = content
+		} else {
+			removed = append(removed, path)
+		}
+	}
+	c.wt.index = maps.Clone(head)
+	return c.restore(files, removed)
+}
+
+// restore writes files into the worktree and removes the removed ones.
+func (c *cmd) restore(files map[string]string, removed []string) error {
+	if err := writeFiles(c.wt.path, files); err != nil {
+		return err
+	}
+	for _, path := range removed {
+		if err := os.Remove(filepath.Join(c.wt.path, filepath.FromSlash(path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// stash runs git stash push [--include-untracked] [-m message], which
+// records the message and resets the worktree to HEAD.
+func (c *cmd) stash() (string, string, error) {
+	if len(c.args) < 2 || c.args[1] != "push" {
+		return unsupported(c.args)
+	}
+	untracked := false
+	message := "WIP on " + c.wt.branch
+	for i := 2; i < len(c.args); i++ {
+		switch c.args[i] {
+		case "--include-untracked", "-u":
+			untracked = true
+		case "-m":
+			if i+1 == len(c.args) {
+				return unsupported(c.args)
+			}
+			i++
+			message = "On " + c.wt.branch + ": " + c.args[i]
+		default:
+			return unsupported(c.args)
+		}
+	}
+
+	entries, err := c.changes(c.wt)
+	if err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	if !untracked {
+		entries = slices.DeleteFunc(entries, func(e string) bool { return strings.HasPrefix(e, "??") })
+	}
+	if len(entries) == 0 {
+		return "No local changes to save\n", "", nil
+	}
+
+	if err := c.reset(entries); err != nil {
+		return fail(128, "fatal: %v", err)
+	}
+	c.r.stashes = append(c.r.stashes, message)
+	return "Saved working directory and index state " + message + "\n", "", nil
+}
@@ -0,0 +1,335 @@
+package gittest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// initRepo creates a Git with a repository in a temporary dir, and a
+// client running commands with it.
+func initRepo(t *testing.T) (*Git, *git.Client, string) {
+	t.Helper()
+	g := New()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Init(root, map[string]string{"README.md": "# project\n"}); err != nil {
+		t.Fatal(err)
+	}
+	return g, g.Client(), root
+}
+
+func TestRepository(t *testing.T) {
+	t.Parallel()
+	_, c, root := initRepo(t)
+
+	got, err := c.FindRepoRoot(filepath.Join(root, ".git"))
+	if err != nil || got != root {
+		t.Errorf("FindRepoRoot = %q, %v, want %q", got, err, root)
+	}
+	if branch, err := c.GetCurrentBranch(root); err != nil || branch != "main" {
+		t.Errorf("GetCurrentBranch = %q, %v, want main", branch, err)
+	}
+	if main := c.GetMainBranch(root); main != "main" {
+		t.Errorf("GetMainBranch = %q, want main", main)
+	}
+	if _, err := c.RevParse(root, "nosuchbranch"); err == nil {
+		t.Error("RevParse of a missing branch succeeded")
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	t.Parallel()
+	g, _, root := initRepo(t)
+	if _, _, err := g.Run(root, "", "gc"); !errors.Is(err, errUnsupported) {
+		t.Errorf("git gc = %v, want errUnsupported", err)
+	}
+	if _, _, err := g.Run(t.TempDir(), "", "status"); err == nil {
+		t.Error("a command outside any repository succeeded")
+	}
+}
+
+func TestWorktreeLifecycle(t *testing.T) {
+	t.Parallel()
+	g, c, root := initRepo(t)
+	wtPath := filepath.Join(root, ".worktrees", "feature")
+
+	if err := c.CreateWorktreeAt(root, wtPath, "feature", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.CreateWorktreeAt(root, filepath.Join(root, "other"), "feature", false, ""); err == nil {
+		t.Error("checking out feature twice succeeded")
+	}
+	worktrees, err := c.ListWorktrees(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(worktrees) != 2 || !worktrees[0].IsMain || worktrees[1].Path != wtPath || worktrees[1].Branch != "feature" {
+		t.Fatalf("ListWorktrees = %+v", worktrees)
+	}
+	if data, err := os.ReadFile(filepath.Join(wtPath, "README.md")); err != nil || string(data) != "# project\n" {
+		t.Errorf("README.md in worktree = %q, %v", data, err)
+	}
+	os.Mkdir(filepath.Join(wtPath, "sub"), 0755)
+	if wt, ok := c.FindWorktree(filepath.Join(wtPath, "sub")); !ok || wt.Path != wtPath {
+		t.Errorf("FindWorktree = %+v, %v", wt, ok)
+	}
+	if !c.IsWorktreePath(wtPath) || c.IsWorktreePath(root) {
+		t.Error("IsWorktreePath doesn't tell the linked worktree from the main checkout")
+	}
+
+	// The main checkout doesn't see the worktree inside it
+	if changes, err := c.Status(root); err != nil || len(changes) != 0 {
+		t.Errorf("Status of main checkout = %+v, %v", changes, err)
+	}
+
+	os.WriteFile(filepath.Join(wtPath, "README.md"), []byte("# feature\n"), 0644)
+	os.WriteFile(filepath.Join(wtPath, "new.go"), []byte("package main\n"), 0644)
+	changes, err := c.Status(wtPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []git.FileChange{
+		{Index: ' ', Worktree: 'M', Path: "README.md"},
+		{Index: '?', Worktree: '?', Path: "new.go"},
+	}
+	if !slices.Equal(changes, want) {
+		t.Errorf("Status = %+v, want %+v", changes, want)
+	}
+	if err := c.RemoveWorktree(root, wtPath); err == nil {
+		t.Error("removing a dirty worktree succeeded")
+	}
+
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	g.SetTime(when)
+	if err := c.CommitAll(wtPath, "Add new.go\n\nWith a body.\n"); err != nil {
+		t.Fatal(err)
+	}
+	if changes, _ := c.Status(wtPath); len(changes) != 0 {
+		t.Errorf("Status after commit = %+v", changes)
+	}
+	if err := c.CommitAll(wtPath, "Nothing"); err == nil {
+		t.Error("committing no changes succeeded")
+	}
+	if subject, body, err := c.LastCommitMessage(wtPath); err != nil || subject != "Add new.go" || body != "With a body." {
+		t.Errorf("LastCommitMessage = %q, %q, %v", subject, body, err)
+	}
+	if got, err := c.GetLastCommitTime(wtPath); err != nil || !got.Equal(when) {
+		t.Errorf("GetLastCommitTime = %v, %v, want %v", got, err, when)
+	}
+	if ahead, behind, err := c.AheadBehind(wtPath, "main"); err != nil || ahead != 1 || behind != 0 {
+		t.Errorf("AheadBehind = %d, %d, %v, want 1, 0", ahead, behind, err)
+	}
+
+	if err := c.DeleteBranch(root, "feature"); err == nil {
+		t.Error("deleting a checked out branch succeeded")
+	}
+	if err := c.RemoveWorktree(root, wtPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wtPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("worktree directory left behind: %v", err)
+	}
+	if err := c.DeleteBranch(root, "feature"); err != nil {
+		t.Fatal(err)
+	}
+	if branches, _ := c.ListBranches(root); !slices.Equal(branches, []string{"main"}) {
+		t.Errorf("ListBranches = %v, want [main]", branches)
+	}
+}
+
+func TestMerges(t *testing.T) {
+	t.Parallel()
+	g, c, root := initRepo(t)
+	for _, branch := range []string{"merged", "squashed", "open"} {
+		wtPath := filepath.Join(root, ".worktrees", branch)
+		if err := c.CreateWorktreeAt(root, wtPath, branch, true, ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.Commit(wtPath, "Work on "+branch+"\n", map[string]string{branch + ".txt": branch}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := g.Merge(root, "merged"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Squash(root, "squashed"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "squashed.txt")); err != nil || string(data) != "squashed" {
+		t.Errorf("squashed.txt in main checkout = %q, %v", data, err)
+	}
+
+	tests := []struct {
+		branch   string
+		ancestor bool
+		squashed bool
+		unpushed int
+	}{
+		{"merged", true, false, 0},
+		{"squashed", false, true, 1},
+		{"open", false, false, 1},
+	}
+	for _, tt := range tests {
+		if got, err := c.IsAncestor(root, tt.branch, "main"); err != nil || got != tt.ancestor {
+			t.Errorf("IsAncestor(%s) = %v, %v, want %v", tt.branch, got, err, tt.ancestor)
+		}
+		if !tt.ancestor {
+			if got, err := c.IsSquashMerged(root, tt.branch, "main"); err != nil || got != tt.squashed {
+				t.Errorf("IsSquashMerged(%s) = %v, %v, want %v", tt.branch, got, err, tt.squashed)
+			}
+		}
+		if got, err := c.CountUnpushed(root, tt.branch, "main"); err != nil || got != tt.unpushed {
+			t.Errorf("CountUnpushed(%s) = %d, %v, want %d", tt.branch, got, err, tt.unpushed)
+		}
+	}
+
+	// A branch without commits of its own is an ancestor but was never merged
+	if err := c.CreateWorktreeAt(root, filepath.Join(root, ".worktrees", "fresh"), "fresh", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	for branch, want := range map[string]bool{"fresh": true, "merged": false} {
+		if got, err := c.IsFirstParent(root, branch, "main"); err != nil || got != want {
+			t.Errorf("IsFirstParent(%s) = %v, %v, want %v", branch, got, err, want)
+		}
+	}
+	if merged, err := c.IsBranchMerged(root, "merged"); err != nil || !merged {
+		t.Errorf("IsBranchMerged(merged) = %v, %v", merged, err)
+	}
+}
+
+func TestStash(t *testing.T) {
+	t.Parallel()
+	g, c, root := initRepo(t)
+	os.WriteFile(filepath.Join(root, "README.md"), []byte("changed\n"), 0644)
+	os.WriteFile(filepath.Join(root, "scratch.txt"), []byte("notes\n"), 0644)
+
+	if err := c.StashAll(root, "cmux cleanup: main"); err != nil {
+		t.Fatal(err)
+	}
+	if changes, err := c.Status(root); err != nil || len(changes) != 0 {
+		t.Errorf("Status after stash = %+v, %v", changes, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "README.md")); string(data) != "# project\n" {
+		t.Errorf("README.md after stash = %q", data)
+	}
+	if stashes := g.Stashes(root); !slices.Equal(stashes, []string{"On main: cmux cleanup: main"}) {
+		t.Errorf("Stashes = %q", stashes)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	t.Parallel()
+	g, c, root := initRepo(t)
+	for _, branch := range []string{"clash", "clean"} {
+		if err := c.CreateWorktreeAt(root, filepath.Join(root, ".worktrees", branch), branch, true, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clash := filepath.Join(root, ".worktrees", "clash")
+	clean := filepath.Join(root, ".worktrees", "clean")
+	if _, err := g.Commit(clash, "Retitle\n", map[string]string{"README.md": "# clash\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Commit(clean, "Add notes\n", map[string]string{"NOTES.md": "notes\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Commit(root, "Rename project\n", map[string]string{"README.md": "# renamed\n"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if files, err := c.MergeConflicts(clash, "main"); err != nil || !slices.Equal(files, []string{"README.md"}) {
+		t.Errorf("MergeConflicts(clash) = %v, %v, want [README.md]", files, err)
+	}
+	if files, err := c.MergeConflicts(clean, "main"); err != nil || len(files) != 0 {
+		t.Errorf("MergeConflicts(clean) = %v, %v, want none", files, err)
+	}
+
+	// A rebase stopped on conflicts is aborted, leaving the branch as it was
+	os.WriteFile(filepath.Join(clash, "scratch.txt"), []byte("local\n"), 0644)
+	var conflict *git.ConflictError
+	if err := c.Rebase(clash, "main"); !errors.As(err, &conflict) || !slices.Equal(conflict.Files, []string{"README.md"}) {
+		t.Fatalf("Rebase(clash) = %v, want a conflict in README.md", err)
+	}
+	if changes, _ := c.Status(clash); len(changes) == 0 || changes[0] != (git.FileChange{Index: 'U', Worktree: 'U', Path: "README.md"}) {
+		t.Errorf("Status while rebasing = %+v", changes)
+	}
+	if err := c.AbortRebase(clash); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(clash, "README.md")); string(data) != "# clash\n" {
+		t.Errorf("README.md after abort = %q", data)
+	}
+	if files, err := c.ConflictedFiles(clash); err != nil || len(files) != 0 {
+		t.Errorf("ConflictedFiles after abort = %v, %v", files, err)
+	}
+	if err := c.AbortRebase(clash); err == nil {
+		t.Error("aborting without a rebase succeeded")
+	}
+
+	// A clean rebase replays the branch on main
+	os.WriteFile(filepath.Join(clean, "NOTES.md"), []byte("more notes\n"), 0644)
+	if err := c.Rebase(clean, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if ahead, behind, err := c.AheadBehind(clean, "main"); err != nil || ahead != 1 || behind != 0 {
+		t.Errorf("AheadBehind after rebase = %d, %d, %v, want 1, 0", ahead, behind, err)
+	}
+	if subject, _, _ := c.LastCommitMessage(clean); subject != "Add notes" {
+		t.Errorf("last commit after rebase = %q", subject)
+	}
+	if data, _ := os.ReadFile(filepath.Join(clean, "README.md")); string(data) != "# renamed\n" {
+		t.Errorf("README.md after rebase = %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(clean, "NOTES.md")); string(data) != "more notes\n" {
+		t.Errorf("local change to NOTES.md after rebase = %q", data)
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	t.Parallel()
+	g, c, root := initRepo(t)
+	wtPath := filepath.Join(root, ".worktrees", "feat")
+	if err := c.CreateWorktreeAt(root, wtPath, "feat", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	head, err := g.Commit(root, "Update README\n", map[string]string{"README.md": "# updated\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Local changes to the files the merge brings stop it
+	if err := os.WriteFile(filepath.Join(wtPath, "README.md"), []byte("local\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.FastForward(root, "feat", head); err == nil {
+		t.Error("FastForward over local changes succeeded")
+	}
+	if err := os.WriteFile(filepath.Join(wtPath, "README.md"), []byte("# project\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.FastForward(root, "feat", head); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(wtPath, "README.md")); err != nil || string(data) != "# updated\n" {
+		t.Errorf("README.md after FastForward = %q, %v", data, err)
+	}
+
+	// A branch checked out nowhere just moves
+	if err := c.FastForward(root, "other", head); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := c.RevParse(root, "other"); err != nil || got != head {
+		t.Errorf("other = %q, %v, want %s", got, err, head)
+	}
+	if _, _, err := g.Run(root, "", "branch", "-f", "feat", head); err == nil {
+		t.Error("branch -f of a checked-out branch succeeded")
+	}
+}
@@ -0,0 +1,89 @@
+package git
+
+import (
+	"strings"
+)
+
+// ListRemoteBranches returns the remote-tracking branches of a repository,
+// such as "origin/feature", without the remotes' HEAD aliases.
+func (c *Client) ListRemoteBranches(repoPath string) ([]string, error) {
+	out, err := c.gitOutput(repoPath, "for-each-ref", "--format=%(refname)", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+	return parseRemoteRefs(out), nil
+}
+
+// parseRemoteRefs turns full remote ref names into short branch names.
+func parseRemoteRefs(output string) []string {
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		name, ok := strings.CutPrefix(line, "refs/remotes/")
+		if !ok || !strings.Contains(name, "/") || strings.HasSuffix(name, "/HEAD") {
+			continue
+		}
+		branches = append(branches, name)
+	}
+	return branches
+}
+
+// SplitRemoteBranch splits "origin/feature/x" into its remote and branch,
+// given the repository's remotes.
+func SplitRemoteBranch(remotes []string, ref string) (remote, branch string, ok bool) {
+	// The longest matching remote wins, as remote names may contain slashes
+	for _, r := range remotes {
+		if rest, found := strings.CutPrefix(ref, r+"/"); found && rest != "" && len(r) > len(remote) {
+			remote, branch, ok = r, rest, true
+		}
+	}
+	return remote, branch, ok
+}
+
+// ListRemotes returns the names of a repository's remotes.
+func (c *Client) ListRemotes(repoPath string) ([]string, error) {
+	out, err := c.gitOutput(repoPath, "remote")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// Fetch fetches refspecs from a remote, or all of its branches when none
+// are given.
+func (c *Client) Fetch(repoPath, remote string, refspecs ...string) error {
+	_, err := c.gitOutput(repoPath, append([]string{"fetch", remote}, refspecs...)...)
+	return err
+}
+
+// FetchAll fetches every remote, pruning branches deleted upstream.
+func (c *Client) FetchAll(repoPath string) error {
+	_, err := c.gitOutput(repoPath, "fetch", "--all", "--prune")
+	return err
+}
+
+// FastForward moves a local branch to commit, which must contain it;
+// callers check that first. A branch checked out in a worktree is merged
+// there, so the worktree's files follow.
+func (c *Client) FastForward(repoPath, branch, commit string) error {
+	worktrees, err := c.ListWorktrees(repoPath)
+	if err != nil {
+		return err
+	}
+	for _, wt := range worktrees {
+		if wt.Branch == branch {
+			_, err := c.gitOutput(wt.Path, "merge", "--ff-only", commit)
+			return err
+		}
+	}
+	_, err = c.gitOutput(repoPath, "branch", "-f", branch, commit)
+	return err
+}
+
+// RevParse returns the commit a revision names.
+func (c *Client) RevParse(repoPath, rev string) (string, error) {
+	out, err := c.gitOutput(repoPath, "rev-parse", "--verify", rev+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
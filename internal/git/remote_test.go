@@ -0,0 +1,35 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRemoteRefs(t *testing.T) {
+	output := "refs/remotes/origin/HEAD\nrefs/remotes/origin/main\nrefs/remotes/origin/feature/x\nrefs/remotes/upstream/dev\nrefs/remotes/stale\n"
+	want := []string{"origin/main", "origin/feature/x", "upstream/dev"}
+	if got := parseRemoteRefs(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRemoteRefs() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitRemoteBranch(t *testing.T) {
+	remotes := []string{"origin", "team", "team/mirror"}
+	tests := []struct {
+		ref, remote, branch string
+		ok                  bool
+	}{
+		{"origin/feature/x", "origin", "feature/x", true},
+		{"team/mirror/dev", "team/mirror", "dev", true},
+		{"team/dev", "team", "dev", true},
+		{"other/dev", "", "", false},
+		{"origin/", "", "", false},
+	}
+	for _, tt := range tests {
+		remote, branch, ok := SplitRemoteBranch(remotes, tt.ref)
+		if remote != tt.remote || branch != tt.branch || ok != tt.ok {
+			t.Errorf("SplitRemoteBranch(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.ref, remote, branch, ok, tt.remote, tt.branch, tt.ok)
+		}
+	}
+}
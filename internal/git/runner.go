@@ -0,0 +1,65 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// Runner runs git commands for a Client: the git binary, or gittest's
+// in-memory repositories in tests.
+type Runner interface {
+	// Run runs git with args in dir, as git -C dir would, feeding it stdin.
+	// A git that fails returns an error, which has an ExitCode method if it
+	// exited with a status, as *exec.ExitError does.
+	Run(dir, stdin string, args ...string) (stdout, stderr string, err error)
+}
+
+// Exec runs the git binary.
+type Exec struct{}
+
+// Run runs git in dir.
+func (Exec) Run(dir, stdin string, args ...string) (string, string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.String(), stderr.String(), err
+}
+
+// Client runs git commands with a Runner. Code that tests give a
+// gittest runner takes a Client; the package's functions use Default.
+type Client struct {
+	runner Runner
+}
+
+// NewClient returns a Client running git commands with r.
+func NewClient(r Runner) *Client {
+	return &Client{runner: r}
+}
+
+// Default runs the git binary.
+var Default = NewClient(Exec{})
+
+// run runs git in dir with the client's runner.
+func (c *Client) run(dir, stdin string, args ...string) (stdout, stderr string, err error) {
+	return c.runner.Run(dir, stdin, args...)
+}
+
+// exitCode returns the status git exited with, or -1 if it didn't run or
+// was killed.
+func exitCode(err error) int {
+	var exited interface{ ExitCode() int }
+	if err == nil {
+		return 0
+	}
+	if errors.As(err, &exited) {
+		return exited.ExitCode()
+	}
+	return -1
+}
@@ -0,0 +1,174 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncStatus is how a branch stands against the branch it is based on.
+type SyncStatus struct {
+	Base      string   // ref compared against, e.g. "origin/main"
+	Ahead     int      // commits on the branch and not on base
+	Behind    int      // commits on base and not on the branch
+	Conflicts []string // files that would conflict bringing base in
+
+	// Conflicts couldn't be checked, e.g. by a git before 2.38
+	ConflictsUnknown bool
+}
+
+// Label returns a short summary such as "↑2 ↓5 conflict", empty when the
+// branch is level with base. Unchecked conflicts show as "conflict?".
+func (s SyncStatus) Label() string {
+	var parts []string
+	if s.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", s.Ahead))
+	}
+	if s.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", s.Behind))
+	}
+	if len(s.Conflicts) > 0 {
+		parts = append(parts, "conflict")
+	} else if s.ConflictsUnknown {
+		parts = append(parts, "conflict?")
+	}
+	return strings.Join(parts, " ")
+}
+
+// WorktreeStatus is the git state of a worktree shown with its session.
+type WorktreeStatus struct {
+	Dirty     int        // modified and untracked files
+	Subject   string     // subject of the last commit
+	Committed time.Time  // time of the last commit
+	Upstream  bool       // the branch exists on a remote
+	Sync      SyncStatus // against the base branch, zero for the base itself
+}
+
+// LastCommit returns the subject and time of HEAD's commit.
+func (c *Client) LastCommit(dir string) (subject string, committed time.Time, err error) {
+	out, err := c.gitOutput(dir, "log", "-1", "--format=%ct%x00%s")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return parseLastCommit(out)
+}
+
+// parseLastCommit parses the "<unix time>\x00<subject>" of LastCommit.
+func parseLastCommit(output string) (string, time.Time, error) {
+	stamp, subject, ok := strings.Cut(strings.TrimRight(output, "\n"), "\x00")
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("unexpected git log output %q", output)
+	}
+	seconds, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("parsing timestamp: %w", err)
+	}
+	return subject, time.Unix(seconds, 0), nil
+}
+
+// HasUpstream reports whether a branch exists on a remote: its upstream
+// branch, or origin's branch of the same name when it has none.
+func (c *Client) HasUpstream(dir, branch string) bool {
+	if _, err := c.gitOutput(dir, "rev-parse", "--verify", "--quiet", branch+"@{upstream}"); err == nil {
+		return true
+	}
+	_, err := c.gitOutput(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+branch)
+	return err == nil
+}
+
+// ConflictError is a rebase that stopped on conflicts. The rebase is left
+// in progress for them to be resolved.
+type ConflictError struct {
+	Files []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("conflicts in %s", strings.Join(e.Files, ", "))
+}
+
+// AheadBehind counts the commits of HEAD not on base and of base not on HEAD.
+func (c *Client) AheadBehind(dir, base string) (ahead, behind int, err error) {
+	out, err := c.gitOutput(dir, "rev-list", "--left-right", "--count", "HEAD..."+base)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseAheadBehind(out)
+}
+
+// parseAheadBehind parses the "<ahead>\t<behind>" of rev-list --left-right --count.
+func parseAheadBehind(output string) (ahead, behind int, err error) {
+	fields := strings.Fields(output)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	if ahead, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if behind, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	return ahead, behind, nil
+}
+
+// MergeConflicts returns the files that would conflict merging base into
+// HEAD. The merge is done in memory by git merge-tree, leaving the worktree
+// and index alone.
+func (c *Client) MergeConflicts(dir, base string) ([]string, error) {
+	stdout, stderr, err := c.run(dir, "", "merge-tree", "--write-tree", "--name-only", "--no-messages", "HEAD", base)
+
+	// Exit status 1 means the merge has conflicts
+	if exitCode(err) == 1 {
+		return parseMergeTree(stdout), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("git merge-tree: %w: %s", err, strings.TrimSpace(stderr))
+	}
+	return nil, nil
+}
+
+// parseMergeTree parses git merge-tree --name-only output: the tree written,
+// then each conflicted file.
+func parseMergeTree(output string) []string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range lines[1:] {
+		if line == "" {
+			break
+		}
+		if !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+// Rebase rebases HEAD onto base, stashing local changes around it. On
+// conflicts it returns a *ConflictError and leaves the rebase in progress.
+func (c *Client) Rebase(dir, base string) error {
+	_, err := c.gitOutput(dir, "rebase", "--autostash", base)
+	if err == nil {
+		return nil
+	}
+	if files, _ := c.ConflictedFiles(dir); len(files) > 0 {
+		return &ConflictError{Files: files}
+	}
+	return err
+}
+
+// AbortRebase stops a rebase in progress, restoring the branch.
+func (c *Client) AbortRebase(dir string) error {
+	_, err := c.gitOutput(dir, "rebase", "--abort")
+	return err
+}
+
+// ConflictedFiles returns the unmerged files of a worktree.
+func (c *Client) ConflictedFiles(dir string) ([]string, error) {
+	out, err := c.gitOutput(dir, "diff", "--name-only", "--diff-filter=U", "-z")
+	if err != nil || out == "" {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(out, "\x00"), "\x00"), nil
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAheadBehind(t *testing.T) {
+	ahead, behind, err := parseAheadBehind("2\t5\n")
+	if err != nil || ahead != 2 || behind != 5 {
+		t.Errorf("parseAheadBehind() = %d, %d, %v; want 2, 5, nil", ahead, behind, err)
+	}
+	if _, _, err := parseAheadBehind("fatal\n"); err == nil {
+		t.Error("parseAheadBehind() of garbage should fail")
+	}
+}
+
+func TestParseMergeTree(t *testing.T) {
+	output := "2bad2be1f739559487718d0ade8fd5313c23ceea\na\nsp ace\na\n"
+	want := []string{"a", "sp ace"}
+	if got := parseMergeTree(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseMergeTree() = %v, want %v", got, want)
+	}
+	if got := parseMergeTree("2bad2be1f739559487718d0ade8fd5313c23ceea\n"); got != nil {
+		t.Errorf("parseMergeTree() of a clean merge = %v, want nil", got)
+	}
+}
+
+func TestSyncStatus_Label(t *testing.T) {
+	tests := []struct {
+		status SyncStatus
+		want   string
+	}{
+		{SyncStatus{}, ""},
+		{SyncStatus{Ahead: 2}, "↑2"},
+		{SyncStatus{Ahead: 2, Behind: 5, Conflicts: []string{"a.go"}}, "↑2 ↓5 conflict"},
+		{SyncStatus{Ahead: 2, Behind: 5, ConflictsUnknown: true}, "↑2 ↓5 conflict?"},
+	}
+	for _, tt := range tests {
+		if got := tt.status.Label(); got != tt.want {
+			t.Errorf("Label() of %+v = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestParseLastCommit(t *testing.T) {
+	subject, committed, err := parseLastCommit("1700000000\x00Fix: the parser\n")
+	if err != nil || subject != "Fix: the parser" || committed.Unix() != 1700000000 {
+		t.Errorf("parseLastCommit() = %q, %v, %v", subject, committed, err)
+	}
+	if _, _, err := parseLastCommit("fatal\n"); err == nil {
+		t.Error("parseLastCommit() of garbage should fail")
+	}
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultWorktreeDir is the directory inside a repository holding its worktrees.
+const DefaultWorktreeDir = ".worktrees"
+
+// ResolveWorktreePath returns where the worktree for branch goes. dir is
+// either a directory relative to the repository (".worktrees") or a path
+// template that may use {repo} and {branch}, such as "~/wt/{repo}/{branch}".
+// Without a {branch} placeholder the branch becomes the last path element.
+func ResolveWorktreePath(repoPath, branch, dir string) string {
+	if dir == "" {
+		dir = DefaultWorktreeDir
+	}
+	branchDir := sanitizeBranchName(branch)
+
+	if !strings.Contains(dir, "{branch}") {
+		dir = filepath.Join(dir, "{branch}")
+	}
+	dir = strings.ReplaceAll(dir, "{repo}", filepath.Base(repoPath))
+	dir = strings.ReplaceAll(dir, "{branch}", branchDir)
+
+	if strings.HasPrefix(dir, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[1:])
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	return filepath.Clean(dir)
+}
+
+// FindWorktree returns the worktree containing path, using git worktree
+// list, so it works wherever worktrees are placed.
+func (c *Client) FindWorktree(path string) (Worktree, bool) {
+	worktrees, err := c.ListWorktrees(path)
+	if err != nil {
+		return Worktree{}, false
+	}
+
+	// git reports resolved paths
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return findWorktree(worktrees, path)
+}
+
+// findWorktree returns the worktree whose directory is the deepest one
+// containing path.
+func findWorktree(worktrees []Worktree, path string) (Worktree, bool) {
+	path = filepath.Clean(path)
+
+	var best Worktree
+	found := false
+	for _, wt := range worktrees {
+		wtPath := filepath.Clean(wt.Path)
+		if path != wtPath && !strings.HasPrefix(path, wtPath+string(filepath.Separator)) {
+			continue
+		}
+		if !found || len(wtPath) > len(best.Path) {
+			best = wt
+			best.Path = wtPath
+			found = true
+		}
+	}
+	return best, found
+}
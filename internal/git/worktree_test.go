@@ -0,0 +1,63 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveWorktreePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	tests := []struct {
+		name   string
+		dir    string
+		branch string
+		want   string
+	}{
+		{"default", "", "feature/auth", "/src/app/.worktrees/feature-auth"},
+		{"inside repo", ".trees", "fix", "/src/app/.trees/fix"},
+		{"sibling template", "../{repo}-wt", "fix", "/src/app-wt/fix"},
+		{"home template", "~/wt/{repo}/{branch}", "feat/x", filepath.Join(home, "wt/app/feat-x")},
+		{"absolute template", "/tmp/{branch}-{repo}", "fix", "/tmp/fix-app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveWorktreePath("/src/app", tt.branch, tt.dir); got != tt.want {
+				t.Errorf("ResolveWorktreePath(%q, %q) = %q, want %q", tt.branch, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindWorktree(t *testing.T) {
+	worktrees := []Worktree{
+		{Path: "/src/app", Branch: "main", IsMain: true},
+		{Path: "/src/app/.worktrees/feature", Branch: "feature"},
+		{Path: "/home/me/wt/app/fix", Branch: "fix"},
+	}
+
+	tests := []struct {
+		path       string
+		wantBranch string
+		wantOK     bool
+	}{
+		{"/src/app", "main", true},
+		{"/src/app/internal", "main", true},
+		{"/src/app/.worktrees/feature/src", "feature", true},
+		{"/home/me/wt/app/fix", "fix", true},
+		{"/home/me/wt/app/fixed", "", false},
+		{"/elsewhere", "", false},
+	}
+
+	for _, tt := range tests {
+		wt, ok := findWorktree(worktrees, tt.path)
+		if ok != tt.wantOK || wt.Branch != tt.wantBranch {
+			t.Errorf("findWorktree(%q) = %q, %v, want %q, %v", tt.path, wt.Branch, ok, tt.wantBranch, tt.wantOK)
+		}
+	}
+}
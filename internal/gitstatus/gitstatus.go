@@ -0,0 +1,144 @@
+// Package gitstatus keeps the git state of worktrees fresh in the
+// background, so the UI reads it without running git.
+package gitstatus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// queueSize is how many directories may wait for a worker; more are
+// dropped and asked for again on the next read.
+const queueSize = 64
+
+// CheckFunc computes the status of a worktree directory.
+type CheckFunc func(dir string) (git.WorktreeStatus, error)
+
+// entry is the last check of a directory.
+type entry struct {
+	status  git.WorktreeStatus
+	valid   bool // status is from a successful check
+	checked time.Time
+	queued  bool
+}
+
+// Refresher caches worktree statuses and recomputes stale ones on a
+// bounded pool of workers.
+type Refresher struct {
+	check CheckFunc
+	ttl   time.Duration
+	queue chan string
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	entries  map[string]*entry
+	onUpdate func(dir string)
+}
+
+// NewRefresher starts workers goroutines running check. Statuses older
+// than ttl are recomputed when read.
+func NewRefresher(workers int, ttl time.Duration, check CheckFunc) *Refresher {
+	r := &Refresher{
+		check:   check,
+		ttl:     ttl,
+		queue:   make(chan string, queueSize),
+		done:    make(chan struct{}),
+		entries: make(map[string]*entry),
+	}
+	for i := 0; i < max(workers, 1); i++ {
+		r.wg.Add(1)
+		go r.work()
+	}
+	return r
+}
+
+// OnUpdate sets a callback invoked, from a worker, after a directory's
+// status is recomputed.
+func (r *Refresher) OnUpdate(fn func(dir string)) {
+	r.mu.Lock()
+	r.onUpdate = fn
+	r.mu.Unlock()
+}
+
+// Get returns the cached status of a directory, if it has been checked,
+// and queues a check when it is missing or stale. It never blocks.
+func (r *Refresher) Get(dir string) (git.WorktreeStatus, bool) {
+	r.mu.Lock()
+	e := r.entries[dir]
+	if e == nil {
+		e = &entry{}
+		r.entries[dir] = e
+	}
+	status, ok := e.status, e.valid
+	stale := !e.queued && time.Since(e.checked) >= r.ttl
+	if stale {
+		e.queued = true
+	}
+	r.mu.Unlock()
+
+	if stale {
+		select {
+		case r.queue <- dir:
+		default:
+			r.mu.Lock()
+			e.queued = false
+			r.mu.Unlock()
+		}
+	}
+	return status, ok
+}
+
+// Invalidate makes the next read of a directory check it again, keeping
+// the old status until then.
+func (r *Refresher) Invalidate(dir string) {
+	r.mu.Lock()
+	if e := r.entries[dir]; e != nil {
+		e.checked = time.Time{}
+	}
+	r.mu.Unlock()
+}
+
+// InvalidateAll makes the next read of every directory check it again.
+func (r *Refresher) InvalidateAll() {
+	r.mu.Lock()
+	for _, e := range r.entries {
+		e.checked = time.Time{}
+	}
+	r.mu.Unlock()
+}
+
+// Close stops the workers once their current checks finish.
+func (r *Refresher) Close() {
+	close(r.done)
+	r.wg.Wait()
+}
+
+// work runs queued checks until the refresher is closed.
+func (r *Refresher) work() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.done:
+			return
+		case dir := <-r.queue:
+			status, err := r.check(dir)
+
+			// A failed check keeps the last good status
+			r.mu.Lock()
+			e := r.entries[dir]
+			if err == nil {
+				e.status, e.valid = status, true
+			}
+			e.checked, e.queued = time.Now(), false
+			onUpdate := r.onUpdate
+			r.mu.Unlock()
+
+			if onUpdate != nil {
+				onUpdate(dir)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package gitstatus
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRefresher_GetChecksInBackground(t *testing.T) {
+	var checks atomic.Int32
+	release := make(chan struct{})
+	r := NewRefresher(2, time.Hour, func(dir string) (git.WorktreeStatus, error) {
+		<-release
+		checks.Add(1)
+		return git.WorktreeStatus{Dirty: 3, Subject: dir}, nil
+	})
+	defer r.Close()
+
+	var updates atomic.Int32
+	r.OnUpdate(func(string) { updates.Add(1) })
+
+	// Reads never wait for the check and queue it only once
+	for i := 0; i < 5; i++ {
+		if _, ok := r.Get("/wt/a"); ok {
+			t.Fatal("Get() before the check finished reported a status")
+		}
+	}
+	close(release)
+	waitFor(t, func() bool { return updates.Load() == 1 })
+
+	status, ok := r.Get("/wt/a")
+	if !ok || status.Dirty != 3 || status.Subject != "/wt/a" {
+		t.Errorf("Get() = %+v, %v; want the checked status", status, ok)
+	}
+	if n := checks.Load(); n != 1 {
+		t.Errorf("check ran %d times, want 1", n)
+	}
+}
+
+func TestRefresher_BoundsWorkers(t *testing.T) {
+	var running, peak atomic.Int32
+	var mu sync.Mutex
+	r := NewRefresher(2, time.Hour, func(dir string) (git.WorktreeStatus, error) {
+		n := running.Add(1)
+		mu.Lock()
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return git.WorktreeStatus{}, nil
+	})
+	defer r.Close()
+
+	var updates atomic.Int32
+	r.OnUpdate(func(string) { updates.Add(1) })
+	for _, dir := range []string{"a", "b", "c", "d", "e", "f"} {
+		r.Get(dir)
+	}
+	waitFor(t, func() bool { return updates.Load() == 6 })
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d checks ran at once, want at most 2", p)
+	}
+}
+
+func TestRefresher_InvalidateKeepsLastStatus(t *testing.T) {
+	var fail atomic.Bool
+	r := NewRefresher(1, time.Hour, func(dir string) (git.WorktreeStatus, error) {
+		if fail.Load() {
+			return git.WorktreeStatus{}, errors.New("check failed")
+		}
+		return git.WorktreeStatus{Dirty: 1}, nil
+	})
+	defer r.Close()
+
+	var updates atomic.Int32
+	r.OnUpdate(func(string) { updates.Add(1) })
+	r.Get("a")
+	waitFor(t, func() bool { return updates.Load() == 1 })
+
+	fail.Store(true)
+	r.Invalidate("a")
+	r.Get("a")
+	waitFor(t, func() bool { return updates.Load() == 2 })
+
+	if status, ok := r.Get("a"); !ok || status.Dirty != 1 {
+		t.Errorf("Get() after a failed check = %+v, %v; want the last good status", status, ok)
+	}
+}
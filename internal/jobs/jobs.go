@@ -0,0 +1,130 @@
+// Package jobs runs long operations in the background and tracks their
+// progress for display.
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// Status is the state of a job.
+type Status string
+
+const (
+	Running Status = "running"
+	Done    Status = "done"
+	Failed  Status = "failed"
+)
+
+// Job is a snapshot of a background operation.
+type Job struct {
+	ID       int
+	Name     string // e.g. "push myrepo/feature"
+	Status   Status
+	Progress string // latest progress message, or the result when done
+	Err      error
+	Started  time.Time
+	Finished time.Time
+}
+
+// Func is the work of a job. It calls progress to report what it is doing
+// and returns the final message shown when it succeeds.
+type Func func(progress func(string)) (string, error)
+
+// Manager runs jobs and keeps their history.
+type Manager struct {
+	mu       sync.Mutex
+	jobs     []*Job
+	nextID   int
+	onUpdate func(Job)
+}
+
+// NewManager creates an empty job manager.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// OnUpdate sets a callback invoked, from the job's goroutine, whenever a
+// job starts, reports progress or finishes.
+func (m *Manager) OnUpdate(fn func(Job)) {
+	m.mu.Lock()
+	m.onUpdate = fn
+	m.mu.Unlock()
+}
+
+// Start runs fn in a new goroutine and returns the job's ID.
+func (m *Manager) Start(name string, fn Func) int {
+	m.mu.Lock()
+	m.nextID++
+	job := &Job{ID: m.nextID, Name: name, Status: Running, Started: time.Now()}
+	m.jobs = append(m.jobs, job)
+	m.mu.Unlock()
+	m.notify(job)
+
+	go func() {
+		result, err := fn(func(msg string) {
+			m.mu.Lock()
+			job.Progress = msg
+			m.mu.Unlock()
+			m.notify(job)
+		})
+
+		m.mu.Lock()
+		job.Finished = time.Now()
+		job.Progress = result
+		job.Err = err
+		job.Status = Done
+		if err != nil {
+			job.Status = Failed
+		}
+		m.mu.Unlock()
+		m.notify(job)
+	}()
+
+	return job.ID
+}
+
+// notify calls the update callback with a snapshot of job.
+func (m *Manager) notify(job *Job) {
+	m.mu.Lock()
+	snapshot := *job
+	fn := m.onUpdate
+	m.mu.Unlock()
+
+	if fn != nil {
+		fn(snapshot)
+	}
+}
+
+// Get returns a snapshot of a job.
+func (m *Manager) Get(id int) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return *job, true
+		}
+	}
+	return Job{}, false
+}
+
+// Visible returns the running jobs and those that finished within linger,
+// oldest first. Older finished jobs are dropped.
+func (m *Manager) Visible(linger time.Duration) []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var visible []Job
+	kept := m.jobs[:0]
+	for _, job := range m.jobs {
+		if job.Status != Running && now.Sub(job.Finished) > linger {
+			continue
+		}
+		kept = append(kept, job)
+		visible = append(visible, *job)
+	}
+	m.jobs = kept
+	return visible
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManager_Start(t *testing.T) {
+	m := NewManager()
+	updates := make(chan Job, 10)
+	m.OnUpdate(func(j Job) { updates <- j })
+
+	release := make(chan struct{})
+	id := m.Start("push", func(progress func(string)) (string, error) {
+		progress("pushing")
+		<-release
+		return "pushed", nil
+	})
+
+	if j := <-updates; j.Status != Running || j.Name != "push" {
+		t.Errorf("first update = %+v, want running push", j)
+	}
+	if j := <-updates; j.Progress != "pushing" {
+		t.Errorf("progress = %q, want %q", j.Progress, "pushing")
+	}
+	if got := m.Visible(0); len(got) != 1 || got[0].ID != id {
+		t.Errorf("Visible() = %+v, want the running job", got)
+	}
+
+	close(release)
+	j := <-updates
+	if j.Status != Done || j.Progress != "pushed" || j.Err != nil {
+		t.Errorf("final update = %+v, want done with result", j)
+	}
+	if got, ok := m.Get(id); !ok || got.Status != Done {
+		t.Errorf("Get(%d) = %+v, %v", id, got, ok)
+	}
+}
+
+func TestManager_Failed(t *testing.T) {
+	m := NewManager()
+	done := make(chan Job, 10)
+	m.OnUpdate(func(j Job) {
+		if j.Status != Running {
+			done <- j
+		}
+	})
+
+	want := errors.New("rejected")
+	m.Start("push", func(progress func(string)) (string, error) {
+		return "", want
+	})
+
+	if j := <-done; j.Status != Failed || !errors.Is(j.Err, want) {
+		t.Errorf("job = %+v, want failed with %v", j, want)
+	}
+}
+
+func TestManager_VisibleDropsOldJobs(t *testing.T) {
+	m := NewManager()
+	done := make(chan struct{})
+	m.OnUpdate(func(j Job) {
+		if j.Status == Done {
+			close(done)
+		}
+	})
+	m.Start("commit", func(progress func(string)) (string, error) { return "ok", nil })
+	<-done
+
+	if got := m.Visible(time.Hour); len(got) != 1 {
+		t.Fatalf("Visible(1h) = %d jobs, want 1", len(got))
+	}
+	time.Sleep(time.Millisecond)
+	if got := m.Visible(0); len(got) != 0 {
+		t.Errorf("Visible(0) = %d jobs, want 0", len(got))
+	}
+	if got := m.Visible(time.Hour); len(got) != 0 {
+		t.Errorf("finished job should have been dropped, got %d", len(got))
+	}
+}
@@ -0,0 +1,278 @@
+// Package notify tells the user when a Claude session needs attention:
+// when it asks for input, finishes a long turn or a tool fails.
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// Kind is what a notification is about.
+type Kind string
+
+const (
+	NeedsInput Kind = "needs_input" // a permission prompt is waiting
+	Finished   Kind = "finished"    // a long turn ended
+	ToolFailed Kind = "tool_failed" // a tool call returned an error
+)
+
+// Notification is a message about a session.
+type Notification struct {
+	Kind    Kind
+	Session string // tmux session
+	Title   string
+	Body    string
+	Time    time.Time
+}
+
+// Clients is the tmux server notifications are shown through.
+type Clients interface {
+	ListClients() ([]tmux.ClientInfo, error)
+	DisplayMessage(client, text string) error
+}
+
+// Notifier turns the hook events of sessions into notifications. It tracks
+// each session's status so only changes are notified.
+type Notifier struct {
+	mu        sync.Mutex
+	tracker   *claude.Tracker
+	events    map[Kind]bool
+	sinks     []Sink
+	clients   Clients
+	minRun    time.Duration
+	rateLimit time.Duration
+	quiet     quietHours
+	now       func() time.Time
+
+	last map[string]time.Time // last notification by tmux session
+
+	// The tmux sessions cmux shows a session inside
+	screens func(session string) []string
+}
+
+// New creates a notifier from the configuration. Unknown events or sinks
+// and malformed quiet hours are errors.
+func New(cfg config.NotifyConfig, clients Clients) (*Notifier, error) {
+	quiet, err := parseQuietHours(cfg.QuietHours)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		tracker:   claude.NewTracker(),
+		events:    make(map[Kind]bool),
+		clients:   clients,
+		minRun:    time.Duration(cfg.MinRun) * time.Second,
+		rateLimit: time.Duration(cfg.RateLimit) * time.Second,
+		quiet:     quiet,
+		now:       time.Now,
+		last:      make(map[string]time.Time),
+	}
+
+	for _, event := range cfg.Events {
+		switch kind := Kind(event); kind {
+		case NeedsInput, Finished, ToolFailed:
+			n.events[kind] = true
+		default:
+			return nil, fmt.Errorf("unknown notify event %q", event)
+		}
+	}
+	for _, name := range cfg.Sinks {
+		sink, err := newSink(name, cfg.Command, clients)
+		if err != nil {
+			return nil, err
+		}
+		n.sinks = append(n.sinks, sink)
+	}
+	return n, nil
+}
+
+// ShowIn tells the notifier that sessions for which shown returns true are
+// on screen inside the host tmux session, so they count as focused while
+// host is.
+func (n *Notifier) ShowIn(host string, shown func(session string) bool) {
+	n.ShowOn(func(session string) []string {
+		if host == "" || !shown(session) {
+			return nil
+		}
+		return []string{host}
+	})
+}
+
+// ShowOn is ShowIn for the TUIs attached to a daemon, each in its own host:
+// hosts returns the tmux sessions showing a session, which counts as
+// focused while any of them is.
+func (n *Notifier) ShowOn(hosts func(session string) []string) {
+	n.mu.Lock()
+	n.screens = hosts
+	n.mu.Unlock()
+}
+
+// OnEvent takes a hook event of a session, as an EventWatcher callback,
+// and sends the notification it calls for in the background.
+func (n *Notifier) OnEvent(tmuxSession string, event claude.HookEvent) {
+	note, ok := n.observe(tmuxSession, event)
+	if !ok || !n.allow(note) {
+		return
+	}
+	go n.Send(note)
+}
+
+// Send delivers a notification to every sink and returns their errors.
+func (n *Notifier) Send(note Notification) error {
+	var errs []error
+	for _, sink := range n.sinks {
+		if err := sink.Send(note); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// observe returns the notification, if any, that a session's hook event
+// calls for. Events replayed from before the notifier started are only
+// tracked.
+func (n *Notifier) observe(session string, event claude.HookEvent) (Notification, bool) {
+	tr, ok := n.tracker.Observe(session, event)
+	if !ok || tr.Replayed {
+		return Notification{}, false
+	}
+
+	note := Notification{Session: session, Title: "cmux: " + session, Time: tr.Time}
+	switch tr.Kind {
+	case claude.TransitionNeedsInput:
+		note.Kind = NeedsInput
+		note.Body = "Needs input"
+		if tr.Tool != "" {
+			note.Body += " for " + tr.Tool
+		} else if tr.Message != "" {
+			note.Body = tr.Message
+		}
+
+	case claude.TransitionToolFailed:
+		note.Kind = ToolFailed
+		note.Body = tr.Tool + " failed"
+		if msg := firstLine(tr.Message); msg != "" {
+			note.Body += ": " + msg
+		}
+
+	case claude.TransitionStop:
+		// Only long turns, and only when their start was seen
+		if tr.Duration == 0 || tr.Duration < n.minRun {
+			return Notification{}, false
+		}
+		note.Kind = Finished
+		note.Body = "Finished after " + tr.Duration.Round(time.Second).String()
+	}
+	return note, n.events[note.Kind]
+}
+
+// allow applies quiet hours, focus and the rate limit to a notification,
+// and records it when it may be sent.
+func (n *Notifier) allow(note Notification) bool {
+	now := n.now()
+	if n.quiet.contains(now) || n.focused(note.Session) {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if last, ok := n.last[note.Session]; ok && now.Sub(last) < n.rateLimit {
+		return false
+	}
+	n.last[note.Session] = now
+	return true
+}
+
+// focused reports whether the session is in front of the user already,
+// shown by a focused tmux client directly or inside the host session.
+func (n *Notifier) focused(session string) bool {
+	if n.clients == nil {
+		return false
+	}
+	clients, err := n.clients.ListClients()
+	if err != nil {
+		return false
+	}
+	focused := tmux.FocusedSessions(clients)
+	if focused[session] {
+		return true
+	}
+
+	n.mu.Lock()
+	screens := n.screens
+	n.mu.Unlock()
+	if screens == nil {
+		return false
+	}
+	for _, host := range screens(session) {
+		if focused[host] {
+			return true
+		}
+	}
+	return false
+}
+
+// firstLine returns the first non-empty line of s, trimmed.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// quietHours is a daily window in minutes after local midnight. It may
+// wrap past midnight; start == end means none.
+type quietHours struct {
+	start, end int
+}
+
+// parseQuietHours parses "HH:MM-HH:MM"; empty means no quiet hours.
+func parseQuietHours(s string) (quietHours, error) {
+	if s == "" {
+		return quietHours{}, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return quietHours{}, fmt.Errorf("malformed quiet hours %q, want HH:MM-HH:MM", s)
+	}
+	start, err1 := parseClock(from)
+	end, err2 := parseClock(to)
+	if err1 != nil || err2 != nil {
+		return quietHours{}, fmt.Errorf("malformed quiet hours %q, want HH:MM-HH:MM", s)
+	}
+	return quietHours{start: start, end: end}, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	h, m, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("malformed time %q", s)
+	}
+	hours, err1 := strconv.Atoi(h)
+	minutes, err2 := strconv.Atoi(m)
+	if err1 != nil || err2 != nil || hours < 0 || hours > 23 || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("malformed time %q", s)
+	}
+	return hours*60 + minutes, nil
+}
+
+// contains reports whether t falls in the quiet hours.
+func (q quietHours) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if q.start <= q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
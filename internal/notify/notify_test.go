@@ -0,0 +1,264 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// fakeClients is a tmux server with fixed clients that records messages.
+type fakeClients struct {
+	mu       sync.Mutex
+	clients  []tmux.ClientInfo
+	messages []string
+}
+
+func (f *fakeClients) ListClients() ([]tmux.ClientInfo, error) {
+	return f.clients, nil
+}
+
+func (f *fakeClients) DisplayMessage(client, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.messages = append(f.messages, client+" "+text)
+	return nil
+}
+
+// newTestNotifier creates a notifier without sinks whose clock is at.
+func newTestNotifier(t *testing.T, clients Clients, at *time.Time) *Notifier {
+	t.Helper()
+	cfg := config.DefaultNotify()
+	cfg.Sinks = nil
+	n, err := New(cfg, clients)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.now = func() time.Time { return *at }
+	return n
+}
+
+func event(name string, at time.Time) claude.HookEvent {
+	return claude.HookEvent{EventName: name, Timestamp: at}
+}
+
+func TestObserveTransitions(t *testing.T) {
+	now := time.Now()
+	n := newTestNotifier(t, nil, &now)
+
+	observe := func(e claude.HookEvent) (Kind, string) {
+		note, ok := n.observe("dev", e)
+		if !ok {
+			return "", ""
+		}
+		return note.Kind, note.Body
+	}
+
+	if kind, _ := observe(event("UserPromptSubmit", now)); kind != "" {
+		t.Errorf("prompt notified %q", kind)
+	}
+
+	permission := event("PermissionRequest", now.Add(time.Second))
+	permission.ToolName = "Bash"
+	if kind, body := observe(permission); kind != NeedsInput || body != "Needs input for Bash" {
+		t.Errorf("permission request = %q %q, want needs input for Bash", kind, body)
+	}
+	prompt := event("Notification", now.Add(2*time.Second))
+	prompt.NotificationType = "permission_prompt"
+	if kind, _ := observe(prompt); kind != "" {
+		t.Errorf("second permission event notified %q, want one notification per prompt", kind)
+	}
+
+	failure := event("PostToolUseFailure", now.Add(3*time.Second))
+	failure.ToolName, failure.Error = "Bash", "\nexit status 1\nmore"
+	if kind, body := observe(failure); kind != ToolFailed || body != "Bash failed: exit status 1" {
+		t.Errorf("tool failure = %q %q", kind, body)
+	}
+	failure.IsInterrupt = true
+	if kind, _ := observe(failure); kind != "" {
+		t.Errorf("interrupted tool notified %q", kind)
+	}
+
+	if kind, _ := observe(event("SubagentStop", now.Add(time.Minute))); kind != "" {
+		t.Errorf("subagent stop notified %q", kind)
+	}
+	if kind, body := observe(event("Stop", now.Add(2*time.Minute))); kind != Finished || body != "Finished after 2m0s" {
+		t.Errorf("stop = %q %q, want finished after 2m0s", kind, body)
+	}
+	if kind, _ := observe(event("Stop", now.Add(3*time.Minute))); kind != "" {
+		t.Errorf("second stop notified %q", kind)
+	}
+
+	// Short turns end quietly
+	observe(event("UserPromptSubmit", now.Add(4*time.Minute)))
+	if kind, _ := observe(event("Stop", now.Add(4*time.Minute+5*time.Second))); kind != "" {
+		t.Errorf("short turn notified %q", kind)
+	}
+}
+
+func TestObserveReplayedEvents(t *testing.T) {
+	now := time.Now()
+	n := newTestNotifier(t, nil, &now)
+
+	// The events file is read from the start: old prompts set the status
+	// but don't notify
+	old := event("PermissionRequest", now.Add(-time.Hour))
+	if _, ok := n.observe("dev", old); ok {
+		t.Error("replayed permission request was notified")
+	}
+	if _, ok := n.observe("dev", event("PermissionRequest", now)); ok {
+		t.Error("still waiting for input, want no second notification")
+	}
+}
+
+func TestObserveEvents(t *testing.T) {
+	now := time.Now()
+	n := newTestNotifier(t, nil, &now)
+	delete(n.events, NeedsInput)
+
+	if _, ok := n.observe("dev", event("PermissionRequest", now)); ok {
+		t.Error("needs_input notified though not configured")
+	}
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	clients := &fakeClients{clients: []tmux.ClientInfo{
+		{TTY: "/dev/pts/1", Session: "cmux", Focused: true},
+		{TTY: "/dev/pts/2", Session: "api"},
+	}}
+	n := newTestNotifier(t, clients, &now)
+	n.ShowIn("cmux", func(s string) bool { return s == "shown" })
+
+	note := func(session string) Notification { return Notification{Session: session} }
+
+	if !n.allow(note("dev")) {
+		t.Error("first notification was held back")
+	}
+	if n.allow(note("dev")) {
+		t.Error("second notification within the rate limit was allowed")
+	}
+	now = now.Add(11 * time.Second)
+	if !n.allow(note("dev")) {
+		t.Error("notification after the rate limit was held back")
+	}
+
+	if n.allow(note("cmux")) {
+		t.Error("focused session was notified")
+	}
+	if n.allow(note("shown")) {
+		t.Error("session shown in focused cmux was notified")
+	}
+	if !n.allow(note("api")) {
+		t.Error("session of an unfocused client was held back")
+	}
+
+	n.quiet, _ = parseQuietHours("11:00-13:00")
+	if n.allow(note("other")) {
+		t.Error("notified during quiet hours")
+	}
+}
+
+func TestShowOn(t *testing.T) {
+	now := time.Now()
+	clients := &fakeClients{clients: []tmux.ClientInfo{
+		{TTY: "/dev/pts/1", Session: "cmux-1"},
+		{TTY: "/dev/pts/2", Session: "cmux-2", Focused: true},
+	}}
+	n := newTestNotifier(t, clients, &now)
+	screens := map[string][]string{"dev": {"cmux-1", "cmux-2"}, "web": {"cmux-1"}}
+	n.ShowOn(func(session string) []string { return screens[session] })
+
+	if !n.focused("dev") {
+		t.Error("session shown in a focused host isn't focused")
+	}
+	if n.focused("web") {
+		t.Error("session shown only in an unfocused host is focused")
+	}
+	if n.focused("api") {
+		t.Error("session shown nowhere is focused")
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.Local) }
+
+	overnight, err := parseQuietHours("22:00-08:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(23, 0), true}, {at(3, 0), true}, {at(8, 0), false}, {at(12, 0), false}, {at(22, 0), true},
+	} {
+		if got := overnight.contains(tt.t); got != tt.want {
+			t.Errorf("contains(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+
+	none, _ := parseQuietHours("")
+	if none.contains(at(12, 0)) {
+		t.Error("no quiet hours contains noon")
+	}
+
+	for _, bad := range []string{"22:00", "25:00-08:00", "22-08", "aa:bb-cc:dd"} {
+		if _, err := parseQuietHours(bad); err == nil {
+			t.Errorf("parseQuietHours(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestNewRejectsUnknownSettings(t *testing.T) {
+	cfg := config.DefaultNotify()
+	cfg.Sinks = []string{"pager"}
+	if _, err := New(cfg, nil); err == nil {
+		t.Error("unknown sink accepted")
+	}
+
+	cfg = config.DefaultNotify()
+	cfg.Events = []string{"started"}
+	if _, err := New(cfg, nil); err == nil {
+		t.Error("unknown event accepted")
+	}
+}
+
+func TestSinks(t *testing.T) {
+	dir := t.TempDir()
+	tty := filepath.Join(dir, "tty")
+	os.WriteFile(tty, nil, 0644)
+	out := filepath.Join(dir, "out")
+
+	clients := &fakeClients{clients: []tmux.ClientInfo{{TTY: tty, Session: "cmux"}}}
+	cfg := config.DefaultNotify()
+	cfg.Sinks = []string{"osc9", "osc777", "bell", "tmux", "command"}
+	cfg.Command = []string{"sh", "-c", `printf '%s|%s' "$1" "$2" > ` + out, "sh", "{session}", "{body}"}
+	n, err := New(cfg, clients)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	note := Notification{Session: "dev", Title: "cmux: dev", Body: "Needs\x1b input"}
+	if err := n.Send(note); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(tty)
+	want := "\x1b]9;cmux: dev: Needs  input\x07" + "\x1b]777;notify;cmux: dev;Needs  input\x07" + "\a"
+	if string(data) != want {
+		t.Errorf("tty got %q, want %q", data, want)
+	}
+	if len(clients.messages) != 1 || !strings.HasPrefix(clients.messages[0], tty+" cmux: dev: ") {
+		t.Errorf("tmux messages = %q", clients.messages)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "dev|Needs\x1b input" {
+		t.Errorf("command got %q", data)
+	}
+}
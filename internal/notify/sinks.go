@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Sink delivers notifications to one place.
+type Sink interface {
+	Send(note Notification) error
+}
+
+// commandTimeout bounds a notification command such as notify-send.
+const commandTimeout = 10 * time.Second
+
+// newSink creates the sink configured by name.
+func newSink(name string, command []string, clients Clients) (Sink, error) {
+	switch name {
+	case "osc9":
+		return &terminalSink{clients: clients, sequence: osc9}, nil
+	case "osc777":
+		return &terminalSink{clients: clients, sequence: osc777}, nil
+	case "bell":
+		return &terminalSink{clients: clients, sequence: bell}, nil
+	case "command":
+		if len(command) == 0 {
+			return nil, fmt.Errorf("notify sink %q needs a command", name)
+		}
+		return &commandSink{argv: command}, nil
+	case "tmux":
+		return &tmuxSink{clients: clients}, nil
+	}
+	return nil, fmt.Errorf("unknown notify sink %q", name)
+}
+
+// terminalSink writes an escape sequence to the terminals of the tmux
+// clients, bypassing tmux, or to cmux's own terminal outside tmux.
+type terminalSink struct {
+	clients  Clients
+	sequence func(Notification) string
+}
+
+// osc9 is the desktop notification of iTerm2, WezTerm, Ghostty and
+// Windows Terminal.
+func osc9(note Notification) string {
+	return "\x1b]9;" + printable(note.Title+": "+note.Body) + "\x07"
+}
+
+// osc777 is the desktop notification of urxvt, foot and Ghostty.
+func osc777(note Notification) string {
+	title := strings.ReplaceAll(printable(note.Title), ";", ",")
+	return "\x1b]777;notify;" + title + ";" + printable(note.Body) + "\x07"
+}
+
+// bell rings the terminal bell.
+func bell(Notification) string {
+	return "\a"
+}
+
+func (s *terminalSink) Send(note Notification) error {
+	ttys := []string{"/dev/tty"}
+	if s.clients != nil {
+		if clients, err := s.clients.ListClients(); err == nil && len(clients) > 0 {
+			ttys = ttys[:0]
+			for _, client := range clients {
+				ttys = append(ttys, client.TTY)
+			}
+		}
+	}
+
+	seq := []byte(s.sequence(note))
+	var errs []error
+	for _, tty := range ttys {
+		if err := writeTTY(tty, seq); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeTTY writes data to a terminal device.
+func writeTTY(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	return err
+}
+
+// printable drops control characters, which would end an escape sequence
+// early.
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// commandSink runs a command such as notify-send.
+type commandSink struct {
+	argv []string
+}
+
+func (s *commandSink) Send(note Notification) error {
+	r := strings.NewReplacer("{title}", note.Title, "{body}", note.Body, "{session}", note.Session)
+	args := make([]string, len(s.argv))
+	for i, arg := range s.argv {
+		args[i] = r.Replace(arg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("notify command %s: %w: %s", args[0], err, stderr.String())
+	}
+	return nil
+}
+
+// tmuxSink shows the notification in the status line of every tmux client.
+type tmuxSink struct {
+	clients Clients
+}
+
+func (s *tmuxSink) Send(note Notification) error {
+	if s.clients == nil {
+		return nil
+	}
+	clients, err := s.clients.ListClients()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, client := range clients {
+		if err := s.clients.DisplayMessage(client.TTY, note.Title+": "+note.Body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
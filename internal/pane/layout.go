@@ -148,3 +148,150 @@ func CalculateUnifiedSidebarLayout(maxX, maxY int) UnifiedSidebarLayout {
 		Main:     Layout{sidebarWidth, 0, maxX - 1, maxY - 1},
 	}
 }
+
+// LayoutPreset names an arrangement for tiled session views.
+type LayoutPreset string
+
+const (
+	PresetGrid           LayoutPreset = "grid"            // rows of panes, as CalculateLayouts
+	PresetMainVertical   LayoutPreset = "main-vertical"   // first pane on the left, the rest stacked on the right
+	PresetEvenHorizontal LayoutPreset = "even-horizontal" // all panes side by side with equal widths
+)
+
+// Presets lists the layout presets in cycling order.
+var Presets = []LayoutPreset{PresetGrid, PresetMainVertical, PresetEvenHorizontal}
+
+// ParsePreset returns the preset with the given name, or PresetGrid if unknown.
+func ParsePreset(name string) LayoutPreset {
+	for _, p := range Presets {
+		if string(p) == name {
+			return p
+		}
+	}
+	return PresetGrid
+}
+
+// NextPreset returns the preset following p in Presets order.
+func NextPreset(p LayoutPreset) LayoutPreset {
+	for i, preset := range Presets {
+		if preset == p {
+			return Presets[(i+1)%len(Presets)]
+		}
+	}
+	return PresetGrid
+}
+
+// Split ratios are the percentage of the area width left of the main vertical split.
+const (
+	DefaultSplitRatio = 50
+	MinSplitRatio     = 20
+	MaxSplitRatio     = 80
+)
+
+// ClampRatio limits a split ratio to [MinSplitRatio, MaxSplitRatio].
+// Zero means unset and returns DefaultSplitRatio.
+func ClampRatio(ratio int) int {
+	switch {
+	case ratio == 0:
+		return DefaultSplitRatio
+	case ratio < MinSplitRatio:
+		return MinSplitRatio
+	case ratio > MaxSplitRatio:
+		return MaxSplitRatio
+	}
+	return ratio
+}
+
+// CalculatePresetLayouts returns layouts for count panes arranged by preset
+// inside area. ratio sets the main vertical split for grid (2-4 panes) and
+// main-vertical; even-horizontal always uses equal widths.
+//
+//	main-vertical:   [     ][ 2 ]     even-horizontal: [ 1 ][ 2 ][ 3 ]
+//	                 [  1  ][ 3 ]
+//	                 [     ][ 4 ]
+func CalculatePresetLayouts(preset LayoutPreset, count int, area Layout, ratio int) []Layout {
+	if count == 0 {
+		return nil
+	}
+
+	w := area.X1 - area.X0 + 1
+	h := area.Y1 - area.Y0 + 1
+	splitX, _ := SplitPosition(preset, count, area, ratio)
+	layouts := make([]Layout, count)
+
+	switch preset {
+	case PresetMainVertical:
+		if count == 1 {
+			layouts[0] = area
+			break
+		}
+		layouts[0] = Layout{area.X0, area.Y0, splitX - 1, area.Y1}
+		stacked := count - 1
+		for i := range stacked {
+			y0 := area.Y0 + (h*i)/stacked
+			y1 := area.Y0 + (h*(i+1))/stacked
+			layouts[i+1] = Layout{splitX, y0, area.X1, y1 - 1}
+		}
+
+	case PresetEvenHorizontal:
+		for i := range count {
+			x0 := area.X0 + (w*i)/count
+			x1 := area.X0 + (w*(i+1))/count
+			layouts[i] = Layout{x0, area.Y0, x1 - 1, area.Y1}
+		}
+
+	default:
+		if count > 4 {
+			for i, l := range CalculateLayouts(count, w, h) {
+				layouts[i] = Layout{l.X0 + area.X0, l.Y0 + area.Y0, l.X1 + area.X0, l.Y1 + area.Y0}
+			}
+			break
+		}
+
+		splitY := area.Y0 + h/2
+		left := Layout{area.X0, area.Y0, splitX - 1, area.Y1}
+		right := Layout{splitX, area.Y0, area.X1, area.Y1}
+		switch count {
+		case 1:
+			layouts[0] = area
+		case 2:
+			layouts[0], layouts[1] = left, right
+		case 3:
+			layouts[0] = Layout{left.X0, area.Y0, left.X1, splitY - 1}
+			layouts[1] = Layout{right.X0, area.Y0, right.X1, splitY - 1}
+			layouts[2] = Layout{area.X0, splitY, area.X1, area.Y1}
+		case 4:
+			layouts[0] = Layout{left.X0, area.Y0, left.X1, splitY - 1}
+			layouts[1] = Layout{right.X0, area.Y0, right.X1, splitY - 1}
+			layouts[2] = Layout{left.X0, splitY, left.X1, area.Y1}
+			layouts[3] = Layout{right.X0, splitY, right.X1, area.Y1}
+		}
+	}
+
+	return layouts
+}
+
+// SplitPosition returns the first column right of the main vertical split,
+// and false when the arrangement has no adjustable split.
+func SplitPosition(preset LayoutPreset, count int, area Layout, ratio int) (int, bool) {
+	w := area.X1 - area.X0 + 1
+	x := area.X0 + (w*ClampRatio(ratio))/100
+
+	switch preset {
+	case PresetMainVertical:
+		return x, count > 1
+	case PresetEvenHorizontal:
+		return 0, false
+	default:
+		return x, count >= 2 && count <= 4
+	}
+}
+
+// RatioAt converts a column inside area to a split ratio, clamped to the allowed range.
+func RatioAt(area Layout, x int) int {
+	w := area.X1 - area.X0 + 1
+	if w <= 0 {
+		return DefaultSplitRatio
+	}
+	return ClampRatio(max((x-area.X0)*100/w, 1))
+}
@@ -81,3 +81,88 @@ func TestCalculateLayouts_Empty(t *testing.T) {
 		t.Errorf("expected nil for 0 panes, got %v", layouts)
 	}
 }
+
+func TestCalculatePresetLayouts_GridMatchesCalculateLayouts(t *testing.T) {
+	area := Layout{0, 0, 99, 49}
+	for count := 1; count <= 6; count++ {
+		got := CalculatePresetLayouts(PresetGrid, count, area, DefaultSplitRatio)
+		want := CalculateLayouts(count, 100, 50)
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("count %d: layout %d = %+v, want %+v", count, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestCalculatePresetLayouts_Offset(t *testing.T) {
+	area := Layout{30, 0, 129, 49}
+	layouts := CalculatePresetLayouts(PresetGrid, 2, area, 70)
+
+	if layouts[0] != (Layout{30, 0, 99, 49}) {
+		t.Errorf("unexpected left layout: %+v", layouts[0])
+	}
+	if layouts[1] != (Layout{100, 0, 129, 49}) {
+		t.Errorf("unexpected right layout: %+v", layouts[1])
+	}
+}
+
+func TestCalculatePresetLayouts_MainVertical(t *testing.T) {
+	area := Layout{0, 0, 99, 59}
+	layouts := CalculatePresetLayouts(PresetMainVertical, 4, area, 60)
+
+	if layouts[0] != (Layout{0, 0, 59, 59}) {
+		t.Errorf("unexpected main layout: %+v", layouts[0])
+	}
+	want := []Layout{{60, 0, 99, 19}, {60, 20, 99, 39}, {60, 40, 99, 59}}
+	for i, w := range want {
+		if layouts[i+1] != w {
+			t.Errorf("stacked layout %d = %+v, want %+v", i, layouts[i+1], w)
+		}
+	}
+}
+
+func TestCalculatePresetLayouts_EvenHorizontal(t *testing.T) {
+	area := Layout{0, 0, 89, 49}
+	layouts := CalculatePresetLayouts(PresetEvenHorizontal, 3, area, 70)
+
+	want := []Layout{{0, 0, 29, 49}, {30, 0, 59, 49}, {60, 0, 89, 49}}
+	for i, w := range want {
+		if layouts[i] != w {
+			t.Errorf("layout %d = %+v, want %+v", i, layouts[i], w)
+		}
+	}
+	if _, ok := SplitPosition(PresetEvenHorizontal, 3, area, 70); ok {
+		t.Error("even-horizontal should have no adjustable split")
+	}
+}
+
+func TestPresetHelpers(t *testing.T) {
+	if ParsePreset("main-vertical") != PresetMainVertical {
+		t.Error("ParsePreset(main-vertical) failed")
+	}
+	if ParsePreset("bogus") != PresetGrid {
+		t.Error("unknown presets should fall back to grid")
+	}
+	if NextPreset(PresetEvenHorizontal) != PresetGrid {
+		t.Error("NextPreset should wrap around")
+	}
+}
+
+func TestRatioAt(t *testing.T) {
+	area := Layout{30, 0, 129, 49}
+	tests := []struct {
+		x    int
+		want int
+	}{
+		{80, 50},
+		{35, MinSplitRatio},
+		{129, MaxSplitRatio},
+		{0, MinSplitRatio},
+	}
+	for _, tt := range tests {
+		if got := RatioAt(area, tt.x); got != tt.want {
+			t.Errorf("RatioAt(%d) = %d, want %d", tt.x, got, tt.want)
+		}
+	}
+}
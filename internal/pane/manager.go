@@ -59,6 +59,19 @@ func (m *Manager) Get(idx int) *Pane {
 	return m.panes[idx]
 }
 
+// IndexOf returns the index of the given pane, or -1 if it is not managed.
+func (m *Manager) IndexOf(p *Pane) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for i, candidate := range m.panes {
+		if candidate == p {
+			return i
+		}
+	}
+	return -1
+}
+
 // Active returns the currently active pane, or nil if none.
 func (m *Manager) Active() *Pane {
 	m.mu.RLock()
@@ -18,7 +18,8 @@ type Pane struct {
 	ViewName   string
 }
 
-// New creates a new Pane with the given session name and dimensions.
+// New creates a new Pane with the given tmux target and dimensions.
+// The target may be a session name or a session:window.pane target.
 // The caller is responsible for starting the control mode connection.
 func New(index int, sessionName string, width, height int) *Pane {
 	// Ensure minimum dimensions
@@ -16,7 +16,8 @@ type Scrollback struct {
 	cacheValid bool     // Whether cache is still valid
 }
 
-// NewScrollback creates a new scrollback manager for the given tmux session.
+// NewScrollback creates a new scrollback manager for the given tmux session
+// or session:window.pane target.
 func NewScrollback(session string) *Scrollback {
 	return &Scrollback{
 		session: session,
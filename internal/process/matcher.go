@@ -0,0 +1,111 @@
+package process
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DefaultClaudePatterns match Claude Code installed natively or with npm,
+// and run through npx or node.
+var DefaultClaudePatterns = []string{"claude", "claude-*", "*/@anthropic-ai/claude-code/*"}
+
+// Matcher recognises processes by glob patterns on their executable.
+type Matcher struct {
+	patterns []*regexp.Regexp
+}
+
+// NewMatcher compiles glob patterns where * matches any run of characters,
+// slashes included, and ? any single character.
+func NewMatcher(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		expr := regexp.QuoteMeta(pattern)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		m.patterns = append(m.patterns, regexp.MustCompile("^"+expr+"$"))
+	}
+	return m
+}
+
+// launchers run the script or package named by their first argument, which
+// is what identifies the process.
+var launchers = map[string]bool{
+	"node": true, "nodejs": true, "npx": true, "bun": true, "bunx": true, "deno": true,
+	"sh": true, "bash": true, "zsh": true, "dash": true, "python": true, "python3": true,
+}
+
+// Match reports whether a process runs a matching executable. The name and
+// program and, for launchers such as node or bash, the script they run are
+// each matched in full and by base name. Other programs' arguments are
+// ignored, so grep claude is not Claude.
+func (m *Matcher) Match(p Process) bool {
+	words := strings.Fields(p.Args)
+	var candidates []string
+	if p.Name != "" {
+		candidates = append(candidates, p.Name)
+	}
+	if len(words) > 0 {
+		candidates = append(candidates, words[0])
+		if launchers[filepath.Base(words[0])] {
+			if script, ok := launchedScript(words[1:]); ok {
+				candidates = append(candidates, script)
+			}
+		}
+	}
+	for _, word := range candidates {
+		for _, re := range m.patterns {
+			if re.MatchString(word) || re.MatchString(filepath.Base(word)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// launchedScript returns the script a launcher runs: its first argument
+// that isn't a flag, after deno's and bun's run subcommand.
+func launchedScript(args []string) (string, bool) {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || arg == "run" {
+			continue
+		}
+		return arg, true
+	}
+	return "", false
+}
+
+// Find returns the matching process in the tree of pid. When a match
+// starts another, such as a wrapper script running Claude, the innermost
+// one is returned.
+func (m *Matcher) Find(t *Table, pid int) (Process, bool) {
+	var found Process
+	ok := false
+	for _, p := range t.Descendants(pid) {
+		if !m.Match(p) {
+			continue
+		}
+		if !ok || t.isDescendant(p.PID, found.PID) {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+// isDescendant reports whether pid was started, directly or not, by
+// ancestor.
+func (t *Table) isDescendant(pid, ancestor int) bool {
+	seen := make(map[int]bool)
+	for pid != ancestor && !seen[pid] {
+		seen[pid] = true
+		p, ok := t.procs[pid]
+		if !ok || p.PPID == pid {
+			return false
+		}
+		pid = p.PPID
+	}
+	return pid == ancestor
+}
@@ -0,0 +1,65 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatcherMatch(t *testing.T) {
+	m := NewMatcher(DefaultClaudePatterns)
+
+	tests := []struct {
+		p    Process
+		want bool
+	}{
+		{Process{Name: "claude", Args: "claude --resume abc"}, true},
+		{Process{Name: "claude", Args: "/home/u/.local/bin/claude"}, true},
+		{Process{Name: "node", Args: "node /usr/lib/node_modules/@anthropic-ai/claude-code/cli.js"}, true},
+		{Process{Name: "node", Args: "node /home/u/.npm/_npx/1/node_modules/.bin/claude"}, true},
+		{Process{Name: "claude-wrapper", Args: "claude-wrapper"}, true},
+		{Process{Name: "node", Args: "node server.js"}, false},
+		{Process{Name: "vim", Args: "vim claude.md"}, false},
+		{Process{Name: "git", Args: "git commit -m claude"}, false},
+		{Process{Name: "grep", Args: "grep claude"}, false},
+		{Process{Name: "man", Args: "man claude"}, false},
+		{Process{Name: "vim", Args: "vim claude-notes.md"}, false},
+		{Process{Name: "tail", Args: "tail -f claude-code.log"}, false},
+		{Process{Name: "npx", Args: "npx -y claude"}, true},
+		{Process{Name: "bun", Args: "bun run /opt/claude"}, true},
+		{Process{Name: "bash", Args: "bash /home/u/bin/claude-yolo"}, true},
+		{Process{}, false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.p); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.p.Args, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherCustomPatterns(t *testing.T) {
+	m := NewMatcher([]string{"cc-?", "*/agents/*"})
+
+	if !m.Match(Process{Args: "/opt/bin/cc-1"}) {
+		t.Error("expected cc-? to match cc-1 by base name")
+	}
+	if !m.Match(Process{Args: "python /srv/agents/run.py"}) {
+		t.Error("expected */agents/* to match the script argument")
+	}
+	if m.Match(Process{Name: "claude", Args: "claude"}) {
+		t.Error("expected claude not to match custom patterns")
+	}
+}
+
+func TestMatcherFindInnermost(t *testing.T) {
+	m := NewMatcher(append([]string{"cc"}, DefaultClaudePatterns...))
+
+	// The wrapper script "cc" matches too, but node is what runs Claude
+	p, ok := m.Find(testTable(time.Now(), 0), 10)
+	if !ok || p.PID != 12 {
+		t.Errorf("Find() = %v, %v, want PID 12", p, ok)
+	}
+
+	if _, ok := m.Find(testTable(time.Now(), 0), 20); ok {
+		t.Error("expected no Claude under vim")
+	}
+}
@@ -0,0 +1,92 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the unit of the CPU times in /proc/[pid]/stat. It is 100
+// on every Linux build in practice.
+const clockTicks = 100
+
+// readProc reads every process from a /proc file system. Processes that
+// exit while it is read are skipped.
+func readProc(root string, pageSize int) ([]Process, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var procs []Process
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join(root, e.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		p, err := parseStat(stat, pageSize)
+		if err != nil || p.PID != pid {
+			continue
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(root, e.Name(), "cmdline")); err == nil {
+			p.Args = parseCmdline(cmdline)
+		}
+		if p.Args == "" {
+			p.Args = p.Name
+		}
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// parseStat parses /proc/[pid]/stat. The executable name is in parentheses
+// and may itself contain spaces and parentheses, so fields are counted from
+// the last closing one.
+func parseStat(data []byte, pageSize int) (Process, error) {
+	s := string(data)
+	start := strings.IndexByte(s, '(')
+	end := strings.LastIndexByte(s, ')')
+	if start < 0 || end < start {
+		return Process{}, fmt.Errorf("malformed stat: %q", s)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(s[:start]))
+	if err != nil {
+		return Process{}, fmt.Errorf("malformed stat pid: %w", err)
+	}
+
+	// Fields from the state (field 3) on
+	fields := strings.Fields(s[end+1:])
+	if len(fields) < 22 {
+		return Process{}, fmt.Errorf("short stat: %q", s)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Process{}, fmt.Errorf("malformed stat ppid: %w", err)
+	}
+	utime, _ := strconv.ParseInt(fields[11], 10, 64)
+	stime, _ := strconv.ParseInt(fields[12], 10, 64)
+	rss, _ := strconv.ParseInt(fields[21], 10, 64)
+
+	return Process{
+		PID:     pid,
+		PPID:    ppid,
+		Name:    s[start+1 : end],
+		CPUTime: time.Duration(utime+stime) * time.Second / clockTicks,
+		RSS:     rss * int64(pageSize),
+	}, nil
+}
+
+// parseCmdline joins the NUL-separated arguments of /proc/[pid]/cmdline.
+func parseCmdline(data []byte) string {
+	data = bytes.TrimRight(data, "\x00")
+	return string(bytes.ReplaceAll(data, []byte{0}, []byte{' '}))
+}
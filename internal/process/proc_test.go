@@ -0,0 +1,75 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseStat(t *testing.T) {
+	stat := "4242 (tmux: server (1)) S 1 4242 4242 0 -1 4194560 2000 0 0 0 150 50 0 0 20 0 1 0 1000 2703360 321 18446744073709551615\n"
+	p, err := parseStat([]byte(stat), 4096)
+	if err != nil {
+		t.Fatalf("parseStat() error: %v", err)
+	}
+	if p.PID != 4242 || p.PPID != 1 {
+		t.Errorf("PID, PPID = %d, %d, want 4242, 1", p.PID, p.PPID)
+	}
+	if p.Name != "tmux: server (1)" {
+		t.Errorf("Name = %q", p.Name)
+	}
+	if p.CPUTime != 2*time.Second {
+		t.Errorf("CPUTime = %v, want 2s", p.CPUTime)
+	}
+	if p.RSS != 321*4096 {
+		t.Errorf("RSS = %d, want %d", p.RSS, 321*4096)
+	}
+
+	for _, bad := range []string{"", "12 (x", "12 (x) S 1 2 3", "x (y) S 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16 17 18 19 20 21 22"} {
+		if _, err := parseStat([]byte(bad), 4096); err == nil {
+			t.Errorf("parseStat(%q) expected error", bad)
+		}
+	}
+}
+
+func TestParseCmdline(t *testing.T) {
+	if got := parseCmdline([]byte("node\x00/usr/bin/claude\x00--resume\x00")); got != "node /usr/bin/claude --resume" {
+		t.Errorf("parseCmdline() = %q", got)
+	}
+	if got := parseCmdline(nil); got != "" {
+		t.Errorf("parseCmdline(nil) = %q", got)
+	}
+}
+
+func TestReadProc(t *testing.T) {
+	root := t.TempDir()
+	write := func(pid, stat, cmdline string) {
+		dir := filepath.Join(root, pid)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644)
+		os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644)
+	}
+	rest := " 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 10\n"
+	write("1", "1 (init) S 0"+rest, "/sbin/init\x00")
+	write("2", "2 (kthreadd) S 0"+rest, "")
+	write("30", "30 (claude) S 1"+rest, "claude\x00--resume\x00")
+	write("31", "garbage", "")
+	os.WriteFile(filepath.Join(root, "uptime"), []byte("1 2"), 0644)
+
+	procs, err := readProc(root, 4096)
+	if err != nil {
+		t.Fatalf("readProc() error: %v", err)
+	}
+	table := NewTable(procs, time.Now())
+
+	if p, ok := table.Get(30); !ok || p.Args != "claude --resume" || p.PPID != 1 || p.RSS != 10*4096 {
+		t.Errorf("process 30 = %+v, %v", p, ok)
+	}
+	if p, ok := table.Get(2); !ok || p.Args != "kthreadd" {
+		t.Errorf("kernel thread = %+v, %v", p, ok)
+	}
+	if _, ok := table.Get(31); ok {
+		t.Error("expected a malformed stat to be skipped")
+	}
+}
@@ -31,54 +31,19 @@ func GetCommandLine(pid int) (string, error) {
 }
 
 // GetChildProcesses returns all direct child processes of the given PID.
-// Works on macOS and Linux using POSIX ps.
 func GetChildProcesses(pid int) ([]Info, error) {
-	// Use ps to list all processes with their PPID, then filter
-	// Format: PID PPID COMMAND (tab-separated for reliable parsing)
-	cmd := exec.Command("ps", "-eo", "pid=,ppid=,args=")
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("ps failed: %w: %s", err, stderr.String())
+	table, err := Snapshot()
+	if err != nil {
+		return nil, err
 	}
 
-	parentPID := strconv.Itoa(pid)
 	var children []Info
-
-	lines := strings.Split(stdout.String(), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Parse: "  PID  PPID COMMAND..."
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue
-		}
-
-		ppid := fields[1]
-		if ppid != parentPID {
-			continue
-		}
-
-		childPID, err := strconv.Atoi(fields[0])
-		if err != nil {
-			continue
-		}
-
-		// Reconstruct command from remaining fields
-		cmdLine := strings.Join(fields[2:], " ")
-
+	for _, child := range table.Children(pid) {
 		children = append(children, Info{
-			PID:     childPID,
-			Command: cmdLine,
+			PID:     child.PID,
+			Command: child.Args,
 		})
 	}
-
 	return children, nil
 }
 
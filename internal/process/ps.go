@@ -0,0 +1,85 @@
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readPS reads every process using POSIX ps.
+func readPS() ([]Process, error) {
+	cmd := exec.Command("ps", "-eo", "pid=,ppid=,rss=,time=,args=")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("ps failed: %w: %s", err, stderr.String())
+	}
+	return parsePS(stdout.String()), nil
+}
+
+// parsePS parses "pid ppid rss time args" lines, with rss in KiB.
+func parsePS(output string) []Process {
+	var procs []Process
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		rss, _ := strconv.ParseInt(fields[2], 10, 64)
+		cpu, _ := parseCPUTime(fields[3])
+		args := strings.Join(fields[4:], " ")
+
+		procs = append(procs, Process{
+			PID:     pid,
+			PPID:    ppid,
+			Name:    extractCommandName(args),
+			Args:    args,
+			CPUTime: cpu,
+			RSS:     rss * 1024,
+		})
+	}
+	return procs
+}
+
+// parseCPUTime parses ps's cumulative CPU time: [[dd-]hh:]mm:ss on Linux,
+// mm:ss.ss on macOS.
+func parseCPUTime(s string) (time.Duration, error) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("malformed cpu time %q", s)
+		}
+		days, s = n, rest
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("malformed cpu time %q", s)
+	}
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("malformed cpu time %q", s)
+	}
+	total := time.Duration(seconds * float64(time.Second))
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, fmt.Errorf("malformed cpu time %q", s)
+		}
+		total += time.Duration(n) * unit
+		unit *= 60
+	}
+	return total + time.Duration(days)*24*time.Hour, nil
+}
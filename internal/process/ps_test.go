@@ -0,0 +1,54 @@
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePS(t *testing.T) {
+	output := `    1     0  9484 00:00:18 /sbin/init splash
+    2     0     0 00:00:00 [kthreadd]
+  300     1 204800 1-02:03:04 node /usr/lib/node_modules/@anthropic-ai/claude-code/cli.js
+bad line
+`
+	procs := parsePS(output)
+	if len(procs) != 3 {
+		t.Fatalf("parsePS() returned %d processes, want 3", len(procs))
+	}
+
+	p := procs[2]
+	if p.PID != 300 || p.PPID != 1 || p.Name != "node" {
+		t.Errorf("process = %+v", p)
+	}
+	if p.RSS != 204800*1024 {
+		t.Errorf("RSS = %d", p.RSS)
+	}
+	if want := 26*time.Hour + 3*time.Minute + 4*time.Second; p.CPUTime != want {
+		t.Errorf("CPUTime = %v, want %v", p.CPUTime, want)
+	}
+}
+
+func TestParseCPUTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"00:00:18", 18 * time.Second},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"2-00:00:01", 48*time.Hour + time.Second},
+		{"0:01.50", time.Second + 500*time.Millisecond},
+		{"12:34", 12*time.Minute + 34*time.Second},
+	}
+	for _, tt := range tests {
+		got, err := parseCPUTime(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseCPUTime(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "x:00", "1:2:3:4", "a-00:00:01"} {
+		if _, err := parseCPUTime(bad); err == nil {
+			t.Errorf("parseCPUTime(%q) expected error", bad)
+		}
+	}
+}
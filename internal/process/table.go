@@ -0,0 +1,127 @@
+package process
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// Process is a row of the process table.
+type Process struct {
+	PID     int
+	PPID    int
+	Name    string        // executable name
+	Args    string        // full command line, or the name for kernel threads
+	CPUTime time.Duration // user and system time used so far
+	RSS     int64         // resident memory in bytes
+}
+
+// Usage is the resources used by a process.
+type Usage struct {
+	CPU float64 // percent of one core used since the previous snapshot
+	RSS int64   // resident memory in bytes
+}
+
+// Table is a snapshot of every process, indexed for walking process trees.
+type Table struct {
+	Time     time.Time
+	procs    map[int]Process
+	children map[int][]int // child PIDs by parent, ascending
+}
+
+// Snapshot reads the process table, from /proc on Linux and from ps
+// elsewhere or when /proc can't be read.
+func Snapshot() (*Table, error) {
+	now := time.Now()
+	if runtime.GOOS == "linux" {
+		if procs, err := readProc("/proc", os.Getpagesize()); err == nil {
+			return NewTable(procs, now), nil
+		}
+	}
+	procs, err := readPS()
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(procs, now), nil
+}
+
+// NewTable indexes processes read at the given time.
+func NewTable(procs []Process, at time.Time) *Table {
+	t := &Table{
+		Time:     at,
+		procs:    make(map[int]Process, len(procs)),
+		children: make(map[int][]int),
+	}
+	for _, p := range procs {
+		t.procs[p.PID] = p
+		// PID 0 is the kernel, the parent of init, not a process of its own
+		if p.PID != p.PPID {
+			t.children[p.PPID] = append(t.children[p.PPID], p.PID)
+		}
+	}
+	for _, pids := range t.children {
+		sort.Ints(pids)
+	}
+	return t
+}
+
+// Get returns a process by PID.
+func (t *Table) Get(pid int) (Process, bool) {
+	p, ok := t.procs[pid]
+	return p, ok
+}
+
+// Children returns the direct children of a process, by ascending PID.
+func (t *Table) Children(pid int) []Process {
+	var children []Process
+	for _, child := range t.children[pid] {
+		children = append(children, t.procs[child])
+	}
+	return children
+}
+
+// Descendants returns a process and everything it started, directly or
+// not, breadth first and starting with the process itself.
+func (t *Table) Descendants(pid int) []Process {
+	var tree []Process
+	if p, ok := t.procs[pid]; ok {
+		tree = append(tree, p)
+	}
+
+	seen := map[int]bool{pid: true}
+	queue := []int{pid}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range t.children[current] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			tree = append(tree, t.procs[child])
+			queue = append(queue, child)
+		}
+	}
+	return tree
+}
+
+// Usage returns the memory a process holds and the CPU it used between a
+// previous snapshot and this one. Without a previous snapshot of the same
+// process the CPU is zero.
+func (t *Table) Usage(pid int, prev *Table) Usage {
+	p, ok := t.procs[pid]
+	if !ok {
+		return Usage{}
+	}
+	usage := Usage{RSS: p.RSS}
+	if prev == nil {
+		return usage
+	}
+	before, ok := prev.procs[pid]
+	elapsed := t.Time.Sub(prev.Time)
+	if ok && elapsed > 0 && p.CPUTime >= before.CPUTime {
+		usage.CPU = 100 * float64(p.CPUTime-before.CPUTime) / float64(elapsed)
+	}
+	return usage
+}
@@ -0,0 +1,82 @@
+package process
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// testTable is a shell running a wrapper that runs node, beside a vim.
+func testTable(at time.Time, nodeCPU time.Duration) *Table {
+	return NewTable([]Process{
+		{PID: 1, PPID: 0, Name: "init", Args: "/sbin/init"},
+		{PID: 10, PPID: 1, Name: "zsh", Args: "-zsh"},
+		{PID: 11, PPID: 10, Name: "bash", Args: "bash /home/u/bin/cc"},
+		{PID: 12, PPID: 11, Name: "node", Args: "node /home/u/.npm/_npx/1/node_modules/.bin/claude", CPUTime: nodeCPU, RSS: 200 << 20},
+		{PID: 13, PPID: 12, Name: "bash", Args: "bash -c ls"},
+		{PID: 20, PPID: 1, Name: "vim", Args: "vim claude.md"},
+	}, at)
+}
+
+func TestTableDescendants(t *testing.T) {
+	table := testTable(time.Now(), 0)
+
+	var pids []int
+	for _, p := range table.Descendants(10) {
+		pids = append(pids, p.PID)
+	}
+	want := []int{10, 11, 12, 13}
+	if len(pids) != len(want) {
+		t.Fatalf("Descendants(10) = %v, want %v", pids, want)
+	}
+	for i := range want {
+		if pids[i] != want[i] {
+			t.Fatalf("Descendants(10) = %v, want %v", pids, want)
+		}
+	}
+
+	if children := table.Children(1); len(children) != 2 || children[0].PID != 10 || children[1].PID != 20 {
+		t.Errorf("Children(1) = %v", children)
+	}
+	if tree := table.Descendants(99); len(tree) != 0 {
+		t.Errorf("Descendants of a missing process = %v", tree)
+	}
+}
+
+func TestTableUsage(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	prev := testTable(start, 10*time.Second)
+	table := testTable(start.Add(2*time.Second), 11*time.Second)
+
+	usage := table.Usage(12, prev)
+	if usage.CPU != 50 {
+		t.Errorf("CPU = %v, want 50", usage.CPU)
+	}
+	if usage.RSS != 200<<20 {
+		t.Errorf("RSS = %d, want %d", usage.RSS, 200<<20)
+	}
+
+	if usage := table.Usage(12, nil); usage.CPU != 0 || usage.RSS != 200<<20 {
+		t.Errorf("Usage without a previous snapshot = %+v", usage)
+	}
+	if usage := table.Usage(99, prev); usage != (Usage{}) {
+		t.Errorf("Usage of a missing process = %+v", usage)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	table, err := Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot() error: %v", err)
+	}
+	self, ok := table.Get(os.Getpid())
+	if !ok {
+		t.Fatal("Snapshot() is missing the current process")
+	}
+	if self.PPID != os.Getppid() {
+		t.Errorf("PPID = %d, want %d", self.PPID, os.Getppid())
+	}
+	if self.Args == "" || self.RSS == 0 {
+		t.Errorf("current process = %+v", self)
+	}
+}
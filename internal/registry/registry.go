@@ -0,0 +1,184 @@
+// Package registry remembers every Claude session cmux has seen so that
+// sessions whose tmux session is gone can be resumed later.
+package registry
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is what the registry knows about one tmux session.
+type Entry struct {
+	Name            string    `json:"name"` // tmux session name
+	RepoPath        string    `json:"repo_path,omitempty"`
+	Branch          string    `json:"branch,omitempty"`
+	Worktree        string    `json:"worktree,omitempty"` // directory Claude ran in
+	ClaudeSessionID string    `json:"claude_session_id,omitempty"`
+	TranscriptPath  string    `json:"transcript_path,omitempty"`
+	PRURL           string    `json:"pr_url,omitempty"` // pull request opened for the branch
+	LastSeen        time.Time `json:"last_seen"`
+}
+
+// Registry is a persistent, concurrency-safe set of entries keyed by name.
+type Registry struct {
+	mu       sync.RWMutex
+	filePath string
+	entries  map[string]*Entry
+}
+
+// New creates a registry backed by the given file.
+func New(filePath string) *Registry {
+	return &Registry{
+		filePath: filePath,
+		entries:  make(map[string]*Entry),
+	}
+}
+
+// Load reads the registry file. A missing file is not an error.
+func (r *Registry) Load() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var entries []*Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return err
+	}
+	r.entries = make(map[string]*Entry, len(entries))
+	for _, e := range entries {
+		if e.Name != "" {
+			r.entries[e.Name] = e
+		}
+	}
+	return nil
+}
+
+// Save writes the registry file, sorted by name. The file is replaced
+// whole, and saves from the GUI and the hook watcher are serialized, so a
+// crash or a concurrent save never leaves it truncated.
+func (r *Registry) Save() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := make([]*Entry, 0, len(r.entries))
+	for _, e := range r.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.filePath), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.filePath), ".registry-*.json")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), r.filePath)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Record merges the non-empty fields of e into the entry for e.Name and
+// marks it seen now. It reports whether anything other than LastSeen
+// changed, i.e. whether the registry is worth saving.
+func (r *Registry) Record(e Entry) bool {
+	if e.Name == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.entries[e.Name]
+	if !ok {
+		e.LastSeen = time.Now()
+		r.entries[e.Name] = &e
+		return true
+	}
+
+	changed := false
+	merge := func(dst *string, src string) {
+		if src != "" && *dst != src {
+			*dst = src
+			changed = true
+		}
+	}
+	merge(&existing.RepoPath, e.RepoPath)
+	merge(&existing.Branch, e.Branch)
+	merge(&existing.Worktree, e.Worktree)
+	merge(&existing.ClaudeSessionID, e.ClaudeSessionID)
+	merge(&existing.TranscriptPath, e.TranscriptPath)
+	merge(&existing.PRURL, e.PRURL)
+	existing.LastSeen = time.Now()
+
+	return changed
+}
+
+// Get returns a copy of the entry for a session.
+func (r *Registry) Get(name string) (Entry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, ok := r.entries[name]
+	if !ok {
+		return Entry{}, false
+	}
+	return *e, true
+}
+
+// Remove forgets a session.
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	delete(r.entries, name)
+	r.mu.Unlock()
+}
+
+// Dormant returns the resumable entries whose session is not in alive,
+// most recently seen first. Entries without a Claude session ID can't be
+// resumed and are skipped.
+func (r *Registry) Dormant(alive []string) []Entry {
+	live := make(map[string]bool, len(alive))
+	for _, name := range alive {
+		live[name] = true
+	}
+
+	r.mu.RLock()
+	var dormant []Entry
+	for name, e := range r.entries {
+		if !live[name] && e.ClaudeSessionID != "" {
+			dormant = append(dormant, *e)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(dormant, func(i, j int) bool {
+		return dormant[i].LastSeen.After(dormant[j].LastSeen)
+	})
+	return dormant
+}
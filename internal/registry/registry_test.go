@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordMerges(t *testing.T) {
+	r := New(filepath.Join(t.TempDir(), "sessions.json"))
+
+	if !r.Record(Entry{Name: "api/auth", RepoPath: "/src/api", Branch: "auth"}) {
+		t.Error("first Record should report a change")
+	}
+	if !r.Record(Entry{Name: "api/auth", ClaudeSessionID: "abc", Worktree: "/src/api/.worktrees/auth"}) {
+		t.Error("new session ID should report a change")
+	}
+	if r.Record(Entry{Name: "api/auth", ClaudeSessionID: "abc"}) {
+		t.Error("repeated fields should not report a change")
+	}
+
+	e, ok := r.Get("api/auth")
+	if !ok {
+		t.Fatal("entry not found")
+	}
+	if e.RepoPath != "/src/api" || e.Branch != "auth" || e.ClaudeSessionID != "abc" || e.Worktree != "/src/api/.worktrees/auth" {
+		t.Errorf("unexpected merged entry: %+v", e)
+	}
+	if e.LastSeen.IsZero() {
+		t.Error("LastSeen should be set")
+	}
+}
+
+func TestDormant(t *testing.T) {
+	r := New(filepath.Join(t.TempDir(), "sessions.json"))
+	r.Record(Entry{Name: "live", ClaudeSessionID: "1"})
+	r.Record(Entry{Name: "old", ClaudeSessionID: "2"})
+	r.Record(Entry{Name: "no-id"})
+	time.Sleep(time.Millisecond)
+	r.Record(Entry{Name: "recent", ClaudeSessionID: "3"})
+
+	dormant := r.Dormant([]string{"live"})
+	if len(dormant) != 2 {
+		t.Fatalf("expected 2 dormant entries, got %+v", dormant)
+	}
+	if dormant[0].Name != "recent" || dormant[1].Name != "old" {
+		t.Errorf("dormant order = %s, %s; want recent, old", dormant[0].Name, dormant[1].Name)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "sessions.json")
+	r := New(path)
+	r.Record(Entry{Name: "web/main", Worktree: "/src/web", ClaudeSessionID: "xyz", TranscriptPath: "/t.jsonl"})
+	if err := r.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := New(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	e, ok := loaded.Get("web/main")
+	if !ok || e.ClaudeSessionID != "xyz" || e.TranscriptPath != "/t.jsonl" {
+		t.Errorf("loaded entry = %+v, %v", e, ok)
+	}
+
+	loaded.Remove("web/main")
+	if _, ok := loaded.Get("web/main"); ok {
+		t.Error("entry should be removed")
+	}
+}
+
+func TestConcurrentSaves(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sessions.json")
+	r := New(path)
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Record(Entry{Name: fmt.Sprintf("web/%d", i), ClaudeSessionID: "xyz"})
+			if err := r.Save(); err != nil {
+				t.Errorf("Save() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded := New(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if dormant := loaded.Dormant(nil); len(dormant) != 10 {
+		t.Errorf("loaded %d entries, want 10", len(dormant))
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 1 {
+		t.Errorf("files left = %v, want only the registry", files)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	r := New(filepath.Join(t.TempDir(), "missing.json"))
+	if err := r.Load(); err != nil {
+		t.Errorf("Load() error = %v, want nil", err)
+	}
+}
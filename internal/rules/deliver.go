@@ -0,0 +1,178 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeliveryStatus is the outcome of one delivery attempt.
+type DeliveryStatus string
+
+const (
+	Delivered DeliveryStatus = "delivered"
+	Retrying  DeliveryStatus = "retrying" // failed, tried again after a delay
+	Failed    DeliveryStatus = "failed"   // failed with no retries left
+	Dropped   DeliveryStatus = "dropped"  // not attempted, the rule's queue was full
+)
+
+// commandTimeout bounds a rule's command, which may run a local CI.
+const commandTimeout = 10 * time.Minute
+
+// Delivery is an entry of the delivery log.
+type Delivery struct {
+	Rule    string
+	Event   Event
+	Attempt int
+	Status  DeliveryStatus
+	Detail  string // HTTP status, or why the attempt failed
+	Time    time.Time
+}
+
+// Log returns the latest deliveries, newest first.
+func (e *Engine) Log() []Delivery {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	log := make([]Delivery, len(e.log))
+	for i, d := range e.log {
+		log[len(e.log)-1-i] = d
+	}
+	return log
+}
+
+// record adds an attempt to the log, dropping the oldest past logSize.
+func (e *Engine) record(d Delivery) {
+	e.mu.Lock()
+	e.log = append(e.log, d)
+	if len(e.log) > logSize {
+		e.log = e.log[len(e.log)-logSize:]
+	}
+	fn := e.onDelivery
+	e.mu.Unlock()
+
+	if fn != nil {
+		fn(d)
+	}
+}
+
+// work delivers a rule's events in order until the engine stops.
+func (e *Engine) work(r *rule) {
+	defer e.wg.Done()
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case ev := <-r.queue:
+			e.deliver(r, ev)
+		}
+	}
+}
+
+// deliver sends an event, retrying with growing delays until it succeeds,
+// the rule's retries run out or the engine stops.
+func (e *Engine) deliver(r *rule, ev Event) {
+	for attempt := 1; ; attempt++ {
+		detail, err := e.send(r, ev)
+		d := Delivery{Rule: r.Name, Event: ev, Attempt: attempt, Status: Delivered, Detail: detail, Time: time.Now()}
+		if err != nil {
+			d.Status, d.Detail = Retrying, err.Error()
+			if attempt > r.retries {
+				d.Status = Failed
+			}
+		}
+		e.record(d)
+		if d.Status != Retrying {
+			return
+		}
+
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-time.After(e.backoff(attempt)):
+		}
+	}
+}
+
+// send makes one attempt at delivering an event.
+func (e *Engine) send(r *rule, ev Event) (string, error) {
+	if r.URL != "" {
+		return e.post(r, ev)
+	}
+	return run(e.ctx, r, ev)
+}
+
+// post sends the event to the rule's URL. Any status but 2xx fails.
+func (e *Engine) post(r *rule, ev Event) (string, error) {
+	var body []byte
+	if r.Payload != "" {
+		body = []byte(r.payload(ev))
+	} else {
+		body, _ = json.Marshal(ev)
+	}
+
+	req, err := http.NewRequestWithContext(e.ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range r.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(reply)))
+	}
+	return "HTTP " + strconv.Itoa(resp.StatusCode), nil
+}
+
+// run runs the rule's command by the shell in the event's directory, with
+// the event as JSON on stdin and in CMUX_ variables, until it finishes,
+// times out or ctx is cancelled.
+func run(ctx context.Context, r *rule, ev Event) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	data, _ := json.Marshal(ev)
+	cmd := exec.CommandContext(ctx, "sh", "-c", r.Command)
+	// Children of the killed shell may hold its output open
+	cmd.WaitDelay = time.Second
+	if info, err := os.Stat(ev.Dir); err == nil && info.IsDir() {
+		cmd.Dir = ev.Dir
+	}
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"CMUX_EVENT="+string(ev.Type),
+		"CMUX_SESSION="+ev.Session,
+		"CMUX_REPO="+ev.Repo,
+		"CMUX_TOOL="+ev.Tool,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %s", r.Command, err, lastLine(string(out)))
+	}
+	return "exit 0", nil
+}
+
+// lastLine returns the last non-empty line of output, where commands
+// usually say why they failed.
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
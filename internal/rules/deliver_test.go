@@ -0,0 +1,181 @@
+package rules
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+)
+
+var errTest = errors.New("no tmux server")
+
+// startEngine creates an engine for rules that retries at once, and the
+// channel its deliveries are logged to.
+func startEngine(t *testing.T, rules ...config.Rule) (*Engine, chan Delivery) {
+	t.Helper()
+	e, err := New(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(e.Stop)
+	e.backoff = func(int) time.Duration { return 0 }
+
+	deliveries := make(chan Delivery, 10)
+	e.OnDelivery(func(d Delivery) { deliveries <- d })
+	return e, deliveries
+}
+
+// next waits for the next delivery.
+func next(t *testing.T, deliveries chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+		return Delivery{}
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	var calls atomic.Int32
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "no token", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+	}))
+	defer server.Close()
+
+	e, deliveries := startEngine(t, config.Rule{
+		Name:    "chat",
+		On:      []string{"needs_input"},
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer token"},
+	})
+	e.Fire(Event{Type: NeedsInput, Session: "dev", Tool: "Bash"})
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		if d := next(t, deliveries); d.Status != Retrying || d.Attempt != attempt || d.Detail != "HTTP 503: busy" {
+			t.Errorf("attempt %d = %+v, want retrying after 503", attempt, d)
+		}
+	}
+	if d := next(t, deliveries); d.Status != Delivered || d.Attempt != 3 || d.Detail != "HTTP 200" {
+		t.Errorf("attempt 3 = %+v, want delivered", d)
+	}
+	if got.Type != NeedsInput || got.Session != "dev" || got.Tool != "Bash" {
+		t.Errorf("posted event = %+v", got)
+	}
+
+	log := e.Log()
+	if len(log) != 3 || log[0].Status != Delivered || log[0].Rule != "chat" {
+		t.Errorf("Log() = %+v, want 3 attempts newest first", log)
+	}
+}
+
+func TestWebhookGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusGone)
+	}))
+	defer server.Close()
+
+	retries := 1
+	e, deliveries := startEngine(t, config.Rule{On: []string{"stop"}, URL: server.URL, Retries: &retries})
+	e.Fire(Event{Type: Stop, Session: "dev"})
+
+	if d := next(t, deliveries); d.Status != Retrying {
+		t.Errorf("first attempt = %+v, want retrying", d)
+	}
+	if d := next(t, deliveries); d.Status != Failed || d.Attempt != 2 {
+		t.Errorf("second attempt = %+v, want failed", d)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	dir := t.TempDir()
+	e, deliveries := startEngine(t, config.Rule{
+		On:      []string{"stop"},
+		Command: `cat > event.json && echo "$CMUX_EVENT $CMUX_SESSION" > env`,
+	})
+	e.Fire(Event{Type: Stop, Session: "dev", Dir: dir, Duration: 42})
+
+	if d := next(t, deliveries); d.Status != Delivered {
+		t.Fatalf("delivery = %+v, want delivered", d)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "event.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Event
+	if err := json.Unmarshal(data, &got); err != nil || got.Duration != 42 {
+		t.Errorf("stdin = %s, want the event", data)
+	}
+	if env, _ := os.ReadFile(filepath.Join(dir, "env")); string(env) != "stop dev\n" {
+		t.Errorf("env = %q", env)
+	}
+}
+
+func TestStopCancelsCommand(t *testing.T) {
+	dir := t.TempDir()
+	e, deliveries := startEngine(t, config.Rule{
+		On:      []string{"stop"},
+		Command: "touch started && sleep 60",
+	})
+	e.Fire(Event{Type: Stop, Session: "dev", Dir: dir})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "started")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		e.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() waited for the command")
+	}
+	if d := next(t, deliveries); d.Status == Delivered {
+		t.Errorf("delivery = %+v, want the cancelled command to fail", d)
+	}
+}
+
+func TestCommandFailure(t *testing.T) {
+	retries := 0
+	e, deliveries := startEngine(t, config.Rule{
+		On:      []string{"stop"},
+		Command: "echo working; echo tests failed >&2; exit 2",
+		Retries: &retries,
+	})
+	e.Fire(Event{Type: Stop, Session: "dev"})
+
+	d := next(t, deliveries)
+	if d.Status != Failed || d.Detail != "echo working; echo tests failed >&2; exit 2: exit status 2: tests failed" {
+		t.Errorf("delivery = %+v", d)
+	}
+}
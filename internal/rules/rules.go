@@ -0,0 +1,304 @@
+// Package rules posts webhooks and runs commands when session events
+// happen, such as a session asking for approval, retrying failed
+// deliveries and logging every attempt.
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+)
+
+// EventType is the kind of session event a rule fires on.
+type EventType string
+
+const (
+	NeedsInput     EventType = "needs_input"     // Claude asks for permission
+	Stop           EventType = "stop"            // a turn ended
+	ToolFailed     EventType = "tool_failed"     // a tool call failed
+	SessionCreated EventType = "session_created" // a tmux session appeared
+	SessionDeleted EventType = "session_deleted" // a tmux session went away
+)
+
+// Event is something that happened to a session. Webhooks post it as JSON
+// unless their rule has a payload, and commands read it on stdin.
+type Event struct {
+	Type     EventType `json:"event"`
+	Session  string    `json:"session"`
+	Repo     string    `json:"repo,omitempty"`
+	Dir      string    `json:"dir,omitempty"`      // the session's worktree or working directory
+	Tool     string    `json:"tool,omitempty"`     // tool asking permission or failing
+	Message  string    `json:"message,omitempty"`  // permission prompt or tool error
+	Duration int64     `json:"duration,omitempty"` // length in seconds of the turn that stopped
+	Time     time.Time `json:"time"`
+}
+
+const (
+	// DefaultRetries is how often a failed delivery is tried again when its
+	// rule doesn't say
+	DefaultRetries = 3
+
+	// queueSize is how many events a rule holds while delivering; more are
+	// dropped
+	queueSize = 64
+
+	// logSize is how many deliveries the log keeps
+	logSize = 200
+
+	// maxBackoff caps the delay between attempts
+	maxBackoff = 5 * time.Minute
+)
+
+// Engine matches session events against the rules and delivers them. Each
+// rule has its own queue, delivered in order by its own goroutine.
+type Engine struct {
+	rules   []*rule
+	tracker *claude.Tracker
+	client  *http.Client
+	backoff func(attempt int) time.Duration
+	ctx     context.Context // cancelled by Stop, ending deliveries under way
+	stop    context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu         sync.Mutex
+	sessions   map[string]sessionInfo // tmux sessions of the last discovery, nil before the first
+	log        []Delivery             // oldest first
+	onDelivery func(Delivery)
+}
+
+// sessionInfo is what discovery knows of a session.
+type sessionInfo struct {
+	repo, dir string
+}
+
+// rule is a configured rule ready to match and deliver.
+type rule struct {
+	config.Rule
+	on      map[EventType]bool
+	retries int
+	queue   chan Event
+}
+
+// New checks the rules and starts a delivery goroutine for each. Call Stop
+// to end them.
+func New(rules []config.Rule) (*Engine, error) {
+	e := &Engine{
+		tracker: claude.NewTracker(),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		backoff: backoff,
+	}
+	e.ctx, e.stop = context.WithCancel(context.Background())
+
+	for i, cfg := range rules {
+		r, err := newRule(cfg, i)
+		if err != nil {
+			return nil, err
+		}
+		e.rules = append(e.rules, r)
+	}
+	for _, r := range e.rules {
+		e.wg.Add(1)
+		go e.work(r)
+	}
+	return e, nil
+}
+
+// newRule checks and prepares the i-th configured rule.
+func newRule(cfg config.Rule, i int) (*rule, error) {
+	if cfg.Name == "" {
+		cfg.Name = "rule " + strconv.Itoa(i+1)
+	}
+	r := &rule{Rule: cfg, on: make(map[EventType]bool), retries: DefaultRetries, queue: make(chan Event, queueSize)}
+	if cfg.Retries != nil {
+		r.retries = max(*cfg.Retries, 0)
+	}
+
+	if len(cfg.On) == 0 {
+		return nil, fmt.Errorf("%s: no events to fire on", cfg.Name)
+	}
+	for _, on := range cfg.On {
+		switch t := EventType(on); t {
+		case NeedsInput, Stop, ToolFailed, SessionCreated, SessionDeleted:
+			r.on[t] = true
+		default:
+			return nil, fmt.Errorf("%s: unknown event %q", cfg.Name, on)
+		}
+	}
+	if (cfg.URL == "") == (cfg.Command == "") {
+		return nil, fmt.Errorf("%s: needs either a url or a command", cfg.Name)
+	}
+	for _, pattern := range []string{cfg.Repo, cfg.Session, cfg.Tool} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: bad pattern %q: %w", cfg.Name, pattern, err)
+		}
+	}
+	if cfg.Payload != "" && !json.Valid([]byte(r.payload(Event{}))) {
+		return nil, fmt.Errorf("%s: payload is not JSON", cfg.Name)
+	}
+	return r, nil
+}
+
+// Stop ends delivery. Queued events and pending retries are dropped, and
+// running commands and requests are cancelled.
+func (e *Engine) Stop() {
+	e.stop()
+	e.wg.Wait()
+}
+
+// OnDelivery sets a callback invoked, from a delivery goroutine, with each
+// attempt as it is logged.
+func (e *Engine) OnDelivery(fn func(Delivery)) {
+	e.mu.Lock()
+	e.onDelivery = fn
+	e.mu.Unlock()
+}
+
+// OnHookEvent takes a hook event of a session, as an EventWatcher
+// callback, and fires the rules its transition matches. Events replayed
+// from before the engine started are only tracked.
+func (e *Engine) OnHookEvent(tmuxSession string, event claude.HookEvent) {
+	tr, ok := e.tracker.Observe(tmuxSession, event)
+	if !ok || tr.Replayed {
+		return
+	}
+
+	ev := Event{
+		Type:     EventType(tr.Kind),
+		Session:  tmuxSession,
+		Dir:      tr.Cwd,
+		Tool:     tr.Tool,
+		Message:  tr.Message,
+		Duration: int64(tr.Duration / time.Second),
+		Time:     tr.Time,
+	}
+	e.mu.Lock()
+	if info, ok := e.sessions[tmuxSession]; ok {
+		ev.Repo = info.repo
+		if info.dir != "" {
+			ev.Dir = info.dir
+		}
+	}
+	e.mu.Unlock()
+	e.Fire(ev)
+}
+
+// Discovered takes a discovery pass and fires the rules for the tmux
+// sessions created and deleted since the previous one. The first pass
+// only records the sessions.
+func (e *Engine) Discovered(snap discovery.Snapshot) {
+	if snap.Err != nil {
+		return // No tmux answer is not every session gone
+	}
+
+	sessions := make(map[string]sessionInfo, len(snap.Live))
+	for _, name := range snap.Live {
+		sessions[name] = sessionInfo{}
+	}
+	for _, sess := range snap.All {
+		dir := sess.Worktree
+		if dir == "" {
+			dir = sess.RepoPath
+		}
+		sessions[sess.Name] = sessionInfo{repo: sess.RepoName, dir: dir}
+	}
+
+	e.mu.Lock()
+	prev := e.sessions
+	e.sessions = sessions
+	e.mu.Unlock()
+	if prev == nil {
+		return
+	}
+
+	var events []Event
+	for name, info := range sessions {
+		if _, ok := prev[name]; !ok {
+			events = append(events, Event{Type: SessionCreated, Session: name, Repo: info.repo, Dir: info.dir, Time: snap.Time})
+		}
+	}
+	for name, info := range prev {
+		if _, ok := sessions[name]; !ok {
+			events = append(events, Event{Type: SessionDeleted, Session: name, Repo: info.repo, Dir: info.dir, Time: snap.Time})
+		}
+	}
+	slices.SortFunc(events, func(a, b Event) int { return strings.Compare(a.Session, b.Session) })
+	for _, ev := range events {
+		e.Fire(ev)
+	}
+}
+
+// Fire queues an event for delivery by every rule it matches.
+func (e *Engine) Fire(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	for _, r := range e.rules {
+		if !r.matches(ev) {
+			continue
+		}
+		select {
+		case r.queue <- ev:
+		default:
+			e.record(Delivery{Rule: r.Name, Event: ev, Status: Dropped, Detail: "queue full", Time: time.Now()})
+		}
+	}
+}
+
+// matches reports whether the rule fires on an event.
+func (r *rule) matches(ev Event) bool {
+	if !r.on[ev.Type] {
+		return false
+	}
+	if ev.Type == Stop && r.MinRun > 0 && ev.Duration < int64(r.MinRun) {
+		return false
+	}
+	return glob(r.Repo, ev.Repo) && glob(r.Session, ev.Session) && glob(r.Tool, ev.Tool)
+}
+
+// glob matches a value against a shell glob; an empty pattern matches
+// anything.
+func glob(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, value)
+	return ok
+}
+
+// payload fills the rule's payload template with an event, escaping the
+// values for use inside JSON strings.
+func (r *rule) payload(ev Event) string {
+	quote := func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data[1 : len(data)-1])
+	}
+	return strings.NewReplacer(
+		"{event}", quote(string(ev.Type)),
+		"{session}", quote(ev.Session),
+		"{repo}", quote(ev.Repo),
+		"{tool}", quote(ev.Tool),
+		"{message}", quote(ev.Message),
+		"{duration}", strconv.FormatInt(ev.Duration, 10),
+		"{dir}", quote(ev.Dir),
+	).Replace(r.Payload)
+}
+
+// backoff is the delay before the attempt after the given one: 1s, 2s, 4s
+// and so on, up to maxBackoff.
+func backoff(attempt int) time.Duration {
+	if attempt > 20 {
+		return maxBackoff
+	}
+	return min(time.Second<<(attempt-1), maxBackoff)
+}
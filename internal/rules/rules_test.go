@@ -0,0 +1,172 @@
+package rules
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/claude"
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/discovery"
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+func TestNewRule(t *testing.T) {
+	retries := 0
+	tests := []struct {
+		name    string
+		rule    config.Rule
+		wantErr bool
+	}{
+		{"webhook", config.Rule{On: []string{"stop"}, URL: "http://example.com"}, false},
+		{"command", config.Rule{On: []string{"session_created"}, Command: "true", Retries: &retries}, false},
+		{"no events", config.Rule{URL: "http://example.com"}, true},
+		{"unknown event", config.Rule{On: []string{"started"}, URL: "http://example.com"}, true},
+		{"no action", config.Rule{On: []string{"stop"}}, true},
+		{"both actions", config.Rule{On: []string{"stop"}, URL: "http://example.com", Command: "true"}, true},
+		{"bad glob", config.Rule{On: []string{"stop"}, URL: "http://example.com", Repo: "[api"}, true},
+		{"bad payload", config.Rule{On: []string{"stop"}, URL: "http://example.com", Payload: `{"text": {session}}`}, true},
+		{"payload", config.Rule{On: []string{"stop"}, URL: "http://example.com", Payload: `{"text": "{session}", "secs": {duration}}`}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRule(tt.rule, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && r.Name != "rule 1" {
+				t.Errorf("unnamed rule is called %q, want %q", r.Name, "rule 1")
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	r, err := newRule(config.Rule{
+		On:      []string{"needs_input", "stop"},
+		Repo:    "api",
+		Session: "api-*",
+		MinRun:  60,
+		URL:     "http://example.com",
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		ev   Event
+		want bool
+	}{
+		{"match", Event{Type: NeedsInput, Repo: "api", Session: "api-fix"}, true},
+		{"other event", Event{Type: ToolFailed, Repo: "api", Session: "api-fix"}, false},
+		{"other repo", Event{Type: NeedsInput, Repo: "web", Session: "api-fix"}, false},
+		{"other session", Event{Type: NeedsInput, Repo: "api", Session: "main"}, false},
+		{"long turn", Event{Type: Stop, Repo: "api", Session: "api-fix", Duration: 90}, true},
+		{"short turn", Event{Type: Stop, Repo: "api", Session: "api-fix", Duration: 10}, false},
+	}
+	for _, tt := range tests {
+		if got := r.matches(tt.ev); got != tt.want {
+			t.Errorf("%s: matches() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPayloadEscapes(t *testing.T) {
+	r, err := newRule(config.Rule{
+		On:      []string{"tool_failed"},
+		URL:     "http://example.com",
+		Payload: `{"text": "{session}: {tool} failed: {message}", "secs": {duration}}`,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := r.payload(Event{Session: "dev", Tool: "Bash", Message: "said \"no\"\nexit 1", Duration: 5})
+	var got map[string]any
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("payload %q is not JSON: %v", body, err)
+	}
+	if got["text"] != "dev: Bash failed: said \"no\"\nexit 1" || got["secs"] != 5.0 {
+		t.Errorf("payload = %v", got)
+	}
+}
+
+// recordingEngine returns an engine with one rule on every event whose
+// deliveries are never made, and the channel its queue drains to.
+func recordingEngine(t *testing.T) (*Engine, chan Event) {
+	t.Helper()
+	e, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := newRule(config.Rule{
+		On:  []string{"needs_input", "stop", "tool_failed", "session_created", "session_deleted"},
+		URL: "http://example.com",
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.rules = []*rule{r}
+	t.Cleanup(e.Stop)
+	return e, r.queue
+}
+
+func TestDiscovered(t *testing.T) {
+	e, queue := recordingEngine(t)
+
+	fix := &state.Session{Name: "api-fix", RepoName: "api", Worktree: "/wt/api-fix"}
+	snap := func(live ...string) discovery.Snapshot {
+		var all []*state.Session
+		for _, name := range live {
+			if name == fix.Name {
+				all = append(all, fix)
+			}
+		}
+		return discovery.Snapshot{Live: live, All: all, Time: time.Now()}
+	}
+
+	e.Discovered(snap("main", "old"))
+	if len(queue) != 0 {
+		t.Fatalf("first discovery fired %d events, want none", len(queue))
+	}
+
+	e.Discovered(discovery.Snapshot{Err: errTest})
+	e.Discovered(snap("main", "api-fix"))
+	if len(queue) != 2 {
+		t.Fatalf("fired %d events, want 2", len(queue))
+	}
+	created, deleted := <-queue, <-queue
+	if created.Type != SessionCreated || created.Session != "api-fix" || created.Repo != "api" || created.Dir != "/wt/api-fix" {
+		t.Errorf("created = %+v", created)
+	}
+	if deleted.Type != SessionDeleted || deleted.Session != "old" {
+		t.Errorf("deleted = %+v", deleted)
+	}
+}
+
+func TestOnHookEvent(t *testing.T) {
+	e, queue := recordingEngine(t)
+	e.Discovered(discovery.Snapshot{
+		Live: []string{"api-fix"},
+		All:  []*state.Session{{Name: "api-fix", RepoName: "api", Worktree: "/wt/api-fix"}},
+	})
+
+	now := time.Now()
+	e.OnHookEvent("api-fix", claude.HookEvent{EventName: "UserPromptSubmit", Timestamp: now})
+	e.OnHookEvent("api-fix", claude.HookEvent{EventName: "PermissionRequest", ToolName: "Bash", Timestamp: now})
+	e.OnHookEvent("api-fix", claude.HookEvent{EventName: "Stop", Timestamp: now.Add(90 * time.Second)})
+	e.OnHookEvent("api-fix", claude.HookEvent{EventName: "PermissionRequest", Timestamp: now.Add(-time.Hour)})
+
+	if len(queue) != 2 {
+		t.Fatalf("fired %d events, want 2", len(queue))
+	}
+	input, stop := <-queue, <-queue
+	if input.Type != NeedsInput || input.Tool != "Bash" || input.Repo != "api" || input.Dir != "/wt/api-fix" {
+		t.Errorf("needs input = %+v", input)
+	}
+	if stop.Type != Stop || stop.Duration != 90 {
+		t.Errorf("stop = %+v", stop)
+	}
+}
@@ -3,7 +3,9 @@ package session
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/abdullathedruid/cmux/internal/config"
@@ -13,31 +15,49 @@ import (
 
 // Manager handles session lifecycle with worktree coupling.
 type Manager struct {
-	tmux   *tmux.RealClient
+	tmux   tmux.Client
+	git    *git.Client
 	config *config.Config
 }
 
 // NewManager creates a new session manager.
-func NewManager(t *tmux.RealClient, cfg *config.Config) *Manager {
+func NewManager(t tmux.Client, g *git.Client, cfg *config.Config) *Manager {
 	return &Manager{
 		tmux:   t,
+		git:    g,
 		config: cfg,
 	}
 }
 
 // CreateSession creates a new tmux session coupled with a git worktree.
-// Session naming: {repoName}/{branchName}
+// Session naming: {repoName}/{branchName}, or the repository's session_name
+// template. Claude runs with the repository's configured flags, environment
+// and initial prompt.
 // For main branch sessions, uses the repo root directory.
-// For other branches, creates/uses a worktree in .worktrees/.
+// For other branches, finds or creates a worktree where the config places
+// them. If only the worktree setup failed, the session is still created and
+// its name is returned with the *SetupError.
 func (m *Manager) CreateSession(repoPath, branchName string, newBranch bool) (string, error) {
+	return m.createSession(repoPath, branchName, newBranch, "", SessionOptions(m.config, repoPath))
+}
+
+// CreateBranchSession is CreateSession for a branch that is created unless
+// the repository has one by that name.
+func (m *Manager) CreateBranchSession(repoPath, branchName string) (string, error) {
+	return m.CreateSession(repoPath, branchName, !localBranchExists(m.git, repoPath, branchName))
+}
+
+// createSession creates a session on a branch running opts. A new branch
+// starts at startPoint, or at the repository's base when it is empty.
+func (m *Manager) createSession(repoPath, branchName string, newBranch bool, startPoint string, opts tmux.SessionOptions) (string, error) {
 	// Get repository info
-	repoInfo, err := git.GetRepoInfo(repoPath)
+	repoInfo, err := m.git.GetRepoInfo(repoPath)
 	if err != nil {
 		return "", fmt.Errorf("getting repo info: %w", err)
 	}
 
 	// Generate session name: repoName/branchName
-	sessionName := GenerateSessionName(repoInfo.Name, branchName)
+	sessionName := SessionNameFor(m.config, repoPath, repoInfo.Name, branchName)
 
 	// Check if session already exists
 	if m.tmux.HasSession(sessionName) {
@@ -46,14 +66,15 @@ func (m *Manager) CreateSession(repoPath, branchName string, newBranch bool) (st
 
 	// Determine working directory
 	var workDir string
-	mainBranch := git.GetMainBranch(repoPath)
+	var setupErr error
+	mainBranch := m.git.GetMainBranch(repoPath)
 
 	if branchName == mainBranch {
 		// Main branch: use repo root
 		workDir = repoPath
 	} else {
 		// Other branch: check/create worktree
-		worktrees, err := git.ListWorktrees(repoPath)
+		worktrees, err := m.git.ListWorktrees(repoPath)
 		if err != nil {
 			return "", fmt.Errorf("listing worktrees: %w", err)
 		}
@@ -68,20 +89,47 @@ func (m *Manager) CreateSession(repoPath, branchName string, newBranch bool) (st
 
 		// Create worktree if needed
 		if workDir == "" {
-			wtPath, err := git.CreateWorktree(repoPath, branchName, newBranch)
-			if err != nil {
+			wtPath, err := CreateWorktreeFrom(m.git, m.config, repoPath, branchName, newBranch, startPoint)
+			if wtPath == "" {
 				return "", fmt.Errorf("creating worktree: %w", err)
 			}
-			workDir = wtPath
+			workDir, setupErr = wtPath, err
 		}
 	}
 
 	// Create tmux session
-	if err := m.tmux.CreateSession(sessionName, workDir, true); err != nil {
+	if err := m.tmux.CreateSessionWithOptions(sessionName, workDir, opts); err != nil {
 		return "", fmt.Errorf("creating tmux session: %w", err)
 	}
 
-	return sessionName, nil
+	return sessionName, setupErr
+}
+
+// ResumeSession recreates a dead tmux session in dir and resumes the given
+// Claude conversation in it with `claude --resume`.
+func (m *Manager) ResumeSession(sessionName, dir, claudeSessionID string) error {
+	if m.tmux.HasSession(sessionName) {
+		return fmt.Errorf("session %s is already running", sessionName)
+	}
+	if claudeSessionID == "" {
+		return fmt.Errorf("no Claude session ID recorded for %s", sessionName)
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("working directory %s no longer exists", dir)
+	}
+
+	repoPath := MainCheckout(m.git, dir)
+	opts := SessionOptions(m.config, repoPath)
+	opts.Command = fmt.Sprintf("%s --resume %s", ClaudeArgs(m.config, repoPath), shellQuote(claudeSessionID))
+	if err := m.tmux.CreateSessionWithOptions(sessionName, dir, opts); err != nil {
+		return fmt.Errorf("creating tmux session: %w", err)
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
 // DeleteSession kills a tmux session and optionally removes its worktree.
@@ -89,6 +137,13 @@ func (m *Manager) DeleteSession(sessionName string, removeWorktree bool) error {
 	// Parse repo and branch from session name
 	repoName, branchName := ParseSessionName(sessionName)
 
+	// Sessions named by a template are found by the directory they started
+	// in instead; their panes may have moved anywhere since
+	var startDir string
+	if removeWorktree {
+		startDir = m.sessionPath(sessionName)
+	}
+
 	// Kill the tmux session first
 	if m.tmux.HasSession(sessionName) {
 		if err := m.tmux.KillSession(sessionName); err != nil {
@@ -96,37 +151,79 @@ func (m *Manager) DeleteSession(sessionName string, removeWorktree bool) error {
 		}
 	}
 
-	if !removeWorktree || repoName == "" || branchName == "" {
+	if !removeWorktree {
 		return nil
 	}
 
 	// Find the repository path from config
 	repoPath := m.findRepoPath(repoName)
-	if repoPath == "" {
-		return nil // Can't find repo, skip worktree removal
+	if repoPath == "" || branchName == "" {
+		return removeWorktreeAt(m.git, startDir, repoPath)
 	}
 
 	// Check if this is the main branch - don't remove main repo
-	mainBranch := git.GetMainBranch(repoPath)
+	mainBranch := m.git.GetMainBranch(repoPath)
 	if branchName == mainBranch {
 		return nil // Don't remove main branch worktree (it's the repo itself)
 	}
 
 	// Find and remove the worktree
-	worktrees, err := git.ListWorktrees(repoPath)
+	worktrees, err := m.git.ListWorktrees(repoPath)
 	if err != nil {
 		return nil // Ignore errors, session is already killed
 	}
 
 	for _, wt := range worktrees {
 		if wt.Branch == branchName && !wt.IsMain {
-			if err := git.RemoveWorktree(repoPath, wt.Path); err != nil {
+			if err := m.git.RemoveWorktree(repoPath, wt.Path); err != nil {
 				return fmt.Errorf("removing worktree: %w", err)
 			}
-			break
+			return nil
 		}
 	}
 
+	// Branches with slashes name their sessions with dashes, e.g.
+	// repo/feature-login, so the branch is found by the worktree instead
+	return removeWorktreeAt(m.git, startDir, repoPath)
+}
+
+// sessionPath returns the directory a session was started in, or "" if it
+// isn't running.
+func (m *Manager) sessionPath(sessionName string) string {
+	sessions, err := m.tmux.ListSessions()
+	if err != nil {
+		return ""
+	}
+	for _, s := range sessions {
+		if s.Name == sessionName {
+			return s.Path
+		}
+	}
+	return ""
+}
+
+// removeWorktreeAt removes the linked worktree containing dir, leaving
+// main checkouts alone. It refuses a worktree of another repository than
+// repoPath, when that is known.
+func removeWorktreeAt(g *git.Client, dir, repoPath string) error {
+	if dir == "" {
+		return nil
+	}
+	wt, ok := g.FindWorktree(dir)
+	if !ok || wt.IsMain {
+		return nil
+	}
+	mainPath := MainCheckout(g, dir)
+	if repoPath != "" {
+		worktrees, err := g.ListWorktrees(repoPath)
+		if err != nil || !slices.ContainsFunc(worktrees, func(w git.Worktree) bool { return w.Path == wt.Path }) {
+			return fmt.Errorf("not removing %s: it is not a worktree of %s", wt.Path, repoPath)
+		}
+		mainPath = repoPath
+	}
+	if err := g.RemoveWorktree(mainPath, wt.Path); err != nil {
+		return fmt.Errorf("removing worktree: %w", err)
+	}
 	return nil
 }
 
@@ -146,11 +243,11 @@ func (m *Manager) GetSessionInfo(sessionName string) (*SessionInfo, error) {
 		info.RepoPath = repoPath
 
 		// Check if it has a worktree
-		mainBranch := git.GetMainBranch(repoPath)
+		mainBranch := m.git.GetMainBranch(repoPath)
 		info.IsMainBranch = branchName == mainBranch
 
 		if !info.IsMainBranch {
-			worktrees, _ := git.ListWorktrees(repoPath)
+			worktrees, _ := m.git.ListWorktrees(repoPath)
 			for _, wt := range worktrees {
 				if wt.Branch == branchName {
 					info.WorktreePath = wt.Path
@@ -206,4 +303,5 @@ type SessionInfo struct {
 	IsMainBranch bool
 	HasWorktree  bool
 	WorktreePath string
+	PRURL        string // pull request opened for the branch, if any
 }
@@ -0,0 +1,113 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// BaseBranch returns the branch a repository's work is based on: its
+// configured base_branch, else its main branch.
+func BaseBranch(g *git.Client, cfg *config.Config, repoPath string) string {
+	if repo, _ := cfg.RepositoryFor(repoPath); repo.BaseBranch != "" {
+		return repo.BaseBranch
+	}
+	return g.GetMainBranch(repoPath)
+}
+
+// baseStartPoint returns where new branches of a repository start: the
+// configured base branch, or HEAD when none is set.
+func baseStartPoint(cfg *config.Config, repoPath string) string {
+	repo, _ := cfg.RepositoryFor(repoPath)
+	return repo.BaseBranch
+}
+
+// ClaudeArgs returns the Claude command line configured for a repository,
+// without the initial prompt. Every argument is shell-quoted; the command
+// itself is used as written so it may contain its own flags.
+func ClaudeArgs(cfg *config.Config, repoPath string) string {
+	repo, _ := cfg.RepositoryFor(repoPath)
+	return claudeCommandLine(cfg.ClaudeCommandFor(repoPath), repo, repoPath)
+}
+
+// claudeCommandLine builds the Claude command line for a repository entry.
+func claudeCommandLine(command string, repo config.Repository, repoPath string) string {
+	parts := []string{command}
+	if repo.Model != "" {
+		parts = append(parts, "--model", shellQuote(repo.Model))
+	}
+	if repo.PermissionMode != "" {
+		parts = append(parts, "--permission-mode", shellQuote(repo.PermissionMode))
+	}
+	for _, dir := range repo.AddDirs {
+		parts = append(parts, "--add-dir", shellQuote(expandDir(repoPath, dir)))
+	}
+	for _, arg := range repo.ClaudeArgs {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// SessionOptions returns how a new session of a repository starts Claude:
+// its command line with the initial prompt, and its environment.
+func SessionOptions(cfg *config.Config, repoPath string) tmux.SessionOptions {
+	repo, _ := cfg.RepositoryFor(repoPath)
+	command := ClaudeArgs(cfg, repoPath)
+	if repo.InitialPrompt != "" {
+		command += " " + shellQuote(repo.InitialPrompt)
+	}
+	return tmux.SessionOptions{Command: command, Env: repo.Env}
+}
+
+// SessionOptionsForDir returns the session options of the repository a
+// directory belongs to, which may be one of its worktrees.
+func SessionOptionsForDir(g *git.Client, cfg *config.Config, dir string) tmux.SessionOptions {
+	return SessionOptions(cfg, MainCheckout(g, dir))
+}
+
+// SessionNameFor names a session of a repository using its session_name
+// template, falling back to GenerateSessionName.
+func SessionNameFor(cfg *config.Config, repoPath, repoName, branchName string) string {
+	repo, _ := cfg.RepositoryFor(repoPath)
+	if repo.SessionName == "" {
+		return GenerateSessionName(repoName, branchName)
+	}
+	name := strings.NewReplacer(
+		"{repo}", repoName,
+		"{branch}", strings.ReplaceAll(branchName, "/", "-"),
+	).Replace(repo.SessionName)
+	// tmux uses . and : in targets
+	return strings.NewReplacer(".", "-", ":", "-").Replace(name)
+}
+
+// MainCheckout returns the main checkout of the repository containing dir,
+// or dir itself when it isn't in a git repository.
+func MainCheckout(g *git.Client, dir string) string {
+	worktrees, err := g.ListWorktrees(dir)
+	if err != nil {
+		return dir
+	}
+	for _, wt := range worktrees {
+		if wt.IsMain {
+			return wt.Path
+		}
+	}
+	return dir
+}
+
+// expandDir resolves ~ and paths relative to the repository.
+func expandDir(repoPath, dir string) string {
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, dir[1:])
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		return filepath.Join(repoPath, dir)
+	}
+	return dir
+}
@@ -0,0 +1,52 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+)
+
+func TestSessionOptions(t *testing.T) {
+	cfg := config.Default()
+	cfg.Repositories = []config.Repository{
+		{Path: "/code/tool"},
+		{
+			Path:           "/code/app",
+			ClaudeCommand:  "claude --verbose",
+			Model:          "opus",
+			PermissionMode: "acceptEdits",
+			AddDirs:        []string{"../shared", "/opt/docs"},
+			ClaudeArgs:     []string{"--debug"},
+			Env:            map[string]string{"NODE_ENV": "test"},
+			InitialPrompt:  "it's ready",
+		},
+	}
+
+	if got := SessionOptions(cfg, "/code/tool"); got.Command != "claude" || got.Env != nil {
+		t.Errorf("plain repo options = %+v, want the global command", got)
+	}
+
+	got := SessionOptions(cfg, "/code/app")
+	want := `claude --verbose --model 'opus' --permission-mode 'acceptEdits' ` +
+		`--add-dir '/code/shared' --add-dir '/opt/docs' '--debug' 'it'\''s ready'`
+	if got.Command != want {
+		t.Errorf("Command = %q, want %q", got.Command, want)
+	}
+	if got.Env["NODE_ENV"] != "test" {
+		t.Errorf("Env = %v, want NODE_ENV", got.Env)
+	}
+}
+
+func TestSessionNameFor(t *testing.T) {
+	cfg := config.Default()
+	cfg.Repositories = []config.Repository{
+		{Path: "/code/app", SessionName: "{repo}.{branch}"},
+	}
+
+	if got := SessionNameFor(cfg, "/code/tool", "tool", "feat/x"); got != "tool/feat-x" {
+		t.Errorf("default name = %q, want %q", got, "tool/feat-x")
+	}
+	if got := SessionNameFor(cfg, "/code/app", "app", "feat/x"); got != "app-feat-x" {
+		t.Errorf("template name = %q, want %q", got, "app-feat-x")
+	}
+}
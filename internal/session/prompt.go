@@ -0,0 +1,102 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// ReadyTimeout is how long SubmitPrompt waits for Claude to start.
+const ReadyTimeout = 2 * time.Minute
+
+// readyPollInterval is how often the screen is checked while waiting.
+const readyPollInterval = 500 * time.Millisecond
+
+// ClaudeReady reports whether a captured screen shows Claude waiting for
+// input: its input box, a "> " line boxed in by │ or between ─ rules,
+// followed by the "? for shortcuts" footer, with no trust dialog in front
+// of it. Shell prompts and quoted text never have both.
+func ClaudeReady(screen string) bool {
+	if strings.Contains(screen, "Do you trust the files") {
+		return false
+	}
+	lines := strings.Split(screen, "\n")
+	for i := range lines {
+		if !inputBoxLine(lines, i) {
+			continue
+		}
+		for _, after := range lines[i+1:] {
+			if strings.TrimSpace(after) == "? for shortcuts" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inputBoxLine reports whether lines[i] is the prompt line of Claude's
+// input box.
+func inputBoxLine(lines []string, i int) bool {
+	line := strings.TrimSpace(lines[i])
+	if boxed, ok := strings.CutPrefix(line, "│"); ok {
+		prompt := strings.TrimSpace(strings.TrimSuffix(boxed, "│"))
+		return isPrompt(prompt)
+	}
+	// Newer versions draw rules above and below instead of a box
+	return isPrompt(line) && i > 0 && i+1 < len(lines) && isRule(lines[i-1]) && isRule(lines[i+1])
+}
+
+// isPrompt reports whether a trimmed line is Claude's prompt, empty or
+// with text typed after it.
+func isPrompt(line string) bool {
+	return line == ">" || line == "❯" || strings.HasPrefix(line, "> ") || strings.HasPrefix(line, "❯ ")
+}
+
+// isRule reports whether a line is a horizontal rule of ─.
+func isRule(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && strings.Trim(line, "─") == ""
+}
+
+// WaitForClaude polls until Claude is ready for input at target and
+// returns the pane it is ready in. A target naming a session is resolved
+// to the pane running Claude in it, so a focused shell or server pane is
+// never mistaken for Claude; other targets are panes used as they are.
+func WaitForClaude(client tmux.Client, target string, timeout time.Duration) (string, error) {
+	name, _, _ := strings.Cut(target, ":")
+	isSession := name == target && !strings.HasPrefix(target, "%")
+	deadline := time.Now().Add(timeout)
+	for {
+		if !client.HasSession(name) {
+			return "", fmt.Errorf("session %s exited", name)
+		}
+		pane := target
+		if isSession {
+			pane = ""
+			if p, err := client.FindClaudePane(name); err == nil {
+				pane = p.ID
+			}
+		}
+		if pane != "" {
+			if screen, err := client.CapturePane(pane, 0); err == nil && ClaudeReady(screen) {
+				return pane, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("Claude in %s was not ready after %s", name, timeout)
+		}
+		time.Sleep(readyPollInterval)
+	}
+}
+
+// SubmitPrompt waits for Claude to be ready at target, a new session or the
+// pane running Claude in an existing one, and submits prompt to it.
+func SubmitPrompt(client tmux.Client, target, prompt string) error {
+	pane, err := WaitForClaude(client, target, ReadyTimeout)
+	if err != nil {
+		return err
+	}
+	return client.SubmitText(pane, prompt)
+}
@@ -0,0 +1,53 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/tmux"
+	"github.com/abdullathedruid/cmux/internal/tmux/tmuxtest"
+)
+
+func TestClaudeReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		screen string
+		want   bool
+	}{
+		{"starting", "$ claude\n", false},
+		{"prompt box", "╭──────╮\n│ >    │\n╰──────╯\n  ? for shortcuts\n", true},
+		{"prompt box without footer", "╭──────╮\n│ >    │\n╰──────╯\n", false},
+		{"shortcuts hint", "──────\n❯ \n──────\n  ? for shortcuts\n", true},
+		{"trust dialog", "Do you trust the files in this folder?\n❯ 1. Yes, proceed\n", false},
+		{"shell continuation", "$ echo 'one\n> two\n> ", false},
+		{"quoted footer", "$ cat notes.md\n> remember\n? for shortcuts\n$ ", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClaudeReady(tt.screen); got != tt.want {
+				t.Errorf("ClaudeReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubmitPromptFindsClaudePane(t *testing.T) {
+	server := tmuxtest.NewServer()
+	if err := server.CreateSessionWithOptions("dev", t.TempDir(), tmux.SessionOptions{Command: "claude"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.SetScreen("dev", tmuxtest.ReadyScreen); err != nil {
+		t.Fatal(err)
+	}
+	pane, err := server.FindClaudePane("dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SubmitPrompt(server, "dev", "fix the tests"); err != nil {
+		t.Fatal(err)
+	}
+	if input := server.Input(pane.ID); len(input) != 1 || input[0] != "fix the tests" {
+		t.Errorf("Claude pane input = %q", input)
+	}
+}
@@ -0,0 +1,245 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// PullRequest is the head of a pull request as resolved by the forge.
+type PullRequest struct {
+	Ref      string // number or URL it was asked for by
+	Number   string // PR number, if the ref has one
+	Branch   string // head branch name
+	FetchRef string // ref to fetch the head from when it isn't on origin
+}
+
+// prURLPattern matches pull and merge request URLs of common forges.
+var prURLPattern = regexp.MustCompile(`^https?://\S+/(?:pull|pulls|merge_requests|pull-requests)/(\d+)`)
+
+// ParsePRRef recognizes a pull request given as "#12" or as a URL, and as a
+// bare number when bareNumber is set. It returns the ref to resolve and the
+// PR number.
+func ParsePRRef(input string, bareNumber bool) (ref, number string, ok bool) {
+	input = strings.TrimSpace(input)
+	if m := prURLPattern.FindStringSubmatch(input); m != nil {
+		return input, m[1], true
+	}
+	digits := strings.TrimPrefix(input, "#")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return "", "", false
+	}
+	if digits == input && !bareNumber {
+		return "", "", false
+	}
+	return digits, digits, true
+}
+
+// parsePRHead parses the output of the PR head command: the head branch,
+// optionally followed by a ref to fetch it from.
+func parsePRHead(output string) (branch, fetchRef string, err error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return "", "", errors.New("PR head command printed no branch")
+	}
+	if len(fields) > 1 {
+		fetchRef = fields[1]
+	}
+	return fields[0], fetchRef, nil
+}
+
+// ResolvePR finds the head branch of a pull request with the configured
+// PR head command, run in the repository with CMUX_PR set.
+func ResolvePR(cfg *config.Config, repoPath, ref, number string) (PullRequest, error) {
+	if cfg.Forge.PRHeadCommand == "" {
+		return PullRequest{}, errors.New("no forge.pr_head_command configured")
+	}
+
+	cmd := exec.Command("sh", "-c", cfg.Forge.PRHeadCommand)
+	cmd.Dir = repoPath
+	cmd.Env = append(os.Environ(), "CMUX_PR="+ref)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return PullRequest{}, fmt.Errorf("PR head command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	branch, fetchRef, err := parsePRHead(stdout.String())
+	if err != nil {
+		return PullRequest{}, err
+	}
+	return PullRequest{Ref: ref, Number: number, Branch: branch, FetchRef: fetchRef}, nil
+}
+
+// Checkout is a branch ready to be checked out into a worktree.
+type Checkout struct {
+	Branch     string
+	NewBranch  bool   // the local branch doesn't exist yet
+	StartPoint string // where a new branch starts
+}
+
+// localBranchExists reports whether a repository has a local branch.
+func localBranchExists(g *git.Client, repoPath, branch string) bool {
+	branches, _ := g.ListBranches(repoPath)
+	for _, b := range branches {
+		if b == branch {
+			return true
+		}
+	}
+	return false
+}
+
+// updateBranch brings an existing local branch up to head, fetched from
+// source. A branch behind head is fast-forwarded and one already containing
+// it is kept; a diverged one is refused rather than reset, as its own
+// commits may be unpushed work.
+func updateBranch(g *git.Client, repoPath, branch, head, source string) error {
+	local, err := g.RevParse(repoPath, branch)
+	if err != nil {
+		return err
+	}
+	if local == head {
+		return nil
+	}
+	if ahead, err := g.IsAncestor(repoPath, head, local); err != nil || ahead {
+		return err
+	}
+	behind, err := g.IsAncestor(repoPath, local, head)
+	if err != nil {
+		return err
+	}
+	if !behind {
+		return fmt.Errorf("local branch %s has diverged from %s; rebase or delete it first", branch, source)
+	}
+	if err := g.FastForward(repoPath, branch, head); err != nil {
+		return fmt.Errorf("fast-forwarding %s: %w", branch, err)
+	}
+	return nil
+}
+
+// checkoutAt returns the checkout of branch at startPoint, fetched from
+// source: a new branch starts there and an existing one is brought up to it.
+func checkoutAt(g *git.Client, repoPath, branch, startPoint, source string) (Checkout, error) {
+	if !localBranchExists(g, repoPath, branch) {
+		return Checkout{Branch: branch, NewBranch: true, StartPoint: startPoint}, nil
+	}
+	head, err := g.RevParse(repoPath, startPoint)
+	if err != nil {
+		return Checkout{}, err
+	}
+	if err := updateBranch(g, repoPath, branch, head, source); err != nil {
+		return Checkout{}, err
+	}
+	return Checkout{Branch: branch}, nil
+}
+
+// PrepareRemoteBranch fetches a remote-tracking branch such as
+// "origin/feature" and returns the local branch tracking it, created at the
+// fetched head or fast-forwarded to it.
+func PrepareRemoteBranch(g *git.Client, repoPath, remoteBranch string) (Checkout, error) {
+	remotes, err := g.ListRemotes(repoPath)
+	if err != nil {
+		return Checkout{}, err
+	}
+	remote, branch, ok := git.SplitRemoteBranch(remotes, remoteBranch)
+	if !ok {
+		return Checkout{}, fmt.Errorf("%s is not a remote branch", remoteBranch)
+	}
+
+	// A failed fetch is fine as long as the branch was fetched before
+	fetchErr := g.Fetch(repoPath, remote, branch)
+	if _, err := g.RevParse(repoPath, remoteBranch); err != nil {
+		if fetchErr != nil {
+			return Checkout{}, fetchErr
+		}
+		return Checkout{}, err
+	}
+	return checkoutAt(g, repoPath, branch, remoteBranch, remoteBranch)
+}
+
+// PreparePR resolves and fetches the head of a pull request. A head on
+// origin gets a local branch tracking it; a head fetched from elsewhere,
+// such as a fork, gets a "pr/<number>" branch. An existing branch is
+// fast-forwarded to the head.
+func PreparePR(g *git.Client, repoPath string, pr PullRequest) (Checkout, error) {
+	// The PR's own ref is authoritative; origin may have an unrelated
+	// branch of the same name, e.g. a fork's "main"
+	head := ""
+	if pr.FetchRef != "" {
+		if err := g.Fetch(repoPath, "origin", pr.FetchRef); err != nil {
+			return Checkout{}, err
+		}
+		var err error
+		if head, err = g.RevParse(repoPath, "FETCH_HEAD"); err != nil {
+			return Checkout{}, err
+		}
+	}
+	originHead := ""
+	if g.Fetch(repoPath, "origin", pr.Branch) == nil {
+		originHead, _ = g.RevParse(repoPath, "origin/"+pr.Branch)
+	}
+
+	switch {
+	case originHead != "" && (head == "" || head == originHead):
+		return checkoutAt(g, repoPath, pr.Branch, "origin/"+pr.Branch, "origin/"+pr.Branch)
+	case head != "":
+		branch := pr.Branch
+		if pr.Number != "" {
+			branch = "pr/" + pr.Number
+		}
+		return checkoutAt(g, repoPath, branch, head, "PR "+pr.Ref)
+	default:
+		return Checkout{}, fmt.Errorf("branch %s of PR %s is not on origin", pr.Branch, pr.Ref)
+	}
+}
+
+// ReviewPrompt renders the configured review prompt for a pull request.
+func ReviewPrompt(cfg *config.Config, repoName, branch, ref string) string {
+	vars := TemplateVars{Repo: repoName, Branch: branch}
+	prompt := strings.ReplaceAll(vars.Render(cfg.Forge.ReviewPrompt), "{pr}", ref)
+	return strings.TrimSpace(prompt)
+}
+
+// CreateSessionFromRemote creates a session for a remote-tracking branch
+// such as "origin/feature", fetching it first.
+func (m *Manager) CreateSessionFromRemote(repoPath, remoteBranch string) (string, error) {
+	co, err := PrepareRemoteBranch(m.git, repoPath, remoteBranch)
+	if err != nil {
+		return "", err
+	}
+	return m.createSession(repoPath, co.Branch, co.NewBranch, co.StartPoint, SessionOptions(m.config, repoPath))
+}
+
+// CreateSessionForPR checks out the head of a pull request into a worktree
+// and creates a session for it. It returns the session name and the review
+// prompt to submit once Claude is ready.
+func (m *Manager) CreateSessionForPR(repoPath, ref, number string) (string, string, error) {
+	pr, err := ResolvePR(m.config, repoPath, ref, number)
+	if err != nil {
+		return "", "", err
+	}
+	co, err := PreparePR(m.git, repoPath, pr)
+	if err != nil {
+		return "", "", err
+	}
+	repoInfo, err := m.git.GetRepoInfo(repoPath)
+	if err != nil {
+		return "", "", fmt.Errorf("getting repo info: %w", err)
+	}
+
+	// The review prompt replaces the repository's initial prompt
+	opts := TemplateOptions(m.config, repoPath, config.SessionTemplate{})
+	name, err := m.createSession(repoPath, co.Branch, co.NewBranch, co.StartPoint, opts)
+	if name == "" {
+		return "", "", err
+	}
+	return name, ReviewPrompt(m.config, repoInfo.Name, co.Branch, ref), err
+}
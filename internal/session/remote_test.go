@@ -0,0 +1,119 @@
+package session
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/git/gittest"
+)
+
+func TestParsePRRef(t *testing.T) {
+	tests := []struct {
+		input      string
+		bareNumber bool
+		ref        string
+		number     string
+		ok         bool
+	}{
+		{"#12", false, "12", "12", true},
+		{"12", false, "", "", false},
+		{" 12 ", true, "12", "12", true},
+		{"https://github.com/o/r/pull/34/files", false, "https://github.com/o/r/pull/34/files", "34", true},
+		{"https://gitlab.com/g/p/-/merge_requests/7", false, "https://gitlab.com/g/p/-/merge_requests/7", "7", true},
+		{"feature/12", true, "", "", false},
+		{"#", false, "", "", false},
+	}
+	for _, tt := range tests {
+		ref, number, ok := ParsePRRef(tt.input, tt.bareNumber)
+		if ref != tt.ref || number != tt.number || ok != tt.ok {
+			t.Errorf("ParsePRRef(%q, %v) = %q, %q, %v; want %q, %q, %v",
+				tt.input, tt.bareNumber, ref, number, ok, tt.ref, tt.number, tt.ok)
+		}
+	}
+}
+
+func TestParsePRHead(t *testing.T) {
+	branch, fetchRef, err := parsePRHead("feature/x refs/pull/12/head\n")
+	if err != nil || branch != "feature/x" || fetchRef != "refs/pull/12/head" {
+		t.Errorf("parsePRHead() = %q, %q, %v", branch, fetchRef, err)
+	}
+	if branch, fetchRef, _ := parsePRHead("dev\n"); branch != "dev" || fetchRef != "" {
+		t.Errorf("parsePRHead() = %q, %q; want dev without a ref", branch, fetchRef)
+	}
+	if _, _, err := parsePRHead("  \n"); err == nil {
+		t.Error("parsePRHead() of empty output should fail")
+	}
+}
+
+func TestUpdateBranch(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := gittest.New()
+	g := fake.Client()
+	root, wt := filepath.Join(dir, "app"), filepath.Join(dir, "checked-out")
+	if err := fake.Init(root, map[string]string{"a.txt": "a\n"}); err != nil {
+		t.Fatal(err)
+	}
+	base, err := g.RevParse(root, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := fake.Commit(root, "Update a", map[string]string{"a.txt": "new\n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CreateWorktreeAt(root, wt, "checked-out", true, base); err != nil {
+		t.Fatal(err)
+	}
+	for _, branch := range []string{"behind", "diverged"} {
+		if err := g.FastForward(root, branch, base); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.FastForward(root, "ahead", head); err != nil {
+		t.Fatal(err)
+	}
+	// Both get a commit of their own
+	local := make(map[string]string)
+	for _, branch := range []string{"ahead", "diverged"} {
+		other := filepath.Join(dir, branch)
+		if err := g.CreateWorktreeAt(root, other, branch, false, ""); err != nil {
+			t.Fatal(err)
+		}
+		if local[branch], err = fake.Commit(other, "Local work", map[string]string{"b.txt": "b\n"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.RemoveWorktree(root, other); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, branch := range []string{"behind", "checked-out"} {
+		if err := updateBranch(g, root, branch, head, "origin/x"); err != nil {
+			t.Errorf("updateBranch(%s) = %v", branch, err)
+		}
+		if got, _ := g.RevParse(root, branch); got != head {
+			t.Errorf("%s is at %s after updateBranch, want %s", branch, got, head)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(wt, "a.txt")); err != nil || string(data) != "new\n" {
+		t.Errorf("a.txt of the checked-out branch = %q, %v; want the fetched content", data, err)
+	}
+
+	if err := updateBranch(g, root, "ahead", head, "origin/x"); err != nil {
+		t.Errorf("updateBranch(ahead) = %v", err)
+	}
+	err = updateBranch(g, root, "diverged", head, "origin/x")
+	if err == nil || !strings.Contains(err.Error(), "diverged from origin/x") {
+		t.Errorf("updateBranch(diverged) = %v, want a divergence error", err)
+	}
+	for branch, want := range local {
+		if got, _ := g.RevParse(root, branch); got != want {
+			t.Errorf("%s moved to %s, want it kept at %s", branch, got, want)
+		}
+	}
+}
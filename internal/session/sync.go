@@ -0,0 +1,114 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// BaseRef returns the ref a worktree is compared against and rebased onto:
+// the repository's base branch, or its origin counterpart when that is
+// ahead of the local branch.
+func BaseRef(g *git.Client, cfg *config.Config, dir string) string {
+	base := BaseBranch(g, cfg, MainCheckout(g, dir))
+	remote := "origin/" + base
+	if _, err := g.RevParse(dir, remote); err != nil {
+		return base
+	}
+	if _, err := g.RevParse(dir, base); err != nil {
+		return remote
+	}
+	if ahead, _ := g.IsAncestor(dir, base, remote); ahead {
+		return remote
+	}
+	return base
+}
+
+// CheckSync compares a worktree with its base ref: how far it is ahead and
+// behind, and which files would conflict bringing the base in. A git too
+// old for merge-tree --write-tree leaves the conflicts unknown.
+func CheckSync(g *git.Client, cfg *config.Config, dir string) (git.SyncStatus, error) {
+	status := git.SyncStatus{Base: BaseRef(g, cfg, dir)}
+
+	ahead, behind, err := g.AheadBehind(dir, status.Base)
+	if err != nil {
+		return status, err
+	}
+	status.Ahead, status.Behind = ahead, behind
+
+	// Nothing can conflict unless both sides have moved
+	if ahead > 0 && behind > 0 {
+		conflicts, err := g.MergeConflicts(dir, status.Base)
+		status.Conflicts, status.ConflictsUnknown = conflicts, err != nil
+	}
+	return status, nil
+}
+
+// CheckWorktree gathers the git state of a session's directory: its
+// changed files, last commit, whether its branch is on a remote and, for a
+// linked worktree, its sync with the base branch.
+func CheckWorktree(g *git.Client, cfg *config.Config, dir string) (git.WorktreeStatus, error) {
+	var status git.WorktreeStatus
+
+	changes, err := g.Status(dir)
+	if err != nil {
+		return status, err
+	}
+	status.Dirty = len(changes)
+
+	// A repository without commits has no last commit
+	if subject, committed, err := g.LastCommit(dir); err == nil {
+		status.Subject, status.Committed = subject, committed
+	}
+	if branch, err := g.GetCurrentBranch(dir); err == nil && branch != "HEAD" {
+		status.Upstream = g.HasUpstream(dir, branch)
+	}
+
+	// The main checkout is the base branch itself
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	if filepath.Clean(MainCheckout(g, dir)) == filepath.Clean(dir) {
+		return status, nil
+	}
+	status.Sync, err = CheckSync(g, cfg, dir)
+	return status, err
+}
+
+// ConflictPrompt renders the configured prompt asking Claude to resolve a
+// stopped rebase.
+func ConflictPrompt(cfg *config.Config, branch, base string, files []string) string {
+	prompt := strings.NewReplacer(
+		"{branch}", branch,
+		"{base}", base,
+		"{files}", strings.Join(files, ", "),
+	).Replace(cfg.Sync.ConflictPrompt)
+	return strings.TrimSpace(prompt)
+}
+
+// Rebase fetches the session's base branch and rebases its worktree onto
+// it. A rebase that stops on conflicts returns a *git.ConflictError and is
+// left in progress, unless the configuration says to abort it.
+func (m *Manager) Rebase(info *SessionInfo) (string, error) {
+	if info.IsMainBranch {
+		return "", fmt.Errorf("%s is on the base branch", info.SessionName)
+	}
+	dir := info.Dir()
+
+	// A failed fetch leaves the local base, which is still worth rebasing onto
+	_ = m.git.Fetch(dir, "origin", BaseBranch(m.git, m.config, info.RepoPath))
+	base := BaseRef(m.git, m.config, dir)
+
+	err := m.git.Rebase(dir, base)
+	var conflict *git.ConflictError
+	if errors.As(err, &conflict) && m.config.Sync.OnConflict == "abort" {
+		if abortErr := m.git.AbortRebase(dir); abortErr != nil {
+			return base, abortErr
+		}
+	}
+	return base, err
+}
@@ -0,0 +1,71 @@
+package session
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+	"github.com/abdullathedruid/cmux/internal/git/gittest"
+)
+
+// oldGit is a git from before 2.38, whose merge-tree has no --write-tree.
+type oldGit struct {
+	*gittest.Git
+}
+
+func (g oldGit) Run(dir, stdin string, args ...string) (string, string, error) {
+	if len(args) > 0 && args[0] == "merge-tree" {
+		return "", "error: unknown option `write-tree'\n", &gittest.ExitError{Code: 129}
+	}
+	return g.Git.Run(dir, stdin, args...)
+}
+
+func TestCheckSync(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := gittest.New()
+	root, wt := filepath.Join(dir, "app"), filepath.Join(dir, "feat")
+	if err := fake.Init(root, map[string]string{"a.txt": "a\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Client().CreateWorktreeAt(root, wt, "feat", true, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.Commit(wt, "feat", map[string]string{"a.txt": "feat\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fake.Commit(root, "main", map[string]string{"a.txt": "main\n"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.Default()
+
+	status, err := CheckSync(fake.Client(), cfg, wt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Base != "main" || status.Ahead != 1 || status.Behind != 1 || !slices.Equal(status.Conflicts, []string{"a.txt"}) || status.ConflictsUnknown {
+		t.Errorf("CheckSync() = %+v, want a.txt conflicting", status)
+	}
+
+	// An old git still counts commits but can't check conflicts
+	status, err = CheckSync(git.NewClient(oldGit{fake}), cfg, wt)
+	if err != nil {
+		t.Fatalf("CheckSync() with an old git: %v", err)
+	}
+	if status.Ahead != 1 || status.Behind != 1 || status.Conflicts != nil || !status.ConflictsUnknown {
+		t.Errorf("CheckSync() with an old git = %+v, want conflicts unknown", status)
+	}
+}
+
+func TestConflictPrompt(t *testing.T) {
+	cfg := config.Default()
+	cfg.Sync.ConflictPrompt = "Rebase {branch} onto {base}: fix {files}. "
+	got := ConflictPrompt(cfg, "feat", "origin/main", []string{"a.go", "b.go"})
+	if want := "Rebase feat onto origin/main: fix a.go, b.go."; got != want {
+		t.Errorf("ConflictPrompt() = %q, want %q", got, want)
+	}
+}
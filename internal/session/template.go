@@ -0,0 +1,115 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// TemplateVars are the values substituted into a session template.
+type TemplateVars struct {
+	Repo   string
+	Branch string
+	Issue  string
+}
+
+// Render replaces {repo}, {branch} and {issue} in text.
+func (v TemplateVars) Render(text string) string {
+	return strings.NewReplacer(
+		"{repo}", v.Repo,
+		"{branch}", v.Branch,
+		"{issue}", v.Issue,
+	).Replace(text)
+}
+
+// TemplateNeedsIssue reports whether a template uses {issue}.
+func TemplateNeedsIssue(tmpl config.SessionTemplate) bool {
+	return strings.Contains(tmpl.Branch, "{issue}") || strings.Contains(tmpl.Prompt, "{issue}") ||
+		strings.Contains(strings.Join(tmpl.Checklist, "\n"), "{issue}")
+}
+
+// TemplatePrompt renders the prompt a template submits: its prompt, then
+// its checklist items as a list.
+func TemplatePrompt(tmpl config.SessionTemplate, vars TemplateVars) string {
+	var b strings.Builder
+	b.WriteString(strings.TrimSpace(vars.Render(tmpl.Prompt)))
+	if len(tmpl.Checklist) > 0 {
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("Checklist:")
+		for _, item := range tmpl.Checklist {
+			fmt.Fprintf(&b, "\n- %s", strings.TrimSpace(vars.Render(item)))
+		}
+	}
+	return b.String()
+}
+
+// TemplateBranch renders a template's branch pattern. Characters git
+// doesn't allow in branch names become dashes.
+func TemplateBranch(tmpl config.SessionTemplate, vars TemplateVars) string {
+	branch := vars.Render(tmpl.Branch)
+	branch = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '~', '^', ':', '?', '*', '[', '\\':
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(branch))
+	return strings.Trim(branch, "/-")
+}
+
+// TemplateOptions returns how a session started from a template runs
+// Claude: the repository's settings with the template's flags, without the
+// repository's initial prompt since the template submits its own.
+func TemplateOptions(cfg *config.Config, repoPath string, tmpl config.SessionTemplate) tmux.SessionOptions {
+	repo, _ := cfg.RepositoryFor(repoPath)
+	if tmpl.Model != "" {
+		repo.Model = tmpl.Model
+	}
+	if tmpl.PermissionMode != "" {
+		repo.PermissionMode = tmpl.PermissionMode
+	}
+	repo.ClaudeArgs = append(append([]string(nil), repo.ClaudeArgs...), tmpl.ClaudeArgs...)
+
+	return tmux.SessionOptions{
+		Command: claudeCommandLine(cfg.ClaudeCommandFor(repoPath), repo, repoPath),
+		Env:     repo.Env,
+	}
+}
+
+// CreateSessionFromTemplate creates a session on the template's branch,
+// creating the branch if needed, and returns its name with the rendered
+// prompt to submit once Claude is ready. vars.Branch is used when the
+// template has no branch pattern. As with CreateSession, a name returned
+// with an error means only the worktree setup failed.
+func (m *Manager) CreateSessionFromTemplate(repoPath string, tmpl config.SessionTemplate, vars TemplateVars) (string, string, error) {
+	repoInfo, err := m.git.GetRepoInfo(repoPath)
+	if err != nil {
+		return "", "", fmt.Errorf("getting repo info: %w", err)
+	}
+	vars.Repo = repoInfo.Name
+	if tmpl.Branch != "" {
+		vars.Branch = TemplateBranch(tmpl, vars)
+	}
+	if vars.Branch == "" {
+		return "", "", fmt.Errorf("template gives no branch name")
+	}
+
+	branches, _ := m.git.ListBranches(repoPath)
+	exists := false
+	for _, b := range branches {
+		if b == vars.Branch {
+			exists = true
+			break
+		}
+	}
+
+	name, err := m.createSession(repoPath, vars.Branch, !exists, "", TemplateOptions(m.config, repoPath, tmpl))
+	if name == "" {
+		return "", "", err
+	}
+	return name, TemplatePrompt(tmpl, vars), err
+}
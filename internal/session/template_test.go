@@ -0,0 +1,66 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+)
+
+func TestTemplateBranch(t *testing.T) {
+	tmpl := config.SessionTemplate{
+		Branch: "fix/{repo}-{issue}",
+		Prompt: "Fix issue {issue} on {branch}",
+	}
+	vars := TemplateVars{Repo: "api", Issue: "flaky CI: step 3"}
+
+	if !TemplateNeedsIssue(tmpl) {
+		t.Error("template uses {issue}")
+	}
+	branch := TemplateBranch(tmpl, vars)
+	if branch != "fix/api-flaky-CI--step-3" {
+		t.Errorf("TemplateBranch() = %q", branch)
+	}
+
+	vars.Branch = branch
+	if got := vars.Render(tmpl.Prompt); got != "Fix issue flaky CI: step 3 on "+branch {
+		t.Errorf("Render() = %q", got)
+	}
+	if TemplateNeedsIssue(config.SessionTemplate{Prompt: "Review {branch}"}) {
+		t.Error("template does not use {issue}")
+	}
+}
+
+func TestTemplatePrompt(t *testing.T) {
+	tmpl := config.SessionTemplate{
+		Prompt:    "Fix issue {issue}.\n",
+		Checklist: []string{"Reproduce {issue} in a test", "Run the linter"},
+	}
+	vars := TemplateVars{Issue: "#12"}
+
+	want := "Fix issue #12.\n\nChecklist:\n- Reproduce #12 in a test\n- Run the linter"
+	if got := TemplatePrompt(tmpl, vars); got != want {
+		t.Errorf("TemplatePrompt() = %q, want %q", got, want)
+	}
+	if !TemplateNeedsIssue(config.SessionTemplate{Checklist: tmpl.Checklist}) {
+		t.Error("checklist uses {issue}")
+	}
+	if got := TemplatePrompt(config.SessionTemplate{Checklist: []string{"Update the docs"}}, vars); got != "Checklist:\n- Update the docs" {
+		t.Errorf("TemplatePrompt() without a prompt = %q", got)
+	}
+}
+
+func TestTemplateOptions(t *testing.T) {
+	cfg := config.Default()
+	cfg.Repositories = []config.Repository{
+		{Path: "/code/app", Model: "sonnet", ClaudeArgs: []string{"--verbose"}, InitialPrompt: "hello"},
+	}
+	tmpl := config.SessionTemplate{Model: "opus", ClaudeArgs: []string{"--debug"}}
+
+	want := `claude --model 'opus' '--verbose' '--debug'`
+	if got := TemplateOptions(cfg, "/code/app", tmpl).Command; got != want {
+		t.Errorf("Command = %q, want %q", got, want)
+	}
+	if got := cfg.Repositories[0].ClaudeArgs; len(got) != 1 {
+		t.Errorf("repository args changed to %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// maxSubjectLength is the longest drafted commit subject.
+const maxSubjectLength = 72
+
+// maxSummaryLines is how much of the assistant's summary goes in a commit body.
+const maxSummaryLines = 20
+
+// urlRegex finds URLs in forge command output.
+var urlRegex = regexp.MustCompile(`https?://\S+`)
+
+// Dir returns the directory the session works in: its worktree, or the
+// repository root for main branch sessions.
+func (i *SessionInfo) Dir() string {
+	if i.HasWorktree && i.WorktreePath != "" {
+		return i.WorktreePath
+	}
+	return i.RepoPath
+}
+
+// DraftCommitMessage drafts a commit message from the prompts of a session
+// and the assistant's last summary. The subject is the first prompt's first
+// line; the body lists the prompts and quotes the summary.
+func DraftCommitMessage(prompts []string, summary string) string {
+	var nonEmpty []string
+	for _, p := range prompts {
+		if p = strings.TrimSpace(p); p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+
+	subject := "Update from Claude session"
+	if len(nonEmpty) > 0 {
+		subject, _, _ = strings.Cut(nonEmpty[0], "\n")
+		subject = strings.TrimSpace(subject)
+		if runes := []rune(subject); len(runes) > maxSubjectLength {
+			subject = strings.TrimSpace(string(runes[:maxSubjectLength-3])) + "..."
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(subject)
+
+	if len(nonEmpty) > 1 {
+		b.WriteString("\n\nPrompts:\n")
+		for _, p := range nonEmpty {
+			line, _, _ := strings.Cut(p, "\n")
+			fmt.Fprintf(&b, "- %s\n", strings.TrimSpace(line))
+		}
+	}
+
+	if summary = strings.TrimSpace(summary); summary != "" {
+		lines := strings.Split(summary, "\n")
+		if len(lines) > maxSummaryLines {
+			lines = lines[:maxSummaryLines]
+		}
+		b.WriteString("\n\n")
+		b.WriteString(strings.TrimSpace(strings.Join(lines, "\n")))
+	}
+
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// CleanCommitMessage drops the comment lines of a commit message edited by
+// the user and trims the blank lines around it, as git commit does.
+func CleanCommitMessage(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	message := strings.Trim(strings.Join(lines, "\n"), "\n")
+	if message == "" {
+		return ""
+	}
+	return message + "\n"
+}
+
+// Commit stages and commits every change in the session's directory.
+func (m *Manager) Commit(info *SessionInfo, message string) error {
+	if strings.TrimSpace(message) == "" {
+		return errors.New("empty commit message")
+	}
+	return m.git.CommitAll(info.Dir(), message)
+}
+
+// Push pushes the session's branch to origin.
+func (m *Manager) Push(info *SessionInfo) error {
+	if info.BranchName == "" {
+		return errors.New("session has no branch")
+	}
+	return m.git.Push(info.Dir(), info.BranchName)
+}
+
+// CreatePullRequest opens a pull request for the session's branch with the
+// configured forge command, titled and described by the last commit. The
+// PR URL printed by the command is stored in info.PRURL and returned.
+func (m *Manager) CreatePullRequest(info *SessionInfo) (string, error) {
+	if m.config.Forge.PRCommand == "" {
+		return "", errors.New("no forge pr_command configured")
+	}
+	if info.BranchName == "" {
+		return "", errors.New("session has no branch")
+	}
+
+	dir := info.Dir()
+	title, body, err := m.git.LastCommitMessage(dir)
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("sh", "-c", m.config.Forge.PRCommand)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"CMUX_PR_TITLE="+title,
+		"CMUX_PR_BODY="+body,
+		"CMUX_BRANCH="+info.BranchName,
+		"CMUX_BASE_BRANCH="+BaseBranch(m.git, m.config, info.RepoPath),
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("forge command: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	url := parsePRURL(stdout.String())
+	if url == "" {
+		// Some forges print the URL on stderr
+		url = parsePRURL(stderr.String())
+	}
+	info.PRURL = url
+	return url, nil
+}
+
+// parsePRURL returns the last URL in the output of a forge command.
+func parsePRURL(output string) string {
+	urls := urlRegex.FindAllString(output, -1)
+	if len(urls) == 0 {
+		return ""
+	}
+	return strings.TrimRight(urls[len(urls)-1], ".,)")
+}
@@ -0,0 +1,95 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDraftCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		prompts []string
+		summary string
+		want    string
+	}{
+		{
+			name: "no prompts",
+			want: "Update from Claude session\n",
+		},
+		{
+			name:    "single prompt with summary",
+			prompts: []string{"  Add a retry to the fetcher\nIt fails on timeouts."},
+			summary: "Added exponential backoff.\n",
+			want:    "Add a retry to the fetcher\n\nAdded exponential backoff.\n",
+		},
+		{
+			name:    "several prompts",
+			prompts: []string{"Add a parser", "", "Now test it"},
+			want:    "Add a parser\n\nPrompts:\n- Add a parser\n- Now test it\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DraftCommitMessage(tt.prompts, tt.summary); got != tt.want {
+				t.Errorf("DraftCommitMessage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDraftCommitMessage_LongSubject(t *testing.T) {
+	got := DraftCommitMessage([]string{strings.Repeat("word ", 30)}, "")
+	subject, _, _ := strings.Cut(got, "\n")
+	if len(subject) > maxSubjectLength {
+		t.Errorf("subject is %d characters, want at most %d", len(subject), maxSubjectLength)
+	}
+	if !strings.HasSuffix(subject, "...") {
+		t.Errorf("subject %q should end with ...", subject)
+	}
+}
+
+func TestDraftCommitMessage_LongMultibyteSubject(t *testing.T) {
+	got := DraftCommitMessage([]string{strings.Repeat("修正 ", 40)}, "")
+	subject, _, _ := strings.Cut(got, "\n")
+	if !utf8.ValidString(subject) {
+		t.Errorf("subject %q was cut inside a character", subject)
+	}
+	if n := utf8.RuneCountInString(subject); n > maxSubjectLength {
+		t.Errorf("subject is %d characters, want at most %d", n, maxSubjectLength)
+	}
+}
+
+func TestCleanCommitMessage(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Fix login\n\nDetails  \n", "Fix login\n\nDetails\n"},
+		{"\nFix login\n# Lines starting with '#' are ignored.\n\n", "Fix login\n"},
+		{"# only comments\n\n", ""},
+	}
+	for _, tt := range tests {
+		if got := CleanCommitMessage(tt.text); got != tt.want {
+			t.Errorf("CleanCommitMessage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParsePRURL(t *testing.T) {
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"https://github.com/o/r/pull/12\n", "https://github.com/o/r/pull/12"},
+		{"Creating pull request for feat into main in o/r\n\nhttps://github.com/o/r/pull/3\n", "https://github.com/o/r/pull/3"},
+		{"View merge request at https://gitlab.com/o/r/-/merge_requests/7.", "https://gitlab.com/o/r/-/merge_requests/7"},
+		{"done\n", ""},
+	}
+
+	for _, tt := range tests {
+		if got := parsePRURL(tt.output); got != tt.want {
+			t.Errorf("parsePRURL(%q) = %q, want %q", tt.output, got, tt.want)
+		}
+	}
+}
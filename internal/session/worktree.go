@@ -0,0 +1,138 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
+)
+
+// SetupError reports worktree setup steps that failed. The worktree itself
+// was created and can be used.
+type SetupError struct {
+	Failed []error
+}
+
+func (e *SetupError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, err := range e.Failed {
+		msgs[i] = err.Error()
+	}
+	return "worktree setup: " + strings.Join(msgs, "; ")
+}
+
+func (e *SetupError) Unwrap() []error {
+	return e.Failed
+}
+
+// CreateWorktree creates a worktree for branchName where the config places
+// the repository's worktrees and runs its setup steps. A new branch starts
+// at the repository's base branch. If only the setup failed, the worktree
+// path is returned with a *SetupError.
+func CreateWorktree(g *git.Client, cfg *config.Config, repoPath, branchName string, createBranch bool) (string, error) {
+	return CreateWorktreeFrom(g, cfg, repoPath, branchName, createBranch, "")
+}
+
+// CreateWorktreeFrom is CreateWorktree with a new branch starting at
+// startPoint instead. A remote-tracking start point makes the branch track
+// it.
+func CreateWorktreeFrom(g *git.Client, cfg *config.Config, repoPath, branchName string, createBranch bool, startPoint string) (string, error) {
+	if startPoint == "" {
+		startPoint = baseStartPoint(cfg, repoPath)
+	}
+	dir, setup := cfg.WorktreeFor(repoPath)
+	worktreePath := git.ResolveWorktreePath(repoPath, branchName, dir)
+
+	if err := g.CreateWorktreeAt(repoPath, worktreePath, branchName, createBranch, startPoint); err != nil {
+		return "", err
+	}
+
+	if err := SetupWorktree(setup, repoPath, worktreePath, branchName); err != nil {
+		return worktreePath, err
+	}
+	return worktreePath, nil
+}
+
+// SetupWorktree copies and links files from the main checkout into a new
+// worktree and runs the setup commands in it. Every step is attempted; the
+// failures are returned as a *SetupError.
+func SetupWorktree(setup config.WorktreeSetup, repoPath, worktreePath, branchName string) error {
+	var errs []error
+
+	for _, rel := range setup.Copy {
+		if err := copyFile(filepath.Join(repoPath, rel), filepath.Join(worktreePath, rel)); err != nil {
+			errs = append(errs, fmt.Errorf("copy %s: %w", rel, err))
+		}
+	}
+
+	for _, rel := range setup.Symlink {
+		target := filepath.Join(repoPath, rel)
+		link := filepath.Join(worktreePath, rel)
+		if _, err := os.Stat(target); err != nil {
+			errs = append(errs, fmt.Errorf("symlink %s: %w", rel, err))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(link), 0755); err != nil {
+			errs = append(errs, fmt.Errorf("symlink %s: %w", rel, err))
+			continue
+		}
+		if err := os.Symlink(target, link); err != nil && !errors.Is(err, os.ErrExist) {
+			errs = append(errs, fmt.Errorf("symlink %s: %w", rel, err))
+		}
+	}
+
+	for _, command := range setup.Run {
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = worktreePath
+		cmd.Env = append(os.Environ(),
+			"CMUX_REPO_PATH="+repoPath,
+			"CMUX_WORKTREE_PATH="+worktreePath,
+			"CMUX_BRANCH="+branchName,
+		)
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w: %s", command, err, strings.TrimSpace(output.String())))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &SetupError{Failed: errs}
+	}
+	return nil
+}
+
+// copyFile copies a regular file, keeping its permissions.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
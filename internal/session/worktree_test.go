@@ -0,0 +1,60 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/config"
+)
+
+func TestSetupWorktree(t *testing.T) {
+	repo := t.TempDir()
+	wt := t.TempDir()
+
+	if err := os.WriteFile(filepath.Join(repo, ".env"), []byte("KEY=1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "node_modules", "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	setup := config.WorktreeSetup{
+		Copy:    []string{".env"},
+		Symlink: []string{"node_modules"},
+		Run:     []string{`echo "$CMUX_BRANCH" > branch.txt`},
+	}
+	if err := SetupWorktree(setup, repo, wt, "feature/x"); err != nil {
+		t.Fatalf("SetupWorktree() error = %v", err)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(wt, ".env")); err != nil || string(data) != "KEY=1\n" {
+		t.Errorf(".env = %q, %v", data, err)
+	}
+	if info, err := os.Stat(filepath.Join(wt, ".env")); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf(".env mode = %v, want 0600", info.Mode().Perm())
+	}
+	if target, err := os.Readlink(filepath.Join(wt, "node_modules")); err != nil || target != filepath.Join(repo, "node_modules") {
+		t.Errorf("node_modules -> %q, %v", target, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(wt, "branch.txt")); err != nil || string(data) != "feature/x\n" {
+		t.Errorf("branch.txt = %q, %v", data, err)
+	}
+}
+
+func TestSetupWorktree_ReportsFailures(t *testing.T) {
+	setup := config.WorktreeSetup{
+		Copy: []string{"missing.env"},
+		Run:  []string{"exit 3", "true"},
+	}
+	err := SetupWorktree(setup, t.TempDir(), t.TempDir(), "main")
+
+	var setupErr *SetupError
+	if !errors.As(err, &setupErr) {
+		t.Fatalf("error = %v, want *SetupError", err)
+	}
+	if len(setupErr.Failed) != 2 {
+		t.Errorf("got %d failures, want 2: %v", len(setupErr.Failed), err)
+	}
+}
@@ -2,9 +2,12 @@
 package state
 
 import (
+	"slices"
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/abdullathedruid/cmux/internal/git"
 )
 
 // SessionStatus represents the current status of a Claude session.
@@ -63,6 +66,42 @@ type Session struct {
 	SessionID   string             // Claude session ID
 	LastPrompt  string             // last user prompt submitted
 	ToolHistory []ToolHistoryEntry // recent tool execution history
+
+	// PRURL is the pull request opened for the session's branch, if any
+	PRURL string
+
+	// Git is the git state of the session's directory, nil until checked
+	Git *git.WorktreeStatus
+
+	// Dormant sessions are remembered from a previous run but have no tmux
+	// session; they can be resumed from SessionID
+	Dormant bool
+
+	// Panes across all windows of the tmux session
+	Panes      []PaneInfo
+	ClaudePane string // session:window.pane target running Claude (empty if unknown)
+
+	// Resources of the Claude process, as of the last discovery
+	ClaudePID int
+	CPU       float64 // percent of one core since the discovery before
+	RSS       int64   // resident memory in bytes
+}
+
+// PaneInfo describes a single pane of a session's tmux windows.
+type PaneInfo struct {
+	Target  string // session:window.pane
+	Command string // foreground command name
+	Role    string // "claude", "dev_server", "shell" or "other"
+}
+
+// HasDevServer returns true if any pane of the session runs a dev server.
+func (s *Session) HasDevServer() bool {
+	for _, p := range s.Panes {
+		if p.Role == "dev_server" {
+			return true
+		}
+	}
+	return false
 }
 
 // Repository represents a git repository with associated sessions.
@@ -117,6 +156,32 @@ func (s *State) UpdateSessions(sessions []*Session) {
 	}
 }
 
+// RemoveSession forgets a session until the next update.
+func (s *State) RemoveSession(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, ok := s.sessions[name]
+	if !ok {
+		return
+	}
+	delete(s.sessions, name)
+
+	repoPath := sess.RepoPath
+	if repoPath == "" {
+		repoPath = "standalone"
+	}
+	if repo, ok := s.repositories[repoPath]; ok {
+		repo.Sessions = slices.DeleteFunc(repo.Sessions, func(r *Session) bool { return r.Name == name })
+		if len(repo.Sessions) == 0 {
+			delete(s.repositories, repoPath)
+		}
+	}
+	if s.selectedSession == name {
+		s.selectedSession = ""
+	}
+}
+
 // addToRepo adds a session to its repository (must be called with lock held).
 func (s *State) addToRepo(sess *Session) {
 	repoPath := sess.RepoPath
@@ -153,7 +218,10 @@ func (s *State) GetSessions() []*Session {
 		if sessions[i].RepoName != sessions[j].RepoName {
 			return sessions[i].RepoName < sessions[j].RepoName
 		}
-		return sessions[i].Created.Before(sessions[j].Created)
+		if !sessions[i].Created.Equal(sessions[j].Created) {
+			return sessions[i].Created.Before(sessions[j].Created)
+		}
+		return sessions[i].Name < sessions[j].Name
 	})
 
 	return sessions
@@ -279,7 +347,10 @@ func (s *State) getSortedSessionsLocked() []*Session {
 		if sessions[i].RepoName != sessions[j].RepoName {
 			return sessions[i].RepoName < sessions[j].RepoName
 		}
-		return sessions[i].Created.Before(sessions[j].Created)
+		if !sessions[i].Created.Equal(sessions[j].Created) {
+			return sessions[i].Created.Before(sessions[j].Created)
+		}
+		return sessions[i].Name < sessions[j].Name
 	})
 
 	return sessions
@@ -39,6 +39,29 @@ func TestUpdateSessions(t *testing.T) {
 	}
 }
 
+func TestRemoveSession(t *testing.T) {
+	s := New()
+
+	s.UpdateSessions([]*Session{
+		{Name: "project-main", RepoName: "project", RepoPath: "/code/project"},
+		{Name: "other-main", RepoName: "other", RepoPath: "/code/other"},
+	})
+	s.SetSelectedSession("other-main")
+
+	s.RemoveSession("other-main")
+	s.RemoveSession("missing")
+
+	if s.SessionCount() != 1 {
+		t.Errorf("expected 1 session, got %d", s.SessionCount())
+	}
+	if repos := s.GetRepositories(); len(repos) != 1 || repos[0].Name != "project" {
+		t.Errorf("expected only the project repository, got %v", repos)
+	}
+	if s.GetSelectedSessionName() != "" {
+		t.Errorf("expected selection cleared, got '%s'", s.GetSelectedSessionName())
+	}
+}
+
 func TestSelection(t *testing.T) {
 	s := New()
 
@@ -242,3 +265,21 @@ func TestSessionWithTimestamps(t *testing.T) {
 		t.Error("LastActive timestamp should not be zero")
 	}
 }
+
+func TestSessionHasDevServer(t *testing.T) {
+	sess := &Session{
+		Name: "test",
+		Panes: []PaneInfo{
+			{Target: "test:0.0", Command: "node", Role: "claude"},
+			{Target: "test:0.1", Command: "zsh", Role: "shell"},
+		},
+	}
+	if sess.HasDevServer() {
+		t.Error("HasDevServer() should be false without a dev server pane")
+	}
+
+	sess.Panes = append(sess.Panes, PaneInfo{Target: "test:1.0", Command: "npm", Role: "dev_server"})
+	if !sess.HasDevServer() {
+		t.Error("HasDevServer() should be true with a dev server pane")
+	}
+}
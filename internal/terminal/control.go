@@ -3,42 +3,83 @@ package terminal
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
-	"regexp"
-	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/creack/pty"
 )
 
-// outputPattern matches "%output %<pane-id> <data>" lines.
-// The line may be prefixed with DCS escape sequences like \033P1000p
-var outputPattern = regexp.MustCompile(`%output %(\d+) (.*)$`)
+// DefaultCommandTimeout is how long Command waits for a reply from tmux.
+const DefaultCommandTimeout = 5 * time.Second
+
+var (
+	// ErrExited is returned when the control connection has ended.
+	ErrExited = errors.New("tmux control mode exited")
+	// ErrTimeout is returned when tmux does not reply to a command in time.
+	ErrTimeout = errors.New("tmux control mode command timed out")
+)
 
 // ControlMode manages a tmux -CC (control mode) connection.
+//
+// Commands are written one per line and tmux answers each with a
+// %begin/%end (or %error) block in the order they were sent, so replies are
+// paired with a FIFO of pending commands. Everything else tmux writes is a
+// notification: %output for the followed pane is delivered on OutputChan, and
+// all notifications are available as typed values on Notifications.
 type ControlMode struct {
-	session  string
-	cmd      *exec.Cmd
-	pty      *os.File
+	target  string
+	cmd     *exec.Cmd
+	pty     *os.File
+	w       io.Writer
+	timeout time.Duration
+
 	outputCh chan []byte
-	doneCh   chan struct{}
-	mu       sync.Mutex
+	notifyCh chan Notification
+	closeCh  chan struct{}
+	exitedCh chan struct{}
+	waitCh   chan struct{}
+
+	closeOnce sync.Once
+	exitOnce  sync.Once
+
+	// mu serializes writes so the pending queue matches tmux's reply order.
+	mu      sync.Mutex
+	pending []chan Reply
+
+	// stateMu guards the fields below.
+	stateMu       sync.Mutex
+	paneID        string // pane whose output goes to outputCh ("" = any)
+	subscribed    bool
+	exitReason    string
+	width, height int    // size tmux last accepted
+	resizing      [2]int // size sent and not yet answered, if any
+	resizeErr     error  // why the last resize failed
 }
 
-// NewControlMode creates a new control mode connection for the given session.
-func NewControlMode(session string) *ControlMode {
+// NewControlMode creates a new control mode connection for the given target.
+// The target is any tmux target accepted by attach-session, e.g. "work" or
+// "work:1.2" to follow a specific window and pane.
+func NewControlMode(target string) *ControlMode {
 	return &ControlMode{
-		session:  session,
+		target:   target,
+		timeout:  DefaultCommandTimeout,
 		outputCh: make(chan []byte, 100),
-		doneCh:   make(chan struct{}),
+		notifyCh: make(chan Notification, 100),
+		closeCh:  make(chan struct{}),
+		exitedCh: make(chan struct{}),
+		waitCh:   make(chan struct{}),
 	}
 }
 
 // Start begins the tmux control mode connection with the given dimensions.
 func (c *ControlMode) Start(width, height int) error {
-	c.cmd = exec.Command("tmux", "-CC", "attach-session", "-t", c.session)
+	c.cmd = exec.Command("tmux", "-CC", "attach-session", "-t", c.target)
 
 	// Start with a PTY (tmux needs a real terminal)
 	var err error
@@ -46,6 +87,7 @@ func (c *ControlMode) Start(width, height int) error {
 	if err != nil {
 		return fmt.Errorf("start pty: %w", err)
 	}
+	c.w = c.pty
 
 	// Set the PTY size
 	pty.Setsize(c.pty, &pty.Winsize{
@@ -53,13 +95,17 @@ func (c *ControlMode) Start(width, height int) error {
 		Cols: uint16(width),
 	})
 
-	// Monitor process exit
+	// Reap the process when it exits
 	go func() {
 		c.cmd.Wait()
+		close(c.waitCh)
 	}()
 
-	// Start reading output
-	go c.readOutput()
+	// Start reading protocol lines
+	go c.readLoop(c.pty)
+
+	// Follow the target's active pane
+	go c.refreshActivePane()
 
 	// Force a full redraw by resizing to a different size first, then back
 	// This tricks tmux into thinking the terminal changed and needs a full redraw
@@ -69,126 +115,319 @@ func (c *ControlMode) Start(width, height int) error {
 	return nil
 }
 
-// Resize tells tmux about the new window size.
+// Resize tells tmux about the new window size. The command is sent without
+// waiting for the reply, since layout resizes on the GUI goroutine; the
+// reply is awaited in the background and a failure kept for ResizeErr.
+// It is a no-op if the size has not changed or is already being sent.
 func (c *ControlMode) Resize(width, height int) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	size := [2]int{width, height}
+	c.stateMu.Lock()
+	if c.resizing == size || (c.resizing == [2]int{} && c.width == width && c.height == height) {
+		c.stateMu.Unlock()
+		return nil
+	}
+	c.resizing = size
+	c.stateMu.Unlock()
 
 	// Resize the PTY
-	pty.Setsize(c.pty, &pty.Winsize{
-		Rows: uint16(height),
-		Cols: uint16(width),
-	})
+	if c.pty != nil {
+		pty.Setsize(c.pty, &pty.Winsize{
+			Rows: uint16(height),
+			Cols: uint16(width),
+		})
+	}
 
 	// Tell tmux to refresh with the new size
-	cmd := fmt.Sprintf("refresh-client -C %d,%d\n", width, height)
-	_, err := c.pty.Write([]byte(cmd))
-	return err
+	command := fmt.Sprintf("refresh-client -C %d,%d", width, height)
+	ch, err := c.send(command)
+	if err != nil {
+		c.resized(size, err)
+		return err
+	}
+	go func() {
+		_, err := c.wait(command, ch)
+		c.resized(size, err)
+	}()
+	return nil
+}
+
+// resized records tmux's answer to a resize. A failed size is not
+// recorded, so resizing to it again sends it again.
+func (c *ControlMode) resized(size [2]int, err error) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.resizing == size {
+		c.resizing = [2]int{}
+	}
+	c.resizeErr = err
+	if err == nil {
+		c.width, c.height = size[0], size[1]
+	}
+}
+
+// ResizeErr returns why the last resize failed, or nil if it succeeded.
+func (c *ControlMode) ResizeErr() error {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.resizeErr
+}
+
+// Command sends a tmux command and waits for its reply.
+// A reply ending in %error is returned together with a non-nil error.
+func (c *ControlMode) Command(command string) (Reply, error) {
+	ch, err := c.send(command)
+	if err != nil {
+		return Reply{}, err
+	}
+	return c.wait(command, ch)
+}
+
+// wait waits for the reply to a command sent on ch.
+func (c *ControlMode) wait(command string, ch <-chan Reply) (Reply, error) {
+	select {
+	case r := <-ch:
+		if r.Err {
+			return r, fmt.Errorf("tmux %s: %s", commandName(command), r.Output())
+		}
+		return r, nil
+	case <-c.exitedCh:
+		return Reply{}, ErrExited
+	case <-time.After(c.timeout):
+		return Reply{}, ErrTimeout
+	}
+}
+
+// send writes a command and registers it in the pending queue.
+// The returned channel receives the reply; callers may ignore it.
+func (c *ControlMode) send(command string) (<-chan Reply, error) {
+	select {
+	case <-c.exitedCh:
+		return nil, ErrExited
+	default:
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.w == nil {
+		return nil, ErrExited
+	}
+
+	ch := make(chan Reply, 1)
+	c.pending = append(c.pending, ch)
+	if _, err := io.WriteString(c.w, command+"\n"); err != nil {
+		c.pending = c.pending[:len(c.pending)-1]
+		return nil, fmt.Errorf("write command: %w", err)
+	}
+	return ch, nil
 }
 
-// readOutput reads lines from tmux control mode and parses them.
-func (c *ControlMode) readOutput() {
+// commandName returns the first word of a command for error messages.
+func commandName(command string) string {
+	name, _, _ := strings.Cut(command, " ")
+	return name
+}
+
+// readLoop reads protocol lines until the connection ends.
+func (c *ControlMode) readLoop(r io.Reader) {
 	defer close(c.outputCh)
+	defer close(c.notifyCh)
 
-	scanner := bufio.NewScanner(c.pty)
-	// Increase buffer size for large outputs
-	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	reader := bufio.NewReader(r)
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	var (
+		inBlock bool
+		block   guardLine
+		lines   []string
+	)
 
-		// Parse %output lines
-		if data, ok := parseOutputLine(line); ok {
-			select {
-			case c.outputCh <- data:
-			case <-c.doneCh:
-				return
+	for {
+		// ReadBytes has no line length limit, unlike bufio.Scanner
+		raw, err := reader.ReadBytes('\n')
+		if len(raw) > 0 {
+			line := trimLine(raw)
+			kind, guard := classifyLine(line)
+
+			switch {
+			case inBlock:
+				if (kind == lineEnd || kind == lineError) && guard.number == block.number {
+					c.deliverReply(block, Reply{Number: block.number, Lines: lines, Err: kind == lineError})
+					inBlock, lines = false, nil
+				} else {
+					lines = append(lines, line)
+				}
+
+			case kind == lineBegin:
+				inBlock, block, lines = true, guard, nil
+
+			case kind == lineNotification:
+				if !c.dispatch(parseNotification(line)) {
+					return
+				}
 			}
 		}
+		if err != nil {
+			c.markExited(err.Error())
+			return
+		}
 	}
 }
 
-// parseOutputLine parses a "%output %N <data>" line and returns decoded data.
-func parseOutputLine(line string) ([]byte, bool) {
-	matches := outputPattern.FindStringSubmatch(line)
-	if matches == nil {
-		return nil, false
+// deliverReply hands a reply to the oldest pending command.
+// Blocks not started by this client (flags bit 0 unset), such as the
+// initial attach-session, are discarded.
+func (c *ControlMode) deliverReply(guard guardLine, r Reply) {
+	if guard.flags&1 == 0 {
+		return
+	}
+
+	c.mu.Lock()
+	if len(c.pending) == 0 {
+		c.mu.Unlock()
+		return
 	}
+	ch := c.pending[0]
+	c.pending = c.pending[1:]
+	c.mu.Unlock()
 
-	// matches[2] contains the octal-encoded data
-	data := decodeOctal(matches[2])
-	return data, true
+	ch <- r
 }
 
-// decodeOctal converts \NNN octal sequences to bytes.
-func decodeOctal(s string) []byte {
-	var result []byte
-	i := 0
-	for i < len(s) {
-		if s[i] == '\\' && i+3 < len(s) {
-			// Check if next 3 chars are octal digits
-			if isOctalDigit(s[i+1]) && isOctalDigit(s[i+2]) && isOctalDigit(s[i+3]) {
-				val, _ := strconv.ParseInt(s[i+1:i+4], 8, 32)
-				result = append(result, byte(val))
-				i += 4
-				continue
-			}
-			// Handle \\ (escaped backslash)
-			if i+1 < len(s) && s[i+1] == '\\' {
-				result = append(result, '\\')
-				i += 2
-				continue
+// dispatch routes a notification. Returns false if the client was closed.
+func (c *ControlMode) dispatch(n Notification) bool {
+	switch n := n.(type) {
+	case OutputNotification:
+		if c.followsPane(n.PaneID) {
+			select {
+			case c.outputCh <- n.Data:
+			case <-c.closeCh:
+				return false
 			}
 		}
-		result = append(result, s[i])
-		i++
+	case WindowPaneChangedNotification, SessionWindowChangedNotification:
+		go c.refreshActivePane()
+	case ExitNotification:
+		c.markExited(n.Reason)
 	}
-	return result
+
+	c.stateMu.Lock()
+	subscribed := c.subscribed
+	c.stateMu.Unlock()
+	if !subscribed {
+		return true
+	}
+
+	select {
+	case c.notifyCh <- n:
+		return true
+	case <-c.closeCh:
+		return false
+	}
+}
+
+// followsPane reports whether output from paneID belongs on OutputChan.
+func (c *ControlMode) followsPane(paneID string) bool {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.paneID == "" || c.paneID == paneID
+}
+
+// refreshActivePane asks tmux which pane the target currently shows.
+func (c *ControlMode) refreshActivePane() {
+	r, err := c.Command(fmt.Sprintf("display-message -p -t %s '#{pane_id}'", quoteArg(c.target)))
+	if err != nil || len(r.Lines) == 0 {
+		return
+	}
+	c.stateMu.Lock()
+	c.paneID = strings.TrimSpace(r.Lines[0])
+	c.stateMu.Unlock()
+}
+
+// markExited records the end of the connection once.
+func (c *ControlMode) markExited(reason string) {
+	c.exitOnce.Do(func() {
+		c.stateMu.Lock()
+		c.exitReason = reason
+		c.stateMu.Unlock()
+		close(c.exitedCh)
+	})
 }
 
-func isOctalDigit(b byte) bool {
-	return b >= '0' && b <= '7'
+// sendTarget returns the target for key input: the followed pane if known.
+func (c *ControlMode) sendTarget() string {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if c.paneID != "" {
+		return c.paneID
+	}
+	return c.target
 }
 
 // SendKeys sends tmux key commands (e.g., "Enter", "C-c", "Up").
+// Keys are sent without waiting for the reply to keep typing responsive.
 func (c *ControlMode) SendKeys(keys string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	cmd := fmt.Sprintf("send-keys -t %s %s\n", c.session, keys)
-	_, err := c.pty.Write([]byte(cmd))
+	_, err := c.send(fmt.Sprintf("send-keys -t %s %s", quoteArg(c.sendTarget()), keys))
 	return err
 }
 
 // SendLiteralKeys sends literal key input to tmux (quoted).
 func (c *ControlMode) SendLiteralKeys(keys string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	cmd := fmt.Sprintf("send-keys -t %s -l %q\n", c.session, keys)
-	_, err := c.pty.Write([]byte(cmd))
+	_, err := c.send(fmt.Sprintf("send-keys -t %s -l %s", quoteArg(c.sendTarget()), quoteArg(keys)))
 	return err
 }
 
-// OutputChan returns the channel that receives terminal output data.
+// OutputChan returns the channel that receives output of the followed pane.
+// It is closed when the connection ends.
 func (c *ControlMode) OutputChan() <-chan []byte {
 	return c.outputCh
 }
 
-// Session returns the tmux session name.
+// Notifications returns the channel of typed notifications, including
+// output for every pane. Notifications are only queued once this has been
+// called, and the reader blocks until they are consumed.
+func (c *ControlMode) Notifications() <-chan Notification {
+	c.stateMu.Lock()
+	c.subscribed = true
+	c.stateMu.Unlock()
+	return c.notifyCh
+}
+
+// Done returns a channel that is closed when the connection ends, either
+// because tmux sent %exit (e.g. the session was killed) or the PTY closed.
+func (c *ControlMode) Done() <-chan struct{} {
+	return c.exitedCh
+}
+
+// ExitReason returns why the connection ended, if it has.
+func (c *ControlMode) ExitReason() string {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.exitReason
+}
+
+// PaneID returns the ID of the followed pane (e.g. "%3"), or "" if unknown.
+func (c *ControlMode) PaneID() string {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.paneID
+}
+
+// Session returns the tmux target this connection was attached to.
 func (c *ControlMode) Session() string {
-	return c.session
+	return c.target
 }
 
-// Close terminates the control mode connection.
+// Close terminates the control mode connection. Safe to call more than once.
 func (c *ControlMode) Close() error {
-	close(c.doneCh)
-	if c.pty != nil {
-		c.pty.Close()
-	}
-	if c.cmd != nil && c.cmd.Process != nil {
-		c.cmd.Process.Kill()
-		c.cmd.Wait()
-	}
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+		if c.pty != nil {
+			c.pty.Close()
+		}
+		if c.cmd != nil && c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+			<-c.waitCh
+		}
+	})
 	return nil
 }
@@ -0,0 +1,269 @@
+package terminal
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTmux wires a ControlMode to in-memory pipes instead of a tmux process.
+type fakeTmux struct {
+	ctrl     *ControlMode
+	toClient *io.PipeWriter // what tmux writes
+	commands *bufio.Reader  // what the client sent
+}
+
+func newFakeTmux(t *testing.T) *fakeTmux {
+	t.Helper()
+
+	serverR, serverW := io.Pipe()
+	clientR, clientW := io.Pipe()
+
+	c := NewControlMode("work")
+	c.w = clientW
+	c.timeout = time.Second
+	go c.readLoop(serverR)
+
+	t.Cleanup(func() {
+		serverW.Close()
+		clientR.Close()
+		c.Close()
+	})
+
+	return &fakeTmux{ctrl: c, toClient: serverW, commands: bufio.NewReader(clientR)}
+}
+
+func (f *fakeTmux) write(t *testing.T, lines ...string) {
+	t.Helper()
+	for _, l := range lines {
+		if _, err := io.WriteString(f.toClient, l+"\r\n"); err != nil {
+			t.Fatalf("write %q: %v", l, err)
+		}
+	}
+}
+
+func (f *fakeTmux) readCommand(t *testing.T) string {
+	t.Helper()
+	line, err := f.commands.ReadString('\n')
+	if err != nil {
+		t.Fatalf("read command: %v", err)
+	}
+	return strings.TrimSuffix(line, "\n")
+}
+
+func TestCommandPairsReplies(t *testing.T) {
+	f := newFakeTmux(t)
+
+	type result struct {
+		reply Reply
+		err   error
+	}
+	first := make(chan result, 1)
+	second := make(chan result, 1)
+
+	go func() {
+		r, err := f.ctrl.Command("list-windows")
+		first <- result{r, err}
+	}()
+	if got := f.readCommand(t); got != "list-windows" {
+		t.Fatalf("first command = %q", got)
+	}
+	go func() {
+		r, err := f.ctrl.Command("bogus-command")
+		second <- result{r, err}
+	}()
+	f.readCommand(t)
+
+	f.write(t,
+		"\033P1000p%begin 100 1 0", // initial attach block, not ours
+		"%end 100 1 0",
+		"%begin 101 2 1",
+		"@1 editor",
+		"%output %1 interleaved?", // inside a block this is reply data
+		"@2 server",
+		"%end 101 2 1",
+		"%begin 102 3 1",
+		"unknown command: bogus-command",
+		"%error 102 3 1",
+	)
+
+	r1 := <-first
+	if r1.err != nil {
+		t.Fatalf("first reply error: %v", r1.err)
+	}
+	want := []string{"@1 editor", "%output %1 interleaved?", "@2 server"}
+	if strings.Join(r1.reply.Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("first reply lines = %q, want %q", r1.reply.Lines, want)
+	}
+
+	r2 := <-second
+	if r2.err == nil || !r2.reply.Err {
+		t.Fatalf("second reply should be an error, got %+v", r2)
+	}
+	if !strings.Contains(r2.err.Error(), "unknown command") {
+		t.Errorf("error = %v, want tmux message", r2.err)
+	}
+}
+
+func TestResizeDoesNotWait(t *testing.T) {
+	f := newFakeTmux(t)
+
+	resized := make(chan error, 1)
+	go func() { resized <- f.ctrl.Resize(80, 24) }()
+	if got := f.readCommand(t); got != "refresh-client -C 80,24" {
+		t.Fatalf("command = %q", got)
+	}
+	select {
+	case err := <-resized:
+		if err != nil {
+			t.Fatalf("Resize() error: %v", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Resize() waited for tmux's reply")
+	}
+
+	// The same size again sends nothing
+	if err := f.ctrl.Resize(80, 24); err != nil {
+		t.Fatalf("Resize() error: %v", err)
+	}
+
+	// The unread reply to the resize doesn't go to the next command
+	done := make(chan Reply, 1)
+	go func() {
+		r, _ := f.ctrl.Command("list-windows")
+		done <- r
+	}()
+	if got := f.readCommand(t); got != "list-windows" {
+		t.Fatalf("next command = %q", got)
+	}
+	f.write(t,
+		"%begin 100 1 1",
+		"%end 100 1 1",
+		"%begin 101 2 1",
+		"@1 editor",
+		"%end 101 2 1",
+	)
+	if r := <-done; len(r.Lines) != 1 || r.Lines[0] != "@1 editor" {
+		t.Errorf("next reply lines = %q", r.Lines)
+	}
+}
+
+func TestResizeError(t *testing.T) {
+	f := newFakeTmux(t)
+
+	// resizeErr polls until tmux's answer to the resize is recorded
+	resizeErr := func(want bool) error {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			err := f.ctrl.ResizeErr()
+			if (err != nil) == want {
+				return err
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("ResizeErr() = %v", err)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	go f.ctrl.Resize(80, 24)
+	f.readCommand(t)
+	f.write(t,
+		"%begin 100 1 1",
+		"invalid size",
+		"%error 100 1 1",
+	)
+	if err := resizeErr(true); !strings.Contains(err.Error(), "invalid size") {
+		t.Errorf("ResizeErr() = %v, want tmux message", err)
+	}
+
+	// The size was not taken, so it is sent again
+	go f.ctrl.Resize(80, 24)
+	if got := f.readCommand(t); got != "refresh-client -C 80,24" {
+		t.Fatalf("command = %q", got)
+	}
+	f.write(t,
+		"%begin 101 2 1",
+		"%end 101 2 1",
+	)
+	resizeErr(false)
+}
+
+func TestOutputFollowsPane(t *testing.T) {
+	f := newFakeTmux(t)
+	f.ctrl.stateMu.Lock()
+	f.ctrl.paneID = "%2"
+	f.ctrl.stateMu.Unlock()
+
+	f.write(t,
+		`%output %1 other pane`,
+		`%output %2 hello\015\012`,
+	)
+
+	select {
+	case data := <-f.ctrl.OutputChan():
+		if string(data) != "hello\r\n" {
+			t.Errorf("output = %q, want %q", data, "hello\r\n")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for output")
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	f := newFakeTmux(t)
+	notes := f.ctrl.Notifications()
+
+	f.write(t, "%window-add @4", "%layout-change @4 abcd,80x24,0,0,5 abcd,80x24,0,0,5 *")
+
+	want := []Notification{
+		WindowAddNotification{WindowID: "@4"},
+		LayoutChangeNotification{WindowID: "@4", Layout: "abcd,80x24,0,0,5", VisibleLayout: "abcd,80x24,0,0,5", Flags: "*"},
+	}
+	for i, w := range want {
+		select {
+		case n := <-notes:
+			if n != w {
+				t.Errorf("notification %d = %#v, want %#v", i, n, w)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for notification %d", i)
+		}
+	}
+}
+
+func TestExitEndsConnection(t *testing.T) {
+	f := newFakeTmux(t)
+
+	pending := make(chan error, 1)
+	go func() {
+		_, err := f.ctrl.Command("list-panes")
+		pending <- err
+	}()
+	f.readCommand(t)
+
+	f.write(t, "%exit session killed")
+	f.toClient.Close()
+
+	select {
+	case <-f.ctrl.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed after exit notification")
+	}
+	if got := f.ctrl.ExitReason(); got != "session killed" {
+		t.Errorf("ExitReason = %q, want %q", got, "session killed")
+	}
+	if err := <-pending; !errors.Is(err, ErrExited) {
+		t.Errorf("pending command error = %v, want ErrExited", err)
+	}
+	if _, ok := <-f.ctrl.OutputChan(); ok {
+		t.Error("OutputChan should be closed")
+	}
+	if _, err := f.ctrl.Command("list-panes"); !errors.Is(err, ErrExited) {
+		t.Errorf("command after exit = %v, want ErrExited", err)
+	}
+}
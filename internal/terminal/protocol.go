@@ -0,0 +1,265 @@
+package terminal
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// dcsPrefix is the DCS sequence tmux -CC writes before the first protocol line.
+const dcsPrefix = "\033P1000p"
+
+// Notification is an asynchronous message sent by tmux in control mode.
+// Concrete types are OutputNotification, LayoutChangeNotification,
+// WindowAddNotification, WindowCloseNotification, WindowRenamedNotification,
+// WindowPaneChangedNotification, SessionChangedNotification,
+// SessionWindowChangedNotification, ExitNotification and UnknownNotification.
+type Notification interface {
+	notification()
+}
+
+// OutputNotification carries pane output from %output or %extended-output.
+type OutputNotification struct {
+	PaneID string // e.g. "%3"
+	Data   []byte // decoded bytes
+}
+
+// LayoutChangeNotification reports a window layout change (%layout-change).
+type LayoutChangeNotification struct {
+	WindowID      string // e.g. "@1"
+	Layout        string
+	VisibleLayout string
+	Flags         string
+}
+
+// WindowAddNotification reports a window linked to the session (%window-add).
+type WindowAddNotification struct {
+	WindowID string
+}
+
+// WindowCloseNotification reports a closed window (%window-close).
+type WindowCloseNotification struct {
+	WindowID string
+}
+
+// WindowRenamedNotification reports a renamed window (%window-renamed).
+type WindowRenamedNotification struct {
+	WindowID string
+	Name     string
+}
+
+// WindowPaneChangedNotification reports a new active pane in a window (%window-pane-changed).
+type WindowPaneChangedNotification struct {
+	WindowID string
+	PaneID   string
+}
+
+// SessionChangedNotification reports the client attaching to a session (%session-changed).
+type SessionChangedNotification struct {
+	SessionID string // e.g. "$1"
+	Name      string
+}
+
+// SessionWindowChangedNotification reports a new current window (%session-window-changed).
+type SessionWindowChangedNotification struct {
+	SessionID string
+	WindowID  string
+}
+
+// ExitNotification reports that tmux is about to close the control client (%exit).
+// Reason is empty for a normal detach.
+type ExitNotification struct {
+	Reason string
+}
+
+// UnknownNotification is any other notification, kept verbatim.
+type UnknownNotification struct {
+	Name string // without the leading '%'
+	Args []string
+}
+
+func (OutputNotification) notification()               {}
+func (LayoutChangeNotification) notification()         {}
+func (WindowAddNotification) notification()            {}
+func (WindowCloseNotification) notification()          {}
+func (WindowRenamedNotification) notification()        {}
+func (WindowPaneChangedNotification) notification()    {}
+func (SessionChangedNotification) notification()       {}
+func (SessionWindowChangedNotification) notification() {}
+func (ExitNotification) notification()                 {}
+func (UnknownNotification) notification()              {}
+
+// Reply is the result of a command sent over the control connection.
+type Reply struct {
+	Number int      // command number assigned by tmux
+	Lines  []string // output lines between %begin and %end/%error
+	Err    bool     // true when the block ended with %error
+}
+
+// Output returns the reply lines joined with newlines.
+func (r Reply) Output() string {
+	return strings.Join(r.Lines, "\n")
+}
+
+// lineKind classifies a single control-mode line.
+type lineKind int
+
+const (
+	lineData lineKind = iota // not a protocol line (part of a reply block)
+	lineBegin
+	lineEnd
+	lineError
+	lineNotification
+)
+
+// guardLine is a parsed %begin/%end/%error line.
+type guardLine struct {
+	number int
+	flags  int
+}
+
+// classifyLine splits a raw line into its kind and, for guard lines, the
+// command number and flags.
+func classifyLine(line string) (lineKind, guardLine) {
+	if !strings.HasPrefix(line, "%") {
+		return lineData, guardLine{}
+	}
+
+	name, rest, _ := strings.Cut(line, " ")
+	var kind lineKind
+	switch name {
+	case "%begin":
+		kind = lineBegin
+	case "%end":
+		kind = lineEnd
+	case "%error":
+		kind = lineError
+	default:
+		return lineNotification, guardLine{}
+	}
+
+	// Guard lines: "%begin <time> <number> <flags>"
+	var g guardLine
+	fields := strings.Fields(rest)
+	if len(fields) >= 2 {
+		g.number, _ = strconv.Atoi(fields[1])
+	}
+	if len(fields) >= 3 {
+		g.flags, _ = strconv.Atoi(fields[2])
+	}
+	return kind, g
+}
+
+// trimLine removes the DCS prefix, string terminator and carriage return
+// that a PTY-attached control client may include around a line.
+func trimLine(raw []byte) string {
+	raw = bytes.TrimSuffix(raw, []byte("\n"))
+	raw = bytes.TrimSuffix(raw, []byte("\r"))
+	if i := bytes.Index(raw, []byte(dcsPrefix)); i >= 0 {
+		raw = raw[i+len(dcsPrefix):]
+	}
+	raw = bytes.TrimSuffix(raw, []byte("\033\\"))
+	return string(raw)
+}
+
+// parseNotification parses a notification line into a typed Notification.
+func parseNotification(line string) Notification {
+	name, rest, _ := strings.Cut(line, " ")
+	name = strings.TrimPrefix(name, "%")
+
+	switch name {
+	case "output":
+		paneID, data, _ := strings.Cut(rest, " ")
+		return OutputNotification{PaneID: paneID, Data: decodeOctal(data)}
+
+	case "extended-output":
+		// "%extended-output %<pane> <age> ... : <data>"
+		header, data, ok := strings.Cut(rest, " : ")
+		if !ok {
+			header, data = strings.TrimSuffix(rest, " :"), ""
+		}
+		paneID, _, _ := strings.Cut(header, " ")
+		return OutputNotification{PaneID: paneID, Data: decodeOctal(data)}
+
+	case "layout-change":
+		f := strings.Fields(rest)
+		n := LayoutChangeNotification{}
+		if len(f) > 0 {
+			n.WindowID = f[0]
+		}
+		if len(f) > 1 {
+			n.Layout = f[1]
+		}
+		if len(f) > 2 {
+			n.VisibleLayout = f[2]
+		}
+		if len(f) > 3 {
+			n.Flags = f[3]
+		}
+		return n
+
+	case "window-add":
+		return WindowAddNotification{WindowID: strings.TrimSpace(rest)}
+
+	case "window-close", "unlinked-window-close":
+		return WindowCloseNotification{WindowID: strings.TrimSpace(rest)}
+
+	case "window-renamed":
+		windowID, newName, _ := strings.Cut(rest, " ")
+		return WindowRenamedNotification{WindowID: windowID, Name: newName}
+
+	case "window-pane-changed":
+		windowID, paneID, _ := strings.Cut(rest, " ")
+		return WindowPaneChangedNotification{WindowID: windowID, PaneID: paneID}
+
+	case "session-changed":
+		sessionID, sessionName, _ := strings.Cut(rest, " ")
+		return SessionChangedNotification{SessionID: sessionID, Name: sessionName}
+
+	case "session-window-changed":
+		sessionID, windowID, _ := strings.Cut(rest, " ")
+		return SessionWindowChangedNotification{SessionID: sessionID, WindowID: windowID}
+
+	case "exit":
+		return ExitNotification{Reason: strings.TrimSpace(rest)}
+	}
+
+	return UnknownNotification{Name: name, Args: strings.Fields(rest)}
+}
+
+// decodeOctal converts \NNN octal sequences to bytes.
+func decodeOctal(s string) []byte {
+	var result []byte
+	i := 0
+	for i < len(s) {
+		if s[i] == '\\' {
+			// Check if next 3 chars are octal digits
+			if i+3 < len(s) && isOctalDigit(s[i+1]) && isOctalDigit(s[i+2]) && isOctalDigit(s[i+3]) {
+				val, _ := strconv.ParseInt(s[i+1:i+4], 8, 32)
+				result = append(result, byte(val))
+				i += 4
+				continue
+			}
+			// Handle \\ (escaped backslash)
+			if i+1 < len(s) && s[i+1] == '\\' {
+				result = append(result, '\\')
+				i += 2
+				continue
+			}
+		}
+		result = append(result, s[i])
+		i++
+	}
+	return result
+}
+
+func isOctalDigit(b byte) bool {
+	return b >= '0' && b <= '7'
+}
+
+// quoteArg quotes a string for the tmux command parser.
+// Single quotes disable all expansion; embedded single quotes are
+// closed, emitted inside double quotes and reopened.
+func quoteArg(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
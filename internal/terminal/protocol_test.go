@@ -0,0 +1,114 @@
+package terminal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		kind   lineKind
+		number int
+		flags  int
+	}{
+		{"%begin 1700000000 12 1", lineBegin, 12, 1},
+		{"%end 1700000000 12 1", lineEnd, 12, 1},
+		{"%error 1700000000 7 0", lineError, 7, 0},
+		{"%output %1 hello", lineNotification, 0, 0},
+		{"plain reply line", lineData, 0, 0},
+		{"", lineData, 0, 0},
+	}
+
+	for _, tt := range tests {
+		kind, guard := classifyLine(tt.line)
+		if kind != tt.kind {
+			t.Errorf("classifyLine(%q) kind = %d, want %d", tt.line, kind, tt.kind)
+		}
+		if guard.number != tt.number || guard.flags != tt.flags {
+			t.Errorf("classifyLine(%q) guard = %+v, want number=%d flags=%d", tt.line, guard, tt.number, tt.flags)
+		}
+	}
+}
+
+func TestTrimLine(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{"\033P1000p%begin 1 2 0\r\n", "%begin 1 2 0"},
+		{"%exit\033\\\r\n", "%exit"},
+		{"%output %1 x\n", "%output %1 x"},
+	}
+
+	for _, tt := range tests {
+		if got := trimLine([]byte(tt.raw)); got != tt.want {
+			t.Errorf("trimLine(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseNotification(t *testing.T) {
+	tests := []struct {
+		line string
+		want Notification
+	}{
+		{`%output %3 hi\015\012`, OutputNotification{PaneID: "%3", Data: []byte("hi\r\n")}},
+		{`%extended-output %4 120 : data\\x`, OutputNotification{PaneID: "%4", Data: []byte(`data\x`)}},
+		{"%layout-change @1 b25d,80x24,0,0,2 b25d,80x24,0,0,2 *", LayoutChangeNotification{
+			WindowID: "@1", Layout: "b25d,80x24,0,0,2", VisibleLayout: "b25d,80x24,0,0,2", Flags: "*",
+		}},
+		{"%window-add @5", WindowAddNotification{WindowID: "@5"}},
+		{"%window-close @5", WindowCloseNotification{WindowID: "@5"}},
+		{"%unlinked-window-close @6", WindowCloseNotification{WindowID: "@6"}},
+		{"%window-renamed @2 dev server", WindowRenamedNotification{WindowID: "@2", Name: "dev server"}},
+		{"%window-pane-changed @1 %7", WindowPaneChangedNotification{WindowID: "@1", PaneID: "%7"}},
+		{"%session-changed $1 cmux/main", SessionChangedNotification{SessionID: "$1", Name: "cmux/main"}},
+		{"%session-window-changed $1 @3", SessionWindowChangedNotification{SessionID: "$1", WindowID: "@3"}},
+		{"%exit", ExitNotification{}},
+		{"%exit server exited", ExitNotification{Reason: "server exited"}},
+		{"%pause %1", UnknownNotification{Name: "pause", Args: []string{"%1"}}},
+	}
+
+	for _, tt := range tests {
+		got := parseNotification(tt.line)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNotification(%q) = %#v, want %#v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeOctal(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`plain`, "plain"},
+		{`\033[1m`, "\033[1m"},
+		{`a\\b`, `a\b`},
+		{`\015\012`, "\r\n"},
+	}
+
+	for _, tt := range tests {
+		if got := string(decodeOctal(tt.input)); got != tt.want {
+			t.Errorf("decodeOctal(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteArg(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"abc", "'abc'"},
+		{"it's", `'it'"'"'s'`},
+		{`$HOME "x"`, `'$HOME "x"'`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteArg(tt.input); got != tt.want {
+			t.Errorf("quoteArg(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package tmux
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ClientInfo describes a terminal attached to the tmux server.
+type ClientInfo struct {
+	TTY     string // the client's terminal, e.g. /dev/pts/3
+	Session string // session the client shows
+	Focused bool   // the terminal has focus; tmux 3.3+ with focus-events on
+}
+
+// ListClients returns the terminals attached to the tmux server.
+func (c *RealClient) ListClients() ([]ClientInfo, error) {
+	cmd := exec.Command("tmux", "list-clients", "-F", "#{client_tty}\t#{client_session}\t#{client_flags}")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tmux list-clients: %w: %s", err, stderr.String())
+	}
+	return parseClients(stdout.String()), nil
+}
+
+// parseClients parses "tty session flags" lines from list-clients.
+func parseClients(output string) []ClientInfo {
+	var clients []ClientInfo
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) < 2 || parts[0] == "" {
+			continue
+		}
+		client := ClientInfo{TTY: parts[0], Session: parts[1]}
+		if len(parts) > 2 {
+			for _, flag := range strings.Split(parts[2], ",") {
+				if flag == "focused" {
+					client.Focused = true
+				}
+			}
+		}
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// FocusedSessions returns the sessions in front of the user: those shown by
+// a client whose terminal has focus or, when no client reports focus, by
+// any client.
+func FocusedSessions(clients []ClientInfo) map[string]bool {
+	tracked := false
+	for _, client := range clients {
+		tracked = tracked || client.Focused
+	}
+
+	sessions := make(map[string]bool)
+	for _, client := range clients {
+		if client.Focused || !tracked {
+			sessions[client.Session] = true
+		}
+	}
+	return sessions
+}
+
+// DisplayMessage shows text in a client's status line.
+func (c *RealClient) DisplayMessage(client, text string) error {
+	// The message is a format; escape it so '#' is shown as typed
+	cmd := exec.Command("tmux", "display-message", "-c", client, strings.ReplaceAll(text, "#", "##"))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tmux display-message: %w: %s", err, stderr.String())
+	}
+	return nil
+}
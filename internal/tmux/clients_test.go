@@ -0,0 +1,46 @@
+package tmux
+
+import "testing"
+
+func TestParseClients(t *testing.T) {
+	output := "/dev/pts/1\tdev\tattached,focused,UTF-8\n" +
+		"/dev/pts/2\tapi\tattached,UTF-8\n" +
+		"/dev/pts/3\tcmux\n"
+
+	clients := parseClients(output)
+	if len(clients) != 3 {
+		t.Fatalf("parseClients() returned %d clients, want 3", len(clients))
+	}
+	if clients[0].TTY != "/dev/pts/1" || clients[0].Session != "dev" || !clients[0].Focused {
+		t.Errorf("clients[0] = %+v", clients[0])
+	}
+	if clients[1].Focused || clients[2].Focused {
+		t.Errorf("unfocused clients reported focus: %+v", clients[1:])
+	}
+}
+
+func TestFocusedSessions(t *testing.T) {
+	tests := []struct {
+		name    string
+		clients []ClientInfo
+		want    []string
+	}{
+		{"none", nil, nil},
+		{"focus tracked", []ClientInfo{{Session: "dev", Focused: true}, {Session: "api"}}, []string{"dev"}},
+		{"focus untracked", []ClientInfo{{Session: "dev"}, {Session: "api"}}, []string{"dev", "api"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FocusedSessions(tt.clients)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FocusedSessions() = %v, want %v", got, tt.want)
+			}
+			for _, s := range tt.want {
+				if !got[s] {
+					t.Errorf("FocusedSessions() = %v, missing %s", got, s)
+				}
+			}
+		})
+	}
+}
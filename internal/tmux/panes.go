@@ -0,0 +1,201 @@
+package tmux
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/process"
+)
+
+// PaneRole describes what a pane is being used for.
+type PaneRole string
+
+const (
+	RoleClaude    PaneRole = "claude"     // Claude Code is running in the pane
+	RoleDevServer PaneRole = "dev_server" // a long-running dev server (npm run dev, vite, ...)
+	RoleShell     PaneRole = "shell"      // an idle shell prompt
+	RoleOther     PaneRole = "other"      // anything else (editor, tests, ...)
+)
+
+// Pane represents a single pane within a tmux session.
+type Pane struct {
+	Session      string
+	WindowIndex  int
+	WindowName   string
+	WindowActive bool // window is the session's current window
+	Index        int  // pane index within the window
+	ID           string
+	PID          int    // PID of the pane's initial process (usually a shell)
+	Command      string // pane_current_command, the foreground process name
+	Path         string // pane_current_path
+	Active       bool   // pane is the window's active pane
+	Role         PaneRole
+	ClaudePID    int // Claude process in the pane's process tree, 0 if none
+}
+
+// Target returns the tmux target for this pane in session:window.pane form.
+func (p Pane) Target() string {
+	return fmt.Sprintf("%s:%d.%d", p.Session, p.WindowIndex, p.Index)
+}
+
+// paneFormat is the list-panes format parsed by parsePanes.
+const paneFormat = "#{session_name}\t#{window_index}\t#{window_name}\t#{window_active}\t" +
+	"#{pane_index}\t#{pane_id}\t#{pane_pid}\t#{pane_active}\t#{pane_current_command}\t#{pane_current_path}"
+
+// ListPanes returns every pane in every window of a session, with roles classified.
+func (c *RealClient) ListPanes(name string) ([]Pane, error) {
+	cmd := exec.Command("tmux", "list-panes", "-s", "-t", name, "-F", paneFormat)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("tmux list-panes: %w: %s", err, stderr.String())
+	}
+
+	panes := parsePanes(stdout.String())
+
+	// One process table snapshot for all panes; without one, panes are
+	// classified by their foreground command alone
+	table, _ := process.Snapshot()
+	c.classifyPanes(panes, table)
+
+	return panes, nil
+}
+
+// classifyPanes sets the role and Claude process of each pane from its
+// process tree.
+func (c *RealClient) classifyPanes(panes []Pane, table *process.Table) {
+	for i := range panes {
+		var tree []process.Process
+		if table != nil {
+			tree = table.Descendants(panes[i].PID)
+			if claude, ok := c.claude.Find(table, panes[i].PID); ok {
+				panes[i].ClaudePID = claude.PID
+			}
+		}
+		panes[i].Role = classifyPane(panes[i], tree, panes[i].ClaudePID != 0)
+	}
+}
+
+// FindClaudePane returns the pane running Claude in a session.
+// If several panes run Claude, the active one is preferred.
+func (c *RealClient) FindClaudePane(name string) (Pane, error) {
+	panes, err := c.ListPanes(name)
+	if err != nil {
+		return Pane{}, err
+	}
+
+	if p, ok := pickClaudePane(panes); ok {
+		return p, nil
+	}
+	return Pane{}, fmt.Errorf("no claude pane in session %s", name)
+}
+
+// pickClaudePane chooses the Claude pane, preferring the active pane of the
+// active window.
+func pickClaudePane(panes []Pane) (Pane, bool) {
+	var found []Pane
+	for _, p := range panes {
+		if p.Role == RoleClaude {
+			found = append(found, p)
+		}
+	}
+	if len(found) == 0 {
+		return Pane{}, false
+	}
+	for _, p := range found {
+		if p.WindowActive && p.Active {
+			return p, true
+		}
+	}
+	return found[0], true
+}
+
+// parsePanes parses tmux list-panes output in paneFormat.
+func parsePanes(output string) []Pane {
+	var panes []Pane
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, "\t")
+		if len(parts) < 10 {
+			continue
+		}
+
+		windowIndex, err := strconv.Atoi(parts[1])
+		if err != nil {
+			continue
+		}
+		paneIndex, err := strconv.Atoi(parts[4])
+		if err != nil {
+			continue
+		}
+		pid, _ := strconv.Atoi(parts[6])
+
+		panes = append(panes, Pane{
+			Session:      parts[0],
+			WindowIndex:  windowIndex,
+			WindowName:   parts[2],
+			WindowActive: parts[3] == "1",
+			Index:        paneIndex,
+			ID:           parts[5],
+			PID:          pid,
+			Active:       parts[7] == "1",
+			Command:      parts[8],
+			Path:         parts[9],
+		})
+	}
+
+	return panes
+}
+
+// devServerPatterns are command-line fragments that identify dev servers.
+var devServerPatterns = []string{
+	"npm run dev", "npm start", "npm run start", "yarn dev", "yarn start",
+	"pnpm dev", "pnpm run dev", "bun dev", "bun run dev",
+	"vite", "next dev", "nuxt dev", "webpack serve", "webpack-dev-server",
+	"nodemon", "rails server", "rails s", "manage.py runserver",
+	"uvicorn", "flask run", "air", "hugo server",
+}
+
+// shells are process names treated as an idle prompt.
+var shells = map[string]bool{
+	"bash": true, "zsh": true, "fish": true, "sh": true, "dash": true, "ksh": true, "nu": true,
+}
+
+// classifyPane decides a pane's role from its foreground command, its
+// process tree (the pane process first) and whether Claude runs in it.
+func classifyPane(p Pane, tree []process.Process, claude bool) PaneRole {
+	if claude || strings.EqualFold(p.Command, "claude") {
+		return RoleClaude
+	}
+
+	for _, proc := range tree {
+		if isDevServerCommand(proc.Args) {
+			return RoleDevServer
+		}
+	}
+
+	// Only the pane's own process running means it's sitting at a prompt
+	if shells[strings.TrimPrefix(p.Command, "-")] && len(tree) <= 1 {
+		return RoleShell
+	}
+	return RoleOther
+}
+
+// isDevServerCommand reports whether a command line looks like a dev server.
+func isDevServerCommand(args string) bool {
+	normalized := " " + strings.Join(strings.Fields(args), " ") + " "
+	for _, pattern := range devServerPatterns {
+		if strings.Contains(normalized, " "+pattern+" ") || strings.Contains(normalized, "/"+pattern+" ") {
+			return true
+		}
+	}
+	return false
+}
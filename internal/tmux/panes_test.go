@@ -0,0 +1,143 @@
+package tmux
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/process"
+)
+
+func TestParsePanes(t *testing.T) {
+	output := "cmux/main\t0\tclaude\t1\t0\t%0\t100\t1\tnode\t/home/user/cmux\n" +
+		"cmux/main\t0\tclaude\t1\t1\t%3\t200\t0\tnpm\t/home/user/cmux\n" +
+		"cmux/main\t1\tzsh\t0\t0\t%4\t300\t1\tzsh\t/home/user\n"
+
+	panes := parsePanes(output)
+	if len(panes) != 3 {
+		t.Fatalf("expected 3 panes, got %d", len(panes))
+	}
+
+	want := Pane{
+		Session:      "cmux/main",
+		WindowIndex:  0,
+		WindowName:   "claude",
+		WindowActive: true,
+		Index:        1,
+		ID:           "%3",
+		PID:          200,
+		Command:      "npm",
+		Path:         "/home/user/cmux",
+		Active:       false,
+	}
+	if !reflect.DeepEqual(panes[1], want) {
+		t.Errorf("panes[1] = %+v, want %+v", panes[1], want)
+	}
+	if panes[2].WindowActive || !panes[2].Active {
+		t.Errorf("panes[2] active flags = window %v pane %v", panes[2].WindowActive, panes[2].Active)
+	}
+}
+
+func TestParsePanesSkipsBadLines(t *testing.T) {
+	output := "too\tfew\tfields\n" +
+		"s\tx\tw\t1\t0\t%1\t1\t1\tzsh\t/tmp\n"
+	if panes := parsePanes(output); len(panes) != 0 {
+		t.Errorf("expected 0 panes, got %d", len(panes))
+	}
+}
+
+func TestPaneTarget(t *testing.T) {
+	p := Pane{Session: "repo/feature", WindowIndex: 2, Index: 1}
+	if got := p.Target(); got != "repo/feature:2.1" {
+		t.Errorf("Target() = %q, want %q", got, "repo/feature:2.1")
+	}
+}
+
+// chainTable is a process table where each command line is run by the one
+// before it, starting with PID 100.
+func chainTable(cmdLines []string) *process.Table {
+	var procs []process.Process
+	for i, args := range cmdLines {
+		procs = append(procs, process.Process{PID: 100 + i, PPID: 99 + i, Name: filepath.Base(strings.Fields(args)[0]), Args: args})
+	}
+	return process.NewTable(procs, time.Now())
+}
+
+func TestClassifyPane(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		cmdLines []string
+		want     PaneRole
+	}{
+		{"claude direct", "claude", []string{"claude"}, RoleClaude},
+		{"claude under shell", "node", []string{"-zsh", "claude --resume abc"}, RoleClaude},
+		{"claude via npx", "node", []string{"bash", "npm exec @anthropic-ai/claude-code", "node /usr/lib/node_modules/@anthropic-ai/claude-code/cli.js"}, RoleClaude},
+		{"claude via node path", "node", []string{"zsh", "node /home/u/.local/bin/claude"}, RoleClaude},
+		{"claude via wrapper", "bash", []string{"zsh", "bash /home/u/bin/ai", "sh -c exec claude", "claude"}, RoleClaude},
+		{"dev server", "npm", []string{"zsh", "npm run dev", "node /repo/node_modules/.bin/vite"}, RoleDevServer},
+		{"vite binary", "node", []string{"zsh", "node /repo/node_modules/.bin/vite --port 3000"}, RoleDevServer},
+		{"idle shell", "zsh", []string{"-zsh"}, RoleShell},
+		{"editor", "nvim", []string{"zsh", "nvim main.go"}, RoleOther},
+		{"claude in args only", "grep", []string{"zsh", "grep -r claude ."}, RoleOther},
+	}
+
+	c := NewClient("claude")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panes := []Pane{{Command: tt.command, PID: 100}}
+			c.classifyPanes(panes, chainTable(tt.cmdLines))
+			if panes[0].Role != tt.want {
+				t.Errorf("classifyPanes() role = %q, want %q", panes[0].Role, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyPanesClaudePID(t *testing.T) {
+	table := chainTable([]string{"-zsh", "npx @anthropic-ai/claude-code", "node /home/u/.npm/_npx/1/node_modules/.bin/claude", "bash -c make"})
+
+	panes := []Pane{{Command: "node", PID: 100}, {Command: "zsh", PID: 500}}
+	NewClient("claude").classifyPanes(panes, table)
+	if panes[0].ClaudePID != 102 {
+		t.Errorf("ClaudePID = %d, want 102", panes[0].ClaudePID)
+	}
+	if panes[1].ClaudePID != 0 || panes[1].Role != RoleShell {
+		t.Errorf("pane without processes = %+v", panes[1])
+	}
+
+	// Custom patterns replace the defaults
+	c := NewClient("claude")
+	c.SetClaudePatterns([]string{"npx"})
+	panes = []Pane{{Command: "node", PID: 100}}
+	c.classifyPanes(panes, table)
+	if panes[0].ClaudePID != 101 {
+		t.Errorf("ClaudePID with custom patterns = %d, want 101", panes[0].ClaudePID)
+	}
+
+	// Without a process table only the foreground command counts
+	panes = []Pane{{Command: "claude", PID: 100}, {Command: "node", PID: 100}}
+	c.classifyPanes(panes, nil)
+	if panes[0].Role != RoleClaude || panes[1].Role != RoleOther {
+		t.Errorf("roles without a process table = %q, %q", panes[0].Role, panes[1].Role)
+	}
+}
+
+func TestPickClaudePane(t *testing.T) {
+	panes := []Pane{
+		{Index: 0, Role: RoleShell, WindowActive: true, Active: true},
+		{Index: 1, Role: RoleClaude, WindowActive: false, Active: true},
+		{Index: 2, Role: RoleClaude, WindowActive: true, Active: true},
+	}
+
+	p, ok := pickClaudePane(panes)
+	if !ok || p.Index != 2 {
+		t.Errorf("pickClaudePane() = %+v, %v; want pane 2", p, ok)
+	}
+
+	if _, ok := pickClaudePane(panes[:1]); ok {
+		t.Error("pickClaudePane() should not find a claude pane")
+	}
+}
@@ -0,0 +1,91 @@
+package tmux
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/abdullathedruid/cmux/internal/process"
+)
+
+// Scan is one look at every tmux session, its panes and the processes
+// running in them.
+type Scan struct {
+	Sessions  []Session
+	Panes     map[string][]Pane // by session name, with roles classified
+	Processes *process.Table    // nil when the process table can't be read
+}
+
+// Scan lists every session and pane with two tmux commands and classifies
+// the panes against a single process table snapshot.
+func (c *RealClient) Scan() (Scan, error) {
+	sessions, err := c.ListSessions()
+	if err != nil {
+		return Scan{}, err
+	}
+	scan := Scan{Sessions: sessions, Panes: make(map[string][]Pane)}
+	if len(sessions) == 0 {
+		return scan, nil
+	}
+
+	cmd := exec.Command("tmux", "list-panes", "-a", "-F", paneFormat)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return Scan{}, fmt.Errorf("tmux list-panes: %w: %s", err, stderr.String())
+	}
+
+	panes := parsePanes(stdout.String())
+	scan.Processes, _ = process.Snapshot()
+	c.classifyPanes(panes, scan.Processes)
+	for _, p := range panes {
+		scan.Panes[p.Session] = append(scan.Panes[p.Session], p)
+	}
+	return scan, nil
+}
+
+// ClaudeSessions returns the sessions that have a hook event file in
+// $TMPDIR/cmux/events or a pane running Claude.
+func (s Scan) ClaudeSessions() []Session {
+	tmpdir := os.Getenv("TMPDIR")
+	if tmpdir == "" {
+		tmpdir = "/tmp"
+	}
+	eventsDir := filepath.Join(tmpdir, "cmux", "events")
+
+	var claudeSessions []Session
+	for _, session := range s.Sessions {
+		// Check if event file exists for this session (fast path)
+		eventFile := filepath.Join(eventsDir, session.Name+".jsonl")
+		if _, err := os.Stat(eventFile); err == nil {
+			claudeSessions = append(claudeSessions, session)
+			continue
+		}
+
+		if _, ok := s.ClaudePane(session.Name); ok {
+			claudeSessions = append(claudeSessions, session)
+		}
+	}
+	return claudeSessions
+}
+
+// ClaudePane returns the pane running Claude in a session, preferring the
+// active pane of the active window.
+func (s Scan) ClaudePane(session string) (Pane, bool) {
+	return pickClaudePane(s.Panes[session])
+}
+
+// ActivePane returns the active pane of a session's current window, the
+// one tmux targets by the session's name.
+func (s Scan) ActivePane(session string) (Pane, bool) {
+	for _, p := range s.Panes[session] {
+		if p.WindowActive && p.Active {
+			return p, true
+		}
+	}
+	return Pane{}, false
+}
@@ -0,0 +1,44 @@
+package tmux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanClaudeSessions(t *testing.T) {
+	tmpdir := t.TempDir()
+	t.Setenv("TMPDIR", tmpdir)
+	eventsDir := filepath.Join(tmpdir, "cmux", "events")
+	os.MkdirAll(eventsDir, 0755)
+	os.WriteFile(filepath.Join(eventsDir, "hooked.jsonl"), nil, 0644)
+
+	scan := Scan{
+		Sessions: []Session{{Name: "hooked"}, {Name: "running"}, {Name: "shell"}},
+		Panes: map[string][]Pane{
+			"running": {
+				{Session: "running", Index: 0, Role: RoleShell, WindowActive: true, Active: true, Path: "/repo"},
+				{Session: "running", Index: 1, Role: RoleClaude, ClaudePID: 42, WindowActive: true},
+			},
+			"shell": {{Session: "shell", Role: RoleShell, WindowActive: true, Active: true}},
+		},
+	}
+
+	var names []string
+	for _, s := range scan.ClaudeSessions() {
+		names = append(names, s.Name)
+	}
+	if len(names) != 2 || names[0] != "hooked" || names[1] != "running" {
+		t.Errorf("ClaudeSessions() = %v, want [hooked running]", names)
+	}
+
+	if p, ok := scan.ClaudePane("running"); !ok || p.ClaudePID != 42 {
+		t.Errorf("ClaudePane() = %+v, %v", p, ok)
+	}
+	if p, ok := scan.ActivePane("running"); !ok || p.Path != "/repo" {
+		t.Errorf("ActivePane() = %+v, %v", p, ok)
+	}
+	if _, ok := scan.ActivePane("missing"); ok {
+		t.Error("ActivePane() found a pane of a missing session")
+	}
+}
@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
+	"sort"
 	"strings"
 	"time"
+
+	"github.com/abdullathedruid/cmux/internal/process"
 )
 
 // Session represents a tmux session.
@@ -28,6 +30,8 @@ type Client interface {
 	DiscoverClaudeSessions() ([]Session, error)
 	// CreateSession creates a new tmux session with the given name in the specified directory.
 	CreateSession(name, dir string, runClaude bool) error
+	// CreateSessionWithOptions creates a new tmux session with the given command and environment.
+	CreateSessionWithOptions(name, dir string, opts SessionOptions) error
 	// AttachSession attaches to the specified session.
 	AttachSession(name string) error
 	// SwitchSession switches the current client to the specified session.
@@ -42,30 +46,67 @@ type Client interface {
 	CapturePane(name string, lines int) (string, error)
 	// SendKeys sends keys to a session.
 	SendKeys(name string, keys string) error
+	// SubmitText pastes text into a pane and presses Enter.
+	SubmitText(target, text string) error
 	// SupportsPopup returns true if tmux version supports display-popup (3.2+).
 	SupportsPopup() bool
 	// DisplayPopup opens a session in a tmux popup window.
 	DisplayPopup(name string) error
 	// DisplayDiffPopup opens a git diff in a tmux popup window.
 	DisplayDiffPopup(workdir string) error
+	// DisplayEditorPopup edits a file with the user's editor in a tmux popup window.
+	DisplayEditorPopup(file string) error
 	// GetCurrentSession returns the name of the current tmux session, or empty if not in tmux.
 	GetCurrentSession() string
 	// GetPanePID returns the PID of the shell process running in the pane.
+	// name may be a session or a session:window.pane target.
 	GetPanePID(name string) (int, error)
 	// GetSessionWorkingDir returns the current working directory of a session's active pane.
 	GetSessionWorkingDir(name string) (string, error)
+	// ListPanes returns all panes across all windows of a session.
+	ListPanes(name string) ([]Pane, error)
+	// FindClaudePane returns the pane of a session that is running Claude.
+	FindClaudePane(name string) (Pane, error)
+	// Scan lists every session and pane, and the processes running in them.
+	Scan() (Scan, error)
+	// ListClients returns the clients attached to the tmux server.
+	ListClients() ([]ClientInfo, error)
+	// DisplayMessage shows text in a client's status line.
+	DisplayMessage(client, text string) error
 }
 
 // RealClient implements Client using actual tmux commands.
 type RealClient struct {
-	claudeCommand string
+	claudeCommand  string
+	sessionOptions func(dir string) SessionOptions
+	claude         *process.Matcher // recognises Claude in pane process trees
+}
+
+// SessionOptions describes what a new session runs.
+type SessionOptions struct {
+	// Command is the shell command run in the session; empty starts the
+	// default shell
+	Command string
+
+	// Env is set in the session's environment
+	Env map[string]string
 }
 
 // NewClient creates a new tmux client.
 func NewClient(claudeCommand string) *RealClient {
 	return &RealClient{
 		claudeCommand: claudeCommand,
+		claude:        process.NewMatcher(process.DefaultClaudePatterns),
+	}
+}
+
+// SetClaudePatterns sets the executable patterns recognising Claude in a
+// pane's process tree. No patterns keeps the defaults.
+func (c *RealClient) SetClaudePatterns(patterns []string) {
+	if len(patterns) == 0 {
+		patterns = process.DefaultClaudePatterns
 	}
+	c.claude = process.NewMatcher(patterns)
 }
 
 // ListSessions returns all tmux sessions.
@@ -91,110 +132,13 @@ func (c *RealClient) ListSessions() ([]Session, error) {
 // DiscoverClaudeSessions returns tmux sessions that have Claude Code running.
 // Detection strategy:
 // 1. Check if session has event file in $TMPDIR/cmux/events/{session}.jsonl
-// 2. Fallback: Check if session is running a process containing "claude"
+// 2. Fallback: Check if any pane's process tree runs Claude
 func (c *RealClient) DiscoverClaudeSessions() ([]Session, error) {
-	allSessions, err := c.ListSessions()
-	if err != nil {
-		return nil, err
-	}
-
-	// Get events directory
-	tmpdir := os.Getenv("TMPDIR")
-	if tmpdir == "" {
-		tmpdir = "/tmp"
-	}
-	eventsDir := filepath.Join(tmpdir, "cmux", "events")
-
-	var claudeSessions []Session
-	for _, session := range allSessions {
-		// Check if event file exists for this session (fast path)
-		eventFile := filepath.Join(eventsDir, session.Name+".jsonl")
-		if _, err := os.Stat(eventFile); err == nil {
-			claudeSessions = append(claudeSessions, session)
-			continue
-		}
-
-		// Fallback: check if session is running claude
-		if c.isRunningClaude(session.Name) {
-			claudeSessions = append(claudeSessions, session)
-		}
-	}
-
-	return claudeSessions, nil
-}
-
-// isRunningClaude checks if a tmux session is running a claude process.
-func (c *RealClient) isRunningClaude(sessionName string) bool {
-	pid, err := c.GetPanePID(sessionName)
-	if err != nil {
-		return false
-	}
-
-	// First check the pane's own command (claude might be the direct process)
-	paneCmd, err := getProcessCommand(pid)
-	if err == nil && strings.Contains(strings.ToLower(paneCmd), "claude") {
-		return true
-	}
-
-	// Then check child processes (claude running under a shell)
-	children, err := getChildProcesses(pid)
+	scan, err := c.Scan()
 	if err != nil {
-		return false
-	}
-
-	for _, child := range children {
-		if strings.Contains(strings.ToLower(child), "claude") {
-			return true
-		}
-	}
-
-	return false
-}
-
-// getProcessCommand returns the command line for a PID.
-func getProcessCommand(pid int) (string, error) {
-	cmd := exec.Command("ps", "-p", fmt.Sprintf("%d", pid), "-o", "args=")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
-		return "", err
-	}
-
-	return strings.TrimSpace(stdout.String()), nil
-}
-
-// getChildProcesses returns command lines of child processes for a PID.
-func getChildProcesses(pid int) ([]string, error) {
-	cmd := exec.Command("ps", "-eo", "pid=,ppid=,args=")
-	var stdout bytes.Buffer
-	cmd.Stdout = &stdout
-
-	if err := cmd.Run(); err != nil {
 		return nil, err
 	}
-
-	parentPID := fmt.Sprintf("%d", pid)
-	var children []string
-
-	lines := strings.Split(stdout.String(), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue
-		}
-
-		if fields[1] == parentPID {
-			children = append(children, strings.Join(fields[2:], " "))
-		}
-	}
-
-	return children, nil
+	return scan.ClaudeSessions(), nil
 }
 
 // parseSessions parses tmux list-sessions output.
@@ -243,11 +187,38 @@ func parseUnixTimestamp(s string) (time.Time, error) {
 	return time.Unix(ts, 0), nil
 }
 
+// SetSessionOptions sets how CreateSession starts Claude in a directory,
+// replacing the client's plain Claude command.
+func (c *RealClient) SetSessionOptions(fn func(dir string) SessionOptions) {
+	c.sessionOptions = fn
+}
+
 // CreateSession creates a new tmux session.
 func (c *RealClient) CreateSession(name, dir string, runClaude bool) error {
+	var opts SessionOptions
+	switch {
+	case runClaude && c.sessionOptions != nil:
+		opts = c.sessionOptions(dir)
+	case runClaude:
+		opts.Command = c.claudeCommand
+	}
+	return c.CreateSessionWithOptions(name, dir, opts)
+}
+
+// CreateSessionWithOptions creates a new tmux session with the given
+// command and environment.
+func (c *RealClient) CreateSessionWithOptions(name, dir string, opts SessionOptions) error {
 	args := []string{"new-session", "-d", "-s", name, "-c", dir}
-	if runClaude {
-		args = append(args, c.claudeCommand)
+	keys := make([]string, 0, len(opts.Env))
+	for key := range opts.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "-e", key+"="+opts.Env[key])
+	}
+	if opts.Command != "" {
+		args = append(args, opts.Command)
 	}
 
 	cmd := exec.Command("tmux", args...)
@@ -349,6 +320,34 @@ func (c *RealClient) SendKeys(name string, keys string) error {
 	return nil
 }
 
+// SubmitText pastes text into a pane and presses Enter. The text is sent as
+// a bracketed paste so its newlines don't submit it early.
+func (c *RealClient) SubmitText(target, text string) error {
+	buffer := "cmux-" + target
+	load := exec.Command("tmux", "load-buffer", "-b", buffer, "-")
+	load.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	load.Stderr = &stderr
+	if err := load.Run(); err != nil {
+		return fmt.Errorf("tmux load-buffer: %w: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	paste := exec.Command("tmux", "paste-buffer", "-p", "-d", "-b", buffer, "-t", target)
+	paste.Stderr = &stderr
+	if err := paste.Run(); err != nil {
+		return fmt.Errorf("tmux paste-buffer: %w: %s", err, stderr.String())
+	}
+
+	stderr.Reset()
+	enter := exec.Command("tmux", "send-keys", "-t", target, "Enter")
+	enter.Stderr = &stderr
+	if err := enter.Run(); err != nil {
+		return fmt.Errorf("tmux send-keys: %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
 // SupportsPopup returns true if tmux version supports display-popup (3.2+).
 func (c *RealClient) SupportsPopup() bool {
 	cmd := exec.Command("tmux", "-V")
@@ -430,7 +429,33 @@ func (c *RealClient) DisplayDiffPopup(workdir string) error {
 	return nil
 }
 
+// DisplayEditorPopup edits a file in a tmux popup window with $VISUAL or
+// $EDITOR, else vi, and returns once the editor exits.
+func (c *RealClient) DisplayEditorPopup(file string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	cmd := exec.Command("tmux", "display-popup",
+		"-E",        // Close popup when command exits
+		"-w", "90%", // Width
+		"-h", "90%", // Height
+		editor+" '"+strings.ReplaceAll(file, "'", `'\''`)+"'",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("tmux display-popup (editor): %w: %s", err, stderr.String())
+	}
+	return nil
+}
+
 // GetPanePID returns the PID of the shell process running in the pane.
+// name may be a session (its active pane) or a session:window.pane target.
 func (c *RealClient) GetPanePID(name string) (int, error) {
 	cmd := exec.Command("tmux", "display-message", "-t", name, "-p", "#{pane_pid}")
 	var stdout, stderr bytes.Buffer
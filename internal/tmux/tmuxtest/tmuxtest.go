@@ -0,0 +1,423 @@
+// Package tmuxtest provides an in-memory tmux server for tests, so flows
+// that create, drive and kill sessions run without tmux.
+package tmuxtest
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// ReadyScreen is what a new Claude session's pane shows: Claude's prompt,
+// waiting for input.
+const ReadyScreen = "╭──────╮\n│ >    │\n╰──────╯\n  ? for shortcuts"
+
+// Server is an in-memory tmux server implementing tmux.Client. Each
+// session has one window with one pane, which runs Claude if the session
+// was created to.
+type Server struct {
+	mu       sync.Mutex
+	sessions []*session
+	nextPane int
+	current  string // session of the client cmux runs in; "" outside tmux
+	clients  []tmux.ClientInfo
+	messages []Message
+	popups   []string
+}
+
+type session struct {
+	name     string
+	dir      string // where it was created
+	cwd      string // where its pane is now
+	opts     tmux.SessionOptions
+	created  time.Time
+	attached bool
+	paneID   string
+	claude   bool
+	screen   string
+	input    []string
+}
+
+// Message is a message shown in a client's status line.
+type Message struct {
+	Client string
+	Text   string
+}
+
+// NewServer returns a tmux server with no sessions.
+func NewServer() *Server {
+	return &Server{}
+}
+
+// find returns a session by a target: its name, a session:window.pane
+// target or a pane ID. The caller holds s.mu.
+func (s *Server) find(target string) *session {
+	name, _, _ := strings.Cut(target, ":")
+	for _, sess := range s.sessions {
+		if sess.name == name || sess.paneID == target {
+			return sess
+		}
+	}
+	return nil
+}
+
+// get returns a session by a target, or an error like tmux's.
+func (s *Server) get(target string) (*session, error) {
+	if sess := s.find(target); sess != nil {
+		return sess, nil
+	}
+	return nil, fmt.Errorf("can't find session: %s", target)
+}
+
+// SetCurrentSession makes cmux run inside a session, or outside tmux if
+// name is empty.
+func (s *Server) SetCurrentSession(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.current = name
+}
+
+// SetClients sets the terminals attached to the server.
+func (s *Server) SetClients(clients ...tmux.ClientInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients = clients
+}
+
+// SetScreen sets what a session's pane shows.
+func (s *Server) SetScreen(target, screen string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(target)
+	if err != nil {
+		return err
+	}
+	sess.screen = screen
+	return nil
+}
+
+// Chdir changes the directory a session's pane is in.
+func (s *Server) Chdir(target, dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(target)
+	if err != nil {
+		return err
+	}
+	sess.cwd = dir
+	return nil
+}
+
+// Input returns what was typed into a session's pane: the text of each
+// SubmitText and the keys of each SendKeys, in order.
+func (s *Server) Input(target string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess := s.find(target); sess != nil {
+		return slices.Clone(sess.input)
+	}
+	return nil
+}
+
+// Options returns what a session was created to run.
+func (s *Server) Options(target string) tmux.SessionOptions {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sess := s.find(target); sess != nil {
+		return sess.opts
+	}
+	return tmux.SessionOptions{}
+}
+
+// Messages returns the messages shown in clients' status lines.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// Popups returns the sessions and directories popups were opened for.
+func (s *Server) Popups() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.popups)
+}
+
+// ListSessions returns every session, oldest first.
+func (s *Server) ListSessions() ([]tmux.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []tmux.Session
+	for _, sess := range s.sessions {
+		out = append(out, tmux.Session{
+			Name:        sess.name,
+			Path:        sess.dir,
+			Created:     sess.created,
+			Attached:    sess.attached,
+			WindowCount: 1,
+		})
+	}
+	return out, nil
+}
+
+// DiscoverClaudeSessions returns the sessions with a hook event file or
+// running Claude.
+func (s *Server) DiscoverClaudeSessions() ([]tmux.Session, error) {
+	scan, err := s.Scan()
+	if err != nil {
+		return nil, err
+	}
+	return scan.ClaudeSessions(), nil
+}
+
+// CreateSession creates a session in dir, running Claude if runClaude.
+func (s *Server) CreateSession(name, dir string, runClaude bool) error {
+	opts := tmux.SessionOptions{}
+	if runClaude {
+		opts.Command = "claude"
+	}
+	return s.CreateSessionWithOptions(name, dir, opts)
+}
+
+// CreateSessionWithOptions creates a session in dir. One with a command
+// runs Claude, and shows ReadyScreen.
+func (s *Server) CreateSessionWithOptions(name, dir string, opts tmux.SessionOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(name) != nil {
+		return fmt.Errorf("tmux new-session: duplicate session: %s", name)
+	}
+	sess := &session{
+		name:    name,
+		dir:     dir,
+		cwd:     dir,
+		opts:    opts,
+		created: time.Now(),
+		paneID:  fmt.Sprintf("%%%d", s.nextPane),
+		claude:  opts.Command != "",
+	}
+	s.nextPane++
+	if sess.claude {
+		sess.screen = ReadyScreen
+	}
+	s.sessions = append(s.sessions, sess)
+	return nil
+}
+
+// AttachSession marks a session attached.
+func (s *Server) AttachSession(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	sess.attached = true
+	return nil
+}
+
+// SwitchSession moves cmux's client to a session.
+func (s *Server) SwitchSession(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	if current := s.find(s.current); current != nil {
+		current.attached = false
+	}
+	sess.attached = true
+	s.current = name
+	return nil
+}
+
+// KillSession removes a session.
+func (s *Server) KillSession(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	s.sessions = slices.DeleteFunc(s.sessions, func(other *session) bool { return other == sess })
+	return nil
+}
+
+// HasSession reports whether a session exists.
+func (s *Server) HasSession(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.find(name) != nil
+}
+
+// IsInsideTmux reports whether cmux runs in a session.
+func (s *Server) IsInsideTmux() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current != ""
+}
+
+// CapturePane returns what a session's pane shows.
+func (s *Server) CapturePane(name string, lines int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return "", err
+	}
+	return sess.screen, nil
+}
+
+// SendKeys records keys typed into a session's pane.
+func (s *Server) SendKeys(name string, keys string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	sess.input = append(sess.input, keys)
+	return nil
+}
+
+// SubmitText records text submitted to a pane.
+func (s *Server) SubmitText(target, text string) error {
+	return s.SendKeys(target, text)
+}
+
+// SupportsPopup reports that popups are supported.
+func (s *Server) SupportsPopup() bool {
+	return true
+}
+
+// DisplayPopup records a popup opened on a session.
+func (s *Server) DisplayPopup(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.get(name); err != nil {
+		return err
+	}
+	s.popups = append(s.popups, name)
+	return nil
+}
+
+// DisplayDiffPopup records a diff popup opened on a directory.
+func (s *Server) DisplayDiffPopup(workdir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.popups = append(s.popups, workdir)
+	return nil
+}
+
+// DisplayEditorPopup records an editor popup opened on a file, which is
+// left as it is.
+func (s *Server) DisplayEditorPopup(file string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.popups = append(s.popups, file)
+	return nil
+}
+
+// GetCurrentSession returns the session cmux runs in, or "" outside tmux.
+func (s *Server) GetCurrentSession() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// GetPanePID fails: no processes run in the fake panes.
+func (s *Server) GetPanePID(name string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.get(name); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no process runs in %s", name)
+}
+
+// GetSessionWorkingDir returns the directory a session's pane is in.
+func (s *Server) GetSessionWorkingDir(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return "", err
+	}
+	return sess.cwd, nil
+}
+
+// pane returns a session's only pane. The caller holds s.mu.
+func (sess *session) pane() tmux.Pane {
+	p := tmux.Pane{
+		Session:      sess.name,
+		WindowName:   "main",
+		WindowActive: true,
+		ID:           sess.paneID,
+		Command:      "zsh",
+		Path:         sess.cwd,
+		Active:       true,
+		Role:         tmux.RoleShell,
+	}
+	if sess.claude {
+		p.Command = "claude"
+		p.Role = tmux.RoleClaude
+	}
+	return p
+}
+
+// ListPanes returns a session's pane.
+func (s *Server) ListPanes(name string) ([]tmux.Pane, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return nil, err
+	}
+	return []tmux.Pane{sess.pane()}, nil
+}
+
+// FindClaudePane returns a session's pane if it runs Claude.
+func (s *Server) FindClaudePane(name string) (tmux.Pane, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, err := s.get(name)
+	if err != nil {
+		return tmux.Pane{}, err
+	}
+	if !sess.claude {
+		return tmux.Pane{}, fmt.Errorf("no claude pane in session %s", name)
+	}
+	return sess.pane(), nil
+}
+
+// Scan returns every session and its pane, without a process table.
+func (s *Server) Scan() (tmux.Scan, error) {
+	sessions, _ := s.ListSessions()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	scan := tmux.Scan{Sessions: sessions, Panes: make(map[string][]tmux.Pane)}
+	for _, sess := range s.sessions {
+		scan.Panes[sess.name] = []tmux.Pane{sess.pane()}
+	}
+	return scan, nil
+}
+
+// ListClients returns the terminals set by SetClients.
+func (s *Server) ListClients() ([]tmux.ClientInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.clients), nil
+}
+
+// DisplayMessage records a message shown in a client's status line.
+func (s *Server) DisplayMessage(client, text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, Message{Client: client, Text: text})
+	return nil
+}
@@ -0,0 +1,75 @@
+package tmuxtest
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+var _ tmux.Client = (*Server)(nil)
+
+func TestSessions(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir()) // no hook event files
+	s := NewServer()
+	if err := s.CreateSession("project/main", "/code/project", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateSession("scratch", "/tmp", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateSession("scratch", "/tmp", false); err == nil {
+		t.Error("creating a duplicate session succeeded")
+	}
+
+	pane, err := s.FindClaudePane("project/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pane.ID != "%0" || pane.Path != "/code/project" || pane.Role != tmux.RoleClaude {
+		t.Errorf("FindClaudePane = %+v", pane)
+	}
+	if _, err := s.FindClaudePane("scratch"); err == nil {
+		t.Error("found Claude in a shell session")
+	}
+
+	claude, err := s.DiscoverClaudeSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claude) != 1 || claude[0].Name != "project/main" {
+		t.Errorf("DiscoverClaudeSessions = %+v", claude)
+	}
+
+	if screen, _ := s.CapturePane("project/main", 0); screen != ReadyScreen {
+		t.Errorf("CapturePane = %q, want ReadyScreen", screen)
+	}
+	s.SubmitText("%0", "fix the bug")
+	s.SendKeys("project/main:0.0", "y")
+	if input := s.Input("project/main"); !slices.Equal(input, []string{"fix the bug", "y"}) {
+		t.Errorf("Input = %q", input)
+	}
+
+	s.Chdir("project/main", "/code/project/sub")
+	if dir, _ := s.GetSessionWorkingDir("project/main"); dir != "/code/project/sub" {
+		t.Errorf("GetSessionWorkingDir = %q", dir)
+	}
+
+	if s.IsInsideTmux() {
+		t.Error("inside tmux before switching to a session")
+	}
+	s.SwitchSession("scratch")
+	if got := s.GetCurrentSession(); got != "scratch" {
+		t.Errorf("GetCurrentSession = %q, want scratch", got)
+	}
+
+	if err := s.KillSession("project/main"); err != nil {
+		t.Fatal(err)
+	}
+	if s.HasSession("project/main") || !s.HasSession("scratch") {
+		t.Error("KillSession killed the wrong session")
+	}
+	if err := s.KillSession("project/main"); err == nil {
+		t.Error("killing a dead session succeeded")
+	}
+}
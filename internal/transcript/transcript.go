@@ -22,7 +22,9 @@ type ToolCall struct {
 // ToolInput contains common tool input fields.
 type ToolInput struct {
 	// Bash
-	Command     string `json:"command,omitempty"`
+	Command string `json:"command,omitempty"`
+
+	// Bash/Task
 	Description string `json:"description,omitempty"`
 
 	// Read/Edit/Write
@@ -31,9 +33,6 @@ type ToolInput struct {
 	// Grep/Glob
 	Pattern string `json:"pattern,omitempty"`
 
-	// Task
-	TaskDescription string `json:"description,omitempty"`
-
 	// WebFetch
 	URL string `json:"url,omitempty"`
 
@@ -240,8 +239,8 @@ func GetToolSummary(tc *ToolCall) string {
 		}
 		return "Glob"
 	case "Task":
-		if tc.Input.TaskDescription != "" {
-			return "Task(" + truncate(tc.Input.TaskDescription, 50) + ")"
+		if tc.Input.Description != "" {
+			return "Task(" + truncate(tc.Input.Description, 50) + ")"
 		}
 		return "Task"
 	case "WebFetch":
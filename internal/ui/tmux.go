@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abdullathedruid/cmux/internal/state"
+)
+
+// statusLineOrder is the order statuses appear in on tmux's status line.
+var statusLineOrder = []state.SessionStatus{
+	state.StatusActive,
+	state.StatusThinking,
+	state.StatusTool,
+	state.StatusNeedsInput,
+	state.StatusStopped,
+	state.StatusIdle,
+}
+
+// TmuxStatusLine renders how many sessions are in each status, such as
+// "3⚙ 1🔔 5✓", with tmux styles in the theme's colors. Statuses no session
+// is in are left out.
+func TmuxStatusLine(statuses []state.SessionStatus) string {
+	counts := make(map[state.SessionStatus]int)
+	for _, status := range statuses {
+		counts[status]++
+	}
+
+	var parts []string
+	for _, status := range statusLineOrder {
+		if n := counts[status]; n > 0 {
+			parts = append(parts, tmuxStyled(status, fmt.Sprintf("%d%s", n, StatusIcon(false, status))))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// TmuxStatus renders one session's status icon and label with tmux styles
+// in the theme's colors.
+func TmuxStatus(status state.SessionStatus) string {
+	return tmuxStyled(status, StatusIcon(false, status)+" "+StatusText(false, status))
+}
+
+// tmuxStyled colors text as a status, escaping tmux's format characters.
+func tmuxStyled(status state.SessionStatus, text string) string {
+	return fmt.Sprintf("#[fg=%s]%s#[default]", StatusColorName(false, status), strings.ReplaceAll(text, "#", "##"))
+}
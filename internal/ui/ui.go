@@ -4,8 +4,10 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/abdullathedruid/cmux/internal/config"
+	"github.com/abdullathedruid/cmux/internal/git"
 	"github.com/abdullathedruid/cmux/internal/state"
 	"github.com/jesseduffield/gocui"
 	"github.com/mattn/go-runewidth"
@@ -33,6 +35,7 @@ const (
 	ColorReset   = "\033[0m"
 	ColorBold    = "\033[1m"
 	ColorDim     = "\033[2m"
+	ColorRed     = "\033[31m"
 	ColorGreen   = "\033[32m"
 	ColorYellow  = "\033[33m"
 	ColorBlue    = "\033[34m"
@@ -72,28 +75,34 @@ func StatusIcon(attached bool, status state.SessionStatus) string {
 
 // StatusColor returns the color for a session status.
 func StatusColor(attached bool, status state.SessionStatus) string {
+	return ColorNameToANSI(StatusColorName(attached, status))
+}
+
+// StatusColorName returns the name of the color for a session status, as
+// the theme configures it.
+func StatusColorName(attached bool, status state.SessionStatus) string {
 	t := GetTheme()
 
 	statusKey := statusToKey(attached, status)
 	if style, ok := t.Status[statusKey]; ok && style.Color != "" {
-		return ColorNameToANSI(style.Color)
+		return style.Color
 	}
 
 	// Fallback defaults
 	if attached {
-		return ColorGreen
+		return "green"
 	}
 	switch status {
 	case state.StatusActive, state.StatusThinking:
-		return ColorYellow
+		return "yellow"
 	case state.StatusTool:
-		return ColorCyan
+		return "cyan"
 	case state.StatusNeedsInput:
-		return ColorMagenta
+		return "magenta"
 	case state.StatusStopped:
-		return ColorGreen
+		return "green"
 	default:
-		return ColorWhite
+		return "white"
 	}
 }
 
@@ -552,3 +561,55 @@ func FormatDuration(seconds int64) string {
 	}
 	return fmt.Sprintf("%dd ago", seconds/86400)
 }
+
+// FormatUsage formats a process's CPU, in percent of one core, and resident
+// memory compactly, e.g. "12% 340M".
+func FormatUsage(cpu float64, rss int64) string {
+	const mb = 1 << 20
+	mem := fmt.Sprintf("%dM", rss/mb)
+	if rss >= 1<<30 {
+		mem = fmt.Sprintf("%.1fG", float64(rss)/(1<<30))
+	}
+	return fmt.Sprintf("%.0f%% %s", cpu, mem)
+}
+
+// GitStatusLine summarizes a worktree's git state in at most width cells:
+// changed files, sync with the base branch, "local" when the branch is on
+// no remote, then the last commit's age and as much of its subject as fits.
+func GitStatusLine(st *git.WorktreeStatus, width int) string {
+	var plain, colored []string
+	add := func(text, color string) {
+		plain = append(plain, text)
+		colored = append(colored, color+text+ColorReset)
+	}
+
+	if st.Dirty > 0 {
+		add(fmt.Sprintf("*%d", st.Dirty), ColorYellow)
+	}
+	if label := st.Sync.Label(); label != "" {
+		color := ColorCyan
+		if len(st.Sync.Conflicts) > 0 {
+			color = ColorRed
+		}
+		add(label, color)
+	}
+	if !st.Upstream {
+		add("local", ColorDim)
+	}
+	if !st.Committed.IsZero() {
+		add(FormatDuration(int64(time.Since(st.Committed).Seconds())), ColorDim)
+	}
+
+	line := strings.Join(colored, " ")
+	used := runewidth.StringWidth(strings.Join(plain, " "))
+	if used > 0 {
+		used++
+	}
+	if room := width - used; st.Subject != "" && room > 3 {
+		if used > 0 {
+			line += " "
+		}
+		line += truncate(st.Subject, room)
+	}
+	return line
+}
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/abdullathedruid/cmux/internal/git"
 	"github.com/abdullathedruid/cmux/internal/state"
 )
 
@@ -277,6 +278,24 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatUsage(t *testing.T) {
+	tests := []struct {
+		cpu  float64
+		rss  int64
+		want string
+	}{
+		{0, 0, "0% 0M"},
+		{12.4, 340 << 20, "12% 340M"},
+		{150, 3 << 29, "150% 1.5G"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatUsage(tt.cpu, tt.rss); got != tt.want {
+			t.Errorf("FormatUsage(%v, %d) = %q, want %q", tt.cpu, tt.rss, got, tt.want)
+		}
+	}
+}
+
 func TestStatusColor(t *testing.T) {
 	// Just verify it returns something for each case
 	tests := []struct {
@@ -335,3 +354,48 @@ func TestMin(t *testing.T) {
 		}
 	}
 }
+
+func TestGitStatusLine(t *testing.T) {
+	st := &git.WorktreeStatus{
+		Dirty:    3,
+		Subject:  "Fix the parser for nested blocks",
+		Upstream: false,
+		Sync:     git.SyncStatus{Ahead: 2, Behind: 5, Conflicts: []string{"a.go"}},
+	}
+	got := GitStatusLine(st, 30)
+	want := ColorYellow + "*3" + ColorReset + " " + ColorRed + "↑2 ↓5 conflict" + ColorReset + " " +
+		ColorDim + "local" + ColorReset + " " + "Fix..."
+	if got != want {
+		t.Errorf("GitStatusLine() = %q, want %q", got, want)
+	}
+
+	// A subject with no room left is dropped
+	if got := GitStatusLine(st, 25); strings.Contains(got, "Fix") {
+		t.Errorf("GitStatusLine() = %q, want no subject", got)
+	}
+
+	clean := &git.WorktreeStatus{Subject: "Initial commit", Upstream: true}
+	if got := GitStatusLine(clean, 30); got != "Initial commit" {
+		t.Errorf("GitStatusLine() of a clean worktree = %q, want the subject", got)
+	}
+}
+
+func TestTmuxStatusLine(t *testing.T) {
+	statuses := []state.SessionStatus{
+		state.StatusStopped, state.StatusTool, state.StatusNeedsInput,
+		state.StatusTool, state.StatusStopped, state.StatusTool,
+	}
+	want := "#[fg=cyan]3⚙#[default] #[fg=magenta]1🔔#[default] #[fg=green]2✓#[default]"
+	if got := TmuxStatusLine(statuses); got != want {
+		t.Errorf("TmuxStatusLine() = %q, want %q", got, want)
+	}
+	if got := TmuxStatusLine(nil); got != "" {
+		t.Errorf("TmuxStatusLine(nil) = %q, want nothing", got)
+	}
+}
+
+func TestTmuxStatus(t *testing.T) {
+	if got, want := TmuxStatus(state.StatusNeedsInput), "#[fg=magenta]🔔 INPUT#[default]"; got != want {
+		t.Errorf("TmuxStatus() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,219 @@
+// Package workspace saves and restores named sets of sessions.
+package workspace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abdullathedruid/cmux/internal/notes"
+	"github.com/abdullathedruid/cmux/internal/session"
+	"github.com/abdullathedruid/cmux/internal/tmux"
+)
+
+// ErrNotFound is returned when a workspace file does not exist.
+var ErrNotFound = errors.New("workspace not found")
+
+// SessionSpec records enough about a session to recreate it.
+type SessionSpec struct {
+	Name     string `json:"name"`                // tmux session name
+	RepoPath string `json:"repo_path,omitempty"` // git repo root (empty if standalone)
+	Branch   string `json:"branch,omitempty"`
+	Worktree string `json:"worktree,omitempty"` // working directory (may equal RepoPath)
+	Note     string `json:"note,omitempty"`
+}
+
+// Layout records how the sessions were tiled.
+type Layout struct {
+	Preset string `json:"preset,omitempty"`
+	Ratio  int    `json:"ratio,omitempty"` // main split, percent of width
+}
+
+// Workspace is a named set of sessions and their layout.
+type Workspace struct {
+	Name     string        `json:"name"`
+	Sessions []SessionSpec `json:"sessions"`
+	Layout   Layout        `json:"layout"`
+	SavedAt  time.Time     `json:"saved_at"`
+}
+
+// Store reads and writes workspaces as JSON files in a directory.
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store for the given directory.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// path returns the file for a workspace name.
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// ValidateName checks that a workspace name can be used as a file name.
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("workspace name is empty")
+	}
+	if strings.ContainsAny(name, `/\`) || name == "." || name == ".." || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid workspace name %q", name)
+	}
+	return nil
+}
+
+// Save writes a workspace, replacing any existing one with the same name.
+func (s *Store) Save(ws *Workspace) error {
+	if err := ValidateName(ws.Name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(ws, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(ws.Name), data, 0644)
+}
+
+// Load reads a workspace by name.
+func (s *Store) Load(name string) (*Workspace, error) {
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(s.path(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return nil, err
+	}
+
+	var ws Workspace
+	if err := json.Unmarshal(data, &ws); err != nil {
+		return nil, fmt.Errorf("parsing workspace %s: %w", name, err)
+	}
+	ws.Name = name
+	return &ws, nil
+}
+
+// List returns the names of all saved workspaces, sorted.
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".json"); ok && !e.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Delete removes a saved workspace.
+func (s *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(name)); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", ErrNotFound, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// RestoreError lists the sessions of a workspace that could not be restored.
+type RestoreError struct {
+	Failed []error
+}
+
+func (e *RestoreError) Error() string {
+	msgs := make([]string, len(e.Failed))
+	for i, err := range e.Failed {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (e *RestoreError) Unwrap() []error {
+	return e.Failed
+}
+
+// Restore recreates the workspace's missing tmux sessions and restores their
+// notes. Sessions that already exist are reused. It returns the names of the
+// sessions that are available, in workspace order, and a *RestoreError if
+// any session failed.
+func Restore(ws *Workspace, mgr *session.Manager, client tmux.Client, noteStore *notes.Store) ([]string, error) {
+	var opened []string
+	var errs []error
+
+	for _, spec := range ws.Sessions {
+		name, err := restoreSession(spec, mgr, client)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", spec.Name, err))
+		}
+		if name == "" {
+			continue
+		}
+		opened = append(opened, name)
+
+		// Bring back the note unless the session already has one
+		if noteStore != nil && spec.Note != "" && noteStore.Get(name) == "" {
+			if err := noteStore.Set(name, spec.Note); err != nil {
+				errs = append(errs, fmt.Errorf("%s: saving note: %w", name, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return opened, &RestoreError{Failed: errs}
+	}
+	return opened, nil
+}
+
+// restoreSession returns the name of the running session for spec, creating
+// it if needed.
+func restoreSession(spec SessionSpec, mgr *session.Manager, client tmux.Client) (string, error) {
+	if client.HasSession(spec.Name) {
+		return spec.Name, nil
+	}
+
+	// Repository sessions go through the session manager so the worktree is
+	// found or recreated
+	if spec.RepoPath != "" && spec.Branch != "" {
+		if _, err := os.Stat(spec.RepoPath); err != nil {
+			return "", fmt.Errorf("repository missing: %w", err)
+		}
+		return mgr.CreateBranchSession(spec.RepoPath, spec.Branch)
+	}
+
+	dir := spec.Worktree
+	if dir == "" {
+		dir = spec.RepoPath
+	}
+	if dir == "" {
+		return "", errors.New("no working directory recorded")
+	}
+	if err := client.CreateSession(spec.Name, dir, true); err != nil {
+		return "", err
+	}
+	return spec.Name, nil
+}
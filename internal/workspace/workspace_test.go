@@ -0,0 +1,70 @@
+package workspace
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	store := NewStore(t.TempDir())
+
+	ws := &Workspace{
+		Name: "backend-sprint",
+		Sessions: []SessionSpec{
+			{Name: "api/auth", RepoPath: "/src/api", Branch: "auth", Worktree: "/src/api/.worktrees/auth", Note: "token refresh"},
+			{Name: "web/main", RepoPath: "/src/web", Branch: "main", Worktree: "/src/web"},
+		},
+		Layout: Layout{Preset: "main-vertical", Ratio: 65},
+	}
+	if err := store.Save(ws); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := store.Load("backend-sprint")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got.Sessions, ws.Sessions) || got.Layout != ws.Layout {
+		t.Errorf("Load() = %+v, want %+v", got, ws)
+	}
+
+	if err := store.Save(&Workspace{Name: "release"}); err != nil {
+		t.Fatal(err)
+	}
+	names, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"backend-sprint", "release"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("List() = %v, want %v", names, want)
+	}
+
+	if err := store.Delete("release"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("release"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() after Delete error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListMissingDir(t *testing.T) {
+	store := NewStore(t.TempDir() + "/missing")
+	names, err := store.List()
+	if err != nil || len(names) != 0 {
+		t.Errorf("List() = %v, %v; want empty", names, err)
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"release", "backend sprint", "v2.1"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "..", ".hidden", "a/b", `a\b`} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) should fail", name)
+		}
+	}
+}